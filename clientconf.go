@@ -1,7 +1,10 @@
 package gortsplib
 
 import (
+	"context"
 	"crypto/tls"
+	"fmt"
+	"io"
 	"net"
 	"time"
 
@@ -22,94 +25,608 @@ func DialRead(address string) (*ClientConn, error) {
 	return DefaultClientConf.DialRead(address)
 }
 
+// DialReadContext connects to a server and starts reading all tracks.
+// The context can be used to cancel the connection establishment.
+func DialReadContext(ctx context.Context, address string) (*ClientConn, error) {
+	return DefaultClientConf.DialReadContext(ctx, address)
+}
+
+// Describe connects to a server, gets the tracks of a stream
+// and closes the connection, without starting to read.
+func Describe(address string) (Tracks, *base.Response, error) {
+	return DefaultClientConf.Describe(address)
+}
+
+// DescribeContext connects to a server, gets the tracks of a stream
+// and closes the connection, without starting to read.
+// The context can be used to cancel the operation.
+func DescribeContext(ctx context.Context, address string) (Tracks, *base.Response, error) {
+	return DefaultClientConf.DescribeContext(ctx, address)
+}
+
 // DialPublish connects to a server and starts publishing the tracks.
 func DialPublish(address string, tracks Tracks) (*ClientConn, error) {
 	return DefaultClientConf.DialPublish(address, tracks)
 }
 
+// DialPublishContext connects to a server and starts publishing the tracks.
+// The context can be used to cancel the connection establishment.
+func DialPublishContext(ctx context.Context, address string, tracks Tracks) (*ClientConn, error) {
+	return DefaultClientConf.DialPublishContext(ctx, address, tracks)
+}
+
 // ClientConf allows to initialize a ClientConn.
 // All fields are optional.
 type ClientConf struct {
-	// the stream protocol (UDP or TCP).
+	// the stream protocol (UDP, TCP or UDP multicast).
 	// If nil, it is chosen automatically (first UDP, then, if it fails, TCP).
 	// It defaults to nil.
 	StreamProtocol *StreamProtocol
 
+	// disable switching from UDP to TCP when the stream protocol is chosen
+	// automatically.
+	// It defaults to false.
+	TCPFallbackDisable bool
+
+	// when the stream protocol is chosen automatically, time to wait for
+	// the first UDP packet before trying again.
+	// It defaults to 3 seconds.
+	ProbeTimeout time.Duration
+
+	// when the stream protocol is chosen automatically, number of attempts
+	// with UDP before switching to TCP.
+	// It defaults to 1.
+	ProbeCount int
+
+	// function that returns the interleaved ids (the channels of RTP and RTCP
+	// frames) that are requested for a track when the stream protocol is TCP.
+	// If the server replies with different ids, frames are remapped to the
+	// right track.
+	// It defaults to nil, that means that track N uses ids 2*N and 2*N+1.
+	InterleavedIds func(track *Track) [2]int
+
+	// use RTSP over HTTP tunneling (the QuickTime GET/POST method),
+	// that allows to reach servers behind HTTP-only firewalls.
+	// When enabled, the stream protocol is always TCP.
+	// Tunneling is also enabled when the URL scheme is rtspt.
+	// It defaults to false.
+	HTTPTunnel bool
+
 	// A TLS configuration to connect to TLS (RTSPS) servers.
+	// It can be used to trust custom certificate authorities (RootCAs),
+	// to present client certificates (Certificates) or to enable
+	// certificate verification (InsecureSkipVerify: false).
+	// If ServerName is empty, it is filled with the server host.
 	// It defaults to &tls.Config{InsecureSkipVerify:true}
 	TLSConfig *tls.Config
 
-	// timeout of read operations.
+	// username used to authenticate with the server.
+	// It is used when the URL doesn't contain credentials, and allows to keep
+	// credentials out of URLs, that can then be safely logged.
+	// It defaults to "".
+	User string
+
+	// password used to authenticate with the server, together with User.
+	// It defaults to "".
+	Pass string
+
+	// callback called when the server requests authentication and credentials
+	// are neither in the URL nor in User and Pass.
+	// It receives the realm provided by the server and returns the credentials.
+	// If it returns an error, the request fails with the error.
+	// It defaults to nil.
+	OnCredentials func(realm string) (user string, pass string, err error)
+
+	// timeout of the TCP connection to the server, including the HTTP
+	// handshake when HTTPTunnel is enabled.
+	// It defaults to ReadTimeout.
+	ConnectTimeout time.Duration
+
+	// timeout of requests, that is the maximum time between the moment
+	// a request is written and the moment its response is received.
+	// It defaults to ReadTimeout.
+	RequestTimeout time.Duration
+
+	// maximum time without receiving frames while reading.
 	// It defaults to 10 seconds.
 	ReadTimeout time.Duration
 
-	// timeout of write operations.
+	// timeout of frame writes.
 	// It defaults to 10 seconds.
 	WriteTimeout time.Duration
 
+	// maximum time to wait for the response to the TEARDOWN request
+	// that is sent when closing the connection.
+	// It defaults to 2 seconds.
+	TeardownTimeout time.Duration
+
 	// disable being redirected to other servers, that can happen during Describe().
 	// It defaults to false.
 	RedirectDisable bool
 
+	// maximum number of redirects that are followed during Describe().
+	// It defaults to 10.
+	MaxRedirects int
+
+	// maximum size of the header of responses and requests received from the server.
+	// It defaults to 0 (only the size of single entries is limited).
+	MaxHeaderSize int
+
+	// maximum number of header entries of responses and requests received from the server.
+	// It defaults to 255.
+	MaxHeaderCount int
+
+	// maximum size of the body of responses and requests received from the server,
+	// including SDPs received with DESCRIBE.
+	// It defaults to 128KB.
+	MaxBodySize int
+
+	// callback called before following a redirect.
+	// It receives the redirect target and returns the URL to connect to,
+	// that can be rewritten. If it returns an error, the redirect is not followed
+	// and Describe() returns the error. If it returns a nil URL, the redirect is
+	// not followed and Describe() returns an ErrWrongStatusCode error.
+	// In both cases, the connection is left open.
+	// It defaults to nil.
+	OnRedirect func(u *base.URL) (*base.URL, error)
+
+	// callback called when the server sends a REDIRECT request while reading
+	// or publishing, asking the client to connect to another URL.
+	// After the callback, the reading or publishing stops with an ErrServerRedirect
+	// error, that contains the new URL and can be used to reconnect, unless
+	// ServerRedirectFollow is true.
+	// It defaults to nil.
+	OnServerRedirect func(u *base.URL)
+
+	// follow REDIRECT requests sent by the server while reading or publishing,
+	// by setting up the same tracks on the new URL, instead of stopping.
+	// It defaults to false.
+	ServerRedirectFollow bool
+
+	// callback called when the server sends a request, different from REDIRECT,
+	// while reading or publishing.
+	// If it returns a response, the response is sent to the server, otherwise
+	// OPTIONS, GET_PARAMETER and SET_PARAMETER keepalives are answered
+	// with 200, ANNOUNCE requests are handled as session updates and the other
+	// requests are answered with 501.
+	// It is called from a background routine and must not block.
+	// It defaults to nil.
+	OnServerRequest func(req *base.Request) *base.Response
+
+	// callback called when the server sends an ANNOUNCE request while reading,
+	// with the updated tracks of the stream, for instance after a change of
+	// codec or resolution.
+	// It defaults to nil.
+	OnSessionUpdate func(tracks Tracks)
+
+	// whether to restart the session when the server sends updated tracks
+	// while reading. The session is torn down, the tracks with the same IDs
+	// of the current ones are set up again and the reading is resumed, without
+	// changing the ReadFrames() callback.
+	// It defaults to false.
+	SessionUpdateRenegotiate bool
+
+	// maximum number of consecutive attempts to reconnect to the server when
+	// the connection is lost while reading or publishing. After a reconnection,
+	// the stream is described (or announced) and set up again, and the reading
+	// or publishing is resumed, without changing the ReadFrames() callback.
+	// While reconnecting, WriteFrame() returns an error.
+	// If negative, attempts are unlimited.
+	// It defaults to 0, that means that reconnection is disabled.
+	ReconnectMaxAttempts int
+
+	// function that returns the delay before a reconnection attempt,
+	// given the attempt number (starting from 1).
+	// It defaults to an exponential backoff that starts from 1 second
+	// and is capped at 30 seconds.
+	ReconnectBackoff func(attempt int) time.Duration
+
+	// callback called after every reconnection attempt, with the
+	// attempt number and the error, that is nil if the attempt succeeded.
+	// It defaults to nil.
+	OnReconnect func(attempt int, err error)
+
+	// callback called when the state of the connection changes.
+	// While reconnecting, it is called by the background routine.
+	// It defaults to nil.
+	OnStateChange func(prev ClientConnState, cur ClientConnState)
+
+	// strategy used to compute the URLs of the tracks received with Describe()
+	// from their control attributes.
+	// It defaults to TrackURLModeAppend.
+	TrackURLMode TrackURLMode
+
+	// function that selects the tracks that are set up by DialRead();
+	// tracks for which it returns false are not set up and not received,
+	// allowing to save bandwidth.
+	// At least one track must be selected.
+	// It defaults to nil, that means that all tracks are set up.
+	TrackFilter func(track *Track) bool
+
+	// function that selects the tracks whose RTP packets are delivered
+	// only when they belong to key frames, allowing to save the CPU needed
+	// to process the entire stream. Only H264 and H265 tracks are filtered,
+	// while RTCP packets are always delivered.
+	// It defaults to nil, that means that all packets are delivered.
+	KeyframesOnly func(track *Track) bool
+
+	// when greater than zero, DialRead() waits until the SPS and PPS of
+	// H264 tracks whose SDP doesn't provide them are received in-band,
+	// and writes them into the tracks, allowing to read them with
+	// Track.ExtractDataH264(). Frames received in the meanwhile are
+	// delivered by ReadFrames(); up to 512 frames are kept until ReadFrames()
+	// is called, and older ones are discarded. If the parameters are not received
+	// within this duration, DialRead() returns an error.
+	// It defaults to 0, that means that DialRead() doesn't wait.
+	H264ParamsTimeout time.Duration
+
+	// request the ONVIF audio backchannel, by adding the
+	// "Require: www.onvif.org/ver20/backchannel" header to DESCRIBE, SETUP and PLAY requests.
+	// Frames of backchannel tracks can be written with WriteFrame() while reading.
+	// It defaults to false.
+	RequireBackchannel bool
+
+	// request the ONVIF replay mechanism, by adding the
+	// "Require: onvif-replay" header to SETUP and PLAY requests.
+	// It allows to read recordings of ONVIF recorders with PlayReplay().
+	// The absolute time of every packet can be decoded with the onvifreplay package.
+	// It defaults to false.
+	RequireReplay bool
+
+	// disable the periodic sending of RTCP receiver reports while reading.
+	// Receiver reports are required by some servers, that close the session
+	// when they don't receive any feedback.
+	// It defaults to false.
+	ReceiverReportDisable bool
+
+	// period of the packets that are sent while reading with UDP, from the local
+	// RTP and RTCP ports toward the ports of the server, in order to open
+	// NAT mappings and keep them open, allowing frames to reach the client.
+	// It defaults to 20 seconds.
+	HolePunchingPeriod time.Duration
+
+	// disable the sending of the packets used to open NAT mappings.
+	// It defaults to false.
+	HolePunchingDisable bool
+
+	// disable the periodic sending of RTCP sender reports while publishing.
+	// Sender reports are used by servers to synchronize tracks.
+	// It defaults to false.
+	SenderReportDisable bool
+
+	// method used to send keepalives when reading with UDP.
+	// It defaults to GET_PARAMETER if supported by the server, otherwise OPTIONS.
+	KeepaliveMethod base.Method
+
+	// period of keepalives sent when reading with UDP.
+	// It defaults to 30 seconds, or less if the server provides a shorter
+	// session timeout.
+	KeepalivePeriod time.Duration
+
+	// size of the buffer used to reorder RTP packets received with UDP,
+	// in number of packets. Packets that are not received before the buffer
+	// is full are considered lost.
+	// It defaults to 0 (reordering is disabled).
+	ReorderBufferSize int
+
+	// enables the retransmission of RTP packets that are lost with UDP,
+	// through generic NACKs (RFC 4585).
+	// When reading, NACKs are sent to the server when packets are lost;
+	// retransmitted packets are received out of order, unless ReorderBufferSize
+	// is set. When publishing, packets requested by the server are retransmitted,
+	// inside RTX packets (RFC 4588) if the track contains a RTX format.
+	// It defaults to false.
+	NACKEnable bool
+
+	// number of RTP packets that are protected by a FEC packet (RFC 5109),
+	// when publishing with UDP a track that contains a FEC format.
+	// Every FEC packet allows the server to recover a lost packet of the group.
+	// When reading with UDP, lost packets are recovered automatically
+	// when the tracks contain a FEC format.
+	// It defaults to 0 (FEC packets are not sent).
+	FECGroupSize int
+
+	// callback called when RTP packets are lost.
+	// Losses are detected from gaps in sequence numbers or, when reordering
+	// is enabled, from packets that are not received before the reorder buffer is full.
+	// It is called by the routine that is reading frames.
+	// It defaults to nil.
+	OnPacketsLost func(trackID int, count int)
+
+	// callback called when the SSRC of the RTP packets of a track that is
+	// being read changes, that usually happens when the source restarts.
+	// Sequence numbers and timestamps of the new source are not compared
+	// with the ones of the previous source.
+	// It is called by the routine that is reading frames.
+	// It defaults to nil.
+	OnSSRCChange func(trackID int, prev uint32, cur uint32)
+
+	// SSRCs of the RTP packets of published tracks, indexed by track ID.
+	// SSRCs are advertised to the server in the SETUP request and are
+	// written into every RTP packet passed to WriteFrame() and WriteFrames(),
+	// overwriting the original ones, in order to keep them constant even
+	// when the source of packets changes. Payloads are modified in place.
+	// It defaults to nil (SSRCs of packets are left untouched).
+	PublishSSRCs map[int]uint32
+
+	// maximum bitrate, in bits per second, of published RTP packets.
+	// If greater than 0, RTP packets passed to WriteFrame() and WriteFrames()
+	// are sent according to their timestamps, and bursts, like the ones caused
+	// by keyframes, are spread over time in order not to exceed the bitrate.
+	// WriteFrame() blocks until the packet can be sent. Packets that can't be
+	// sent within 1 second, since the stream bitrate is greater, are discarded.
+	// It defaults to 0 (packets are sent as soon as they are written).
+	PublishPacingBitrate int
+
+	// time after which a track that doesn't receive RTP packets while
+	// reading is considered stalled.
+	// It is used only when OnStall is not nil.
+	// It defaults to 5 seconds.
+	StallTimeout time.Duration
+
+	// callback called when a track that is being read doesn't receive RTP
+	// packets for StallTimeout. It is called once per stall, and called again
+	// only if packets are received and the track stalls again.
+	// It can be used to trigger a reconnection or to raise alerts.
+	// It defaults to nil.
+	OnStall func(trackID int)
+
+	// write buffer count.
+	// If greater than 0, frames written with WriteFrame() while publishing are
+	// queued and written by a background routine, without blocking the caller.
+	// When the buffer is full, the oldest frames are discarded.
+	// It defaults to 0 (frames are written synchronously).
+	WriteBufferCount uint64
+
+	// callback called when a frame written in background can't be sent.
+	// It is used only when WriteBufferCount is greater than 0.
+	// It defaults to nil.
+	OnWriteError func(err error)
+
 	// read buffer count.
 	// If greater than 1, allows to pass buffers to routines different than the one
 	// that is reading frames.
 	// It defaults to 1.
 	ReadBufferCount uint64
 
-	// callback called before every request.
+	// read buffer size.
+	// This must be touched only when the server sends frames bigger than the default size,
+	// since it is the maximum size of received frames. Bigger frames are discarded
+	// when reading with UDP, and cause an error when reading with TCP.
+	// It defaults to 2048.
+	ReadBufferSize uint64
+
+	// size of the kernel buffer of UDP sockets (SO_RCVBUF).
+	// Increasing it allows to avoid packet losses with high bitrate streams.
+	// It defaults to 524288.
+	UDPKernelReadBufferSize int
+
+	// Differentiated Services Code Point (0-63) of the packets sent through
+	// the TCP connection and the UDP listeners, used by QoS-enabled networks
+	// to prioritize traffic. It is applied on Linux only.
+	// It defaults to 0, that means that packets are not marked.
+	DSCP int
+
+	// priority (SO_PRIORITY) of the TCP connection and the UDP listeners,
+	// that is used by the Linux traffic control to select queues.
+	// It is applied on Linux only.
+	// It defaults to 0.
+	SocketPriority int
+
+	// size of the buffer used to write requests and frames with TCP.
+	// It defaults to 4096.
+	WriteBufferSize int
+
+	// headers added to every request.
+	// They can be used to override the User-Agent or to send vendor-specific
+	// headers. CSeq can't be overridden.
+	// It defaults to nil.
+	RequestHeader base.Header
+
+	// callback called before every request is written.
+	// The request can be inspected or modified, for instance to
+	// log the RTSP exchange or to work around vendor quirks.
+	// It defaults to nil.
 	OnRequest func(req *base.Request)
 
-	// callback called after very response.
+	// callback called after every response is read, before it is processed.
+	// Responses to keepalives sent while reading or publishing with UDP are
+	// passed to the callback from a background routine.
+	// It defaults to nil.
 	OnResponse func(res *base.Response)
 
+	// logger that receives connection lifecycle events, requests, responses,
+	// transport fallbacks and errors.
+	// It defaults to nil, that means that nothing is logged.
+	Logger Logger
+
+	// metrics provider that receives counters and gauges about connections,
+	// frames, bytes, lost packets and reconnections.
+	// It defaults to nil, that means that metrics are not collected.
+	Metrics Metrics
+
+	// writer that receives a trace of the RTSP exchange, useful to debug
+	// servers and cameras that don't behave correctly.
+	// Requests and responses are written as they are sent on the wire, each
+	// one preceded by a line that starts with '#' and contains the direction
+	// and the time. Credentials are redacted.
+	// It defaults to nil, that means that traces are not written.
+	TraceWriter io.Writer
+
+	// whether to write the channel and size of interleaved frames to TraceWriter.
+	// It defaults to false.
+	TraceFrames bool
+
 	// function used to initialize the TCP client.
-	// It defaults to net.DialTimeout.
+	// It defaults to a net.Dialer that honors the context passed to the Dial*Context functions.
 	DialTimeout func(network, address string, timeout time.Duration) (net.Conn, error)
 
+	// function used to initialize the TCP client, that receives the context
+	// passed to the Dial*Context functions.
+	// It allows to route connections through proxies or tunnels, or to use
+	// a pre-established net.Conn. It takes precedence over DialTimeout.
+	// It defaults to nil.
+	DialContextFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
 	// function used to initialize UDP listeners.
 	// It defaults to net.ListenPacket.
 	ListenPacket func(network, address string) (net.PacketConn, error)
+
+	// IP address of the local interface that is used to talk with the server.
+	// It is used as source address of the TCP connection, and UDP listeners
+	// are bound to it. It is ignored when DialTimeout or DialContextFunc are set,
+	// and by listeners of UDPListenerPool.
+	// It defaults to "", that means that the interface is chosen by the system.
+	LocalAddr string
+
+	// name of the local interface that is used to talk with the server.
+	// It works like LocalAddr, and the address of the interface is chosen
+	// with the same family of the address of the server.
+	// It is used to join multicast groups too.
+	// It defaults to "", that means that the interface is chosen by the system.
+	LocalInterface string
+
+	// accept UDP packets that come from the IP of the server but from
+	// a port that is different from the advertised one, as done by some
+	// cameras behind NAT.
+	// Since it allows anyone who can send packets from the server IP to inject
+	// frames into the stream, it must be enabled only when needed.
+	// It can't be used with UDPListenerPool.
+	// It defaults to false.
+	AnyPortEnable bool
+
+	// accept UDP packets that come from any IP and port, as done by cameras
+	// that send frames from an address different from the one of the server.
+	// Since it allows anyone who can reach the UDP listeners to inject frames
+	// into the stream, it must be enabled only when needed.
+	// It can't be used with UDPListenerPool.
+	// It defaults to false.
+	AnySourceEnable bool
+
+	// callback called when a UDP packet is accepted from an address different
+	// from the one advertised by the server, that can happen when AnyPortEnable
+	// or AnySourceEnable are set. It is called again when the address changes.
+	// It defaults to nil.
+	OnUnexpectedSource func(trackID int, streamType StreamType, addr *net.UDPAddr)
+
+	// pool of UDP listeners that is used to receive and send RTP and RTCP
+	// packets with UDP, instead of opening dedicated listeners for every track.
+	// It can be shared by multiple ClientConns, and it is not used when
+	// client ports are provided to Setup().
+	// It defaults to nil.
+	UDPListenerPool *ClientUDPListenerPool
 }
 
 // Dial connects to a server.
 func (c ClientConf) Dial(scheme string, host string) (*ClientConn, error) {
-	return newClientConn(c, scheme, host)
+	return c.DialContext(context.Background(), scheme, host)
 }
 
-// DialRead connects to the address and starts reading all tracks.
-func (c ClientConf) DialRead(address string) (*ClientConn, error) {
+// DialContext connects to a server.
+// The context can be used to cancel the connection establishment.
+func (c ClientConf) DialContext(ctx context.Context, scheme string, host string) (*ClientConn, error) {
+	return newClientConn(ctx, c, scheme, host)
+}
+
+// Describe connects to the address, gets the tracks of the stream
+// and closes the connection, without starting to read.
+// The raw SDP is available in the Body of the returned response.
+func (c ClientConf) Describe(address string) (Tracks, *base.Response, error) {
+	return c.DescribeContext(context.Background(), address)
+}
+
+// DescribeContext connects to the address, gets the tracks of the stream
+// and closes the connection, without starting to read.
+// The raw SDP is available in the Body of the returned response.
+// The context can be used to cancel the operation.
+func (c ClientConf) DescribeContext(ctx context.Context, address string) (Tracks, *base.Response, error) {
 	u, err := base.ParseURL(address)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	conn, err := c.Dial(u.Scheme, u.Host)
+	conn, err := c.DialContext(ctx, u.Scheme, u.Host)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	defer conn.Close()
+
+	var tracks Tracks
+	var res *base.Response
+
+	err = conn.runContext(ctx, func() error {
+		_, err := conn.Options(u)
+		if err != nil {
+			return err
+		}
 
-	_, err = conn.Options(u)
+		tracks, res, err = conn.describe(ctx, u, nil)
+		return err
+	})
+	if err != nil {
+		return nil, res, err
+	}
+
+	return tracks, res, nil
+}
+
+// DialRead connects to the address and starts reading all tracks.
+func (c ClientConf) DialRead(address string) (*ClientConn, error) {
+	return c.DialReadContext(context.Background(), address)
+}
+
+// DialReadContext connects to the address and starts reading all tracks.
+// The context can be used to cancel the connection establishment.
+func (c ClientConf) DialReadContext(ctx context.Context, address string) (*ClientConn, error) {
+	u, err := base.ParseURL(address)
 	if err != nil {
-		conn.Close()
 		return nil, err
 	}
 
-	tracks, _, err := conn.Describe(u)
+	conn, err := c.DialContext(ctx, u.Scheme, u.Host)
 	if err != nil {
-		conn.Close()
 		return nil, err
 	}
 
-	for _, track := range tracks {
-		_, err := conn.Setup(headers.TransportModePlay, track, 0, 0)
+	err = conn.runContext(ctx, func() error {
+		_, err := conn.Options(u)
 		if err != nil {
-			conn.Close()
-			return nil, err
+			return err
 		}
-	}
 
-	_, err = conn.Play()
+		tracks, _, err := conn.describe(ctx, u, nil)
+		if err != nil {
+			return err
+		}
+
+		setupCount := 0
+		for _, track := range tracks {
+			if c.TrackFilter != nil && !c.TrackFilter(track) {
+				continue
+			}
+
+			_, err := conn.Setup(headers.TransportModePlay, track, 0, 0)
+			if err != nil {
+				return err
+			}
+			setupCount++
+		}
+
+		if setupCount == 0 {
+			return fmt.Errorf("no tracks have been selected")
+		}
+
+		_, err = conn.Play(nil)
+		if err != nil {
+			return err
+		}
+
+		if c.H264ParamsTimeout > 0 {
+			return conn.readH264Params(c.H264ParamsTimeout)
+		}
+
+		return nil
+	})
 	if err != nil {
 		conn.Close()
 		return nil, err
@@ -120,37 +637,43 @@ func (c ClientConf) DialRead(address string) (*ClientConn, error) {
 
 // DialPublish connects to the address and starts publishing the tracks.
 func (c ClientConf) DialPublish(address string, tracks Tracks) (*ClientConn, error) {
-	u, err := base.ParseURL(address)
-	if err != nil {
-		return nil, err
-	}
+	return c.DialPublishContext(context.Background(), address, tracks)
+}
 
-	conn, err := c.Dial(u.Scheme, u.Host)
+// DialPublishContext connects to the address and starts publishing the tracks.
+// The context can be used to cancel the connection establishment.
+func (c ClientConf) DialPublishContext(ctx context.Context, address string, tracks Tracks) (*ClientConn, error) {
+	u, err := base.ParseURL(address)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = conn.Options(u)
+	conn, err := c.DialContext(ctx, u.Scheme, u.Host)
 	if err != nil {
-		conn.Close()
 		return nil, err
 	}
 
-	_, err = conn.Announce(u, tracks)
-	if err != nil {
-		conn.Close()
-		return nil, err
-	}
+	err = conn.runContext(ctx, func() error {
+		_, err := conn.Options(u)
+		if err != nil {
+			return err
+		}
 
-	for _, track := range tracks {
-		_, err := conn.Setup(headers.TransportModeRecord, track, 0, 0)
+		_, err = conn.Announce(u, tracks)
 		if err != nil {
-			conn.Close()
-			return nil, err
+			return err
+		}
+
+		for _, track := range tracks {
+			_, err := conn.Setup(headers.TransportModeRecord, track, 0, 0)
+			if err != nil {
+				return err
+			}
 		}
-	}
 
-	_, err = conn.Record()
+		_, err = conn.Record()
+		return err
+	})
 	if err != nil {
 		conn.Close()
 		return nil, err
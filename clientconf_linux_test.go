@@ -0,0 +1,72 @@
+//go:build linux
+// +build linux
+
+package gortsplib
+
+import (
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aler9/gortsplib/pkg/rtsptest"
+)
+
+func getsockoptInt(t *testing.T, conn syscall.Conn, level int, opt int) int {
+	rc, err := conn.SyscallConn()
+	require.NoError(t, err)
+
+	var v int
+	var serr error
+	err = rc.Control(func(fd uintptr) {
+		v, serr = syscall.GetsockoptInt(int(fd), level, opt)
+	})
+	require.NoError(t, err)
+	require.NoError(t, serr)
+	return v
+}
+
+func TestClientBufferSizes(t *testing.T) {
+	s, err := rtsptest.New(testStream())
+	require.NoError(t, err)
+	defer s.Close()
+
+	conn, err := ClientConf{
+		StreamProtocol:          testStreamProtocol("udp"),
+		UDPKernelReadBufferSize: 0x10000,
+		WriteBufferSize:         8192,
+	}.DialRead(s.URL().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// the kernel doubles the requested size, in order to allow space for bookkeeping
+	for _, l := range []*clientConnUDPListener{conn.udpRTPListeners[0], conn.udpRTCPListeners[0]} {
+		require.Equal(t, 2*0x10000, getsockoptInt(t, l.pc.(*net.UDPConn), syscall.SOL_SOCKET, syscall.SO_RCVBUF))
+	}
+
+	require.Equal(t, 8192, conn.bw.Size())
+}
+
+func TestClientSocketQoS(t *testing.T) {
+	s, err := rtsptest.New(testStream())
+	require.NoError(t, err)
+	defer s.Close()
+
+	conn, err := ClientConf{
+		StreamProtocol: testStreamProtocol("udp"),
+		DSCP:           46,
+		SocketPriority: 5,
+	}.DialRead(s.URL().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	for _, sc := range []syscall.Conn{
+		conn.nconn.(*net.TCPConn),
+		conn.udpRTPListeners[0].pc.(*net.UDPConn),
+		conn.udpRTCPListeners[0].pc.(*net.UDPConn),
+	} {
+		require.Equal(t, 46<<2, getsockoptInt(t, sc, syscall.IPPROTO_IP, syscall.IP_TOS))
+		require.Equal(t, 5, getsockoptInt(t, sc, syscall.SOL_SOCKET, syscall.SO_PRIORITY))
+	}
+}
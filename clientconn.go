@@ -9,55 +9,82 @@ package gortsplib
 
 import (
 	"bufio"
+	"context"
 	"crypto/tls"
 	"fmt"
 	"math/rand"
 	"net"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aler9/gortsplib/pkg/auth"
 	"github.com/aler9/gortsplib/pkg/base"
 	"github.com/aler9/gortsplib/pkg/headers"
 	"github.com/aler9/gortsplib/pkg/multibuffer"
+	"github.com/aler9/gortsplib/pkg/ringbuffer"
 	"github.com/aler9/gortsplib/pkg/rtcpreceiver"
 	"github.com/aler9/gortsplib/pkg/rtcpsender"
+	"github.com/aler9/gortsplib/pkg/rtpfec"
+	"github.com/aler9/gortsplib/pkg/rtpnack"
+	"github.com/aler9/gortsplib/pkg/rtppacer"
+	"github.com/aler9/gortsplib/pkg/rtpreorderer"
 )
 
 const (
-	clientConnReadBufferSize         = 4096
-	clientConnWriteBufferSize        = 4096
-	clientConnReceiverReportPeriod   = 10 * time.Second
-	clientConnSenderReportPeriod     = 10 * time.Second
-	clientConnUDPCheckStreamPeriod   = 5 * time.Second
-	clientConnUDPKeepalivePeriod     = 30 * time.Second
-	clientConnTCPFrameReadBufferSize = 2048
+	clientConnReadBufferSize       = 4096
+	clientConnUDPCheckStreamPeriod = 5 * time.Second
+	clientConnUDPKeepalivePeriod   = 30 * time.Second
+	clientConnMaxRedirects         = 10
+	clientConnMaxReconnectBackoff  = 30 * time.Second
+	clientConnNACKBufferSize       = 512
+	clientConnEarlyReadQueueSize   = 512
+
+	onvifBackchannelFeature = "www.onvif.org/ver20/backchannel"
+	onvifReplayFeature      = "onvif-replay"
 )
 
-type clientConnState int
+// report periods are variables in order to allow tests to shorten them.
+var (
+	clientConnReceiverReportPeriod = 10 * time.Second
+	clientConnSenderReportPeriod   = 10 * time.Second
+)
+
+// ClientConnState is the state of the connection.
+// Tracks that are set up bring the connection into the PrePlay or PreRecord state,
+// ReadFrames() and Record() into the Play or Record state, and Pause() back into
+// the PrePlay or PreRecord state. Teardown() brings the connection back into
+// the Initial state, while Close() into the Closed state.
+type ClientConnState int
 
+// standard states.
 const (
-	clientConnStateInitial clientConnState = iota
-	clientConnStatePrePlay
-	clientConnStatePlay
-	clientConnStatePreRecord
-	clientConnStateRecord
+	ClientConnStateInitial ClientConnState = iota
+	ClientConnStatePrePlay
+	ClientConnStatePlay
+	ClientConnStatePreRecord
+	ClientConnStateRecord
+	ClientConnStateClosed
 )
 
-func (s clientConnState) String() string {
+// String implements fmt.Stringer.
+func (s ClientConnState) String() string {
 	switch s {
-	case clientConnStateInitial:
+	case ClientConnStateInitial:
 		return "initial"
-	case clientConnStatePrePlay:
+	case ClientConnStatePrePlay:
 		return "prePlay"
-	case clientConnStatePlay:
+	case ClientConnStatePlay:
 		return "play"
-	case clientConnStatePreRecord:
+	case ClientConnStatePreRecord:
 		return "preRecord"
-	case clientConnStateRecord:
+	case ClientConnStateRecord:
 		return "record"
+	case ClientConnStateClosed:
+		return "closed"
 	}
 	return "uknown"
 }
@@ -65,41 +92,80 @@ func (s clientConnState) String() string {
 // ClientConn is a client-side RTSP connection.
 type ClientConn struct {
 	conf                  ClientConf
+	scheme                string
+	host                  string
 	nconn                 net.Conn
+	nconnClosed           bool
 	isTLS                 bool
 	br                    *bufio.Reader
 	bw                    *bufio.Writer
+	requestMutex          sync.Mutex
 	session               string
+	sessionTimeout        time.Duration
 	cseq                  int
 	sender                *auth.Sender
-	state                 clientConnState
+	sessionMutex          sync.RWMutex
+	state                 ClientConnState
+	stateMutex            sync.Mutex
 	streamURL             *base.URL
+	describeURL           *base.URL
+	sdp                   []byte
 	streamProtocol        *StreamProtocol
 	tracks                Tracks
 	udpRTPListeners       map[int]*clientConnUDPListener
 	udpRTCPListeners      map[int]*clientConnUDPListener
+	tcpChannels           map[int]clientConnTCPChannel
+	tcpTrackChannels      map[int][2]int
 	getParameterSupported bool
+	serverHeader          string
+	publicMethods         []base.Method
 
 	// read only
 	rtcpReceivers     map[int]*rtcpreceiver.RTCPReceiver
 	udpLastFrameTimes map[int]*int64
+	udpFrameReceived  int32
+	rtpLastFrameTimes map[int]*int64
+	stalledTracks     map[int]struct{}
+	rtpInfo           map[int]*headers.RTPInfoEntry
 	tcpFrameBuffer    *multibuffer.MultiBuffer
 	readCB            func(int, StreamType, []byte)
+	readFrameCh       chan clientConnFrame
+	readFrameDone     chan error
+	readFrameErr      error
+	backchannelTracks map[int]struct{}
+	backchannelOpen   bool
+	earlyRead         *clientConnEarlyRead
 
 	// publish only
-	rtcpSenders       map[int]*rtcpsender.RTCPSender
-	publishError      error
-	publishWriteMutex sync.RWMutex
-	publishOpen       bool
+	rtcpSenders         map[int]*rtcpsender.RTCPSender
+	nackResponders      map[int]*rtpnack.Responder
+	fecEncoders         map[int]*rtpfec.Encoder
+	pacer               *rtppacer.Pacer
+	publishError        error
+	publishWriteMutex   sync.RWMutex
+	publishOpen         bool
+	writeRingBuffer     *ringbuffer.RingBuffer
+	backgroundWriteDone chan struct{}
+
+	// background responses
+	backgroundReaderRunning int32
+	backgroundReaderDone    chan struct{}
+	backgroundResponseMutex sync.Mutex
+	backgroundResponseCSeq  string
+	backgroundResponses     chan *base.Response
+
+	// trace
+	tracer *clientConnTracer
 
 	// in
+	backgroundRunning   bool
 	backgroundTerminate chan struct{}
 
 	// out
 	backgroundDone chan struct{}
 }
 
-func newClientConn(conf ClientConf, scheme string, host string) (*ClientConn, error) {
+func newClientConn(ctx context.Context, conf ClientConf, scheme string, host string) (*ClientConn, error) {
 	if conf.TLSConfig == nil {
 		conf.TLSConfig = &tls.Config{InsecureSkipVerify: true}
 	}
@@ -109,68 +175,227 @@ func newClientConn(conf ClientConf, scheme string, host string) (*ClientConn, er
 	if conf.WriteTimeout == 0 {
 		conf.WriteTimeout = 10 * time.Second
 	}
+	if conf.HolePunchingPeriod == 0 {
+		conf.HolePunchingPeriod = 20 * time.Second
+	}
+	if conf.ConnectTimeout == 0 {
+		conf.ConnectTimeout = conf.ReadTimeout
+	}
+	if conf.RequestTimeout == 0 {
+		conf.RequestTimeout = conf.ReadTimeout
+	}
+	if conf.TeardownTimeout == 0 {
+		conf.TeardownTimeout = 2 * time.Second
+	}
 	if conf.ReadBufferCount == 0 {
 		conf.ReadBufferCount = 1
 	}
-	if conf.DialTimeout == nil {
-		conf.DialTimeout = net.DialTimeout
+	if conf.ReadBufferSize == 0 {
+		conf.ReadBufferSize = 2048
+	}
+	if conf.UDPKernelReadBufferSize == 0 {
+		// use the same buffer size as gstreamer's rtspsrc
+		conf.UDPKernelReadBufferSize = 0x80000
+	}
+	if conf.WriteBufferSize == 0 {
+		conf.WriteBufferSize = 4096
+	}
+	if conf.ProbeTimeout == 0 {
+		conf.ProbeTimeout = 3 * time.Second
+	}
+	if conf.ProbeCount == 0 {
+		conf.ProbeCount = 1
+	}
+	if conf.StallTimeout == 0 {
+		conf.StallTimeout = 5 * time.Second
 	}
 	if conf.ListenPacket == nil {
 		conf.ListenPacket = net.ListenPacket
 	}
+	if conf.ReconnectBackoff == nil {
+		conf.ReconnectBackoff = clientConnReconnectBackoff
+	}
 
-	if scheme != "rtsp" && scheme != "rtsps" {
+	if scheme != "rtsp" && scheme != "rtsps" && scheme != "rtspt" {
 		return nil, fmt.Errorf("unsupported scheme '%s'", scheme)
 	}
 
-	v := StreamProtocolUDP
-	if scheme == "rtsps" && conf.StreamProtocol == &v {
-		return nil, fmt.Errorf("RTSPS can't be used with UDP")
+	if scheme == "rtspt" {
+		conf.HTTPTunnel = true
+	}
+
+	if scheme == "rtsps" && conf.StreamProtocol != nil && *conf.StreamProtocol == StreamProtocolUDP {
+		return nil, fmt.Errorf("%w: RTSPS can't be used with UDP", ErrUnsupportedTransport)
+	}
+
+	if conf.HTTPTunnel && conf.StreamProtocol != nil && *conf.StreamProtocol == StreamProtocolUDP {
+		return nil, fmt.Errorf("%w: HTTP tunneling can't be used with UDP", ErrUnsupportedTransport)
+	}
+
+	if conf.FECGroupSize != 0 && (conf.FECGroupSize < 2 || conf.FECGroupSize > rtpfec.MaxGroupSize) {
+		return nil, fmt.Errorf("invalid FEC group size (%d)", conf.FECGroupSize)
+	}
+
+	if conf.DSCP < 0 || conf.DSCP > 63 {
+		return nil, fmt.Errorf("invalid DSCP (%d)", conf.DSCP)
+	}
+
+	if conf.UDPListenerPool != nil && (conf.AnyPortEnable || conf.AnySourceEnable) {
+		return nil, fmt.Errorf("AnyPortEnable and AnySourceEnable can't be used with UDPListenerPool")
+	}
+
+	if conf.LocalAddr != "" && net.ParseIP(conf.LocalAddr) == nil {
+		return nil, fmt.Errorf("invalid local address '%s'", conf.LocalAddr)
+	}
+
+	// add the default port. The host can be an IPv6 address enclosed in brackets.
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"), "554")
+	}
+
+	dial := func() (net.Conn, error) {
+		if conf.DialContextFunc != nil {
+			return conf.DialContextFunc(ctx, "tcp", host)
+		}
+		if conf.DialTimeout != nil {
+			return conf.DialTimeout("tcp", host, conf.ConnectTimeout)
+		}
+
+		dialer := &net.Dialer{Timeout: conf.ConnectTimeout}
+
+		if conf.LocalAddr != "" || conf.LocalInterface != "" {
+			hostname, port, _ := net.SplitHostPort(host)
+			addrs, err := net.DefaultResolver.LookupIPAddr(ctx, hostname)
+			if err != nil {
+				return nil, err
+			}
+
+			// use the first server address that can be reached from the local one
+			for _, addr := range addrs {
+				var ip net.IP
+				ip, err = localIP(conf, addr.IP)
+				if err != nil {
+					continue
+				}
+
+				dialer.LocalAddr = &net.TCPAddr{IP: ip}
+				return dialer.DialContext(ctx, "tcp", net.JoinHostPort(addr.String(), port))
+			}
+			return nil, err
+		}
+
+		return dialer.DialContext(ctx, "tcp", host)
 	}
 
-	if !strings.Contains(host, ":") {
-		host += ":554"
+	nconn, err := func() (net.Conn, error) {
+		if conf.HTTPTunnel {
+			return newClientConnTunnel(dial, host, conf.ConnectTimeout)
+		}
+		return dial()
+	}()
+	if err != nil {
+		return nil, err
 	}
 
-	nconn, err := conf.DialTimeout("tcp", host, conf.ReadTimeout)
+	err = setSocketQoS(nconn, conf.DSCP, conf.SocketPriority)
 	if err != nil {
+		nconn.Close()
 		return nil, err
 	}
 
 	conn := func() net.Conn {
 		if scheme == "rtsps" {
-			return tls.Client(nconn, conf.TLSConfig)
+			tlsConf := conf.TLSConfig
+
+			// use the host as server name, in order to allow certificate
+			// verification and to send the SNI extension
+			if tlsConf.ServerName == "" {
+				tlsConf = tlsConf.Clone()
+				tlsConf.ServerName, _, _ = net.SplitHostPort(host)
+			}
+
+			return tls.Client(nconn, tlsConf)
 		}
 		return nconn
 	}()
 
+	log(conf.Logger, LogLevelInfo, "connection opened",
+		LogField{"host", host},
+		LogField{"scheme", scheme})
+	addGauge(conf.Metrics, MetricClientConnections, 1)
+
+	var tracer *clientConnTracer
+	if conf.TraceWriter != nil {
+		tracer = newClientConnTracer(conf.TraceWriter, conf.TraceFrames)
+	}
+
 	return &ClientConn{
-		conf:              conf,
-		nconn:             nconn,
-		isTLS:             (scheme == "rtsps"),
-		br:                bufio.NewReaderSize(conn, clientConnReadBufferSize),
-		bw:                bufio.NewWriterSize(conn, clientConnWriteBufferSize),
-		udpRTPListeners:   make(map[int]*clientConnUDPListener),
-		udpRTCPListeners:  make(map[int]*clientConnUDPListener),
-		rtcpReceivers:     make(map[int]*rtcpreceiver.RTCPReceiver),
-		udpLastFrameTimes: make(map[int]*int64),
-		tcpFrameBuffer:    multibuffer.New(conf.ReadBufferCount, clientConnTCPFrameReadBufferSize),
-		rtcpSenders:       make(map[int]*rtcpsender.RTCPSender),
-		publishError:      fmt.Errorf("not running"),
+		conf:                conf,
+		scheme:              scheme,
+		host:                host,
+		nconn:               nconn,
+		isTLS:               (scheme == "rtsps"),
+		br:                  bufio.NewReaderSize(conn, clientConnReadBufferSize),
+		bw:                  bufio.NewWriterSize(conn, conf.WriteBufferSize),
+		udpRTPListeners:     make(map[int]*clientConnUDPListener),
+		udpRTCPListeners:    make(map[int]*clientConnUDPListener),
+		tcpChannels:         make(map[int]clientConnTCPChannel),
+		tcpTrackChannels:    make(map[int][2]int),
+		rtcpReceivers:       make(map[int]*rtcpreceiver.RTCPReceiver),
+		udpLastFrameTimes:   make(map[int]*int64),
+		rtpLastFrameTimes:   make(map[int]*int64),
+		stalledTracks:       make(map[int]struct{}),
+		backchannelTracks:   make(map[int]struct{}),
+		tcpFrameBuffer:      multibuffer.New(conf.ReadBufferCount, conf.ReadBufferSize),
+		rtcpSenders:         make(map[int]*rtcpsender.RTCPSender),
+		nackResponders:      make(map[int]*rtpnack.Responder),
+		fecEncoders:         make(map[int]*rtpfec.Encoder),
+		publishError:        fmt.Errorf("not running"),
+		backgroundResponses: make(chan *base.Response, 1),
+		tracer:              tracer,
 	}, nil
 }
 
+func (c *ClientConn) keepaliveMethod() base.Method {
+	if c.conf.KeepaliveMethod != "" {
+		return c.conf.KeepaliveMethod
+	}
+
+	// the vlc integrated rtsp server requires GET_PARAMETER
+	if c.getParameterSupported {
+		return base.GetParameter
+	}
+	return base.Options
+}
+
+func (c *ClientConn) keepalivePeriod() time.Duration {
+	if c.conf.KeepalivePeriod != 0 {
+		return c.conf.KeepalivePeriod
+	}
+
+	// send keepalives before the session timeout expires
+	if timeout := c.SessionTimeout(); timeout != 0 {
+		if v := timeout * 8 / 10; v < clientConnUDPKeepalivePeriod {
+			return v
+		}
+	}
+
+	return clientConnUDPKeepalivePeriod
+}
+
 // Close closes all the ClientConn resources.
+// If a stream is set up, a TEARDOWN request is sent before closing the connection,
+// in order to release the session on the server, and its response is awaited
+// for at most ClientConf.TeardownTimeout. In this case, the returned error
+// reports whether the TEARDOWN request succeeded.
 func (c *ClientConn) Close() error {
-	if c.state == clientConnStatePlay || c.state == clientConnStateRecord {
-		close(c.backgroundTerminate)
-		<-c.backgroundDone
-
-		c.Do(&base.Request{
-			Method:       base.Teardown,
-			URL:          c.streamURL,
-			SkipResponse: true,
-		})
+	c.stopBackground()
+
+	var teardownErr error
+
+	// the session may have not been restored by a reconnection
+	if c.streamURL != nil {
+		teardownErr = c.closeTeardown()
 	}
 
 	for _, l := range c.udpRTPListeners {
@@ -181,40 +406,435 @@ func (c *ClientConn) Close() error {
 		l.close()
 	}
 
-	err := c.nconn.Close()
+	// the connection may have been closed by a failed reconnection
+	var err error
+	if !c.nconnClosed {
+		err = c.nconn.Close()
+		addGauge(c.conf.Metrics, MetricClientConnections, -1)
+	}
+	c.setState(ClientConnStateClosed)
+
+	log(c.conf.Logger, LogLevelInfo, "connection closed",
+		LogField{"host", c.host})
+
+	if teardownErr != nil {
+		return teardownErr
+	}
 	return err
 }
 
-func (c *ClientConn) checkState(allowed map[clientConnState]struct{}) error {
-	if _, ok := allowed[c.state]; ok {
+func (c *ClientConn) closeTeardown() error {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), c.conf.TeardownTimeout)
+	defer ctxCancel()
+
+	return c.runContext(ctx, func() error {
+		res, err := c.Do(&base.Request{
+			Method: base.Teardown,
+			URL:    c.streamURL,
+		})
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode != base.StatusOK {
+			return ErrWrongStatusCode{Response: res}
+		}
+
+		return nil
+	})
+}
+
+// stopBackground stops the background routine, if it is running.
+func (c *ClientConn) stopBackground() {
+	if !c.backgroundRunning {
+		return
+	}
+
+	c.backgroundRunning = false
+	close(c.backgroundTerminate)
+	<-c.backgroundDone
+	c.earlyRead = nil
+}
+
+// State returns the state of the connection.
+// It can be called from any routine.
+func (c *ClientConn) State() ClientConnState {
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+	return c.state
+}
+
+func (c *ClientConn) setState(state ClientConnState) {
+	c.stateMutex.Lock()
+	prev := c.state
+	c.state = state
+	c.stateMutex.Unlock()
+
+	if state != prev && c.conf.OnStateChange != nil {
+		c.conf.OnStateChange(prev, state)
+	}
+}
+
+func (c *ClientConn) checkState(allowed map[ClientConnState]struct{}) error {
+	// the state can be changed by the background routine
+	state := c.State()
+	if _, ok := allowed[state]; ok {
 		return nil
 	}
 
-	var allowedList []clientConnState
+	var allowedList []ClientConnState
 	for a := range allowed {
 		allowedList = append(allowedList, a)
 	}
 	return fmt.Errorf("must be in state %v, while is in state %v",
-		allowedList, c.state)
+		allowedList, state)
+}
+
+// runContext runs fn and closes the connection if ctx is done before fn returns,
+// in order to interrupt any pending operation.
+func (c *ClientConn) runContext(ctx context.Context, fn func() error) error {
+	// context can't be canceled
+	if ctx.Done() == nil {
+		return fn()
+	}
+
+	terminate := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		select {
+		case <-ctx.Done():
+			// the connection can be replaced by a redirect
+			c.NetConn().Close()
+		case <-terminate:
+		}
+	}()
+
+	err := fn()
+
+	close(terminate)
+	<-done
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
 }
 
 // NetConn returns the underlying net.Conn.
 func (c *ClientConn) NetConn() net.Conn {
+	c.sessionMutex.RLock()
+	defer c.sessionMutex.RUnlock()
 	return c.nconn
 }
 
 // Tracks returns all the tracks that the connection is reading or publishing.
 func (c *ClientConn) Tracks() Tracks {
+	c.sessionMutex.RLock()
+	defer c.sessionMutex.RUnlock()
 	return c.tracks
 }
 
+// StreamProtocol returns the stream protocol that has been negotiated with
+// the server, that can be different from the one in ClientConf when it is
+// chosen automatically.
+// It returns nil if no track has been set up.
+func (c *ClientConn) StreamProtocol() *StreamProtocol {
+	c.sessionMutex.RLock()
+	defer c.sessionMutex.RUnlock()
+	return c.streamProtocol
+}
+
+// StreamURL returns the URL of the stream that is being read or published,
+// that is the one actually used after following redirects.
+// It returns nil if no stream has been set up.
+func (c *ClientConn) StreamURL() *base.URL {
+	c.sessionMutex.RLock()
+	defer c.sessionMutex.RUnlock()
+	return c.streamURL
+}
+
+// Session returns the ID of the session provided by the server.
+// It returns an empty string if no session has been created.
+func (c *ClientConn) Session() string {
+	c.sessionMutex.RLock()
+	defer c.sessionMutex.RUnlock()
+	return c.session
+}
+
+// SessionTimeout returns the session timeout provided by the server
+// with the "timeout" parameter of the Session header.
+// It returns zero if the server has not provided it.
+func (c *ClientConn) SessionTimeout() time.Duration {
+	c.sessionMutex.RLock()
+	defer c.sessionMutex.RUnlock()
+	return c.sessionTimeout
+}
+
+// ServerHeader returns the Server header received with the OPTIONS response,
+// that usually contains the name and version of the server.
+// It returns an empty string if the header has not been received.
+func (c *ClientConn) ServerHeader() string {
+	c.sessionMutex.RLock()
+	defer c.sessionMutex.RUnlock()
+	return c.serverHeader
+}
+
+// PublicMethods returns the methods supported by the server, listed in the
+// Public header received with the OPTIONS response.
+// It returns nil if the header has not been received.
+func (c *ClientConn) PublicMethods() []base.Method {
+	c.sessionMutex.RLock()
+	defer c.sessionMutex.RUnlock()
+	return c.publicMethods
+}
+
+// TrackStats returns statistics about the RTP packets received on a track
+// that is being read.
+// It returns false if the track is not being read.
+func (c *ClientConn) TrackStats(trackID int) (rtcpreceiver.Stats, bool) {
+	c.sessionMutex.RLock()
+	defer c.sessionMutex.RUnlock()
+	rr, ok := c.rtcpReceivers[trackID]
+	if !ok {
+		return rtcpreceiver.Stats{}, false
+	}
+	return rr.Stats(), true
+}
+
+// TrackRemoteStats returns statistics about the RTP packets sent on a track
+// that is being published, provided by the server through RTCP receiver reports.
+// They can be used to adapt the bitrate to network conditions.
+// It returns false if the track is not being published or if no receiver
+// report has been received yet.
+func (c *ClientConn) TrackRemoteStats(trackID int) (rtcpsender.RemoteStats, bool) {
+	c.sessionMutex.RLock()
+	defer c.sessionMutex.RUnlock()
+	rs, ok := c.rtcpSenders[trackID]
+	if !ok {
+		return rtcpsender.RemoteStats{}, false
+	}
+	return rs.RemoteStats()
+}
+
+// TrackSSRC returns the SSRC of the last RTP packet received on a track that is being read.
+// It returns false if no RTP packet has been received yet.
+func (c *ClientConn) TrackSSRC(trackID int) (uint32, bool) {
+	c.sessionMutex.RLock()
+	defer c.sessionMutex.RUnlock()
+	rr, ok := c.rtcpReceivers[trackID]
+	if !ok {
+		return 0, false
+	}
+	return rr.SSRC()
+}
+
+// TrackRTPInfo returns the entry of the RTP-Info header associated with a track,
+// sent by the server in response to the last PLAY request. It contains the
+// sequence number and the RTP timestamp of the first packet of the track.
+// It returns false if the server didn't provide it.
+func (c *ClientConn) TrackRTPInfo(trackID int) (*headers.RTPInfoEntry, bool) {
+	c.sessionMutex.RLock()
+	defer c.sessionMutex.RUnlock()
+	e, ok := c.rtpInfo[trackID]
+	return e, ok
+}
+
+// PacketNTP returns the absolute time of a RTP packet of a track that is being read,
+// computed by using the RTCP sender reports sent by the server.
+// It returns false if no sender report has been received yet.
+// It can be called inside the callback passed to ReadFrames().
+func (c *ClientConn) PacketNTP(trackID int, rtpTime uint32) (time.Time, bool) {
+	c.sessionMutex.RLock()
+	defer c.sessionMutex.RUnlock()
+	rr, ok := c.rtcpReceivers[trackID]
+	if !ok {
+		return time.Time{}, false
+	}
+	return rr.PacketNTP(rtpTime)
+}
+
+// PacketPTS returns the presentation time of a RTP packet of a track that is being read,
+// relative to the first packet received on the track.
+// It returns false if no packet has been received yet.
+// It can be called inside the callback passed to ReadFrames().
+func (c *ClientConn) PacketPTS(trackID int, rtpTime uint32) (time.Duration, bool) {
+	c.sessionMutex.RLock()
+	defer c.sessionMutex.RUnlock()
+	rr, ok := c.rtcpReceivers[trackID]
+	if !ok {
+		return 0, false
+	}
+	return rr.PacketPTS(rtpTime)
+}
+
+// SDP returns the raw SDP received with the last DESCRIBE response.
+// It can be used to access session-level and vendor-specific attributes
+// that are not parsed into tracks.
+func (c *ClientConn) SDP() []byte {
+	c.sessionMutex.RLock()
+	defer c.sessionMutex.RUnlock()
+	return c.sdp
+}
+
 // Do writes a Request and reads a Response.
+// The CSeq, Session, Authorization and User-Agent headers are filled automatically,
+// therefore Do can be used to send any request, including non-standard methods,
+// within the current session.
+// It can be called while reading or publishing too, in which case the response
+// is received by the reading routine.
 // Interleaved frames received before the response are ignored.
+// It can be called by multiple routines; requests that need a response
+// are sent one at a time.
 func (c *ClientConn) Do(req *base.Request) (*base.Response, error) {
+	// the reading routine routes responses to a single waiter
+	if !req.SkipResponse {
+		c.requestMutex.Lock()
+		defer c.requestMutex.Unlock()
+	}
+
+	return c.do(req, false)
+}
+
+func (c *ClientConn) do(req *base.Request, nonceRenewed bool) (*base.Response, error) {
+	// when the connection is read in background, requests must not
+	// be interleaved with frames and responses are routed by the reading routine
+	inBackground := atomic.LoadInt32(&c.backgroundReaderRunning) == 1
+
+	// the connection can be replaced by a reconnection
+	c.publishWriteMutex.Lock()
+	err := c.writeRequest(req, inBackground && !req.SkipResponse)
+	c.publishWriteMutex.Unlock()
+	if err != nil {
+		c.setBackgroundResponseCSeq("")
+		return nil, err
+	}
+
+	if req.SkipResponse {
+		return nil, nil
+	}
+
+	var res *base.Response
+	if inBackground {
+		res, err = c.waitBackgroundResponse()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// read the response and ignore interleaved frames in between;
+		// interleaved frames are sent in two situations:
+		// * when the server is v4lrtspserver, before the PLAY response
+		// * when the stream is already playing
+		res = &base.Response{}
+		c.nconn.SetReadDeadline(time.Now().Add(c.conf.RequestTimeout))
+		err = res.ReadIgnoreFramesWithLimits(c.br, c.tcpFrameBuffer.Next(), c.readLimits())
+		if err != nil {
+			return nil, err
+		}
+
+		if c.tracer != nil {
+			c.tracer.response(false, res)
+		}
+	}
+
+	if c.conf.OnResponse != nil {
+		c.conf.OnResponse(res)
+	}
+
+	log(c.conf.Logger, LogLevelDebug, "response",
+		LogField{"method", req.Method},
+		LogField{"status", res.StatusCode})
+
+	// get session from response
+	if v, ok := res.Header["Session"]; ok {
+		sx, err := headers.ReadSession(v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse session header: %s", err)
+		}
+
+		// the session is read by keepalives, that are sent in background
+		c.sessionMutex.Lock()
+		c.session = sx.Session
+		if sx.Timeout != nil {
+			c.sessionTimeout = time.Duration(*sx.Timeout) * time.Second
+		}
+		c.sessionMutex.Unlock()
+	}
+
+	c.sessionMutex.RLock()
+	sender := c.sender
+	c.sessionMutex.RUnlock()
+
+	// setup authentication
+	if res.StatusCode == base.StatusUnauthorized && sender == nil {
+		user, pass, ok, err := c.credentials(req.URL, res.Header["WWW-Authenticate"])
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return res, nil
+		}
+
+		sender, err := auth.NewSender(res.Header["WWW-Authenticate"], user, pass)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to setup authentication: %s", ErrAuthFailed, err)
+		}
+		c.sessionMutex.Lock()
+		c.sender = sender
+		c.sessionMutex.Unlock()
+
+		// send request again
+		return c.do(req, nonceRenewed)
+	}
+
+	// the nonce expired; send the request again with the new one
+	if res.StatusCode == base.StatusUnauthorized && sender != nil && !nonceRenewed {
+		c.sessionMutex.Lock()
+		err := sender.RefreshNonce(res.Header["WWW-Authenticate"])
+		c.sessionMutex.Unlock()
+		if err == nil {
+			return c.do(req, true)
+		}
+	}
+
+	return res, nil
+}
+
+// readLimits returns the limits that are applied to requests and responses sent by the server.
+func (c *ClientConn) readLimits() base.ReadLimits {
+	return base.ReadLimits{
+		MaxHeaderSize:  c.conf.MaxHeaderSize,
+		MaxHeaderCount: c.conf.MaxHeaderCount,
+		MaxBodySize:    c.conf.MaxBodySize,
+	}
+}
+
+// writeRequest fills the automatic headers of a request and writes it.
+// If waitInBackground is true, the response is routed by the reading routine
+// to waitBackgroundResponse().
+func (c *ClientConn) writeRequest(req *base.Request, waitInBackground bool) error {
 	if req.Header == nil {
 		req.Header = make(base.Header)
 	}
 
+	// request ONVIF features
+	var require []string
+	if c.conf.RequireBackchannel &&
+		(req.Method == base.Describe || req.Method == base.Setup || req.Method == base.Play) {
+		require = append(require, onvifBackchannelFeature)
+	}
+	if c.conf.RequireReplay &&
+		(req.Method == base.Setup || req.Method == base.Play) {
+		require = append(require, onvifReplayFeature)
+	}
+	if require != nil {
+		req.Header["Require"] = base.HeaderValue{strings.Join(require, ", ")}
+	}
+
+	c.sessionMutex.Lock()
+
 	// add session
 	if c.session != "" {
 		req.Header["Session"] = base.HeaderValue{c.session}
@@ -227,73 +847,280 @@ func (c *ClientConn) Do(req *base.Request) (*base.Response, error) {
 
 	// add cseq
 	c.cseq++
-	req.Header["CSeq"] = base.HeaderValue{strconv.FormatInt(int64(c.cseq), 10)}
+	cseq := strconv.FormatInt(int64(c.cseq), 10)
+	req.Header["CSeq"] = base.HeaderValue{cseq}
+
+	c.sessionMutex.Unlock()
+
+	// the response can be received as soon as the request is written
+	if waitInBackground {
+		c.setBackgroundResponseCSeq(cseq)
+	}
 
 	// add user agent
 	req.Header["User-Agent"] = base.HeaderValue{"gortsplib"}
 
+	// add custom headers
+	for k, v := range c.conf.RequestHeader {
+		if k != "CSeq" {
+			req.Header[k] = v
+		}
+	}
+
 	if c.conf.OnRequest != nil {
 		c.conf.OnRequest(req)
 	}
 
-	c.nconn.SetWriteDeadline(time.Now().Add(c.conf.WriteTimeout))
-	err := req.Write(c.bw)
-	if err != nil {
-		return nil, err
+	log(c.conf.Logger, LogLevelDebug, "request",
+		LogField{"method", req.Method},
+		LogField{"url", req.URL.Redacted()})
+
+	if c.tracer != nil {
+		c.tracer.request(true, req)
 	}
 
-	if req.SkipResponse {
-		return nil, nil
+	c.nconn.SetWriteDeadline(time.Now().Add(c.conf.RequestTimeout))
+	return req.Write(c.bw)
+}
+
+// handleBackgroundResponse is called by the reading routine when a response is received.
+func (c *ClientConn) handleBackgroundResponse(res *base.Response) {
+	if c.tracer != nil {
+		c.tracer.response(false, res)
 	}
 
-	// read the response and ignore interleaved frames in between;
-	// interleaved frames are sent in two situations:
-	// * when the server is v4lrtspserver, before the PLAY response
-	// * when the stream is already playing
-	var res base.Response
-	c.nconn.SetReadDeadline(time.Now().Add(c.conf.ReadTimeout))
-	err = res.ReadIgnoreFrames(c.br, c.tcpFrameBuffer.Next())
-	if err != nil {
-		return nil, err
+	c.backgroundResponseMutex.Lock()
+	waiting := false
+	if v, ok := res.Header["CSeq"]; ok && len(v) == 1 && c.backgroundResponseCSeq != "" &&
+		v[0] == c.backgroundResponseCSeq {
+		c.backgroundResponseCSeq = ""
+		waiting = true
+	}
+	c.backgroundResponseMutex.Unlock()
+
+	if waiting {
+		c.backgroundResponses <- res
+		return
 	}
 
 	if c.conf.OnResponse != nil {
-		c.conf.OnResponse(&res)
+		c.conf.OnResponse(res)
 	}
+}
 
-	// get session from response
-	if v, ok := res.Header["Session"]; ok {
-		sx, err := headers.ReadSession(v)
-		if err != nil {
-			return nil, fmt.Errorf("unable to parse session header: %s", err)
+// handleBackgroundRequest is called by the reading routine when a request
+// is received from the server. It returns an error if the reading must stop.
+func (c *ClientConn) handleBackgroundRequest(req *base.Request) error {
+	log(c.conf.Logger, LogLevelDebug, "server request",
+		LogField{"method", req.Method},
+		LogField{"url", req.URL.Redacted()})
+
+	if c.tracer != nil {
+		c.tracer.request(false, req)
+	}
+
+	res := base.Response{
+		StatusCode: base.StatusNotImplemented,
+		Header: base.Header{
+			"CSeq": req.Header["CSeq"],
+		},
+	}
+
+	if req.Method != base.Redirect && c.conf.OnServerRequest != nil {
+		if cres := c.conf.OnServerRequest(req); cres != nil {
+			if cres.Header == nil {
+				cres.Header = make(base.Header)
+			}
+			cres.Header["CSeq"] = req.Header["CSeq"]
+			c.writeBackgroundResponse(cres)
+			return nil
 		}
-		c.session = sx.Session
 	}
 
-	// setup authentication
-	if res.StatusCode == base.StatusUnauthorized && req.URL.User != nil && c.sender == nil {
-		pass, _ := req.URL.User.Password()
-		user := req.URL.User.Username()
+	err := c.processBackgroundRequest(req, &res)
+	c.writeBackgroundResponse(&res)
+	return err
+}
 
-		sender, err := auth.NewSender(res.Header["WWW-Authenticate"], user, pass)
-		if err != nil {
-			return nil, fmt.Errorf("unable to setup authentication: %s", err)
+// processBackgroundRequest fills the response to a request received from the
+// server while reading or publishing.
+func (c *ClientConn) processBackgroundRequest(req *base.Request, res *base.Response) error {
+	var err error
+
+	switch req.Method {
+	case base.Options:
+		res.StatusCode = base.StatusOK
+		res.Header["Public"] = base.HeaderValue{strings.Join([]string{
+			string(base.Announce),
+			string(base.GetParameter),
+			string(base.Options),
+			string(base.Redirect),
+			string(base.SetParameter),
+		}, ", ")}
+
+	// keepalives
+	case base.GetParameter, base.SetParameter:
+		if len(req.Body) == 0 {
+			res.StatusCode = base.StatusOK
+		} else {
+			res.StatusCode = base.StatusParameterNotUnderstood
 		}
-		c.sender = sender
 
-		// send request again
-		return c.Do(req)
+	case base.Announce:
+		if c.State() != ClientConnStatePlay {
+			break
+		}
+
+		tracks, aerr := c.readUpdatedTracks(req)
+		if aerr != nil {
+			res.StatusCode = base.StatusBadRequest
+			break
+		}
+
+		res.StatusCode = base.StatusOK
+
+		log(c.conf.Logger, LogLevelInfo, "session updated",
+			LogField{"tracks", len(tracks)})
+
+		if c.conf.OnSessionUpdate != nil {
+			c.conf.OnSessionUpdate(tracks)
+		}
+
+		if c.conf.SessionUpdateRenegotiate {
+			err = errClientConnSessionUpdated{tracks: tracks, sdp: req.Body}
+		}
+
+	case base.Redirect:
+		res.StatusCode = base.StatusBadRequest
+
+		if v, ok := req.Header["Location"]; ok && len(v) == 1 {
+			u, perr := base.ParseURL(v[0])
+			if perr == nil {
+				res.StatusCode = base.StatusOK
+				err = ErrServerRedirect{Location: u}
+
+				if c.conf.OnServerRedirect != nil {
+					c.conf.OnServerRedirect(u)
+				}
+			}
+		}
+	}
+
+	return err
+}
+
+func (c *ClientConn) writeBackgroundResponse(res *base.Response) {
+	if c.tracer != nil {
+		c.tracer.response(true, res)
+	}
+
+	c.publishWriteMutex.Lock()
+	c.nconn.SetWriteDeadline(time.Now().Add(c.conf.RequestTimeout))
+	res.Write(c.bw)
+	c.publishWriteMutex.Unlock()
+}
+
+// logBackgroundError logs an error that stopped the background routine,
+// unless the routine has been terminated on purpose.
+func (c *ClientConn) logBackgroundError(event string, err error) {
+	select {
+	case <-c.backgroundTerminate:
+	default:
+		log(c.conf.Logger, LogLevelError, event,
+			LogField{"error", err})
+	}
+}
+
+// startBackgroundReader marks the connection as read by the reading routine.
+// It waits for the pending request, whose response is read by the requesting routine.
+func (c *ClientConn) startBackgroundReader() {
+	c.requestMutex.Lock()
+	c.backgroundReaderDone = make(chan struct{})
+	atomic.StoreInt32(&c.backgroundReaderRunning, 1)
+	c.requestMutex.Unlock()
+}
+
+// stopBackgroundReader is called after the reading routine has exited.
+func (c *ClientConn) stopBackgroundReader() {
+	atomic.StoreInt32(&c.backgroundReaderRunning, 0)
+	close(c.backgroundReaderDone)
+}
+
+// setBackgroundResponseCSeq sets the CSeq of the response that is awaited
+// by waitBackgroundResponse(). Requests are serialized by Do(), therefore
+// there's at most one response to wait for.
+func (c *ClientConn) setBackgroundResponseCSeq(cseq string) {
+	c.backgroundResponseMutex.Lock()
+	c.backgroundResponseCSeq = cseq
+	c.backgroundResponseMutex.Unlock()
+}
+
+// waitBackgroundResponse waits for the response to the last request
+// from the reading routine.
+func (c *ClientConn) waitBackgroundResponse() (*base.Response, error) {
+	defer func() {
+		c.setBackgroundResponseCSeq("")
+
+		// discard a response received after the timeout
+		select {
+		case <-c.backgroundResponses:
+		default:
+		}
+	}()
+
+	timer := time.NewTimer(c.conf.RequestTimeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-c.backgroundResponses:
+		return res, nil
+
+	case <-timer.C:
+		return nil, fmt.Errorf("response not received")
+
+	case <-c.backgroundReaderDone:
+		return nil, fmt.Errorf("terminated")
 	}
+}
+
+// credentials returns the credentials used to authenticate, picking them
+// from the URL, from the configuration or from the OnCredentials callback.
+func (c *ClientConn) credentials(u *base.URL, wwwAuth base.HeaderValue) (string, string, bool, error) {
+	if u.User != nil {
+		pass, _ := u.User.Password()
+		return u.User.Username(), pass, true, nil
+	}
+
+	if c.conf.User != "" {
+		return c.conf.User, c.conf.Pass, true, nil
+	}
+
+	if c.conf.OnCredentials != nil {
+		realm := ""
+		for _, v := range wwwAuth {
+			auth, err := headers.ReadAuth(base.HeaderValue{v})
+			if err == nil && auth.Realm != nil {
+				realm = *auth.Realm
+				break
+			}
+		}
 
-	return &res, nil
+		user, pass, err := c.conf.OnCredentials(realm)
+		if err != nil {
+			return "", "", false, err
+		}
+		return user, pass, true, nil
+	}
+
+	return "", "", false, nil
 }
 
 // Options writes an OPTIONS request and reads a response.
 func (c *ClientConn) Options(u *base.URL) (*base.Response, error) {
-	err := c.checkState(map[clientConnState]struct{}{
-		clientConnStateInitial:   {},
-		clientConnStatePrePlay:   {},
-		clientConnStatePreRecord: {},
+	err := c.checkState(map[ClientConnState]struct{}{
+		ClientConnStateInitial:   {},
+		ClientConnStatePrePlay:   {},
+		ClientConnStatePreRecord: {},
 	})
 	if err != nil {
 		return nil, err
@@ -313,32 +1140,72 @@ func (c *ClientConn) Options(u *base.URL) (*base.Response, error) {
 		if res.StatusCode == base.StatusNotFound {
 			return res, nil
 		}
-		return res, fmt.Errorf("bad status code: %d (%s)", res.StatusCode, res.StatusMessage)
+		return res, ErrWrongStatusCode{Response: res}
 	}
 
-	c.getParameterSupported = func() bool {
-		pub, ok := res.Header["Public"]
-		if !ok || len(pub) != 1 {
-			return false
-		}
+	c.serverHeader = ""
+	if v, ok := res.Header["Server"]; ok && len(v) == 1 {
+		c.serverHeader = v[0]
+	}
 
+	c.publicMethods = nil
+	if pub, ok := res.Header["Public"]; ok && len(pub) == 1 {
 		for _, m := range strings.Split(pub[0], ",") {
-			if base.Method(m) == base.GetParameter {
-				return true
-			}
+			c.publicMethods = append(c.publicMethods, base.Method(strings.TrimSpace(m)))
 		}
-		return false
-	}()
+	}
+
+	c.getParameterSupported = false
+	for _, m := range c.publicMethods {
+		if m == base.GetParameter {
+			c.getParameterSupported = true
+			break
+		}
+	}
 
 	return res, nil
 }
 
+// contentBase returns the base URL of the tracks provided by a DESCRIBE response,
+// that is the Content-Base header or, if missing, the Content-Location header
+// or the request URL, as described in RFC 2326.
+func contentBase(u *base.URL, res *base.Response) (*base.URL, error) {
+	for _, key := range []string{"Content-Base", "Content-Location"} {
+		v, ok := res.Header[key]
+		if !ok || len(v) != 1 {
+			continue
+		}
+
+		ref, err := url.Parse(v[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s header: %s", key, err)
+		}
+
+		ur := (*base.URL)((*url.URL)(u).ResolveReference(ref))
+		if ur.Scheme != "rtsp" && ur.Scheme != "rtsps" && ur.Scheme != "rtspt" {
+			return nil, fmt.Errorf("invalid %s header: unsupported scheme '%s'", key, ur.Scheme)
+		}
+
+		// keep credentials if the host is the same
+		if ur.User == nil && ur.Host == u.Host {
+			ur.User = u.User
+		}
+		return ur, nil
+	}
+
+	return u, nil
+}
+
 // Describe writes a DESCRIBE request and reads a Response.
 func (c *ClientConn) Describe(u *base.URL) (Tracks, *base.Response, error) {
-	err := c.checkState(map[clientConnState]struct{}{
-		clientConnStateInitial:   {},
-		clientConnStatePrePlay:   {},
-		clientConnStatePreRecord: {},
+	return c.describe(context.Background(), u, nil)
+}
+
+func (c *ClientConn) describe(ctx context.Context, u *base.URL, visited []string) (Tracks, *base.Response, error) {
+	err := c.checkState(map[ClientConnState]struct{}{
+		ClientConnStateInitial:   {},
+		ClientConnStatePrePlay:   {},
+		ClientConnStatePreRecord: {},
 	})
 	if err != nil {
 		return nil, nil, err
@@ -362,28 +1229,51 @@ func (c *ClientConn) Describe(u *base.URL) (Tracks, *base.Response, error) {
 			res.StatusCode <= base.StatusUseProxy &&
 			len(res.Header["Location"]) == 1 {
 
-			c.Close()
+			visited = append(visited, u.String())
+
+			maxRedirects := c.conf.MaxRedirects
+			if maxRedirects == 0 {
+				maxRedirects = clientConnMaxRedirects
+			}
+			if len(visited) > maxRedirects {
+				return nil, res, ErrTooManyRedirects
+			}
 
 			u, err := base.ParseURL(res.Header["Location"][0])
 			if err != nil {
 				return nil, nil, err
 			}
 
-			nc, err := c.conf.Dial(u.Scheme, u.Host)
+			if c.conf.OnRedirect != nil {
+				u, err = c.conf.OnRedirect(u)
+				if err != nil {
+					return nil, res, err
+				}
+				if u == nil {
+					return nil, res, ErrWrongStatusCode{Response: res}
+				}
+			}
+
+			for _, v := range visited {
+				if v == u.String() {
+					return nil, res, ErrRedirectLoop
+				}
+			}
+
+			err = c.redirect(ctx, u)
 			if err != nil {
 				return nil, nil, err
 			}
-			*c = *nc //nolint:govet
 
 			_, err = c.Options(u)
 			if err != nil {
 				return nil, nil, err
 			}
 
-			return c.Describe(u)
+			return c.describe(ctx, u, visited)
 		}
 
-		return nil, res, fmt.Errorf("bad status code: %d (%s)", res.StatusCode, res.StatusMessage)
+		return nil, res, ErrWrongStatusCode{Response: res}
 	}
 
 	payloadType, ok := res.Header["Content-Type"]
@@ -400,10 +1290,22 @@ func (c *ClientConn) Describe(u *base.URL) (Tracks, *base.Response, error) {
 		return nil, nil, err
 	}
 
+	baseURL := u
+	if c.conf.TrackURLMode == TrackURLModeStrict {
+		baseURL, err = contentBase(u, res)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	for _, t := range tracks {
-		t.BaseURL = u
+		t.BaseURL = baseURL
+		t.URLMode = c.conf.TrackURLMode
 	}
 
+	c.describeURL = u
+	c.sdp = res.Body
+
 	return tracks, res, nil
 }
 
@@ -412,21 +1314,22 @@ func (c *ClientConn) Describe(u *base.URL) (Tracks, *base.Response, error) {
 // if rtpPort and rtcpPort are zero, they are chosen automatically.
 func (c *ClientConn) Setup(mode headers.TransportMode, track *Track,
 	rtpPort int, rtcpPort int) (*base.Response, error) {
-	err := c.checkState(map[clientConnState]struct{}{
-		clientConnStateInitial:   {},
-		clientConnStatePrePlay:   {},
-		clientConnStatePreRecord: {},
+	err := c.checkState(map[ClientConnState]struct{}{
+		ClientConnStateInitial:   {},
+		ClientConnStatePrePlay:   {},
+		ClientConnStatePreRecord: {},
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	if mode == headers.TransportModeRecord && c.state != clientConnStatePreRecord {
+	state := c.State()
+	if mode == headers.TransportModeRecord && state != ClientConnStatePreRecord {
 		return nil, fmt.Errorf("cannot read and publish at the same time")
 	}
 
-	if mode == headers.TransportModePlay && c.state != clientConnStatePrePlay &&
-		c.state != clientConnStateInitial {
+	if mode == headers.TransportModePlay && state != ClientConnStatePrePlay &&
+		state != ClientConnStateInitial {
 		return nil, fmt.Errorf("cannot read and publish at the same time")
 	}
 
@@ -437,12 +1340,16 @@ func (c *ClientConn) Setup(mode headers.TransportMode, track *Track,
 	var rtpListener *clientConnUDPListener
 	var rtcpListener *clientConnUDPListener
 
-	// always use TCP if encrypted
-	if c.isTLS {
+	// always use TCP if encrypted or tunneled
+	if c.isTLS || c.conf.HTTPTunnel {
 		v := StreamProtocolTCP
 		c.streamProtocol = &v
 	}
 
+	// UDP needs the address of the server, that is not available when
+	// connections are provided by DialContextFunc and are not TCP connections.
+	remoteAddr, isTCP := c.nconn.RemoteAddr().(*net.TCPAddr)
+
 	proto := func() StreamProtocol {
 		// protocol set by previous Setup()
 		if c.streamProtocol != nil {
@@ -455,9 +1362,16 @@ func (c *ClientConn) Setup(mode headers.TransportMode, track *Track,
 		}
 
 		// try UDP
+		if !isTCP {
+			return StreamProtocolTCP
+		}
 		return StreamProtocolUDP
 	}()
 
+	if proto == StreamProtocolUDPMulticast && mode == headers.TransportModeRecord {
+		return nil, fmt.Errorf("%w: multicast can't be used to publish", ErrUnsupportedTransport)
+	}
+
 	th := headers.Transport{
 		Protocol: proto,
 		Delivery: func() *base.StreamDelivery {
@@ -467,7 +1381,25 @@ func (c *ClientConn) Setup(mode headers.TransportMode, track *Track,
 		Mode: &mode,
 	}
 
-	if proto == base.StreamProtocolUDP {
+	if mode == headers.TransportModeRecord {
+		if ssrc, ok := c.conf.PublishSSRCs[track.ID]; ok {
+			th.SSRC = &ssrc
+		}
+	}
+
+	switch proto {
+	case StreamProtocolUDPMulticast:
+		// ports and destination are chosen by the server
+		th.Protocol = StreamProtocolUDP
+		v := base.StreamDeliveryMulticast
+		th.Delivery = &v
+
+	case StreamProtocolUDP:
+		if !isTCP {
+			return nil, fmt.Errorf("%w: UDP can't be used with a %s connection",
+				ErrUnsupportedTransport, c.nconn.RemoteAddr().Network())
+		}
+
 		if (rtpPort == 0 && rtcpPort != 0) ||
 			(rtpPort != 0 && rtcpPort == 0) {
 			return nil, fmt.Errorf("rtpPort and rtcpPort must be both zero or non-zero")
@@ -479,6 +1411,17 @@ func (c *ClientConn) Setup(mode headers.TransportMode, track *Track,
 
 		var err error
 		rtpListener, rtcpListener, err = func() (*clientConnUDPListener, *clientConnUDPListener, error) {
+			if rtpPort == 0 && c.conf.UDPListenerPool != nil {
+				rtpListener, rtcpListener, err := c.conf.UDPListenerPool.listenerPair(c)
+				if err != nil {
+					return nil, nil, err
+				}
+
+				rtpPort = rtpListener.pooled.port()
+				rtcpPort = rtcpListener.pooled.port()
+				return rtpListener, rtcpListener, nil
+			}
+
 			if rtpPort != 0 {
 				rtpListener, err := newClientConnUDPListener(c, rtpPort)
 				if err != nil {
@@ -521,13 +1464,23 @@ func (c *ClientConn) Setup(mode headers.TransportMode, track *Track,
 
 		th.ClientPorts = &[2]int{rtpPort, rtcpPort}
 
-	} else {
-		th.InterleavedIds = &[2]int{(track.ID * 2), (track.ID * 2) + 1}
+	default:
+		ids := [2]int{(track.ID * 2), (track.ID * 2) + 1}
+		if c.conf.InterleavedIds != nil {
+			ids = c.conf.InterleavedIds(track)
+		}
+
+		err := c.checkInterleavedIds(ids)
+		if err != nil {
+			return nil, err
+		}
+
+		th.InterleavedIds = &ids
 	}
 
 	trackURL, err := track.URL()
 	if err != nil {
-		if proto == StreamProtocolUDP {
+		if rtpListener != nil {
 			rtpListener.close()
 			rtcpListener.close()
 		}
@@ -542,7 +1495,7 @@ func (c *ClientConn) Setup(mode headers.TransportMode, track *Track,
 		},
 	})
 	if err != nil {
-		if proto == StreamProtocolUDP {
+		if rtpListener != nil {
 			rtpListener.close()
 			rtcpListener.close()
 		}
@@ -550,7 +1503,7 @@ func (c *ClientConn) Setup(mode headers.TransportMode, track *Track,
 	}
 
 	if res.StatusCode != base.StatusOK {
-		if proto == StreamProtocolUDP {
+		if rtpListener != nil {
 			rtpListener.close()
 			rtcpListener.close()
 		}
@@ -558,39 +1511,107 @@ func (c *ClientConn) Setup(mode headers.TransportMode, track *Track,
 		// switch protocol automatically
 		if res.StatusCode == base.StatusUnsupportedTransport &&
 			c.streamProtocol == nil &&
-			c.conf.StreamProtocol == nil {
+			c.conf.StreamProtocol == nil &&
+			!c.conf.TCPFallbackDisable {
+
+			log(c.conf.Logger, LogLevelWarn, "transport fallback",
+				LogField{"protocol", StreamProtocolTCP},
+				LogField{"reason", "UDP is not supported by the server"})
 
 			v := StreamProtocolTCP
 			c.streamProtocol = &v
 
-			return c.Setup(headers.TransportModePlay, track, 0, 0)
+			return c.Setup(mode, track, 0, 0)
 		}
 
-		return res, fmt.Errorf("bad status code: %d (%s)", res.StatusCode, res.StatusMessage)
+		return res, ErrWrongStatusCode{Response: res}
 	}
 
 	thRes, err := headers.ReadTransport(res.Header["Transport"])
 	if err != nil {
-		if proto == StreamProtocolUDP {
+		if rtpListener != nil {
 			rtpListener.close()
 			rtcpListener.close()
 		}
 		return nil, fmt.Errorf("transport header: %s", err)
 	}
 
-	if proto == StreamProtocolUDP {
+	switch proto {
+	case StreamProtocolUDPMulticast:
+		if thRes.Delivery == nil || *thRes.Delivery != base.StreamDeliveryMulticast {
+			return nil, fmt.Errorf("transport header does not have delivery=multicast")
+		}
+
+		if thRes.Destination == nil {
+			return nil, fmt.Errorf("transport header does not have a destination")
+		}
+
+		// IPv6 destinations may contain a zone
+		dest := *thRes.Destination
+		zone := ""
+		if i := strings.IndexByte(dest, '%'); i >= 0 {
+			dest, zone = dest[:i], dest[i+1:]
+		}
+
+		ip := net.ParseIP(dest)
+		if ip == nil || !ip.IsMulticast() {
+			return nil, fmt.Errorf("invalid multicast destination (%s)", *thRes.Destination)
+		}
+
+		if thRes.Ports == nil {
+			return nil, fmt.Errorf("transport header does not have ports")
+		}
+
+		intf, err := c.multicastInterface(ip, zone)
+		if err != nil {
+			return nil, err
+		}
+
+		rtpListener, err = newClientConnUDPListenerMulticast(c, intf, ip, (*thRes.Ports)[0])
+		if err != nil {
+			return nil, err
+		}
+
+		rtcpListener, err = newClientConnUDPListenerMulticast(c, intf, ip, (*thRes.Ports)[1])
+		if err != nil {
+			rtpListener.close()
+			return nil, err
+		}
+
+	case StreamProtocolUDP:
 		if thRes.ServerPorts == nil {
 			rtpListener.close()
 			rtcpListener.close()
 			return nil, fmt.Errorf("server ports not provided")
 		}
 
-	} else {
-		if thRes.InterleavedIds == nil ||
-			(*thRes.InterleavedIds)[0] != (*th.InterleavedIds)[0] ||
-			(*thRes.InterleavedIds)[1] != (*th.InterleavedIds)[1] {
-			return nil, fmt.Errorf("transport header does not have interleaved ids %v (%s)",
-				*th.InterleavedIds, res.Header["Transport"])
+		// packets of the same server are routed by SSRC, when it is provided
+		if rtpListener.pooled != nil {
+			var ssrc *uint32
+			if mode == headers.TransportModePlay {
+				ssrc = thRes.SSRC
+			}
+
+			if !rtpListener.pooled.canAddListener(remoteAddr.IP, (*thRes.ServerPorts)[0], ssrc) ||
+				!rtcpListener.pooled.canAddListener(remoteAddr.IP, (*thRes.ServerPorts)[1], ssrc) {
+				rtpListener.close()
+				rtcpListener.close()
+				return nil, fmt.Errorf("server ports %d-%d are already in use by another listener of the pool",
+					(*thRes.ServerPorts)[0], (*thRes.ServerPorts)[1])
+			}
+		}
+
+	default:
+		if thRes.InterleavedIds == nil {
+			return nil, fmt.Errorf("transport header does not have interleaved ids (%s)",
+				res.Header["Transport"])
+		}
+
+		// some servers reply with different ids than the requested ones;
+		// use the ids of the server, and remap frames on receive.
+		err := c.checkInterleavedIds(*thRes.InterleavedIds)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -599,56 +1620,366 @@ func (c *ClientConn) Setup(mode headers.TransportMode, track *Track,
 	if mode == headers.TransportModePlay {
 		c.rtcpReceivers[track.ID] = rtcpreceiver.New(nil, clockRate)
 
-		if proto == StreamProtocolUDP {
+		if proto != StreamProtocolTCP {
 			v := time.Now().Unix()
 			c.udpLastFrameTimes[track.ID] = &v
 		}
+
+		v := time.Now().UnixNano()
+		c.rtpLastFrameTimes[track.ID] = &v
+
+		// frames of backchannel tracks are sent by the client
+		if c.conf.RequireBackchannel && track.IsBackchannel() {
+			c.backchannelTracks[track.ID] = struct{}{}
+			c.rtcpSenders[track.ID] = rtcpsender.New(clockRate)
+		}
 	} else {
 		c.rtcpSenders[track.ID] = rtcpsender.New(clockRate)
+
+		if c.conf.PublishPacingBitrate > 0 {
+			if c.pacer == nil {
+				c.pacer = rtppacer.New(c.conf.PublishPacingBitrate)
+			}
+			if clockRate != 0 {
+				c.pacer.AddTrack(track.ID, clockRate)
+			}
+		}
+
+		if proto == StreamProtocolUDP && c.conf.NACKEnable {
+			if rtxPayloadType, ok := track.rtxPayloadType(); ok {
+				c.nackResponders[track.ID] = rtpnack.NewResponderWithRTX(clientConnNACKBufferSize, rtxPayloadType)
+			} else {
+				c.nackResponders[track.ID] = rtpnack.NewResponder(clientConnNACKBufferSize)
+			}
+		}
+
+		if fecPayloadType, ok := track.fecPayloadType(); ok &&
+			proto == StreamProtocolUDP && c.conf.FECGroupSize > 0 {
+			// the group size has already been validated
+			c.fecEncoders[track.ID], _ = rtpfec.NewEncoder(fecPayloadType, c.conf.FECGroupSize)
+		}
 	}
 
 	c.streamURL = track.BaseURL
 	c.streamProtocol = &proto
 	c.tracks = append(c.tracks, track)
 
-	if proto == StreamProtocolUDP {
-		rtpListener.remoteIP = c.nconn.RemoteAddr().(*net.TCPAddr).IP
-		rtpListener.remoteZone = c.nconn.RemoteAddr().(*net.TCPAddr).Zone
+	if mode == headers.TransportModePlay && proto != StreamProtocolTCP &&
+		c.conf.ReorderBufferSize > 0 {
+		rtpListener.reorderer = rtpreorderer.New(c.conf.ReorderBufferSize)
+	}
+
+	if fecPayloadType, ok := track.fecPayloadType(); ok &&
+		mode == headers.TransportModePlay && proto != StreamProtocolTCP {
+		payloadType, _ := track.PayloadType()
+		rtpListener.fecDecoder = rtpfec.NewDecoder(payloadType, fecPayloadType)
+	}
+
+	// NACKs can't be sent to multicast senders
+	if mode == headers.TransportModePlay && proto == StreamProtocolUDP &&
+		c.conf.NACKEnable {
+		if rtxPayloadType, ok := track.rtxPayloadType(); ok {
+			payloadType, _ := track.PayloadType()
+			rtpListener.nackRequester = rtpnack.NewRequesterWithRTX(nil, rtxPayloadType, payloadType)
+		} else {
+			rtpListener.nackRequester = rtpnack.NewRequester(nil)
+		}
+	}
+
+	switch proto {
+	case StreamProtocolUDPMulticast:
+		rtpListener.trackID = track.ID
+		rtpListener.streamType = StreamTypeRTP
+		c.udpRTPListeners[track.ID] = rtpListener
+
+		rtcpListener.trackID = track.ID
+		rtcpListener.streamType = StreamTypeRTCP
+		c.udpRTCPListeners[track.ID] = rtcpListener
+
+	case StreamProtocolUDP:
+		rtpListener.remoteIP = remoteAddr.IP
+		rtpListener.remoteZone = remoteAddr.Zone
 		rtpListener.remotePort = (*thRes.ServerPorts)[0]
 		rtpListener.trackID = track.ID
+		if mode == headers.TransportModePlay {
+			rtpListener.remoteSSRC = thRes.SSRC
+		}
 		rtpListener.streamType = StreamTypeRTP
 		c.udpRTPListeners[track.ID] = rtpListener
 
-		rtcpListener.remoteIP = c.nconn.RemoteAddr().(*net.TCPAddr).IP
-		rtcpListener.remoteZone = c.nconn.RemoteAddr().(*net.TCPAddr).Zone
+		rtcpListener.remoteIP = remoteAddr.IP
+		rtcpListener.remoteZone = remoteAddr.Zone
 		rtcpListener.remotePort = (*thRes.ServerPorts)[1]
 		rtcpListener.trackID = track.ID
+		if mode == headers.TransportModePlay {
+			rtcpListener.remoteSSRC = thRes.SSRC
+		}
 		rtcpListener.streamType = StreamTypeRTCP
+		rtcpListener.publish = (mode == headers.TransportModeRecord)
 		c.udpRTCPListeners[track.ID] = rtcpListener
+
+	default:
+		ids := *thRes.InterleavedIds
+		c.tcpChannels[ids[0]] = clientConnTCPChannel{track.ID, StreamTypeRTP}
+		c.tcpChannels[ids[1]] = clientConnTCPChannel{track.ID, StreamTypeRTCP}
+		c.tcpTrackChannels[track.ID] = ids
 	}
 
 	if mode == headers.TransportModePlay {
-		c.state = clientConnStatePrePlay
+		c.setState(ClientConnStatePrePlay)
 	} else {
-		c.state = clientConnStatePreRecord
+		c.setState(ClientConnStatePreRecord)
 	}
 
 	return res, nil
 }
 
+// clientConnTCPChannel is the destination of an interleaved channel.
+type clientConnTCPChannel struct {
+	trackID    int
+	streamType StreamType
+}
+
+// tcpFrame returns the interleaved frame that carries a frame of a track.
+func (c *ClientConn) tcpFrame(trackID int, streamType StreamType, payload []byte) base.InterleavedFrame {
+	ids := c.tcpTrackChannels[trackID]
+	if streamType == StreamTypeRTP {
+		return base.InterleavedFrame{Channel: ids[0], Payload: payload}
+	}
+	return base.InterleavedFrame{Channel: ids[1], Payload: payload}
+}
+
+// redirect closes the session and the connection, and replaces them with
+// a new connection to the server of the given URL.
+func (c *ClientConn) redirect(ctx context.Context, u *base.URL) error {
+	if c.streamURL != nil {
+		c.closeTeardown()
+	}
+	c.releaseConn()
+
+	nc, err := c.conf.DialContext(ctx, u.Scheme, u.Host)
+	if err != nil {
+		return err
+	}
+
+	c.requestMutex.Lock()
+	c.adoptSession(nc)
+	c.requestMutex.Unlock()
+
+	return nil
+}
+
+func (c *ClientConn) checkInterleavedIds(ids [2]int) error {
+	for _, id := range ids {
+		if id < 0 || id > 255 {
+			return fmt.Errorf("invalid interleaved id (%d)", id)
+		}
+
+		if _, ok := c.tcpChannels[id]; ok {
+			return fmt.Errorf("interleaved id %d is already in use by another track", id)
+		}
+	}
+
+	if ids[0] == ids[1] {
+		return fmt.Errorf("RTP and RTCP interleaved ids must be different (%d)", ids[0])
+	}
+
+	return nil
+}
+
+// Teardown writes a TEARDOWN request and reads a Response.
+// It stops reading or publishing and releases the resources of the session;
+// then, if the server keeps the connection open, another stream can be set up.
+// This can be called only after Setup(), Play() or Record().
+func (c *ClientConn) Teardown() (*base.Response, error) {
+	c.stopBackground()
+
+	err := c.checkState(map[ClientConnState]struct{}{
+		ClientConnStatePrePlay:   {},
+		ClientConnStatePlay:      {},
+		ClientConnStatePreRecord: {},
+		ClientConnStateRecord:    {},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.Do(&base.Request{
+		Method: base.Teardown,
+		URL:    c.streamURL,
+	})
+
+	for _, l := range c.udpRTPListeners {
+		l.close()
+	}
+	for _, l := range c.udpRTCPListeners {
+		l.close()
+	}
+
+	c.resetSession()
+	c.streamURL = nil
+	c.streamProtocol = nil
+	c.rtcpSenders = make(map[int]*rtcpsender.RTCPSender)
+	c.nackResponders = make(map[int]*rtpnack.Responder)
+	c.fecEncoders = make(map[int]*rtpfec.Encoder)
+
+	c.publishWriteMutex.Lock()
+	c.pacer = nil
+	c.publishWriteMutex.Unlock()
+
+	c.readFrameCh = nil
+	c.readFrameDone = nil
+	c.readFrameErr = nil
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != base.StatusOK {
+		return res, ErrWrongStatusCode{Response: res}
+	}
+
+	return res, nil
+}
+
+// resetSession resets the state of the session. UDP listeners must be closed before.
+func (c *ClientConn) resetSession() {
+	c.setState(ClientConnStateInitial)
+
+	c.sessionMutex.Lock()
+	defer c.sessionMutex.Unlock()
+
+	c.session = ""
+	c.sessionTimeout = 0
+	c.tracks = nil
+	c.udpRTPListeners = make(map[int]*clientConnUDPListener)
+	c.udpRTCPListeners = make(map[int]*clientConnUDPListener)
+	c.tcpChannels = make(map[int]clientConnTCPChannel)
+	c.tcpTrackChannels = make(map[int][2]int)
+	c.rtcpReceivers = make(map[int]*rtcpreceiver.RTCPReceiver)
+	c.udpLastFrameTimes = make(map[int]*int64)
+	c.rtpLastFrameTimes = make(map[int]*int64)
+	c.backchannelTracks = make(map[int]struct{})
+	c.rtpInfo = nil
+}
+
+// newSessionConn returns a ClientConn that shares the connection of c,
+// in order to set up a new session without changing c while it is used
+// by other routines.
+func (c *ClientConn) newSessionConn() *ClientConn {
+	c.sessionMutex.RLock()
+	defer c.sessionMutex.RUnlock()
+
+	return &ClientConn{
+		conf:                  c.conf,
+		scheme:                c.scheme,
+		host:                  c.host,
+		nconn:                 c.nconn,
+		isTLS:                 c.isTLS,
+		br:                    c.br,
+		bw:                    c.bw,
+		session:               c.session,
+		sessionTimeout:        c.sessionTimeout,
+		cseq:                  c.cseq,
+		sender:                c.sender,
+		streamURL:             c.streamURL,
+		describeURL:           c.describeURL,
+		sdp:                   c.sdp,
+		getParameterSupported: c.getParameterSupported,
+		serverHeader:          c.serverHeader,
+		publicMethods:         c.publicMethods,
+		udpRTPListeners:       make(map[int]*clientConnUDPListener),
+		udpRTCPListeners:      make(map[int]*clientConnUDPListener),
+		tcpChannels:           make(map[int]clientConnTCPChannel),
+		tcpTrackChannels:      make(map[int][2]int),
+		rtcpReceivers:         make(map[int]*rtcpreceiver.RTCPReceiver),
+		udpLastFrameTimes:     make(map[int]*int64),
+		rtpLastFrameTimes:     make(map[int]*int64),
+		backchannelTracks:     make(map[int]struct{}),
+		tcpFrameBuffer:        c.tcpFrameBuffer,
+		rtcpSenders:           make(map[int]*rtcpsender.RTCPSender),
+		nackResponders:        make(map[int]*rtpnack.Responder),
+		fecEncoders:           make(map[int]*rtpfec.Encoder),
+		backgroundResponses:   make(chan *base.Response, 1),
+		tracer:                c.tracer,
+	}
+}
+
+// adoptSession replaces the connection and the session with the ones
+// that have been set up by another ClientConn.
+// It is called by the background routine after a reconnection or a restart,
+// while the reading or publishing is stopped; the caller must hold requestMutex.
+func (c *ClientConn) adoptSession(nc *ClientConn) {
+	c.publishWriteMutex.Lock()
+	defer c.publishWriteMutex.Unlock()
+
+	c.sessionMutex.Lock()
+	defer c.sessionMutex.Unlock()
+
+	nc.sessionMutex.Lock()
+	defer nc.sessionMutex.Unlock()
+
+	c.scheme = nc.scheme
+	c.host = nc.host
+	c.nconn = nc.nconn
+	c.nconnClosed = false
+	c.isTLS = nc.isTLS
+	c.br = nc.br
+	c.bw = nc.bw
+	c.session = nc.session
+	c.sessionTimeout = nc.sessionTimeout
+	c.cseq = nc.cseq
+	c.sender = nc.sender
+	c.streamURL = nc.streamURL
+	c.describeURL = nc.describeURL
+	c.sdp = nc.sdp
+	c.streamProtocol = nc.streamProtocol
+	c.tracks = nc.tracks
+	c.udpRTPListeners = nc.udpRTPListeners
+	c.udpRTCPListeners = nc.udpRTCPListeners
+	c.tcpChannels = nc.tcpChannels
+	c.tcpTrackChannels = nc.tcpTrackChannels
+	c.getParameterSupported = nc.getParameterSupported
+	c.serverHeader = nc.serverHeader
+	c.publicMethods = nc.publicMethods
+	c.rtcpReceivers = nc.rtcpReceivers
+	c.udpLastFrameTimes = nc.udpLastFrameTimes
+	c.rtpLastFrameTimes = nc.rtpLastFrameTimes
+	c.rtpInfo = nc.rtpInfo
+	c.backchannelTracks = nc.backchannelTracks
+	c.rtcpSenders = nc.rtcpSenders
+	c.nackResponders = nc.nackResponders
+	c.fecEncoders = nc.fecEncoders
+
+	for _, l := range c.udpRTPListeners {
+		l.c = c
+	}
+	for _, l := range c.udpRTCPListeners {
+		l.c = c
+	}
+}
+
 // Pause writes a PAUSE request and reads a Response.
 // This can be called only after Play() or Record().
 func (c *ClientConn) Pause() (*base.Response, error) {
-	err := c.checkState(map[clientConnState]struct{}{
-		clientConnStatePlay:   {},
-		clientConnStateRecord: {},
+	c.stopBackground()
+
+	// the state may have been changed by a failed reconnection
+	err := c.checkState(map[ClientConnState]struct{}{
+		ClientConnStatePlay:   {},
+		ClientConnStateRecord: {},
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	close(c.backgroundTerminate)
-	<-c.backgroundDone
+	// background activity is stopped, even if the request fails
+	switch c.State() {
+	case ClientConnStatePlay:
+		c.setState(ClientConnStatePrePlay)
+	case ClientConnStateRecord:
+		c.setState(ClientConnStatePreRecord)
+	}
 
 	res, err := c.Do(&base.Request{
 		Method: base.Pause,
@@ -659,14 +1990,7 @@ func (c *ClientConn) Pause() (*base.Response, error) {
 	}
 
 	if res.StatusCode != base.StatusOK {
-		return res, fmt.Errorf("bad status code: %d (%s)", res.StatusCode, res.StatusMessage)
-	}
-
-	switch c.state {
-	case clientConnStatePlay:
-		c.state = clientConnStatePrePlay
-	case clientConnStateRecord:
-		c.state = clientConnStatePreRecord
+		return res, ErrWrongStatusCode{Response: res}
 	}
 
 	return res, nil
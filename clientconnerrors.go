@@ -0,0 +1,65 @@
+package gortsplib
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aler9/gortsplib/pkg/base"
+)
+
+// ErrAuthFailed is returned when the server refuses the provided credentials,
+// or when the authentication can't be performed.
+var ErrAuthFailed = errors.New("authentication failed")
+
+// ErrSessionTimedOut is returned when the session is closed by the server,
+// or when the server stops sending frames.
+var ErrSessionTimedOut = errors.New("session timed out")
+
+// ErrUnsupportedTransport is returned when the requested transport can't be used.
+var ErrUnsupportedTransport = errors.New("unsupported transport")
+
+// ErrTooManyRedirects is returned when the server redirects the client
+// more times than allowed by ClientConf.MaxRedirects.
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// ErrRedirectLoop is returned when the server redirects the client
+// to an URL that has already been visited.
+var ErrRedirectLoop = errors.New("redirect loop")
+
+// ErrServerRedirect is returned when the server sends a REDIRECT request
+// while reading or publishing, asking the client to connect to another URL.
+type ErrServerRedirect struct {
+	Location *base.URL
+}
+
+// Error implements the error interface.
+func (e ErrServerRedirect) Error() string {
+	return fmt.Sprintf("redirected by the server to %s", e.Location)
+}
+
+// ErrWrongStatusCode is returned when the server replies with an unexpected status code.
+// It can be unwrapped into ErrAuthFailed, ErrSessionTimedOut or ErrUnsupportedTransport,
+// depending on the status code.
+type ErrWrongStatusCode struct {
+	*base.Response
+}
+
+// Error implements the error interface.
+func (e ErrWrongStatusCode) Error() string {
+	return fmt.Sprintf("bad status code: %d (%s)", e.StatusCode, e.StatusMessage)
+}
+
+// Unwrap returns the error associated with the status code, if any.
+func (e ErrWrongStatusCode) Unwrap() error {
+	switch e.StatusCode {
+	case base.StatusUnauthorized:
+		return ErrAuthFailed
+
+	case base.StatusSessionNotFound:
+		return ErrSessionTimedOut
+
+	case base.StatusUnsupportedTransport:
+		return ErrUnsupportedTransport
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package gortsplib
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aler9/gortsplib/pkg/rtph264"
+)
+
+// clientConnEarlyRead is a reading that is started before ReadFrames() is
+// called. Frames are queued until ReadFrames() provides a callback; if the
+// queue is full, the oldest frames are discarded.
+type clientConnEarlyRead struct {
+	mutex   sync.Mutex
+	onFrame func(int, StreamType, []byte)
+	queue   []clientConnFrame
+	done    chan error
+}
+
+// push queues a frame. It must be called with the mutex locked.
+func (er *clientConnEarlyRead) push(fr clientConnFrame) {
+	if len(er.queue) >= clientConnEarlyReadQueueSize {
+		copy(er.queue, er.queue[1:])
+		er.queue = er.queue[:len(er.queue)-1]
+	}
+	er.queue = append(er.queue, fr)
+}
+
+func (er *clientConnEarlyRead) attach(onFrame func(int, StreamType, []byte)) chan error {
+	er.mutex.Lock()
+	defer er.mutex.Unlock()
+
+	for _, fr := range er.queue {
+		onFrame(fr.trackID, fr.streamType, fr.payload)
+	}
+	er.queue = nil
+	er.onFrame = onFrame
+
+	return er.done
+}
+
+type clientConnH264Sniffer struct {
+	decoder *rtph264.Decoder
+	sps     []byte
+	pps     []byte
+}
+
+// process decodes a RTP packet and returns whether both SPS and PPS have been found.
+func (s *clientConnH264Sniffer) process(payload []byte) bool {
+	nalus, _, err := s.decoder.Decode(payload)
+	if err != nil {
+		return false
+	}
+
+	for _, nalu := range nalus {
+		switch rtph264.NALUType(nalu[0] & 0x1F) {
+		case rtph264.NALUTypeSPS:
+			s.sps = append([]byte(nil), nalu...)
+
+		case rtph264.NALUTypePPS:
+			s.pps = append([]byte(nil), nalu...)
+		}
+	}
+
+	return s.sps != nil && s.pps != nil
+}
+
+// readH264Params starts reading and waits until the SPS and PPS of H264 tracks
+// that don't provide them in the SDP are received in-band. Tracks are then updated
+// and frames received in the meanwhile are delivered by ReadFrames().
+func (c *ClientConn) readH264Params(timeout time.Duration) error {
+	sniffers := make(map[int]*clientConnH264Sniffer)
+
+	for _, track := range c.tracks {
+		if !track.IsH264() {
+			continue
+		}
+
+		_, _, err := track.ExtractDataH264()
+		if err == nil {
+			continue
+		}
+
+		sniffers[track.ID] = &clientConnH264Sniffer{
+			decoder: rtph264.NewDecoder(nil),
+		}
+	}
+
+	if len(sniffers) == 0 {
+		return nil
+	}
+
+	tracks := make(map[int]*Track)
+	for _, track := range c.tracks {
+		tracks[track.ID] = track
+	}
+
+	er := &clientConnEarlyRead{}
+	found := make(chan struct{})
+
+	er.done = c.ReadFrames(func(trackID int, streamType StreamType, payload []byte) {
+		er.mutex.Lock()
+		defer er.mutex.Unlock()
+
+		if s, ok := sniffers[trackID]; ok && streamType == StreamTypeRTP {
+			if s.process(payload) {
+				tracks[trackID].SetDataH264(s.sps, s.pps)
+				delete(sniffers, trackID)
+				if len(sniffers) == 0 {
+					close(found)
+				}
+			}
+		}
+
+		if er.onFrame != nil {
+			er.onFrame(trackID, streamType, payload)
+			return
+		}
+
+		// the read buffer is reused; copy the payload
+		er.push(clientConnFrame{trackID, streamType, append([]byte(nil), payload...)})
+	})
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
+	select {
+	case <-found:
+		c.earlyRead = er
+		return nil
+
+	case err := <-er.done:
+		return err
+
+	case <-t.C:
+		return fmt.Errorf("H264 parameters have not been received within %v", timeout)
+	}
+}
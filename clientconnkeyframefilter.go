@@ -0,0 +1,205 @@
+package gortsplib
+
+import (
+	"encoding/binary"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/h264"
+	"github.com/aler9/gortsplib/pkg/rtph264"
+	"github.com/aler9/gortsplib/pkg/rtph265"
+)
+
+type clientConnKeyframeKind int
+
+const (
+	// the NALU doesn't tell whether the access unit is a key frame
+	// (i.e. parameters or SEI)
+	clientConnKeyframeUndecided clientConnKeyframeKind = iota
+	clientConnKeyframeKey
+	clientConnKeyframeNonKey
+)
+
+func keyframeKindH264(typ h264.NALUType) clientConnKeyframeKind {
+	switch typ {
+	case h264.NALUTypeIDR:
+		return clientConnKeyframeKey
+
+	case h264.NALUTypeNonIDR, h264.NALUTypeDataPartitionA,
+		h264.NALUTypeDataPartitionB, h264.NALUTypeDataPartitionC:
+		return clientConnKeyframeNonKey
+	}
+
+	return clientConnKeyframeUndecided
+}
+
+func keyframeKindH265(typ rtph265.NALUType) clientConnKeyframeKind {
+	switch {
+	case typ >= rtph265.NALUTypeBlaWLp && typ <= rtph265.NALUTypeCraNut:
+		return clientConnKeyframeKey
+
+	case typ <= rtph265.NALUTypeRaslR:
+		return clientConnKeyframeNonKey
+	}
+
+	return clientConnKeyframeUndecided
+}
+
+// keyframeKindAggregated returns the kind of an aggregation packet,
+// that is the kind of the first NALU that is not undecided.
+func keyframeKindAggregated(payload []byte,
+	kindOf func(nalu []byte) clientConnKeyframeKind) clientConnKeyframeKind {
+	for len(payload) >= 2 {
+		size := int(binary.BigEndian.Uint16(payload))
+		payload = payload[2:]
+
+		if size == 0 || len(payload) < size {
+			break
+		}
+
+		if k := kindOf(payload[:size]); k != clientConnKeyframeUndecided {
+			return k
+		}
+		payload = payload[size:]
+	}
+
+	return clientConnKeyframeUndecided
+}
+
+func keyframeKindPayloadH264(payload []byte) clientConnKeyframeKind {
+	if len(payload) < 2 {
+		return clientConnKeyframeUndecided
+	}
+
+	typ := rtph264.NALUType(payload[0] & 0x1F)
+
+	switch typ {
+	case rtph264.NALUTypeStapA:
+		return keyframeKindAggregated(payload[1:], func(nalu []byte) clientConnKeyframeKind {
+			return keyframeKindH264(h264.TypeOf(nalu))
+		})
+
+	case rtph264.NALUTypeFuA:
+		return keyframeKindH264(h264.NALUType(payload[1] & 0x1F))
+	}
+
+	return keyframeKindH264(typ)
+}
+
+func keyframeKindPayloadH265(payload []byte) clientConnKeyframeKind {
+	if len(payload) < 3 {
+		return clientConnKeyframeUndecided
+	}
+
+	typ := rtph265.NALUType((payload[0] >> 1) & 0x3F)
+
+	switch typ {
+	case rtph265.NALUTypeAP:
+		return keyframeKindAggregated(payload[2:], func(nalu []byte) clientConnKeyframeKind {
+			return keyframeKindH265(rtph265.NALUType((nalu[0] >> 1) & 0x3F))
+		})
+
+	case rtph265.NALUTypeFU:
+		return keyframeKindH265(rtph265.NALUType(payload[2] & 0x3F))
+	}
+
+	return keyframeKindH265(typ)
+}
+
+// clientConnKeyframeFilter filters the RTP packets of a track, letting
+// through only the ones that belong to key frames.
+// Access units are identified by their timestamp; packets that precede the
+// first slice of an access unit (parameters, SEI) are kept until the kind of
+// the access unit is known.
+type clientConnKeyframeFilter struct {
+	kindOf func(payload []byte) clientConnKeyframeKind
+
+	started bool
+	ts      uint32
+	kind    clientConnKeyframeKind
+	queue   [][]byte
+}
+
+func newClientConnKeyframeFilter(track *Track) *clientConnKeyframeFilter {
+	switch {
+	case track.IsH264():
+		return &clientConnKeyframeFilter{kindOf: keyframeKindPayloadH264}
+
+	case track.IsH265():
+		return &clientConnKeyframeFilter{kindOf: keyframeKindPayloadH265}
+	}
+
+	return nil
+}
+
+// process returns the packets that must be delivered.
+func (f *clientConnKeyframeFilter) process(payload []byte) [][]byte {
+	var pkt rtp.Packet
+	err := pkt.Unmarshal(payload)
+	if err != nil {
+		return nil
+	}
+
+	if !f.started || pkt.Timestamp != f.ts {
+		f.started = true
+		f.ts = pkt.Timestamp
+		f.kind = clientConnKeyframeUndecided
+		f.queue = nil
+	}
+
+	if f.kind == clientConnKeyframeUndecided {
+		f.kind = f.kindOf(pkt.Payload)
+
+		if f.kind == clientConnKeyframeUndecided {
+			// the read buffer is reused; copy the payload
+			f.queue = append(f.queue, append([]byte(nil), payload...))
+			return nil
+		}
+	}
+
+	if f.kind == clientConnKeyframeNonKey {
+		f.queue = nil
+		return nil
+	}
+
+	ret := append(f.queue, payload)
+	f.queue = nil
+	return ret
+}
+
+// keyframeFilterCB wraps a read callback with the filters of the tracks
+// selected by ClientConf.KeyframesOnly.
+func (c *ClientConn) keyframeFilterCB(onFrame func(int, StreamType, []byte)) func(int, StreamType, []byte) {
+	if c.conf.KeyframesOnly == nil {
+		return onFrame
+	}
+
+	filters := make(map[int]*clientConnKeyframeFilter)
+	for _, track := range c.tracks {
+		if !c.conf.KeyframesOnly(track) {
+			continue
+		}
+
+		if f := newClientConnKeyframeFilter(track); f != nil {
+			filters[track.ID] = f
+		}
+	}
+
+	if len(filters) == 0 {
+		return onFrame
+	}
+
+	// every track is read by a single routine, therefore filters
+	// don't need to be protected.
+	return func(trackID int, streamType StreamType, payload []byte) {
+		f, ok := filters[trackID]
+		if !ok || streamType != StreamTypeRTP {
+			onFrame(trackID, streamType, payload)
+			return
+		}
+
+		for _, pkt := range f.process(payload) {
+			onFrame(trackID, streamType, pkt)
+		}
+	}
+}
@@ -1,19 +1,22 @@
 package gortsplib
 
 import (
+	"encoding/binary"
 	"fmt"
 	"strconv"
 	"time"
 
+	"github.com/pion/rtp"
 	psdp "github.com/pion/sdp/v3"
 
 	"github.com/aler9/gortsplib/pkg/base"
+	"github.com/aler9/gortsplib/pkg/ringbuffer"
 )
 
 // Announce writes an ANNOUNCE request and reads a Response.
 func (c *ClientConn) Announce(u *base.URL, tracks Tracks) (*base.Response, error) {
-	err := c.checkState(map[clientConnState]struct{}{
-		clientConnStateInitial: {},
+	err := c.checkState(map[ClientConnState]struct{}{
+		ClientConnStateInitial: {},
 	})
 	if err != nil {
 		return nil, err
@@ -24,10 +27,13 @@ func (c *ClientConn) Announce(u *base.URL, tracks Tracks) (*base.Response, error
 		t.ID = i
 		t.BaseURL = u
 
-		t.Media.Attributes = append(t.Media.Attributes, psdp.Attribute{
-			Key:   "control",
-			Value: "trackID=" + strconv.FormatInt(int64(i), 10),
-		})
+		// tracks can be announced again after a reconnection
+		if _, ok := t.Attribute("control"); !ok {
+			t.Media.Attributes = append(t.Media.Attributes, psdp.Attribute{
+				Key:   "control",
+				Value: "trackID=" + strconv.FormatInt(int64(i), 10),
+			})
+		}
 	}
 
 	res, err := c.Do(&base.Request{
@@ -43,11 +49,11 @@ func (c *ClientConn) Announce(u *base.URL, tracks Tracks) (*base.Response, error
 	}
 
 	if res.StatusCode != base.StatusOK {
-		return nil, fmt.Errorf("bad status code: %d (%s)", res.StatusCode, res.StatusMessage)
+		return nil, ErrWrongStatusCode{Response: res}
 	}
 
 	c.streamURL = u
-	c.state = clientConnStatePreRecord
+	c.setState(ClientConnStatePreRecord)
 
 	return res, nil
 }
@@ -55,8 +61,8 @@ func (c *ClientConn) Announce(u *base.URL, tracks Tracks) (*base.Response, error
 // Record writes a RECORD request and reads a Response.
 // This can be called only after Announce() and Setup().
 func (c *ClientConn) Record() (*base.Response, error) {
-	err := c.checkState(map[clientConnState]struct{}{
-		clientConnStatePreRecord: {},
+	err := c.checkState(map[ClientConnState]struct{}{
+		ClientConnStatePreRecord: {},
 	})
 	if err != nil {
 		return nil, err
@@ -71,59 +77,160 @@ func (c *ClientConn) Record() (*base.Response, error) {
 	}
 
 	if res.StatusCode != base.StatusOK {
-		return nil, fmt.Errorf("bad status code: %d (%s)", res.StatusCode, res.StatusMessage)
+		return nil, ErrWrongStatusCode{Response: res}
 	}
 
-	c.state = clientConnStateRecord
+	c.setState(ClientConnStateRecord)
 	c.publishOpen = true
+	c.backgroundRunning = true
 	c.backgroundTerminate = make(chan struct{})
 	c.backgroundDone = make(chan struct{})
 
-	if *c.streamProtocol == StreamProtocolUDP {
-		go c.backgroundRecordUDP()
-	} else {
-		go c.backgroundRecordTCP()
+	if c.conf.WriteBufferCount > 0 {
+		if c.writeRingBuffer == nil {
+			c.writeRingBuffer = ringbuffer.New(c.conf.WriteBufferCount)
+		} else {
+			c.writeRingBuffer.Reset()
+		}
+		c.backgroundWriteDone = make(chan struct{})
+		go c.backgroundWrite()
 	}
 
+	go c.backgroundRecord()
+
 	return nil, nil
 }
 
-func (c *ClientConn) backgroundRecordUDP() {
+func (c *ClientConn) stopPublish() {
+	c.publishWriteMutex.Lock()
+	c.publishOpen = false
+	c.publishWriteMutex.Unlock()
+
+	if c.conf.WriteBufferCount > 0 {
+		c.writeRingBuffer.Close()
+		<-c.backgroundWriteDone
+	}
+}
+
+func (c *ClientConn) backgroundWrite() {
+	defer close(c.backgroundWriteDone)
+
+	for {
+		what, ok := c.writeRingBuffer.Pull()
+		if !ok {
+			return
+		}
+
+		frame := what.(*base.InterleavedFrame)
+
+		c.publishWriteMutex.RLock()
+		var err error
+		if c.publishOpen {
+			err = c.writeFrameSync(frame.TrackID, frame.StreamType, frame.Payload)
+		} else {
+			// the connection is being restored
+			err = c.publishError
+		}
+		c.publishWriteMutex.RUnlock()
+
+		if err != nil && c.conf.OnWriteError != nil {
+			c.conf.OnWriteError(err)
+		}
+	}
+}
+
+func (c *ClientConn) backgroundRecord() {
 	defer close(c.backgroundDone)
 
-	defer func() {
+	defer c.stopPublish()
+
+	for {
+		var err error
+		if *c.streamProtocol == StreamProtocolUDP {
+			err = c.backgroundRecordUDP()
+		} else {
+			err = c.backgroundRecordTCP()
+		}
+
 		c.publishWriteMutex.Lock()
-		defer c.publishWriteMutex.Unlock()
 		c.publishOpen = false
+		c.publishError = err
+		c.publishWriteMutex.Unlock()
+
+		if !c.shouldReconnect(err) {
+			c.logBackgroundError("publishing stopped", err)
+			return
+		}
+
+		err = c.reconnect(err)
+		if err != nil {
+			c.logBackgroundError("publishing stopped", err)
+			c.publishWriteMutex.Lock()
+			c.publishError = err
+			c.publishWriteMutex.Unlock()
+			return
+		}
+	}
+}
+
+func (c *ClientConn) backgroundRecordUDP() error {
+	// receive RTCP receiver reports
+	for trackID := range c.udpRTCPListeners {
+		c.udpRTCPListeners[trackID].start()
+	}
+
+	defer func() {
+		for trackID := range c.udpRTCPListeners {
+			c.udpRTCPListeners[trackID].stop()
+		}
 	}()
 
 	// disable deadline
 	c.nconn.SetReadDeadline(time.Time{})
 
+	c.startBackgroundReader()
+	defer c.stopBackgroundReader()
+
 	readerDone := make(chan error)
 	go func() {
 		for {
-			var res base.Response
-			err := res.Read(c.br)
+			req := &base.Request{}
+			res := &base.Response{}
+			what, err := base.ReadRequestOrResponseWithLimits(req, res, c.br, c.readLimits())
 			if err != nil {
 				readerDone <- err
 				return
 			}
+
+			switch what.(type) {
+			case *base.Response:
+				c.handleBackgroundResponse(res)
+
+			case *base.Request:
+				err := c.handleBackgroundRequest(req)
+				if err != nil {
+					readerDone <- err
+					return
+				}
+			}
 		}
 	}()
 
-	reportTicker := time.NewTicker(clientConnSenderReportPeriod)
-	defer reportTicker.Stop()
+	var reportTickerC <-chan time.Time
+	if !c.conf.SenderReportDisable {
+		reportTicker := time.NewTicker(clientConnSenderReportPeriod)
+		defer reportTicker.Stop()
+		reportTickerC = reportTicker.C
+	}
 
 	for {
 		select {
 		case <-c.backgroundTerminate:
 			c.nconn.SetReadDeadline(time.Now())
 			<-readerDone
-			c.publishError = fmt.Errorf("terminated")
-			return
+			return fmt.Errorf("terminated")
 
-		case <-reportTicker.C:
+		case <-reportTickerC:
 			c.publishWriteMutex.Lock()
 			now := time.Now()
 			for trackID := range c.rtcpSenders {
@@ -135,40 +242,90 @@ func (c *ClientConn) backgroundRecordUDP() {
 			c.publishWriteMutex.Unlock()
 
 		case err := <-readerDone:
-			c.publishError = err
-			return
+			return err
 		}
 	}
 }
 
-func (c *ClientConn) backgroundRecordTCP() {
-	defer close(c.backgroundDone)
+func (c *ClientConn) backgroundRecordTCP() error {
+	// disable deadline
+	c.nconn.SetReadDeadline(time.Time{})
 
-	defer func() {
-		c.publishWriteMutex.Lock()
-		defer c.publishWriteMutex.Unlock()
-		c.publishOpen = false
+	c.startBackgroundReader()
+	defer c.stopBackgroundReader()
+
+	// read requests, responses and RTCP receiver reports
+	readerDone := make(chan error)
+	go func() {
+		req := &base.Request{}
+		res := &base.Response{}
+
+		for {
+			frame := base.InterleavedFrame{
+				Payload: c.tcpFrameBuffer.Next(),
+			}
+			what, err := base.ReadInterleavedFrameOrRequestOrResponseWithLimits(&frame, req, res, c.br, c.readLimits())
+			if err != nil {
+				readerDone <- err
+				return
+			}
+
+			switch what.(type) {
+			case *base.Response:
+				c.handleBackgroundResponse(res)
+				res = &base.Response{}
+
+			case *base.Request:
+				err := c.handleBackgroundRequest(req)
+				if err != nil {
+					readerDone <- err
+					return
+				}
+				req = &base.Request{}
+
+			case *base.InterleavedFrame:
+				if c.tracer != nil {
+					c.tracer.frame(false, &frame)
+				}
+
+				// frames sent on channels that were not set up are discarded
+				ch, ok := c.tcpChannels[frame.Channel]
+				if !ok || ch.streamType != StreamTypeRTCP {
+					continue
+				}
+
+				c.rtcpSenders[ch.trackID].ProcessReceiverReport(time.Now(), frame.Payload)
+			}
+		}
 	}()
 
-	reportTicker := time.NewTicker(clientConnSenderReportPeriod)
-	defer reportTicker.Stop()
+	var reportTickerC <-chan time.Time
+	if !c.conf.SenderReportDisable {
+		reportTicker := time.NewTicker(clientConnSenderReportPeriod)
+		defer reportTicker.Stop()
+		reportTickerC = reportTicker.C
+	}
 
 	for {
 		select {
 		case <-c.backgroundTerminate:
-			return
+			c.nconn.SetReadDeadline(time.Now())
+			<-readerDone
+			return fmt.Errorf("terminated")
 
-		case <-reportTicker.C:
+		case err := <-readerDone:
+			return err
+
+		case <-reportTickerC:
 			c.publishWriteMutex.Lock()
 			now := time.Now()
 			for trackID := range c.rtcpSenders {
 				r := c.rtcpSenders[trackID].Report(now)
 				if r != nil {
 					c.nconn.SetWriteDeadline(time.Now().Add(c.conf.WriteTimeout))
-					frame := base.InterleavedFrame{
-						TrackID:    trackID,
-						StreamType: StreamTypeRTCP,
-						Payload:    r,
+					frame := c.tcpFrame(trackID, StreamTypeRTCP, r)
+					if c.tracer != nil {
+						c.tracer.frame(true, &frame)
 					}
 					frame.Write(c.bw)
 				}
@@ -179,31 +336,189 @@ func (c *ClientConn) backgroundRecordTCP() {
 }
 
 // WriteFrame writes a frame.
-// This can be called only after Record().
+// This can be called only after Record(), or after ReadFrames() when
+// writing to a backchannel track.
+// If ClientConf.WriteBufferCount is greater than zero, frames written after
+// Record() are queued and written in background; in this case, the payload
+// must not be modified after calling WriteFrame().
+// If ClientConf.PublishSSRCs is set, the SSRC of RTP packets is overwritten
+// in place, inside the payload.
 func (c *ClientConn) WriteFrame(trackID int, streamType StreamType, payload []byte) error {
+	// the pacer is removed by Teardown(); wait outside the lock,
+	// in order not to block Close() and Pause()
+	c.publishWriteMutex.RLock()
+	pacer := c.pacer
+	c.publishWriteMutex.RUnlock()
+
+	if pacer != nil && streamType == StreamTypeRTP && !pacer.Wait(trackID, payload) {
+		return nil
+	}
+
 	c.publishWriteMutex.RLock()
 	defer c.publishWriteMutex.RUnlock()
 
 	if !c.publishOpen {
-		return c.publishError
+		if !c.backchannelOpen {
+			return c.publishError
+		}
+
+		if _, ok := c.backchannelTracks[trackID]; !ok {
+			return fmt.Errorf("track %d is not a backchannel track", trackID)
+		}
+	}
+
+	c.pinSSRC(trackID, streamType, payload)
+	c.rtcpSenders[trackID].ProcessFrame(time.Now(), streamType, payload)
+
+	addCounter(c.conf.Metrics, MetricClientFramesSent, 1)
+	addCounter(c.conf.Metrics, MetricClientBytesSent, float64(len(payload)))
+
+	if r, ok := c.nackResponders[trackID]; ok && streamType == StreamTypeRTP {
+		r.ProcessFrame(payload)
+	}
+
+	if c.publishOpen && c.conf.WriteBufferCount > 0 {
+		c.writeRingBuffer.Push(&base.InterleavedFrame{
+			TrackID:    trackID,
+			StreamType: streamType,
+			Payload:    payload,
+		})
+		return nil
+	}
+
+	return c.writeFrameSync(trackID, streamType, payload)
+}
+
+// WritePacket writes a RTP packet, including its header extensions,
+// whose IDs can be associated to extensions with Track.AddExtension().
+// This can be called in the same cases of WriteFrame().
+func (c *ClientConn) WritePacket(trackID int, pkt *rtp.Packet) error {
+	byts, err := pkt.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return c.WriteFrame(trackID, StreamTypeRTP, byts)
+}
+
+// WriteFrames writes multiple frames at once.
+// When publishing with TCP, frames are grouped into as few write
+// operations as possible.
+// This can be called in the same cases of WriteFrame(), and payloads
+// are subject to the same constraints; the slice can be reused after the call.
+// Frames are routed by their TrackID and StreamType, while Channel is ignored.
+func (c *ClientConn) WriteFrames(frames []base.InterleavedFrame) error {
+	c.publishWriteMutex.RLock()
+	pacer := c.pacer
+	c.publishWriteMutex.RUnlock()
+
+	// pacing spreads frames over time, therefore they can't be grouped
+	if pacer != nil {
+		for _, f := range frames {
+			err := c.WriteFrame(f.TrackID, f.StreamType, f.Payload)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	c.publishWriteMutex.RLock()
+	defer c.publishWriteMutex.RUnlock()
+
+	if !c.publishOpen {
+		if !c.backchannelOpen {
+			return c.publishError
+		}
+
+		for _, f := range frames {
+			if _, ok := c.backchannelTracks[f.TrackID]; !ok {
+				return fmt.Errorf("track %d is not a backchannel track", f.TrackID)
+			}
+		}
 	}
 
 	now := time.Now()
+	for _, f := range frames {
+		c.pinSSRC(f.TrackID, f.StreamType, f.Payload)
+		c.rtcpSenders[f.TrackID].ProcessFrame(now, f.StreamType, f.Payload)
+
+		addCounter(c.conf.Metrics, MetricClientFramesSent, 1)
+		addCounter(c.conf.Metrics, MetricClientBytesSent, float64(len(f.Payload)))
 
-	c.rtcpSenders[trackID].ProcessFrame(now, streamType, payload)
+		if r, ok := c.nackResponders[f.TrackID]; ok && f.StreamType == StreamTypeRTP {
+			r.ProcessFrame(f.Payload)
+		}
+	}
+
+	if c.publishOpen && c.conf.WriteBufferCount > 0 {
+		for _, f := range frames {
+			f := f
+			c.writeRingBuffer.Push(&f)
+		}
+		return nil
+	}
 
+	if *c.streamProtocol == StreamProtocolUDP {
+		for _, f := range frames {
+			err := c.writeFrameSync(f.TrackID, f.StreamType, f.Payload)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tcpFrames := make([]base.InterleavedFrame, len(frames))
+	for i, f := range frames {
+		tcpFrames[i] = c.tcpFrame(f.TrackID, f.StreamType, f.Payload)
+		if c.tracer != nil {
+			c.tracer.frame(true, &tcpFrames[i])
+		}
+	}
+
+	c.nconn.SetWriteDeadline(now.Add(c.conf.WriteTimeout))
+	return base.WriteInterleavedFrames(tcpFrames, c.bw)
+}
+
+// pinSSRC overwrites the SSRC of a RTP packet with the one set in ClientConf.PublishSSRCs.
+// The payload is modified in place, without being copied.
+func (c *ClientConn) pinSSRC(trackID int, streamType StreamType, payload []byte) {
+	if streamType != StreamTypeRTP || len(payload) < 12 {
+		return
+	}
+
+	ssrc, ok := c.conf.PublishSSRCs[trackID]
+	if !ok {
+		return
+	}
+
+	binary.BigEndian.PutUint32(payload[8:12], ssrc)
+}
+
+func (c *ClientConn) writeFrameSync(trackID int, streamType StreamType, payload []byte) error {
 	if *c.streamProtocol == StreamProtocolUDP {
 		if streamType == StreamTypeRTP {
-			return c.udpRTPListeners[trackID].write(payload)
+			err := c.udpRTPListeners[trackID].write(payload)
+			if err != nil {
+				return err
+			}
+
+			if enc, ok := c.fecEncoders[trackID]; ok {
+				fec, err := enc.Encode(payload)
+				if err == nil && fec != nil {
+					return c.udpRTPListeners[trackID].write(fec)
+				}
+			}
+			return nil
 		}
 		return c.udpRTCPListeners[trackID].write(payload)
 	}
 
-	c.nconn.SetWriteDeadline(now.Add(c.conf.WriteTimeout))
-	frame := base.InterleavedFrame{
-		TrackID:    trackID,
-		StreamType: streamType,
-		Payload:    payload,
+	c.nconn.SetWriteDeadline(time.Now().Add(c.conf.WriteTimeout))
+	frame := c.tcpFrame(trackID, streamType, payload)
+	if c.tracer != nil {
+		c.tracer.frame(true, &frame)
 	}
 	return frame.Write(c.bw)
 }
@@ -1,53 +1,357 @@
 package gortsplib
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/pion/rtp"
+
 	"github.com/aler9/gortsplib/pkg/base"
+	"github.com/aler9/gortsplib/pkg/headers"
 )
 
+var errClientConnUDPProbeFailed = errors.New("no UDP packets received")
+
+// errClientConnSessionUpdated is returned by the reading routine when the
+// server announced new tracks and the session must be renegotiated.
+type errClientConnSessionUpdated struct {
+	tracks Tracks
+	sdp    []byte
+}
+
+// Error implements the error interface.
+func (e errClientConnSessionUpdated) Error() string {
+	return "session updated by the server"
+}
+
 // Play writes a PLAY request and reads a Response.
-// This can be called only after Setup().
-func (c *ClientConn) Play() (*base.Response, error) {
-	err := c.checkState(map[clientConnState]struct{}{
-		clientConnStatePrePlay: {},
+// The range is optional and allows to seek to a given position of
+// recorded streams; if nil, the stream is played from the current position.
+// This can be called only after Setup() or Pause().
+func (c *ClientConn) Play(ra *headers.Range) (*base.Response, error) {
+	res, _, err := c.PlayScale(ra, 0, 0)
+	return res, err
+}
+
+// PlayScale writes a PLAY request with the Scale and Speed headers, and reads a Response.
+// Scale and Speed allow to play recorded streams at a different rate;
+// a negative scale plays the stream backwards. Zero values are not sent.
+// It returns the scale accepted by the server.
+// This can be called only after Setup() or Pause().
+func (c *ClientConn) PlayScale(ra *headers.Range, scale float64, speed float64) (*base.Response, float64, error) {
+	return c.play(ra, scale, speed, make(base.Header))
+}
+
+// ReplayOptions contains the options of a PLAY request that uses
+// the ONVIF replay mechanism.
+type ReplayOptions struct {
+	// disable rate control, by sending the "Rate-Control: no" header.
+	// The server sends data as fast as possible, and the absolute time of
+	// packets must be read from their RTP header extension.
+	RateControlDisable bool
+
+	// play immediately, by sending the "Immediate: yes" header.
+	// The server discards data of previous PLAY requests.
+	Immediate bool
+
+	// (optional) frames that are sent, with the "Frames" header.
+	// It can be "intra", "intra/<interval in milliseconds>" or "predicted".
+	Frames string
+}
+
+// PlayReplay writes a PLAY request that uses the ONVIF replay mechanism, and reads a Response.
+// It allows to read recordings of ONVIF recorders. The range is usually
+// an absolute (clock) range; a zero scale is not sent.
+// ClientConf.RequireReplay must be enabled.
+// This can be called only after Setup() or Pause().
+func (c *ClientConn) PlayReplay(ra *headers.Range, scale float64, opts ReplayOptions) (*base.Response, error) {
+	if !c.conf.RequireReplay {
+		return nil, fmt.Errorf("replay has not been required")
+	}
+
+	header := make(base.Header)
+	if opts.RateControlDisable {
+		header["Rate-Control"] = base.HeaderValue{"no"}
+	}
+	if opts.Immediate {
+		header["Immediate"] = base.HeaderValue{"yes"}
+	}
+	if opts.Frames != "" {
+		header["Frames"] = base.HeaderValue{opts.Frames}
+	}
+
+	res, _, err := c.play(ra, scale, 0, header)
+	return res, err
+}
+
+func (c *ClientConn) play(ra *headers.Range, scale float64, speed float64,
+	header base.Header) (*base.Response, float64, error) {
+	err := c.checkState(map[ClientConnState]struct{}{
+		ClientConnStatePrePlay: {},
 	})
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	if ra != nil {
+		header["Range"] = ra.Write()
+	}
+	if scale != 0 {
+		header["Scale"] = base.HeaderValue{strconv.FormatFloat(scale, 'f', -1, 64)}
+	}
+	if speed != 0 {
+		header["Speed"] = base.HeaderValue{strconv.FormatFloat(speed, 'f', -1, 64)}
 	}
 
 	res, err := c.Do(&base.Request{
 		Method: base.Play,
 		URL:    c.streamURL,
+		Header: header,
 	})
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	if res.StatusCode != base.StatusOK {
-		return nil, fmt.Errorf("bad status code: %d (%s)", res.StatusCode, res.StatusMessage)
+		return nil, 0, ErrWrongStatusCode{Response: res}
+	}
+
+	c.rtpInfo = c.tracksRTPInfo(res.Header["RTP-INFO"])
+
+	// servers that don't support scaling play the stream at normal rate
+	acceptedScale := float64(1)
+	if v, ok := res.Header["Scale"]; ok && len(v) == 1 {
+		acceptedScale, err = strconv.ParseFloat(strings.TrimSpace(v[0]), 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid Scale header (%v)", v)
+		}
+	}
+
+	return res, acceptedScale, nil
+}
+
+// tracksRTPInfo associates the entries of a RTP-Info header with the setupped tracks.
+// Entries are associated by path, since servers behind NAT may use another host;
+// if no path matches, entries are associated in order.
+// Invalid headers are ignored, since they're not needed to read the stream.
+func (c *ClientConn) tracksRTPInfo(v base.HeaderValue) map[int]*headers.RTPInfoEntry {
+	if v == nil {
+		return nil
+	}
+
+	rtpInfo, err := headers.ReadRTPInfo(v)
+	if err != nil {
+		return nil
 	}
 
-	return res, nil
+	ret := make(map[int]*headers.RTPInfoEntry)
+
+	for _, e := range *rtpInfo {
+		for _, track := range c.tracks {
+			u, err := track.URL()
+			if err != nil {
+				continue
+			}
+
+			if eu, err := base.ParseURL(e.URL); err == nil {
+				if strings.TrimSuffix(eu.Path, "/") != strings.TrimSuffix(u.Path, "/") {
+					continue
+				}
+
+				// relative URLs
+			} else if !strings.HasSuffix(u.String(), "/"+e.URL) {
+				continue
+			}
+
+			ret[track.ID] = e
+			break
+		}
+	}
+
+	if len(ret) == 0 && len(*rtpInfo) == len(c.tracks) {
+		for i, track := range c.tracks {
+			ret[track.ID] = (*rtpInfo)[i]
+		}
+	}
+
+	return ret
 }
 
-func (c *ClientConn) backgroundPlayUDP(done chan error) {
+func (c *ClientConn) backgroundPlay(done chan error) {
 	defer close(c.backgroundDone)
+	defer c.setBackchannelOpen(false)
 
-	var returnError error
+	udpAttempts := 0
 
-	defer func() {
-		for trackID := range c.udpRTPListeners {
-			c.udpRTPListeners[trackID].stop()
-			c.udpRTCPListeners[trackID].stop()
+	for {
+		var err error
+		if *c.streamProtocol == StreamProtocolTCP {
+			err = c.backgroundPlayTCP()
+		} else {
+			udpAttempts++
+			err = c.backgroundPlayUDP()
 		}
 
-		done <- returnError
+		var uerr errClientConnSessionUpdated
+		if errors.As(err, &uerr) {
+			err = c.renegotiatePlay(uerr.tracks, uerr.sdp)
+			if err != nil {
+				c.logBackgroundError("reading stopped", err)
+				done <- err
+				return
+			}
+			continue
+		}
+
+		if err != errClientConnUDPProbeFailed {
+			if !c.shouldReconnect(err) {
+				c.logBackgroundError("reading stopped", err)
+				done <- err
+				return
+			}
+
+			c.setBackchannelOpen(false)
+
+			err = c.reconnect(err)
+			if err != nil {
+				c.logBackgroundError("reading stopped", err)
+				done <- err
+				return
+			}
+			continue
+		}
+
+		proto := StreamProtocolUDP
+		if udpAttempts >= c.conf.ProbeCount {
+			proto = StreamProtocolTCP
+
+			log(c.conf.Logger, LogLevelWarn, "transport fallback",
+				LogField{"protocol", proto},
+				LogField{"reason", err.Error()})
+		}
+
+		err = c.restartPlay(proto, c.tracks, c.sdp)
+		if err != nil {
+			c.logBackgroundError("reading stopped", err)
+			done <- err
+			return
+		}
+	}
+}
+
+// readUpdatedTracks decodes the tracks of an ANNOUNCE request sent by the
+// server while reading.
+func (c *ClientConn) readUpdatedTracks(req *base.Request) (Tracks, error) {
+	ct, ok := req.Header["Content-Type"]
+	if !ok || len(ct) != 1 || ct[0] != "application/sdp" {
+		return nil, fmt.Errorf("wrong Content-Type, expected application/sdp")
+	}
+
+	tracks, err := ReadTracks(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// control attributes are resolved against the URL of the current tracks
+	baseURL := c.describeURL
+	if len(c.tracks) > 0 && c.tracks[0].BaseURL != nil {
+		baseURL = c.tracks[0].BaseURL
+	}
+
+	for _, t := range tracks {
+		t.BaseURL = baseURL
+		t.URLMode = c.conf.TrackURLMode
+	}
+
+	return tracks, nil
+}
+
+// renegotiatePlay restarts the session with the tracks announced by the server.
+// The tracks with the same IDs of the current ones are set up.
+func (c *ClientConn) renegotiatePlay(tracks Tracks, sdp []byte) error {
+	var newTracks Tracks
+	for _, track := range c.tracks {
+		if track.ID >= len(tracks) {
+			return fmt.Errorf("track %d is not available anymore", track.ID)
+		}
+		newTracks = append(newTracks, tracks[track.ID])
+	}
+
+	return c.restartPlay(*c.streamProtocol, newTracks, sdp)
+}
+
+// restartPlay restarts the session with the given protocol, tracks and SDP.
+// The new session is set up on a separate ClientConn and then adopted.
+func (c *ClientConn) restartPlay(proto StreamProtocol, tracks Tracks, sdp []byte) error {
+	c.setBackchannelOpen(false)
+
+	// the connection is used by the new session until it is adopted
+	c.requestMutex.Lock()
+	defer c.requestMutex.Unlock()
+
+	nc := c.newSessionConn()
+	nc.conf.OnStateChange = nil
+
+	_, err := nc.Do(&base.Request{
+		Method: base.Teardown,
+		URL:    nc.streamURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, l := range c.udpRTPListeners {
+		l.close()
+	}
+	for _, l := range c.udpRTCPListeners {
+		l.close()
+	}
+
+	c.resetSession()
+
+	nc.streamProtocol = &proto
+	nc.sdp = sdp
+
+	err = func() error {
+		for _, track := range tracks {
+			_, err := nc.Setup(headers.TransportModePlay, track, 0, 0)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := nc.Play(nil)
+		return err
 	}()
+	if err != nil {
+		for _, l := range nc.udpRTPListeners {
+			l.close()
+		}
+		for _, l := range nc.udpRTCPListeners {
+			l.close()
+		}
+		return err
+	}
+
+	c.adoptSession(nc)
 
-	// open the firewall by sending packets to the counterpart
+	c.setState(ClientConnStatePlay)
+
+	if len(c.backchannelTracks) > 0 {
+		c.setBackchannelOpen(true)
+	}
+
+	return nil
+}
+
+// udpPunchHoles opens the firewall and the NAT mappings between client and server
+// by sending packets from the local ports toward the ports of the server.
+func (c *ClientConn) udpPunchHoles() {
 	for trackID := range c.udpRTPListeners {
 		c.udpRTPListeners[trackID].write(
 			[]byte{0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
@@ -55,6 +359,28 @@ func (c *ClientConn) backgroundPlayUDP(done chan error) {
 		c.udpRTCPListeners[trackID].write(
 			[]byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00})
 	}
+}
+
+func (c *ClientConn) backgroundPlayUDP() error {
+	defer func() {
+		for trackID := range c.udpRTPListeners {
+			c.udpRTPListeners[trackID].stop()
+		}
+		for trackID := range c.udpRTCPListeners {
+			c.udpRTCPListeners[trackID].stop()
+		}
+	}()
+
+	var holePunchingTickerC <-chan time.Time
+
+	// multicast streams don't need hole punching
+	if !c.conf.HolePunchingDisable && *c.streamProtocol != StreamProtocolUDPMulticast {
+		c.udpPunchHoles()
+
+		holePunchingTicker := time.NewTicker(c.conf.HolePunchingPeriod)
+		defer holePunchingTicker.Stop()
+		holePunchingTickerC = holePunchingTicker.C
+	}
 
 	for trackID := range c.udpRTPListeners {
 		c.udpRTPListeners[trackID].start()
@@ -64,51 +390,92 @@ func (c *ClientConn) backgroundPlayUDP(done chan error) {
 	// disable deadline
 	c.nconn.SetReadDeadline(time.Time{})
 
+	c.startBackgroundReader()
+	defer c.stopBackgroundReader()
+
 	readerDone := make(chan error)
 	go func() {
 		for {
-			var res base.Response
-			err := res.Read(c.br)
+			req := &base.Request{}
+			res := &base.Response{}
+			what, err := base.ReadRequestOrResponseWithLimits(req, res, c.br, c.readLimits())
 			if err != nil {
 				readerDone <- err
 				return
 			}
+
+			switch what.(type) {
+			case *base.Response:
+				c.handleBackgroundResponse(res)
+
+			case *base.Request:
+				err := c.handleBackgroundRequest(req)
+				if err != nil {
+					readerDone <- err
+					return
+				}
+			}
 		}
 	}()
 
-	reportTicker := time.NewTicker(clientConnReceiverReportPeriod)
-	defer reportTicker.Stop()
+	var reportTickerC <-chan time.Time
+	if !c.conf.ReceiverReportDisable {
+		reportTicker := time.NewTicker(clientConnReceiverReportPeriod)
+		defer reportTicker.Stop()
+		reportTickerC = reportTicker.C
+	}
 
-	keepaliveTicker := time.NewTicker(clientConnUDPKeepalivePeriod)
+	keepaliveTicker := time.NewTicker(c.keepalivePeriod())
 	defer keepaliveTicker.Stop()
 
 	checkStreamTicker := time.NewTicker(clientConnUDPCheckStreamPeriod)
 	defer checkStreamTicker.Stop()
 
+	stallTickerC, stopStallTicker := c.startStallTicker()
+	defer stopStallTicker()
+
+	// when the protocol is chosen automatically, check that packets are
+	// actually received, otherwise switch protocol
+	var probeTimerC <-chan time.Time
+	if *c.streamProtocol == StreamProtocolUDP &&
+		c.conf.StreamProtocol == nil &&
+		!c.conf.TCPFallbackDisable {
+		atomic.StoreInt32(&c.udpFrameReceived, 0)
+		probeTimer := time.NewTimer(c.conf.ProbeTimeout)
+		defer probeTimer.Stop()
+		probeTimerC = probeTimer.C
+	}
+
 	for {
 		select {
 		case <-c.backgroundTerminate:
 			c.nconn.SetReadDeadline(time.Now())
 			<-readerDone
-			returnError = fmt.Errorf("terminated")
-			return
+			return fmt.Errorf("terminated")
 
-		case <-reportTicker.C:
+		case <-probeTimerC:
+			if atomic.LoadInt32(&c.udpFrameReceived) == 0 {
+				c.nconn.SetReadDeadline(time.Now())
+				<-readerDone
+				return errClientConnUDPProbeFailed
+			}
+
+		case <-reportTickerC:
 			now := time.Now()
 			for trackID := range c.rtcpReceivers {
 				r := c.rtcpReceivers[trackID].Report(now)
 				c.udpRTCPListeners[trackID].write(r)
 			}
 
+		case <-stallTickerC:
+			c.checkStalls()
+
+		case <-holePunchingTickerC:
+			c.udpPunchHoles()
+
 		case <-keepaliveTicker.C:
 			_, err := c.Do(&base.Request{
-				Method: func() base.Method {
-					// the vlc integrated rtsp server requires GET_PARAMETER
-					if c.getParameterSupported {
-						return base.GetParameter
-					}
-					return base.Options
-				}(),
+				Method: c.keepaliveMethod(),
 				// use the stream path, otherwise some cameras do not reply
 				URL:          c.streamURL,
 				SkipResponse: true,
@@ -116,59 +483,96 @@ func (c *ClientConn) backgroundPlayUDP(done chan error) {
 			if err != nil {
 				c.nconn.SetReadDeadline(time.Now())
 				<-readerDone
-				returnError = err
-				return
+				return err
 			}
 
 		case <-checkStreamTicker.C:
 			now := time.Now()
 
-			for _, lastUnix := range c.udpLastFrameTimes {
+			for trackID, lastUnix := range c.udpLastFrameTimes {
+				// backchannel tracks do not receive frames
+				if _, ok := c.backchannelTracks[trackID]; ok {
+					continue
+				}
+
 				last := time.Unix(atomic.LoadInt64(lastUnix), 0)
 
 				if now.Sub(last) >= c.conf.ReadTimeout {
 					c.nconn.SetReadDeadline(time.Now())
 					<-readerDone
-					returnError = fmt.Errorf("no UDP packets received recently (maybe there's a firewall/NAT in between)")
-					return
+					return fmt.Errorf("%w: no UDP packets received recently (maybe there's a firewall/NAT in between)", ErrSessionTimedOut)
 				}
 			}
 
 		case err := <-readerDone:
-			returnError = err
-			return
+			return err
 		}
 	}
 }
 
-func (c *ClientConn) backgroundPlayTCP(done chan error) {
-	defer close(c.backgroundDone)
-
-	var returnError error
-
-	defer func() {
-		done <- returnError
-	}()
+func (c *ClientConn) backgroundPlayTCP() error {
+	c.startBackgroundReader()
+	defer c.stopBackgroundReader()
 
 	readerDone := make(chan error)
 	go func() {
+		req := &base.Request{}
+		res := &base.Response{}
+
 		for {
 			frame := base.InterleavedFrame{
 				Payload: c.tcpFrameBuffer.Next(),
 			}
-			err := frame.Read(c.br)
+			what, err := base.ReadInterleavedFrameOrRequestOrResponseWithLimits(&frame, req, res, c.br, c.readLimits())
 			if err != nil {
 				readerDone <- err
 				return
 			}
 
-			c.rtcpReceivers[frame.TrackID].ProcessFrame(time.Now(), frame.StreamType, frame.Payload)
-			c.readCB(frame.TrackID, frame.StreamType, frame.Payload)
+			switch what.(type) {
+			case *base.Response:
+				c.handleBackgroundResponse(res)
+				res = &base.Response{}
+				continue
+
+			case *base.Request:
+				err := c.handleBackgroundRequest(req)
+				if err != nil {
+					readerDone <- err
+					return
+				}
+				req = &base.Request{}
+				continue
+			}
+
+			if c.tracer != nil {
+				c.tracer.frame(false, &frame)
+			}
+
+			// frames sent on channels that were not set up are discarded
+			ch, ok := c.tcpChannels[frame.Channel]
+			if !ok {
+				continue
+			}
+
+			now := time.Now()
+			if ch.streamType == StreamTypeRTP {
+				atomic.StoreInt64(c.rtpLastFrameTimes[ch.trackID], now.UnixNano())
+			}
+
+			lost := c.processReceivedFrame(ch.trackID, now, ch.streamType, frame.Payload)
+			c.packetsLost(ch.trackID, lost)
+
+			c.readCB(ch.trackID, ch.streamType, frame.Payload)
 		}
 	}()
 
-	reportTicker := time.NewTicker(clientConnReceiverReportPeriod)
-	defer reportTicker.Stop()
+	var reportTickerC <-chan time.Time
+	if !c.conf.ReceiverReportDisable {
+		reportTicker := time.NewTicker(clientConnReceiverReportPeriod)
+		defer reportTicker.Stop()
+		reportTickerC = reportTicker.C
+	}
 
 	// for some reason, SetReadDeadline() must always be called in the same
 	// goroutine, otherwise Read() freezes.
@@ -176,62 +580,250 @@ func (c *ClientConn) backgroundPlayTCP(done chan error) {
 	deadlineTicker := time.NewTicker(1 * time.Second)
 	defer deadlineTicker.Stop()
 
+	stallTickerC, stopStallTicker := c.startStallTicker()
+	defer stopStallTicker()
+
 	for {
 		select {
 		case <-deadlineTicker.C:
 			c.nconn.SetReadDeadline(time.Now().Add(c.conf.ReadTimeout))
 
+		case <-stallTickerC:
+			c.checkStalls()
+
 		case <-c.backgroundTerminate:
 			c.nconn.SetReadDeadline(time.Now())
 			<-readerDone
-			returnError = fmt.Errorf("terminated")
-			return
+			return fmt.Errorf("terminated")
 
-		case <-reportTicker.C:
+		case <-reportTickerC:
+			// frames of backchannel tracks are written by WriteFrame()
+			c.publishWriteMutex.Lock()
 			now := time.Now()
 			for trackID := range c.rtcpReceivers {
 				r := c.rtcpReceivers[trackID].Report(now)
 				c.nconn.SetWriteDeadline(time.Now().Add(c.conf.WriteTimeout))
-				frame := base.InterleavedFrame{
-					TrackID:    trackID,
-					StreamType: StreamTypeRTCP,
-					Payload:    r,
+				frame := c.tcpFrame(trackID, StreamTypeRTCP, r)
+				if c.tracer != nil {
+					c.tracer.frame(true, &frame)
 				}
 				frame.Write(c.bw)
 			}
+			c.publishWriteMutex.Unlock()
 
 		case err := <-readerDone:
-			returnError = err
-			return
+			// the server stopped sending frames
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				err = fmt.Errorf("%w: %s", ErrSessionTimedOut, err)
+			}
+			return err
 		}
 	}
 }
 
+// processReceivedFrame passes a received frame to the RTCP receiver of the track,
+// and detects SSRC changes. It returns the number of lost RTP packets.
+func (c *ClientConn) processReceivedFrame(trackID int, now time.Time,
+	streamType StreamType, payload []byte) int {
+	addCounter(c.conf.Metrics, MetricClientFramesReceived, 1)
+	addCounter(c.conf.Metrics, MetricClientBytesReceived, float64(len(payload)))
+
+	rr := c.rtcpReceivers[trackID]
+
+	if streamType != StreamTypeRTP || c.conf.OnSSRCChange == nil {
+		return rr.ProcessFrame(now, streamType, payload)
+	}
+
+	prev, prevOK := rr.SSRC()
+	lost := rr.ProcessFrame(now, streamType, payload)
+
+	if cur, ok := rr.SSRC(); prevOK && ok && cur != prev {
+		c.conf.OnSSRCChange(trackID, prev, cur)
+	}
+
+	return lost
+}
+
+// packetsLost reports lost RTP packets.
+func (c *ClientConn) packetsLost(trackID int, lost int) {
+	if lost == 0 {
+		return
+	}
+
+	addCounter(c.conf.Metrics, MetricClientPacketsLost, float64(lost))
+
+	if c.conf.OnPacketsLost != nil {
+		c.conf.OnPacketsLost(trackID, lost)
+	}
+}
+
+func (c *ClientConn) setBackchannelOpen(v bool) {
+	c.publishWriteMutex.Lock()
+	defer c.publishWriteMutex.Unlock()
+	c.backchannelOpen = v
+}
+
 // ReadFrames starts reading frames.
+// Frames are read into a fixed set of buffers (ClientConf.ReadBufferCount
+// buffers of ClientConf.ReadBufferSize bytes), that are reused without
+// allocating new memory; therefore the payload is valid only inside the callback,
+// unless ReadBufferCount is greater than 1, in which case it is valid until
+// ReadBufferCount other frames have been received.
 // it returns a channel that is written when the reading stops.
 // This can be called only after Play().
 func (c *ClientConn) ReadFrames(onFrame func(int, StreamType, []byte)) chan error {
+	// reading has already been started by DialRead()
+	if c.earlyRead != nil {
+		er := c.earlyRead
+		c.earlyRead = nil
+		return er.attach(onFrame)
+	}
+
 	// channel is buffered, since listening to it is not mandatory
 	done := make(chan error, 1)
 
-	err := c.checkState(map[clientConnState]struct{}{
-		clientConnStatePrePlay: {},
+	err := c.checkState(map[ClientConnState]struct{}{
+		ClientConnStatePrePlay: {},
 	})
 	if err != nil {
 		done <- err
 		return done
 	}
 
-	c.state = clientConnStatePlay
-	c.readCB = onFrame
+	c.setState(ClientConnStatePlay)
+	c.readCB = c.keyframeFilterCB(onFrame)
+	c.backgroundRunning = true
 	c.backgroundTerminate = make(chan struct{})
 	c.backgroundDone = make(chan struct{})
 
-	if *c.streamProtocol == StreamProtocolUDP {
-		go c.backgroundPlayUDP(done)
-	} else {
-		go c.backgroundPlayTCP(done)
+	if len(c.backchannelTracks) > 0 {
+		c.setBackchannelOpen(true)
 	}
 
+	go c.backgroundPlay(done)
+
 	return done
 }
+
+// startStallTicker starts the ticker that is used to detect stalled tracks.
+// It returns a nil channel if stall detection is disabled.
+func (c *ClientConn) startStallTicker() (<-chan time.Time, func()) {
+	if c.conf.OnStall == nil {
+		return nil, func() {}
+	}
+
+	// the stall timeout starts when reading starts
+	now := time.Now().UnixNano()
+	for _, lastUnix := range c.rtpLastFrameTimes {
+		atomic.StoreInt64(lastUnix, now)
+	}
+	c.stalledTracks = make(map[int]struct{})
+
+	stallTicker := time.NewTicker(c.conf.StallTimeout / 4)
+	return stallTicker.C, stallTicker.Stop
+}
+
+func (c *ClientConn) checkStalls() {
+	now := time.Now()
+
+	for trackID, lastUnix := range c.rtpLastFrameTimes {
+		// backchannel tracks do not receive frames
+		if _, ok := c.backchannelTracks[trackID]; ok {
+			continue
+		}
+
+		last := time.Unix(0, atomic.LoadInt64(lastUnix))
+
+		if now.Sub(last) < c.conf.StallTimeout {
+			delete(c.stalledTracks, trackID)
+			continue
+		}
+
+		if _, ok := c.stalledTracks[trackID]; !ok {
+			c.stalledTracks[trackID] = struct{}{}
+			c.conf.OnStall(trackID)
+		}
+	}
+}
+
+type clientConnFrame struct {
+	trackID    int
+	streamType StreamType
+	payload    []byte
+}
+
+// ReadFrame reads a single frame, blocking until a frame is available.
+// It is an alternative to ReadFrames() for applications that have their own loop:
+// the first call starts reading, and frames that are not read by the application
+// are not discarded but slow down the reading.
+// The payload is a copy, and therefore it remains valid after the call.
+// It returns an error when the reading stops.
+// This can be called only after Play(), and can't be used together with ReadFrames().
+func (c *ClientConn) ReadFrame() (int, StreamType, []byte, error) {
+	if c.readFrameCh == nil {
+		// reading may have already been started by DialRead()
+		if c.earlyRead == nil {
+			err := c.checkState(map[ClientConnState]struct{}{
+				ClientConnStatePrePlay: {},
+			})
+			if err != nil {
+				return 0, 0, nil, err
+			}
+		}
+
+		c.readFrameCh = make(chan clientConnFrame)
+		c.readFrameDone = c.ReadFrames(func(trackID int, streamType StreamType, payload []byte) {
+			// the read buffer is reused; copy the payload
+			select {
+			case c.readFrameCh <- clientConnFrame{trackID, streamType, append([]byte(nil), payload...)}:
+			case <-c.backgroundTerminate:
+			}
+		})
+	}
+
+	if c.readFrameErr != nil {
+		return 0, 0, nil, c.readFrameErr
+	}
+
+	select {
+	case fr := <-c.readFrameCh:
+		return fr.trackID, fr.streamType, fr.payload, nil
+
+	case err := <-c.readFrameDone:
+		c.readFrameErr = err
+		return 0, 0, nil, err
+	}
+}
+
+// ReadPackets starts reading frames, like ReadFrames, but delivers RTP frames
+// as parsed RTP packets. RTP frames that can't be parsed are discarded.
+// onRTP receives also the header extensions of packets that are declared
+// by the track, indexed by URI (see Track.Extensions()).
+// onRTCP is optional and receives the RTCP frames.
+// Packets and extensions point to the read buffer, therefore they are valid
+// only inside the callback, unless ReadBufferCount is greater than 1.
+// it returns a channel that is written when the reading stops.
+// This can be called only after Play().
+func (c *ClientConn) ReadPackets(onRTP func(int, *rtp.Packet, map[string][]byte),
+	onRTCP func(int, []byte)) chan error {
+	extensionURIs := make(map[int]map[uint8]string)
+	for _, track := range c.tracks {
+		extensionURIs[track.ID] = track.extensionURIs()
+	}
+
+	return c.ReadFrames(func(trackID int, streamType StreamType, payload []byte) {
+		if streamType == StreamTypeRTP {
+			var pkt rtp.Packet
+			err := pkt.Unmarshal(payload)
+			if err != nil {
+				return
+			}
+			onRTP(trackID, &pkt, packetExtensions(extensionURIs[trackID], &pkt))
+			return
+		}
+
+		if onRTCP != nil {
+			onRTCP(trackID, payload)
+		}
+	})
+}
@@ -0,0 +1,238 @@
+package gortsplib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aler9/gortsplib/pkg/base"
+	"github.com/aler9/gortsplib/pkg/headers"
+)
+
+// clientConnReconnectBackoff is the default ClientConf.ReconnectBackoff.
+func clientConnReconnectBackoff(attempt int) time.Duration {
+	d := time.Second
+	for i := 1; i < attempt && d < clientConnMaxReconnectBackoff; i++ {
+		d *= 2
+	}
+	if d > clientConnMaxReconnectBackoff {
+		d = clientConnMaxReconnectBackoff
+	}
+	return d
+}
+
+// shouldReconnect checks whether the background routine must reconnect
+// after being stopped by the given error.
+func (c *ClientConn) shouldReconnect(err error) bool {
+	select {
+	case <-c.backgroundTerminate:
+		return false
+	default:
+	}
+
+	// the server asked to connect to another URL
+	var rerr ErrServerRedirect
+	if errors.As(err, &rerr) {
+		return c.conf.ServerRedirectFollow
+	}
+
+	return c.conf.ReconnectMaxAttempts != 0
+}
+
+// reconnect re-establishes the connection with the server and restores the session.
+// It is called by the background routine, after the reading or publishing
+// stopped with the given error.
+func (c *ClientConn) reconnect(err error) error {
+	mode := headers.TransportModePlay
+	u := c.describeURL
+	if c.State() == ClientConnStateRecord {
+		mode = headers.TransportModeRecord
+		u = c.streamURL
+	}
+	tracks := c.tracks
+
+	// the session is set up on the new URL once, like a DESCRIBE redirect
+	var rerr ErrServerRedirect
+	if errors.As(err, &rerr) {
+		log(c.conf.Logger, LogLevelInfo, "following server redirect",
+			LogField{"location", rerr.Location.Redacted()})
+
+		c.releaseConn()
+		return c.reconnectOnce(mode, rerr.Location, tracks)
+	}
+
+	log(c.conf.Logger, LogLevelWarn, "connection lost, reconnecting",
+		LogField{"host", c.host})
+
+	c.releaseConn()
+
+	for attempt := 1; ; attempt++ {
+		t := time.NewTimer(c.conf.ReconnectBackoff(attempt))
+		select {
+		case <-t.C:
+		case <-c.backgroundTerminate:
+			t.Stop()
+			return fmt.Errorf("terminated")
+		}
+
+		addCounter(c.conf.Metrics, MetricClientReconnects, 1)
+
+		err := c.reconnectOnce(mode, u, tracks)
+
+		if err != nil {
+			log(c.conf.Logger, LogLevelWarn, "reconnection failed",
+				LogField{"attempt", attempt},
+				LogField{"error", err})
+		} else {
+			log(c.conf.Logger, LogLevelInfo, "reconnected",
+				LogField{"attempt", attempt})
+		}
+
+		if c.conf.OnReconnect != nil {
+			c.conf.OnReconnect(attempt, err)
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		if c.conf.ReconnectMaxAttempts > 0 && attempt >= c.conf.ReconnectMaxAttempts {
+			return err
+		}
+	}
+}
+
+// releaseConn releases the resources of the lost connection.
+func (c *ClientConn) releaseConn() {
+	for _, l := range c.udpRTPListeners {
+		l.close()
+	}
+	for _, l := range c.udpRTCPListeners {
+		l.close()
+	}
+	c.nconn.Close()
+	c.nconnClosed = true
+	addGauge(c.conf.Metrics, MetricClientConnections, -1)
+
+	c.resetSession()
+
+	c.sessionMutex.Lock()
+	c.streamURL = nil
+	c.sender = nil
+	c.sessionMutex.Unlock()
+}
+
+// reconnectOnce sets up the session on a new connection, that replaces the
+// current one only when the session has been restored, in order not to
+// change the ClientConn while it is used by other routines.
+// The attempt is interrupted when the background routine is terminated.
+func (c *ClientConn) reconnectOnce(mode headers.TransportMode, u *base.URL, tracks Tracks) error {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	go func() {
+		select {
+		case <-c.backgroundTerminate:
+			ctxCancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	conf := c.conf
+	conf.OnStateChange = nil
+
+	// the URL can point to another server when following a redirect
+	nc, err := newClientConn(ctx, conf, u.Scheme, u.Host)
+	if err != nil {
+		return err
+	}
+	nc.tracer = c.tracer
+
+	err = nc.runContext(ctx, func() error {
+		_, err := nc.Options(u)
+		if err != nil {
+			return err
+		}
+
+		if mode == headers.TransportModePlay {
+			return nc.reconnectPlay(ctx, u, tracks)
+		}
+		return nc.reconnectRecord(u, tracks)
+	})
+	if err != nil {
+		nc.Close()
+		return err
+	}
+
+	c.requestMutex.Lock()
+	c.adoptSession(nc)
+	c.requestMutex.Unlock()
+
+	if mode == headers.TransportModePlay {
+		c.setState(ClientConnStatePlay)
+
+		if len(c.backchannelTracks) > 0 {
+			c.setBackchannelOpen(true)
+		}
+		return nil
+	}
+
+	c.setState(ClientConnStateRecord)
+
+	c.publishWriteMutex.Lock()
+	c.publishOpen = true
+	c.publishWriteMutex.Unlock()
+
+	return nil
+}
+
+func (c *ClientConn) reconnectPlay(ctx context.Context, u *base.URL, tracks Tracks) error {
+	newTracks, _, err := c.describe(ctx, u, nil)
+	if err != nil {
+		return err
+	}
+
+	// set up the same tracks of the previous session
+	for _, track := range tracks {
+		if track.ID >= len(newTracks) {
+			return fmt.Errorf("track %d is not available anymore", track.ID)
+		}
+
+		_, err := c.Setup(headers.TransportModePlay, newTracks[track.ID], 0, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = c.Play(nil)
+	return err
+}
+
+func (c *ClientConn) reconnectRecord(u *base.URL, tracks Tracks) error {
+	_, err := c.Announce(u, tracks)
+	if err != nil {
+		return err
+	}
+
+	for _, track := range tracks {
+		_, err := c.Setup(headers.TransportModeRecord, track, 0, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	res, err := c.Do(&base.Request{
+		Method: base.Record,
+		URL:    c.streamURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != base.StatusOK {
+		return ErrWrongStatusCode{Response: res}
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package gortsplib
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/aler9/gortsplib/pkg/base"
+)
+
+// headers that contain credentials and are redacted from traces.
+var clientConnTraceRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+}
+
+// clientConnTracer writes the RTSP exchange between a client and a server
+// to ClientConf.TraceWriter.
+// Every message is preceded by a line that starts with '#' and contains
+// the direction and the time, followed by the message as it is sent
+// on the wire, therefore traces can be parsed and replayed.
+type clientConnTracer struct {
+	w      io.Writer
+	frames bool
+
+	mutex sync.Mutex
+}
+
+func newClientConnTracer(w io.Writer, frames bool) *clientConnTracer {
+	return &clientConnTracer{
+		w:      w,
+		frames: frames,
+	}
+}
+
+func (t *clientConnTracer) writeHeader(outgoing bool, what string) *bufio.Writer {
+	dir := "S->C"
+	if outgoing {
+		dir = "C->S"
+	}
+
+	bw := bufio.NewWriter(t.w)
+	fmt.Fprintf(bw, "# %s %s %s\n", time.Now().UTC().Format(time.RFC3339Nano), dir, what)
+	return bw
+}
+
+func redactHeader(h base.Header) base.Header {
+	ret := make(base.Header, len(h))
+	for k, v := range h {
+		ret[k] = v
+	}
+
+	for _, k := range clientConnTraceRedactedHeaders {
+		if _, ok := ret[k]; ok {
+			ret[k] = base.HeaderValue{"REDACTED"}
+		}
+	}
+
+	return ret
+}
+
+func (t *clientConnTracer) request(outgoing bool, req *base.Request) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	bw := t.writeHeader(outgoing, "request")
+
+	// the URL is written without credentials
+	base.Request{
+		Method: req.Method,
+		URL:    req.URL,
+		Header: redactHeader(req.Header),
+		Body:   req.Body,
+	}.Write(bw)
+}
+
+func (t *clientConnTracer) response(outgoing bool, res *base.Response) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	bw := t.writeHeader(outgoing, "response")
+
+	base.Response{
+		StatusCode:    res.StatusCode,
+		StatusMessage: res.StatusMessage,
+		Header:        redactHeader(res.Header),
+		Body:          res.Body,
+	}.Write(bw)
+}
+
+// frame writes the channel and the size of an interleaved frame.
+// The payload is not written, since it would make traces too big.
+func (t *clientConnTracer) frame(outgoing bool, f *base.InterleavedFrame) {
+	if !t.frames {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	bw := t.writeHeader(outgoing, fmt.Sprintf("frame channel=%d size=%d", f.Channel, len(f.Payload)))
+	bw.Flush()
+}
@@ -0,0 +1,146 @@
+package gortsplib
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	clientConnTunnelReadBufferSize = 4096
+)
+
+// clientConnTunnel is a net.Conn that implements RTSP over HTTP tunneling.
+// Data is received through a HTTP GET request and sent through
+// a HTTP POST request, encoded in base64.
+type clientConnTunnel struct {
+	getConn  net.Conn
+	postConn net.Conn
+	br       *bufio.Reader
+}
+
+func newClientConnTunnel(dial func() (net.Conn, error), host string,
+	timeout time.Duration) (*clientConnTunnel, error) {
+	cookieByts := make([]byte, 16)
+	rand.Read(cookieByts)
+	cookie := hex.EncodeToString(cookieByts)
+
+	getConn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+
+	getConn.SetDeadline(time.Now().Add(timeout))
+	_, err = getConn.Write([]byte("GET / HTTP/1.0\r\n" +
+		"Host: " + host + "\r\n" +
+		"x-sessioncookie: " + cookie + "\r\n" +
+		"Accept: application/x-rtsp-tunnelled\r\n" +
+		"Pragma: no-cache\r\n" +
+		"Cache-Control: no-cache\r\n" +
+		"\r\n"))
+	if err != nil {
+		getConn.Close()
+		return nil, err
+	}
+
+	br := bufio.NewReaderSize(getConn, clientConnTunnelReadBufferSize)
+	res, err := http.ReadResponse(br, nil)
+	if err != nil {
+		getConn.Close()
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		getConn.Close()
+		return nil, fmt.Errorf("tunnel: bad status code: %d (%s)", res.StatusCode, res.Status)
+	}
+	getConn.SetDeadline(time.Time{})
+
+	postConn, err := dial()
+	if err != nil {
+		getConn.Close()
+		return nil, err
+	}
+
+	// the POST request has no response
+	postConn.SetWriteDeadline(time.Now().Add(timeout))
+	_, err = postConn.Write([]byte("POST / HTTP/1.0\r\n" +
+		"Host: " + host + "\r\n" +
+		"x-sessioncookie: " + cookie + "\r\n" +
+		"Content-Type: application/x-rtsp-tunnelled\r\n" +
+		"Pragma: no-cache\r\n" +
+		"Cache-Control: no-cache\r\n" +
+		"Content-Length: 32767\r\n" +
+		"Expires: Sun, 9 Jan 1972 00:00:00 GMT\r\n" +
+		"\r\n"))
+	if err != nil {
+		getConn.Close()
+		postConn.Close()
+		return nil, err
+	}
+	postConn.SetWriteDeadline(time.Time{})
+
+	return &clientConnTunnel{
+		getConn:  getConn,
+		postConn: postConn,
+		br:       br,
+	}, nil
+}
+
+// Read implements net.Conn.
+func (t *clientConnTunnel) Read(b []byte) (int, error) {
+	return t.br.Read(b)
+}
+
+// Write implements net.Conn.
+func (t *clientConnTunnel) Write(b []byte) (int, error) {
+	_, err := t.postConn.Write([]byte(base64.StdEncoding.EncodeToString(b)))
+	if err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close implements net.Conn.
+func (t *clientConnTunnel) Close() error {
+	err1 := t.getConn.Close()
+	err2 := t.postConn.Close()
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
+
+// LocalAddr implements net.Conn.
+func (t *clientConnTunnel) LocalAddr() net.Addr {
+	return t.getConn.LocalAddr()
+}
+
+// RemoteAddr implements net.Conn.
+func (t *clientConnTunnel) RemoteAddr() net.Addr {
+	return t.getConn.RemoteAddr()
+}
+
+// SetDeadline implements net.Conn.
+func (t *clientConnTunnel) SetDeadline(ts time.Time) error {
+	err := t.getConn.SetDeadline(ts)
+	if err != nil {
+		return err
+	}
+	return t.postConn.SetDeadline(ts)
+}
+
+// SetReadDeadline implements net.Conn.
+func (t *clientConnTunnel) SetReadDeadline(ts time.Time) error {
+	return t.getConn.SetReadDeadline(ts)
+}
+
+// SetWriteDeadline implements net.Conn.
+func (t *clientConnTunnel) SetWriteDeadline(ts time.Time) error {
+	return t.postConn.SetWriteDeadline(ts)
+}
@@ -1,68 +1,251 @@
 package gortsplib
 
 import (
+	"fmt"
 	"net"
 	"strconv"
 	"sync/atomic"
 	"time"
 
-	"github.com/aler9/gortsplib/pkg/multibuffer"
-)
-
-const (
-	// use the same buffer size as gstreamer's rtspsrc
-	clientConnUDPKernelReadBufferSize = 0x80000
-
-	clientConnUDPReadBufferSize = 2048
+	"github.com/aler9/gortsplib/pkg/rtpfec"
+	"github.com/aler9/gortsplib/pkg/rtpnack"
+	"github.com/aler9/gortsplib/pkg/rtpreorderer"
 )
 
 type clientConnUDPListener struct {
-	c              *ClientConn
-	pc             net.PacketConn
-	remoteIP       net.IP
-	remoteZone     string
-	remotePort     int
-	udpFrameBuffer *multibuffer.MultiBuffer
-	trackID        int
-	streamType     StreamType
-	running        bool
+	c                *ClientConn
+	pc               net.PacketConn
+	remoteIP         net.IP
+	remoteZone       string
+	remotePort       int
+	remoteSSRC       *uint32
+	udpFrameReader   *udpBatchReader
+	isMulticast      bool
+	trackID          int
+	streamType       StreamType
+	reorderer        *rtpreorderer.Reorderer
+	fecDecoder       *rtpfec.Decoder
+	nackRequester    *rtpnack.Requester
+	publish          bool
+	pooled           *clientUDPPoolSocket
+	unexpectedSource *net.UDPAddr
+	running          bool
 
 	done chan struct{}
 }
 
 func newClientConnUDPListener(c *ClientConn, port int) (*clientConnUDPListener, error) {
-	pc, err := c.conf.ListenPacket("udp", ":"+strconv.FormatInt(int64(port), 10))
+	// when the local interface is pinned, listen on the address
+	// that is being used to talk with the server
+	// (connections returned by ClientConf.DialContextFunc may not be TCP connections)
+	host := ""
+	if c.conf.LocalAddr != "" || c.conf.LocalInterface != "" {
+		if addr, ok := c.nconn.LocalAddr().(*net.TCPAddr); ok {
+			host = addr.IP.String()
+		}
+	}
+
+	pc, err := c.conf.ListenPacket("udp", net.JoinHostPort(host, strconv.FormatInt(int64(port), 10)))
+	if err != nil {
+		return nil, err
+	}
+
+	err = pc.(*net.UDPConn).SetReadBuffer(c.conf.UDPKernelReadBufferSize)
+	if err != nil {
+		return nil, err
+	}
+
+	err = setSocketQoS(pc, c.conf.DSCP, c.conf.SocketPriority)
+	if err != nil {
+		pc.Close()
+		return nil, err
+	}
+
+	return &clientConnUDPListener{
+		c:              c,
+		pc:             pc,
+		udpFrameReader: newUDPBatchReader(pc, c.conf.ReadBufferCount, c.conf.ReadBufferSize+1),
+	}, nil
+}
+
+func newClientConnUDPListenerMulticast(c *ClientConn, intf *net.Interface,
+	ip net.IP, port int) (*clientConnUDPListener, error) {
+	pc, err := net.ListenMulticastUDP("udp", intf, &net.UDPAddr{
+		IP:   ip,
+		Port: port,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	err = pc.(*net.UDPConn).SetReadBuffer(clientConnUDPKernelReadBufferSize)
+	err = pc.SetReadBuffer(c.conf.UDPKernelReadBufferSize)
 	if err != nil {
+		pc.Close()
+		return nil, err
+	}
+
+	err = setSocketQoS(pc, c.conf.DSCP, c.conf.SocketPriority)
+	if err != nil {
+		pc.Close()
 		return nil, err
 	}
 
 	return &clientConnUDPListener{
 		c:              c,
 		pc:             pc,
-		udpFrameBuffer: multibuffer.New(c.conf.ReadBufferCount, clientConnUDPReadBufferSize),
+		remoteIP:       ip,
+		remoteZone:     zoneOfInterface(intf),
+		remotePort:     port,
+		isMulticast:    true,
+		udpFrameReader: newUDPBatchReader(pc, c.conf.ReadBufferCount, c.conf.ReadBufferSize+1),
 	}, nil
 }
 
+// multicastInterface returns the interface used to join a multicast group.
+// IPv4 groups are joined on the interface chosen by the kernel. IPv6 groups
+// are joined on the interface specified by the zone or, if the zone is missing,
+// on the interface that is being used to talk with the server, since IPv6
+// multicast addresses are often scoped to a link.
+func (c *ClientConn) multicastInterface(ip net.IP, zone string) (*net.Interface, error) {
+	if c.conf.LocalInterface != "" {
+		return net.InterfaceByName(c.conf.LocalInterface)
+	}
+
+	if c.conf.LocalAddr != "" {
+		return interfaceOfIP(net.ParseIP(c.conf.LocalAddr)), nil
+	}
+
+	if ip.To4() != nil {
+		return nil, nil
+	}
+
+	if zone == "" {
+		addr, ok := c.nconn.LocalAddr().(*net.TCPAddr)
+		if !ok {
+			return nil, nil
+		}
+		if addr.Zone != "" {
+			zone = addr.Zone
+		} else {
+			return interfaceOfIP(addr.IP), nil
+		}
+	}
+
+	// zones can be interface names or interface indexes
+	if index, err := strconv.Atoi(zone); err == nil {
+		return net.InterfaceByIndex(index)
+	}
+	return net.InterfaceByName(zone)
+}
+
+func interfaceOfIP(ip net.IP) *net.Interface {
+	intfs, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+
+	for _, intf := range intfs {
+		addrs, err := intf.Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.Equal(ip) {
+				return &intf
+			}
+		}
+	}
+
+	return nil
+}
+
+// localIP returns the local IP that must be used to talk with a server
+// with the given IP, according to ClientConf.LocalAddr and ClientConf.LocalInterface.
+// The address of the interface must have the same family of the server address,
+// and must be link-local only if the server address is.
+func localIP(conf ClientConf, remoteIP net.IP) (net.IP, error) {
+	if conf.LocalAddr != "" {
+		ip := net.ParseIP(conf.LocalAddr)
+		if (ip.To4() != nil) != (remoteIP.To4() != nil) {
+			return nil, fmt.Errorf("local address %s can't be used to reach %s", ip, remoteIP)
+		}
+		return ip, nil
+	}
+
+	intf, err := net.InterfaceByName(conf.LocalInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := intf.Addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+
+		if (ipnet.IP.To4() != nil) == (remoteIP.To4() != nil) &&
+			ipnet.IP.IsLinkLocalUnicast() == remoteIP.IsLinkLocalUnicast() {
+			return ipnet.IP, nil
+		}
+	}
+
+	return nil, fmt.Errorf("interface %s has no address that can be used to reach %s",
+		conf.LocalInterface, remoteIP)
+}
+
+func zoneOfInterface(intf *net.Interface) string {
+	if intf == nil {
+		return ""
+	}
+	return intf.Name
+}
+
+func newClientConnUDPListenerPooled(c *ClientConn, sock *clientUDPPoolSocket) *clientConnUDPListener {
+	return &clientConnUDPListener{
+		c:      c,
+		pc:     sock.pc,
+		pooled: sock,
+	}
+}
+
 func (l *clientConnUDPListener) close() {
 	if l.running {
 		l.stop()
 	}
-	l.pc.Close()
+
+	// sockets of pools are closed by the pool
+	if l.pooled == nil {
+		l.pc.Close()
+	}
 }
 
 func (l *clientConnUDPListener) start() {
 	l.running = true
+
+	if l.pooled != nil {
+		l.pooled.addListener(l)
+		return
+	}
+
 	l.pc.SetReadDeadline(time.Time{})
 	l.done = make(chan struct{})
 	go l.run()
 }
 
 func (l *clientConnUDPListener) stop() {
+	l.running = false
+
+	if l.pooled != nil {
+		l.pooled.removeListener(l)
+		return
+	}
+
 	l.pc.SetReadDeadline(time.Now())
 	<-l.done
 }
@@ -71,24 +254,108 @@ func (l *clientConnUDPListener) run() {
 	defer close(l.done)
 
 	for {
-		buf := l.udpFrameBuffer.Next()
-		n, addr, err := l.pc.ReadFrom(buf)
+		err := l.udpFrameReader.read(func(buf []byte, addr *net.UDPAddr) {
+			// packets bigger than the read buffer would be truncated; discard them.
+			// buffers have an additional byte in order to detect them.
+			if uint64(len(buf)) > l.c.conf.ReadBufferSize {
+				return
+			}
+
+			// multicast packets are sent to the group, and their source
+			// is not known in advance
+			if !l.isMulticast && !l.checkSource(addr) {
+				return
+			}
+
+			l.processFrame(buf)
+		})
 		if err != nil {
 			return
 		}
+	}
+}
 
-		uaddr := addr.(*net.UDPAddr)
+// checkSource checks whether a packet comes from the address advertised by the server
+// or, if AnyPortEnable or AnySourceEnable are set, from an acceptable one.
+func (l *clientConnUDPListener) checkSource(addr *net.UDPAddr) bool {
+	if l.remoteIP.Equal(addr.IP) && l.remotePort == addr.Port {
+		return true
+	}
 
-		if !l.remoteIP.Equal(uaddr.IP) || l.remotePort != uaddr.Port {
-			continue
+	switch {
+	case l.c.conf.AnySourceEnable:
+	case l.c.conf.AnyPortEnable && l.remoteIP.Equal(addr.IP):
+	default:
+		return false
+	}
+
+	if l.c.conf.OnUnexpectedSource != nil &&
+		(l.unexpectedSource == nil || !l.unexpectedSource.IP.Equal(addr.IP) ||
+			l.unexpectedSource.Port != addr.Port) {
+		l.unexpectedSource = addr
+		l.c.conf.OnUnexpectedSource(l.trackID, l.streamType, addr)
+	}
+
+	return true
+}
+
+func (l *clientConnUDPListener) processFrame(pkt []byte) {
+	now := time.Now()
+
+	// when publishing, the server sends only RTCP receiver reports
+	if l.publish {
+		l.c.rtcpSenders[l.trackID].ProcessReceiverReport(now, pkt)
+
+		if r, ok := l.c.nackResponders[l.trackID]; ok {
+			for _, pkt := range r.ProcessNACK(pkt) {
+				l.c.udpRTPListeners[l.trackID].write(pkt)
+			}
 		}
+		return
+	}
 
-		now := time.Now()
-		atomic.StoreInt64(l.c.udpLastFrameTimes[l.trackID], now.Unix())
-		l.c.rtcpReceivers[l.trackID].ProcessFrame(now, l.streamType, buf[:n])
+	if l.fecDecoder != nil {
+		var err error
+		pkt, err = l.fecDecoder.Decode(pkt)
+		if err != nil || pkt == nil {
+			return
+		}
+	}
+
+	if l.nackRequester != nil {
+		var nack []byte
+		pkt, nack = l.nackRequester.Process(pkt)
+
+		if nack != nil {
+			l.c.udpRTCPListeners[l.trackID].write(nack)
+		}
+
+		if pkt == nil {
+			return
+		}
+	}
 
-		l.c.readCB(l.trackID, l.streamType, buf[:n])
+	atomic.StoreInt64(l.c.udpLastFrameTimes[l.trackID], now.Unix())
+	if l.streamType == StreamTypeRTP {
+		atomic.StoreInt32(&l.c.udpFrameReceived, 1)
+		atomic.StoreInt64(l.c.rtpLastFrameTimes[l.trackID], now.UnixNano())
 	}
+
+	if l.reorderer != nil {
+		pkts, lost := l.reorderer.Process(pkt)
+		l.c.packetsLost(l.trackID, lost)
+
+		for _, opkt := range pkts {
+			l.c.processReceivedFrame(l.trackID, now, l.streamType, opkt)
+			l.c.readCB(l.trackID, l.streamType, opkt)
+		}
+		return
+	}
+
+	lost := l.c.processReceivedFrame(l.trackID, now, l.streamType, pkt)
+	l.c.packetsLost(l.trackID, lost)
+
+	l.c.readCB(l.trackID, l.streamType, pkt)
 }
 
 func (l *clientConnUDPListener) write(buf []byte) error {
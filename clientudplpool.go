@@ -0,0 +1,314 @@
+package gortsplib
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"sync"
+)
+
+type clientUDPPoolSocket struct {
+	pc             net.PacketConn
+	streamType     StreamType
+	reader         *udpBatchReader
+	readBufferSize uint64
+
+	listenersMutex sync.RWMutex
+	listeners      map[publisherAddr][]*clientConnUDPListener
+
+	// out
+	done chan struct{}
+}
+
+func newClientUDPPoolSocket(conf ClientConf, port int, streamType StreamType) (*clientUDPPoolSocket, error) {
+	pc, err := conf.ListenPacket("udp", ":"+strconv.FormatInt(int64(port), 10))
+	if err != nil {
+		return nil, err
+	}
+
+	return &clientUDPPoolSocket{
+		pc:         pc,
+		streamType: streamType,
+		listeners:  make(map[publisherAddr][]*clientConnUDPListener),
+		done:       make(chan struct{}),
+	}, nil
+}
+
+func (s *clientUDPPoolSocket) initialize(conf ClientConf) error {
+	// sockets returned by ClientConf.ListenPacket may not be UDP sockets
+	if pc, ok := s.pc.(interface{ SetReadBuffer(int) error }); ok {
+		err := pc.SetReadBuffer(conf.UDPKernelReadBufferSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := setSocketQoS(s.pc, conf.DSCP, conf.SocketPriority)
+	if err != nil {
+		return err
+	}
+
+	s.reader = newUDPBatchReader(s.pc, conf.ReadBufferCount, conf.ReadBufferSize+1)
+	s.readBufferSize = conf.ReadBufferSize
+	go s.run()
+	return nil
+}
+
+func (s *clientUDPPoolSocket) run() {
+	defer close(s.done)
+
+	for {
+		err := s.reader.read(func(buf []byte, addr *net.UDPAddr) {
+			// packets bigger than the read buffer would be truncated; discard them.
+			// buffers have an additional byte in order to detect them.
+			if uint64(len(buf)) > s.readBufferSize {
+				return
+			}
+
+			s.listenersMutex.RLock()
+			defer s.listenersMutex.RUnlock()
+
+			var pubAddr publisherAddr
+			pubAddr.fill(addr.IP, addr.Port)
+			l := s.findListener(s.listeners[pubAddr], buf)
+			if l == nil {
+				return
+			}
+
+			l.processFrame(buf)
+		})
+		if err != nil {
+			return
+		}
+	}
+}
+
+// findListener returns the listener of a packet, among the ones that
+// receive packets from its source address.
+// When there are multiple listeners, the packet is routed by its SSRC.
+func (s *clientUDPPoolSocket) findListener(ls []*clientConnUDPListener, buf []byte) *clientConnUDPListener {
+	if len(ls) == 1 {
+		return ls[0]
+	}
+
+	ssrc, ok := packetSSRC(s.streamType, buf)
+	if !ok {
+		return nil
+	}
+
+	for _, l := range ls {
+		if l.remoteSSRC != nil && *l.remoteSSRC == ssrc {
+			return l
+		}
+	}
+	return nil
+}
+
+// packetSSRC returns the SSRC of a RTP packet or the sender SSRC of a RTCP packet.
+func packetSSRC(streamType StreamType, buf []byte) (uint32, bool) {
+	if streamType == StreamTypeRTP {
+		if len(buf) < 12 {
+			return 0, false
+		}
+		return binary.BigEndian.Uint32(buf[8:12]), true
+	}
+
+	if len(buf) < 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(buf[4:8]), true
+}
+
+func (s *clientUDPPoolSocket) port() int {
+	return s.pc.LocalAddr().(*net.UDPAddr).Port
+}
+
+// canAddListener checks whether packets of a listener with the given source
+// address and SSRC can be told apart from the ones of the existing listeners.
+func (s *clientUDPPoolSocket) canAddListener(ip net.IP, port int, ssrc *uint32) bool {
+	s.listenersMutex.RLock()
+	defer s.listenersMutex.RUnlock()
+
+	return s.canAddListenerUnlocked(ip, port, ssrc)
+}
+
+func (s *clientUDPPoolSocket) canAddListenerUnlocked(ip net.IP, port int, ssrc *uint32) bool {
+	var addr publisherAddr
+	addr.fill(ip, port)
+
+	ls := s.listeners[addr]
+	if len(ls) == 0 {
+		return true
+	}
+
+	if ssrc == nil {
+		return false
+	}
+
+	for _, l := range ls {
+		if l.remoteSSRC == nil || *l.remoteSSRC == *ssrc {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *clientUDPPoolSocket) addListener(l *clientConnUDPListener) {
+	s.listenersMutex.Lock()
+	defer s.listenersMutex.Unlock()
+
+	if !s.canAddListenerUnlocked(l.remoteIP, l.remotePort, l.remoteSSRC) {
+		return
+	}
+
+	var addr publisherAddr
+	addr.fill(l.remoteIP, l.remotePort)
+	s.listeners[addr] = append(s.listeners[addr], l)
+}
+
+func (s *clientUDPPoolSocket) removeListener(l *clientConnUDPListener) {
+	s.listenersMutex.Lock()
+	defer s.listenersMutex.Unlock()
+
+	var addr publisherAddr
+	addr.fill(l.remoteIP, l.remotePort)
+
+	ls := s.listeners[addr]
+	for i, cur := range ls {
+		if cur == l {
+			ls = append(ls[:i:i], ls[i+1:]...)
+			break
+		}
+	}
+
+	if len(ls) == 0 {
+		delete(s.listeners, addr)
+	} else {
+		s.listeners[addr] = ls
+	}
+}
+
+// ClientUDPListenerPool is a set of UDP listeners that can be shared by multiple
+// ClientConns, in order to receive and send RTP and RTCP packets with UDP
+// without opening dedicated sockets and routines for every track.
+// Packets are routed to tracks by their source address and, when the server
+// provides it in the Transport header, by their SSRC. Therefore a pool can't
+// be used to receive the same stream from the same server more than once at
+// a time on the same listener pair, unless the server provides distinct SSRCs.
+type ClientUDPListenerPool struct {
+	count int
+
+	mutex       sync.Mutex
+	rtpSockets  []*clientUDPPoolSocket
+	rtcpSockets []*clientUDPPoolSocket
+	next        int
+}
+
+// NewClientUDPListenerPool allocates a ClientUDPListenerPool with the given
+// number of RTP/RTCP listener pairs, on randomly chosen ports.
+// Listeners are opened when the pool is used for the first time.
+func NewClientUDPListenerPool(count int) (*ClientUDPListenerPool, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("count must be greater than zero")
+	}
+
+	return &ClientUDPListenerPool{
+		count: count,
+	}, nil
+}
+
+// Close closes the pool.
+// It must be called after all the ClientConns that use the pool have been closed.
+func (p *ClientUDPListenerPool) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.closeSockets()
+}
+
+func (p *ClientUDPListenerPool) closeSockets() {
+	for _, s := range append(p.rtpSockets, p.rtcpSockets...) {
+		s.pc.Close()
+
+		if s.reader != nil {
+			<-s.done
+		}
+	}
+
+	p.rtpSockets = nil
+	p.rtcpSockets = nil
+}
+
+// open opens the listeners with the settings of the given ClientConf.
+func (p *ClientUDPListenerPool) open(conf ClientConf) error {
+	for len(p.rtpSockets) < p.count {
+		// choose two consecutive ports in range 65535-10000
+		// rtp must be even and rtcp odd
+		rtpPort := (rand.Intn((65535-10000)/2) * 2) + 10000
+
+		rtpSocket, err := newClientUDPPoolSocket(conf, rtpPort, StreamTypeRTP)
+		if err != nil {
+			continue
+		}
+
+		rtcpSocket, err := newClientUDPPoolSocket(conf, rtpPort+1, StreamTypeRTCP)
+		if err != nil {
+			rtpSocket.pc.Close()
+			continue
+		}
+
+		p.rtpSockets = append(p.rtpSockets, rtpSocket)
+		p.rtcpSockets = append(p.rtcpSockets, rtcpSocket)
+	}
+
+	for _, s := range append(p.rtpSockets, p.rtcpSockets...) {
+		err := s.initialize(conf)
+		if err != nil {
+			p.closeSockets()
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listenerPair returns a RTP and RTCP listener pair of the pool.
+// Pairs that are already in use by the ClientConn are avoided, if possible,
+// since tracks of the same server can be sent from the same address.
+// Listeners are opened with ClientConf.ListenPacket, read buffers and socket
+// options of the first ClientConn that uses the pool.
+func (p *ClientUDPListenerPool) listenerPair(c *ClientConn) (*clientConnUDPListener, *clientConnUDPListener, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.rtpSockets == nil {
+		err := p.open(c.conf)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	inUse := func(i int) bool {
+		for _, l := range c.udpRTPListeners {
+			if l.pooled == p.rtpSockets[i] {
+				return true
+			}
+		}
+		return false
+	}
+
+	i := p.next
+	for j := 0; j < len(p.rtpSockets); j++ {
+		k := (p.next + j) % len(p.rtpSockets)
+		if !inUse(k) {
+			i = k
+			break
+		}
+	}
+	p.next = (i + 1) % len(p.rtpSockets)
+
+	return newClientConnUDPListenerPooled(c, p.rtpSockets[i]),
+		newClientConnUDPListenerPooled(c, p.rtcpSockets[i]), nil
+}
@@ -13,6 +13,10 @@ const (
 
 	// StreamProtocolTCP means that the stream uses the TCP protocol
 	StreamProtocolTCP StreamProtocol = base.StreamProtocolTCP
+
+	// StreamProtocolUDPMulticast means that the stream uses the UDP protocol
+	// with multicast delivery
+	StreamProtocolUDPMulticast StreamProtocol = base.StreamProtocolUDPMulticast
 )
 
 // StreamType is the stream type.
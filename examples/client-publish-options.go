@@ -47,9 +47,13 @@ func main() {
 	conf := gortsplib.ClientConf{
 		// the stream protocol (UDP or TCP). If nil, it is chosen automatically
 		StreamProtocol: nil,
-		// timeout of read operations
+		// timeout of the TCP connection
+		ConnectTimeout: 10 * time.Second,
+		// timeout of requests
+		RequestTimeout: 10 * time.Second,
+		// maximum time without receiving frames
 		ReadTimeout: 10 * time.Second,
-		// timeout of write operations
+		// timeout of frame writes
 		WriteTimeout: 10 * time.Second,
 	}
 
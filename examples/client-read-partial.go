@@ -47,7 +47,7 @@ func main() {
 		}
 	}
 
-	_, err = conn.Play()
+	_, err = conn.Play(nil)
 	if err != nil {
 		panic(err)
 	}
@@ -84,7 +84,7 @@ func handleConn(conn *gortsplib.ServerConn) {
 	}
 
 	// called after receiving a PLAY request.
-	onPlay := func(req *base.Request) (*base.Response, error) {
+	onPlay := func(req *base.Request, rng *headers.Range) (*base.Response, error) {
 		mutex.Lock()
 		defer mutex.Unlock()
 
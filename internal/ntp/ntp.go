@@ -0,0 +1,24 @@
+// Package ntp contains functions to convert NTP timestamps.
+package ntp
+
+import (
+	"time"
+)
+
+// seconds between 1st January 1900 and 1st January 1970
+const epochOffset = 2208988800
+
+// Decode decodes a NTP timestamp.
+func Decode(v uint64) time.Time {
+	secs := int64(v>>32) - epochOffset
+	nsecs := int64(((v & 0xFFFFFFFF) * 1000000000) >> 32)
+	return time.Unix(secs, nsecs)
+}
+
+// Encode encodes a NTP timestamp.
+// The fractional part is rounded up, in order to allow Decode() to
+// return the original time.
+func Encode(t time.Time) uint64 {
+	ns := uint64(t.UnixNano()) + epochOffset*1000000000
+	return (ns/1000000000)<<32 | ((ns%1000000000)<<32+999999999)/1000000000
+}
@@ -0,0 +1,29 @@
+package ntp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	require.True(t, time.Date(2021, 2, 27, 11, 32, 4, 500000000, time.UTC).Equal(
+		Decode(0xe3e4ac3480000000)))
+}
+
+func TestEncode(t *testing.T) {
+	require.Equal(t, uint64(0xe3e4ac3480000000),
+		Encode(time.Date(2021, 2, 27, 11, 32, 4, 500000000, time.UTC)))
+}
+
+func TestEncodeDecode(t *testing.T) {
+	for _, ts := range []time.Time{
+		time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC),
+		time.Date(2008, 5, 20, 22, 15, 20, 1, time.UTC),
+		time.Date(2020, 5, 18, 10, 2, 44, 123456789, time.UTC),
+		time.Date(2021, 2, 27, 11, 32, 4, 999999999, time.UTC),
+	} {
+		require.True(t, ts.Equal(Decode(Encode(ts))))
+	}
+}
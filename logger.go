@@ -0,0 +1,56 @@
+package gortsplib
+
+// LogLevel is the level of a log entry.
+type LogLevel int
+
+// standard log levels.
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+// String implements fmt.Stringer.
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "debug"
+	case LogLevelInfo:
+		return "info"
+	case LogLevelWarn:
+		return "warn"
+	case LogLevelError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// LogField is a key-value pair attached to a log entry.
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger receives log entries from clients and servers.
+// It allows to forward connection lifecycle events, requests, responses,
+// transport fallbacks and errors to a structured logging library.
+// Log can be called from multiple routines at once.
+type Logger interface {
+	Log(level LogLevel, event string, fields ...LogField)
+}
+
+// LoggerFunc allows to use a function as a Logger.
+type LoggerFunc func(level LogLevel, event string, fields ...LogField)
+
+// Log implements Logger.
+func (f LoggerFunc) Log(level LogLevel, event string, fields ...LogField) {
+	f(level, event, fields...)
+}
+
+// log sends an entry to a Logger, if it is not nil.
+func log(l Logger, level LogLevel, event string, fields ...LogField) {
+	if l != nil {
+		l.Log(level, event, fields...)
+	}
+}
@@ -0,0 +1,67 @@
+package gortsplib
+
+// names of the metrics sent to Metrics.
+// They follow the Prometheus naming conventions, therefore they can be used
+// directly as names of Prometheus counters and gauges.
+const (
+	// gauge, number of open client connections.
+	MetricClientConnections = "gortsplib_client_connections"
+
+	// counter, number of reconnection attempts of clients.
+	MetricClientReconnects = "gortsplib_client_reconnects_total"
+
+	// counters, frames and bytes received by clients.
+	MetricClientFramesReceived = "gortsplib_client_frames_received_total"
+	MetricClientBytesReceived  = "gortsplib_client_bytes_received_total"
+
+	// counters, frames and bytes sent by clients.
+	MetricClientFramesSent = "gortsplib_client_frames_sent_total"
+	MetricClientBytesSent  = "gortsplib_client_bytes_sent_total"
+
+	// counter, RTP packets lost by clients.
+	MetricClientPacketsLost = "gortsplib_client_packets_lost_total"
+
+	// gauge, number of open server connections.
+	MetricServerConnections = "gortsplib_server_connections"
+
+	// gauge, number of server connections that are reading or publishing.
+	MetricServerSessions = "gortsplib_server_sessions"
+
+	// counters, frames and bytes received by servers from publishers.
+	MetricServerFramesReceived = "gortsplib_server_frames_received_total"
+	MetricServerBytesReceived  = "gortsplib_server_bytes_received_total"
+
+	// counters, frames and bytes sent by servers to readers.
+	MetricServerFramesSent = "gortsplib_server_frames_sent_total"
+	MetricServerBytesSent  = "gortsplib_server_bytes_sent_total"
+
+	// counter, RTP packets of publishers lost by servers.
+	MetricServerPacketsLost = "gortsplib_server_packets_lost_total"
+)
+
+// Metrics receives counters and gauges from clients and servers.
+// It allows to export metrics to monitoring systems like Prometheus,
+// by mapping every metric name to a counter or a gauge.
+// Methods are called from multiple routines at once, and some of them
+// are called for every frame, therefore they must not block.
+type Metrics interface {
+	// AddCounter increases the counter with the given name.
+	AddCounter(name string, value float64)
+
+	// AddGauge adds the given value, that can be negative, to the gauge with the given name.
+	AddGauge(name string, value float64)
+}
+
+// addCounter increases a counter of a Metrics, if it is not nil.
+func addCounter(m Metrics, name string, value float64) {
+	if m != nil {
+		m.AddCounter(name, value)
+	}
+}
+
+// addGauge changes a gauge of a Metrics, if it is not nil.
+func addGauge(m Metrics, name string, value float64) {
+	if m != nil {
+		m.AddGauge(name, value)
+	}
+}
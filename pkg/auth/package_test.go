@@ -2,6 +2,7 @@ package auth
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -143,3 +144,183 @@ func TestAuthHashed(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthDigestResponse(t *testing.T) {
+	// https://tools.ietf.org/html/rfc7616#section-3.9.1
+	for _, ca := range []struct {
+		algorithm string
+		response  string
+	}{
+		{
+			"MD5",
+			"8ca523f5e9506fed4657c9700eebdbec",
+		},
+		{
+			"SHA-256",
+			"753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1",
+		},
+	} {
+		t.Run(ca.algorithm, func(t *testing.T) {
+			hash, sess, err := digestAlgorithm(ca.algorithm)
+			require.NoError(t, err)
+
+			response := digestResponse(hash, sess, "Mufasa", "http-auth@example.org", "Circle of Life",
+				"7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+				"auth", "00000001", "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ",
+				base.Method("GET"), "/dir/index.html")
+			require.Equal(t, ca.response, response)
+		})
+	}
+}
+
+func TestAuthDigestAlgorithms(t *testing.T) {
+	for _, algorithm := range []string{
+		"MD5",
+		"MD5-sess",
+		"SHA-256",
+		"SHA-256-sess",
+	} {
+		for _, qop := range []string{"", "auth"} {
+			t.Run(algorithm+"_"+qop, func(t *testing.T) {
+				va := NewValidator("testuser", "testpass", []headers.AuthMethod{headers.AuthDigest})
+
+				h := headers.Auth{
+					Method:    headers.AuthDigest,
+					Realm:     &va.realm,
+					Nonce:     &va.nonce,
+					Opaque:    &va.opaque,
+					Algorithm: &algorithm,
+				}
+				if qop != "" {
+					h.QOP = &qop
+				}
+
+				se, err := NewSender(h.Write(), "testuser", "testpass")
+				require.NoError(t, err)
+
+				for i := 0; i < 2; i++ {
+					authorization := se.GenerateHeader(base.Announce,
+						base.MustParseURL("rtsp://myhost/mypath"))
+
+					err = va.ValidateHeader(authorization, base.Announce,
+						base.MustParseURL("rtsp://myhost/mypath"))
+					require.NoError(t, err)
+				}
+			})
+		}
+	}
+}
+
+func TestAuthDigestPreferSHA256(t *testing.T) {
+	md5 := "MD5"
+	sha256 := "SHA-256"
+	unsupported := "SHA-512-256"
+	realm := "myrealm"
+	nonce := "abcde"
+
+	var v base.HeaderValue
+	for _, algorithm := range []*string{&md5, &unsupported, &sha256} {
+		v = append(v, headers.Auth{
+			Method:    headers.AuthDigest,
+			Realm:     &realm,
+			Nonce:     &nonce,
+			Algorithm: algorithm,
+		}.Write()...)
+	}
+
+	se, err := NewSender(v, "testuser", "testpass")
+	require.NoError(t, err)
+
+	authorization := se.GenerateHeader(base.Announce, base.MustParseURL("rtsp://myhost/mypath"))
+	auth, err := headers.ReadAuth(authorization)
+	require.NoError(t, err)
+	require.Equal(t, &sha256, auth.Algorithm)
+}
+
+func TestAuthValidateRequest(t *testing.T) {
+	va := NewValidator("testuser", "testpass", []headers.AuthMethod{headers.AuthDigest})
+	u := base.MustParseURL("rtsp://myhost/mypath")
+
+	res := va.ValidateRequest(&base.Request{
+		Method: base.Describe,
+		URL:    u,
+		Header: base.Header{},
+	})
+	require.NotEqual(t, (*base.Response)(nil), res)
+	require.Equal(t, base.StatusUnauthorized, res.StatusCode)
+
+	se, err := NewSender(res.Header["WWW-Authenticate"], "testuser", "testpass")
+	require.NoError(t, err)
+
+	res = va.ValidateRequest(&base.Request{
+		Method: base.Describe,
+		URL:    u,
+		Header: base.Header{
+			"Authorization": se.GenerateHeader(base.Describe, u),
+		},
+	})
+	require.Equal(t, (*base.Response)(nil), res)
+}
+
+func TestAuthNonceExpiry(t *testing.T) {
+	va := NewValidator("testuser", "testpass", []headers.AuthMethod{headers.AuthDigest})
+	va.NonceValidity = time.Minute
+	u := base.MustParseURL("rtsp://myhost/mypath")
+
+	se, err := NewSender(va.GenerateHeader(), "testuser", "testpass")
+	require.NoError(t, err)
+
+	err = va.ValidateHeader(se.GenerateHeader(base.Setup, u), base.Setup, u)
+	require.NoError(t, err)
+
+	va.nonceCreated = va.nonceCreated.Add(-2 * time.Minute)
+
+	// wrong credentials are not reported as stale
+	wrongSe, err := NewSender(va.GenerateHeader(), "testuser", "wrongpass")
+	require.NoError(t, err)
+	wrongSe.nonce = va.prevNonce
+	err = va.ValidateHeader(wrongSe.GenerateHeader(base.Setup, u), base.Setup, u)
+	require.EqualError(t, err, "wrong response")
+
+	res := va.ValidateRequest(&base.Request{
+		Method: base.Setup,
+		URL:    u,
+		Header: base.Header{
+			"Authorization": se.GenerateHeader(base.Setup, u),
+		},
+	})
+	require.NotEqual(t, (*base.Response)(nil), res)
+
+	auth, err := headers.ReadAuth(res.Header["WWW-Authenticate"])
+	require.NoError(t, err)
+	require.Equal(t, "true", *auth.Stale)
+
+	err = se.RefreshNonce(res.Header["WWW-Authenticate"])
+	require.NoError(t, err)
+
+	err = va.ValidateHeader(se.GenerateHeader(base.Setup, u), base.Setup, u)
+	require.NoError(t, err)
+
+	// the nonce is refreshed only when it is stale
+	err = se.RefreshNonce(va.GenerateHeader())
+	require.EqualError(t, err, "the nonce is not stale")
+}
+
+func TestAuthWrongOpaque(t *testing.T) {
+	va := NewValidator("testuser", "testpass", []headers.AuthMethod{headers.AuthDigest})
+	u := base.MustParseURL("rtsp://myhost/mypath")
+
+	se, err := NewSender(va.GenerateHeader(), "testuser", "testpass")
+	require.NoError(t, err)
+
+	opaque := "wrong"
+	se.opaque = &opaque
+
+	err = va.ValidateHeader(se.GenerateHeader(base.Setup, u), base.Setup, u)
+	require.EqualError(t, err, "wrong opaque")
+
+	se.opaque = nil
+
+	err = va.ValidateHeader(se.GenerateHeader(base.Setup, u), base.Setup, u)
+	require.EqualError(t, err, "opaque not provided")
+}
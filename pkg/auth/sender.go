@@ -1,7 +1,9 @@
 package auth
 
 import (
+	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"strings"
 
@@ -11,44 +13,107 @@ import (
 
 // Sender allows to generate credentials for a Validator.
 type Sender struct {
-	user   string
-	pass   string
-	method headers.AuthMethod
-	realm  string
-	nonce  string
+	user      string
+	pass      string
+	method    headers.AuthMethod
+	realm     string
+	nonce     string
+	opaque    *string
+	algorithm *string
+	qop       string
+	cnonce    string
+	nc        uint32
+}
+
+// digestPriority returns the priority of a digest challenge, or -1 if
+// the challenge is not supported.
+func digestPriority(auth *headers.Auth) int {
+	algorithm := ""
+	if auth.Algorithm != nil {
+		algorithm = *auth.Algorithm
+	}
+
+	_, _, err := digestAlgorithm(algorithm)
+	if err != nil {
+		return -1
+	}
+
+	if auth.QOP != nil && !qopSupportsAuth(*auth.QOP) {
+		return -1
+	}
+
+	// prefer SHA-256 to MD5
+	if strings.HasPrefix(strings.ToUpper(algorithm), "SHA-256") {
+		return 1
+	}
+	return 0
+}
+
+func qopSupportsAuth(qop string) bool {
+	for _, v := range strings.Split(qop, ",") {
+		if strings.TrimSpace(v) == "auth" {
+			return true
+		}
+	}
+	return false
 }
 
 // NewSender allocates a Sender with the WWW-Authenticate header provided by
 // a Validator and a set of credentials.
+// Digest is preferred to Basic; among Digest challenges, SHA-256 is preferred to MD5.
 func NewSender(v base.HeaderValue, user string, pass string) (*Sender, error) {
 	// prefer digest
-	if headerAuthDigest := func() string {
-		for _, vi := range v {
-			if strings.HasPrefix(vi, "Digest ") {
-				return vi
-			}
+	var digestAuth *headers.Auth
+	for _, vi := range v {
+		if !strings.HasPrefix(vi, "Digest ") {
+			continue
 		}
-		return ""
-	}(); headerAuthDigest != "" {
-		auth, err := headers.ReadAuth(base.HeaderValue{headerAuthDigest})
+
+		auth, err := headers.ReadAuth(base.HeaderValue{vi})
 		if err != nil {
 			return nil, err
 		}
 
-		if auth.Realm == nil {
+		prio := digestPriority(auth)
+		if prio < 0 {
+			continue
+		}
+
+		if digestAuth == nil || prio > digestPriority(digestAuth) {
+			digestAuth = auth
+		}
+	}
+
+	if digestAuth != nil {
+		if digestAuth.Realm == nil {
 			return nil, fmt.Errorf("realm not provided")
 		}
 
-		if auth.Nonce == nil {
+		if digestAuth.Nonce == nil {
 			return nil, fmt.Errorf("nonce not provided")
 		}
 
+		qop := ""
+		if digestAuth.QOP != nil {
+			qop = "auth"
+		}
+
+		cnonceByts := make([]byte, 16)
+		_, err := rand.Read(cnonceByts)
+		if err != nil {
+			return nil, err
+		}
+
 		return &Sender{
-			user:   user,
-			pass:   pass,
-			method: headers.AuthDigest,
-			realm:  *auth.Realm,
-			nonce:  *auth.Nonce,
+			user:      user,
+			pass:      pass,
+			method:    headers.AuthDigest,
+			realm:     *digestAuth.Realm,
+			nonce:     *digestAuth.Nonce,
+			opaque:    digestAuth.Opaque,
+			algorithm: digestAuth.Algorithm,
+			qop:       qop,
+			cnonce:    hex.EncodeToString(cnonceByts),
 		}, nil
 	}
 
@@ -80,6 +145,42 @@ func NewSender(v base.HeaderValue, user string, pass string) (*Sender, error) {
 	return nil, fmt.Errorf("there are no authentication methods available")
 }
 
+// RefreshNonce replaces the nonce with the one of a Digest challenge that
+// marks the current nonce as stale, while keeping the other parameters.
+// It returns an error if the WWW-Authenticate header doesn't contain such challenge.
+func (se *Sender) RefreshNonce(v base.HeaderValue) error {
+	if se.method != headers.AuthDigest {
+		return fmt.Errorf("nonces are not used by the current method")
+	}
+
+	for _, vi := range v {
+		if !strings.HasPrefix(vi, "Digest ") {
+			continue
+		}
+
+		auth, err := headers.ReadAuth(base.HeaderValue{vi})
+		if err != nil {
+			return err
+		}
+
+		if auth.Stale == nil || !strings.EqualFold(*auth.Stale, "true") ||
+			auth.Realm == nil || *auth.Realm != se.realm {
+			continue
+		}
+
+		if auth.Nonce == nil {
+			return fmt.Errorf("nonce not provided")
+		}
+
+		se.nonce = *auth.Nonce
+		se.opaque = auth.Opaque
+		se.nc = 0
+		return nil
+	}
+
+	return fmt.Errorf("the nonce is not stale")
+}
+
 // GenerateHeader generates an Authorization Header that allows to authenticate a request with
 // the given method and url.
 func (se *Sender) GenerateHeader(method base.Method, ur *base.URL) base.HeaderValue {
@@ -92,17 +193,39 @@ func (se *Sender) GenerateHeader(method base.Method, ur *base.URL) base.HeaderVa
 		return base.HeaderValue{"Basic " + response}
 
 	case headers.AuthDigest:
-		response := md5Hex(md5Hex(se.user+":"+se.realm+":"+se.pass) + ":" +
-			se.nonce + ":" + md5Hex(string(method)+":"+urStr))
-
-		return headers.Auth{
-			Method:   headers.AuthDigest,
-			Username: &se.user,
-			Realm:    &se.realm,
-			Nonce:    &se.nonce,
-			URI:      &urStr,
-			Response: &response,
-		}.Write()
+		algorithm := ""
+		if se.algorithm != nil {
+			algorithm = *se.algorithm
+		}
+		hash, sess, _ := digestAlgorithm(algorithm)
+
+		se.nc++
+		nc := fmt.Sprintf("%08x", se.nc)
+
+		response := digestResponse(hash, sess, se.user, se.realm, se.pass, se.nonce,
+			se.qop, nc, se.cnonce, method, urStr)
+
+		h := headers.Auth{
+			Method:    headers.AuthDigest,
+			Username:  &se.user,
+			Realm:     &se.realm,
+			Nonce:     &se.nonce,
+			URI:       &urStr,
+			Response:  &response,
+			Opaque:    se.opaque,
+			Algorithm: se.algorithm,
+		}
+
+		if se.qop != "" || sess {
+			h.CNonce = &se.cnonce
+		}
+
+		if se.qop != "" {
+			h.QOP = &se.qop
+			h.NC = &nc
+		}
+
+		return h.Write()
 	}
 
 	return nil
@@ -5,6 +5,10 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/aler9/gortsplib/pkg/base"
 )
 
 func md5Hex(in string) string {
@@ -13,8 +17,54 @@ func md5Hex(in string) string {
 	return hex.EncodeToString(h.Sum(nil))
 }
 
+func sha256Hex(in string) string {
+	h := sha256.New()
+	h.Write([]byte(in))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 func sha256Base64(in string) string {
 	h := sha256.New()
 	h.Write([]byte(in))
 	return base64.StdEncoding.EncodeToString(h.Sum(nil))
 }
+
+// digestAlgorithm returns the hash function of a digest algorithm
+// and whether the algorithm is a session variant.
+// https://tools.ietf.org/html/rfc7616#section-3.3
+func digestAlgorithm(algorithm string) (func(string) string, bool, error) {
+	switch strings.ToUpper(algorithm) {
+	case "", "MD5":
+		return md5Hex, false, nil
+
+	case "MD5-SESS":
+		return md5Hex, true, nil
+
+	case "SHA-256":
+		return sha256Hex, false, nil
+
+	case "SHA-256-SESS":
+		return sha256Hex, true, nil
+	}
+
+	return nil, false, fmt.Errorf("unsupported algorithm (%v)", algorithm)
+}
+
+// digestResponse computes the response of the digest authentication.
+// qop, nc and cnonce are used only if qop is not empty or the algorithm is a session variant.
+func digestResponse(hash func(string) string, sess bool,
+	user string, realm string, pass string, nonce string,
+	qop string, nc string, cnonce string,
+	method base.Method, uri string) string {
+	ha1 := hash(user + ":" + realm + ":" + pass)
+	if sess {
+		ha1 = hash(ha1 + ":" + nonce + ":" + cnonce)
+	}
+
+	ha2 := hash(string(method) + ":" + uri)
+
+	if qop != "" {
+		return hash(ha1 + ":" + nonce + ":" + nc + ":" + cnonce + ":" + qop + ":" + ha2)
+	}
+	return hash(ha1 + ":" + nonce + ":" + ha2)
+}
@@ -4,8 +4,11 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/aler9/gortsplib/pkg/base"
 	"github.com/aler9/gortsplib/pkg/headers"
@@ -19,7 +22,18 @@ type Validator struct {
 	passHashed bool
 	methods    []headers.AuthMethod
 	realm      string
-	nonce      string
+	opaque     string
+
+	// validity of nonces. When a nonce expires, a new one is generated
+	// and clients that use the old one are asked to authenticate again.
+	// If zero, nonces never expire.
+	// It defaults to zero.
+	NonceValidity time.Duration
+
+	mutex        sync.Mutex
+	nonce        string
+	nonceCreated time.Time
+	prevNonce    string
 }
 
 // NewValidator allocates a Validator.
@@ -50,24 +64,47 @@ func NewValidator(user string, pass string, methods []headers.AuthMethod) *Valid
 		methods = []headers.AuthMethod{headers.AuthBasic}
 	}
 
-	nonceByts := make([]byte, 16)
-	rand.Read(nonceByts)
-	nonce := hex.EncodeToString(nonceByts)
-
 	return &Validator{
-		user:       user,
-		userHashed: userHashed,
-		pass:       pass,
-		passHashed: passHashed,
-		methods:    methods,
-		realm:      "IPCAM",
-		nonce:      nonce,
+		user:         user,
+		userHashed:   userHashed,
+		pass:         pass,
+		passHashed:   passHashed,
+		methods:      methods,
+		realm:        "IPCAM",
+		opaque:       randomHex(),
+		nonce:        randomHex(),
+		nonceCreated: time.Now(),
+	}
+}
+
+func randomHex() string {
+	byts := make([]byte, 16)
+	rand.Read(byts)
+	return hex.EncodeToString(byts)
+}
+
+// currentNonce returns the current nonce, generating a new one if it expired.
+// It must be called with the mutex locked.
+func (va *Validator) currentNonce() string {
+	if va.NonceValidity > 0 && time.Since(va.nonceCreated) >= va.NonceValidity {
+		va.prevNonce = va.nonce
+		va.nonce = randomHex()
+		va.nonceCreated = time.Now()
 	}
+	return va.nonce
 }
 
 // GenerateHeader generates the WWW-Authenticate header needed by a client to
 // authenticate.
 func (va *Validator) GenerateHeader() base.HeaderValue {
+	return va.generateHeader(false)
+}
+
+func (va *Validator) generateHeader(stale bool) base.HeaderValue {
+	va.mutex.Lock()
+	nonce := va.currentNonce()
+	va.mutex.Unlock()
+
 	var ret base.HeaderValue
 	for _, m := range va.methods {
 		switch m {
@@ -78,16 +115,42 @@ func (va *Validator) GenerateHeader() base.HeaderValue {
 			}).Write()...)
 
 		case headers.AuthDigest:
-			ret = append(ret, headers.Auth{
+			h := headers.Auth{
 				Method: headers.AuthDigest,
 				Realm:  &va.realm,
-				Nonce:  &va.nonce,
-			}.Write()...)
+				Nonce:  &nonce,
+				Opaque: &va.opaque,
+			}
+			if stale {
+				v := "true"
+				h.Stale = &v
+			}
+			ret = append(ret, h.Write()...)
 		}
 	}
 	return ret
 }
 
+// ValidateRequest validates the credentials of a request, and can be used
+// inside server handlers to protect requests.
+// If credentials are missing or invalid, it returns a 401 response that asks
+// the client to authenticate, and that can be returned by the handler.
+// Otherwise, it returns nil.
+func (va *Validator) ValidateRequest(req *base.Request) *base.Response {
+	err := va.ValidateHeader(req.Header["Authorization"], req.Method, req.URL)
+	if err != nil {
+		return &base.Response{
+			StatusCode: base.StatusUnauthorized,
+			Header: base.Header{
+				"WWW-Authenticate": va.generateHeader(err == errStaleNonce),
+			},
+		}
+	}
+	return nil
+}
+
+var errStaleNonce = errors.New("stale nonce")
+
 // ValidateHeader validates the Authorization header sent by a client after receiving the
 // WWW-Authenticate header.
 func (va *Validator) ValidateHeader(v base.HeaderValue, method base.Method, ur *base.URL) error {
@@ -159,8 +222,28 @@ func (va *Validator) ValidateHeader(v base.HeaderValue, method base.Method, ur *
 			return fmt.Errorf("response not provided")
 		}
 
-		if *auth.Nonce != va.nonce {
-			return fmt.Errorf("wrong nonce")
+		if auth.Opaque == nil {
+			return fmt.Errorf("opaque not provided")
+		}
+
+		va.mutex.Lock()
+		nonce := va.currentNonce()
+		prevNonce := va.prevNonce
+		va.mutex.Unlock()
+
+		// the previous nonce is reported as stale only if the rest
+		// of the credentials is valid
+		stale := false
+		if *auth.Nonce != nonce {
+			if prevNonce == "" || *auth.Nonce != prevNonce {
+				return fmt.Errorf("wrong nonce")
+			}
+			stale = true
+			nonce = prevNonce
+		}
+
+		if *auth.Opaque != va.opaque {
+			return fmt.Errorf("wrong opaque")
 		}
 
 		if *auth.Realm != va.realm {
@@ -184,13 +267,49 @@ func (va *Validator) ValidateHeader(v base.HeaderValue, method base.Method, ur *
 			}
 		}
 
-		response := md5Hex(md5Hex(va.user+":"+va.realm+":"+va.pass) +
-			":" + va.nonce + ":" + md5Hex(string(method)+":"+uri))
+		algorithm := ""
+		if auth.Algorithm != nil {
+			algorithm = *auth.Algorithm
+		}
+
+		hash, sess, err := digestAlgorithm(algorithm)
+		if err != nil {
+			return err
+		}
+
+		qop := ""
+		nc := ""
+		cnonce := ""
+
+		if auth.QOP != nil {
+			if *auth.QOP != "auth" {
+				return fmt.Errorf("unsupported qop (%v)", *auth.QOP)
+			}
+			if auth.NC == nil {
+				return fmt.Errorf("nc not provided")
+			}
+			qop = *auth.QOP
+			nc = *auth.NC
+		}
+
+		if auth.QOP != nil || sess {
+			if auth.CNonce == nil {
+				return fmt.Errorf("cnonce not provided")
+			}
+			cnonce = *auth.CNonce
+		}
+
+		response := digestResponse(hash, sess, va.user, va.realm, va.pass, nonce,
+			qop, nc, cnonce, method, uri)
 
 		if *auth.Response != response {
 			return fmt.Errorf("wrong response")
 		}
 
+		if stale {
+			return errStaleNonce
+		}
+
 	} else {
 		return fmt.Errorf("unsupported authorization header")
 	}
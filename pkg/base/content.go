@@ -2,14 +2,13 @@ package base
 
 import (
 	"bufio"
-	"fmt"
 	"io"
 	"strconv"
 )
 
 type payload []byte
 
-func (c *payload) read(rb *bufio.Reader, header Header) error {
+func (c *payload) read(rb *bufio.Reader, header Header, maxSize int) error {
 	cls, ok := header["Content-Length"]
 	if !ok || len(cls) != 1 {
 		*c = nil
@@ -18,11 +17,19 @@ func (c *payload) read(rb *bufio.Reader, header Header) error {
 
 	cl, err := strconv.ParseInt(cls[0], 10, 64)
 	if err != nil {
-		return fmt.Errorf("invalid Content-Length")
+		return ErrMalformed{"invalid Content-Length"}
 	}
 
-	if cl > rtspMaxContentLength {
-		return fmt.Errorf("Content-Length exceeds %d", rtspMaxContentLength)
+	if cl < 0 {
+		return ErrMalformed{"negative Content-Length"}
+	}
+
+	if maxSize == 0 {
+		maxSize = rtspMaxContentLength
+	}
+
+	if cl > int64(maxSize) {
+		return ErrLimitExceeded{"Content-Length", maxSize}
 	}
 
 	*c = make([]byte, cl)
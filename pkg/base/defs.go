@@ -9,6 +9,10 @@ const (
 
 	// StreamProtocolTCP means that the stream uses the TCP protocol
 	StreamProtocolTCP
+
+	// StreamProtocolUDPMulticast means that the stream uses the UDP protocol
+	// with multicast delivery
+	StreamProtocolUDPMulticast
 )
 
 // String implements fmt.Stringer.
@@ -19,6 +23,9 @@ func (sp StreamProtocol) String() string {
 
 	case StreamProtocolTCP:
 		return "tcp"
+
+	case StreamProtocolUDPMulticast:
+		return "udp-multicast"
 	}
 	return "unknown"
 }
@@ -0,0 +1,32 @@
+package base
+
+import (
+	"fmt"
+)
+
+// ErrLimitExceeded is returned by readers when an element of a message
+// exceeds its maximum size or count. The limit is checked before any memory
+// is allocated for the element.
+type ErrLimitExceeded struct {
+	// name of the element
+	Element string
+
+	// limit that has been exceeded
+	Limit int
+}
+
+// Error implements the error interface.
+func (e ErrLimitExceeded) Error() string {
+	return fmt.Sprintf("%s exceeds %d", e.Element, e.Limit)
+}
+
+// ErrMalformed is returned by readers when a message can't be parsed.
+type ErrMalformed struct {
+	// reason why the message can't be parsed
+	Reason string
+}
+
+// Error implements the error interface.
+func (e ErrMalformed) Error() string {
+	return e.Reason
+}
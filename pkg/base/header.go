@@ -2,7 +2,6 @@ package base
 
 import (
 	"bufio"
-	"fmt"
 	"net/http"
 	"sort"
 	"strings"
@@ -34,8 +33,14 @@ type HeaderValue []string
 // Header is a RTSP reader, present in both Requests and Responses.
 type Header map[string]HeaderValue
 
-func (h *Header) read(rb *bufio.Reader) error {
+func (h *Header) read(rb *bufio.Reader, maxSize int, maxCount int) error {
 	*h = make(Header)
+	size := 0
+	count := 0
+
+	if maxCount == 0 {
+		maxCount = headerMaxEntryCount
+	}
 
 	for {
 		byt, err := rb.ReadByte()
@@ -52,12 +57,15 @@ func (h *Header) read(rb *bufio.Reader) error {
 			break
 		}
 
-		if len(*h) >= headerMaxEntryCount {
-			return fmt.Errorf("headers count exceeds %d", headerMaxEntryCount)
+		// entries with the same key are counted separately, since they are
+		// stored separately
+		count++
+		if count > maxCount {
+			return ErrLimitExceeded{"header count", maxCount}
 		}
 
 		key := string([]byte{byt})
-		byts, err := readBytesLimited(rb, ':', headerMaxKeyLength-1)
+		byts, err := readBytesLimited(rb, ':', headerMaxKeyLength-1, "header key length")
 		if err != nil {
 			return err
 		}
@@ -78,14 +86,14 @@ func (h *Header) read(rb *bufio.Reader) error {
 		}
 		rb.UnreadByte()
 
-		byts, err = readBytesLimited(rb, '\r', headerMaxValueLength)
+		byts, err = readBytesLimited(rb, '\r', headerMaxValueLength, "header value length")
 		if err != nil {
 			return err
 		}
 		val := string(byts[:len(byts)-1])
 
 		if len(val) == 0 {
-			return fmt.Errorf("empty header value")
+			return ErrMalformed{"empty header value"}
 		}
 
 		err = readByteEqual(rb, '\n')
@@ -93,6 +101,12 @@ func (h *Header) read(rb *bufio.Reader) error {
 			return err
 		}
 
+		// key, ": " and "\r\n"
+		size += len(key) + len(val) + 4
+		if maxSize != 0 && size > maxSize {
+			return ErrLimitExceeded{"header size", maxSize}
+		}
+
 		(*h)[key] = append((*h)[key], val)
 	}
 
@@ -94,7 +94,7 @@ func TestHeaderRead(t *testing.T) {
 	for _, c := range casesHeader {
 		t.Run(c.name, func(t *testing.T) {
 			h := make(Header)
-			err := h.read(bufio.NewReader(bytes.NewBuffer(c.dec)))
+			err := h.read(bufio.NewReader(bytes.NewBuffer(c.dec)), 0, 0)
 			require.NoError(t, err)
 			require.Equal(t, c.header, h)
 		})
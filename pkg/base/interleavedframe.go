@@ -11,8 +11,15 @@ const (
 	interleavedFrameMagicByte = 0x24
 )
 
-// ReadInterleavedFrameOrRequest reads an InterleavedFrame or a Response.
+// ReadInterleavedFrameOrRequest reads an InterleavedFrame or a Request.
 func ReadInterleavedFrameOrRequest(frame *InterleavedFrame, req *Request, br *bufio.Reader) (interface{}, error) {
+	return ReadInterleavedFrameOrRequestWithLimits(frame, req, br, ReadLimits{})
+}
+
+// ReadInterleavedFrameOrRequestWithLimits reads an InterleavedFrame or a Request,
+// by applying the given limits.
+func ReadInterleavedFrameOrRequestWithLimits(frame *InterleavedFrame, req *Request,
+	br *bufio.Reader, limits ReadLimits) (interface{}, error) {
 	b, err := br.ReadByte()
 	if err != nil {
 		return nil, err
@@ -27,7 +34,7 @@ func ReadInterleavedFrameOrRequest(frame *InterleavedFrame, req *Request, br *bu
 		return frame, err
 	}
 
-	err = req.Read(br)
+	err = req.ReadWithLimits(br, limits)
 	if err != nil {
 		return nil, err
 	}
@@ -36,6 +43,11 @@ func ReadInterleavedFrameOrRequest(frame *InterleavedFrame, req *Request, br *bu
 
 // ReadInterleavedFrameOrResponse reads an InterleavedFrame or a Response.
 func ReadInterleavedFrameOrResponse(frame *InterleavedFrame, res *Response, br *bufio.Reader) (interface{}, error) {
+	return readInterleavedFrameOrResponse(frame, res, br, ReadLimits{})
+}
+
+func readInterleavedFrameOrResponse(frame *InterleavedFrame, res *Response,
+	br *bufio.Reader, limits ReadLimits) (interface{}, error) {
 	b, err := br.ReadByte()
 	if err != nil {
 		return nil, err
@@ -50,20 +62,81 @@ func ReadInterleavedFrameOrResponse(frame *InterleavedFrame, res *Response, br *
 		return frame, err
 	}
 
-	err = res.Read(br)
+	err = res.ReadWithLimits(br, limits)
 	if err != nil {
 		return nil, err
 	}
 	return res, nil
 }
 
+// ReadRequestOrResponse reads a Request or a Response.
+func ReadRequestOrResponse(req *Request, res *Response, br *bufio.Reader) (interface{}, error) {
+	return ReadRequestOrResponseWithLimits(req, res, br, ReadLimits{})
+}
+
+// ReadRequestOrResponseWithLimits reads a Request or a Response,
+// by applying the given limits.
+func ReadRequestOrResponseWithLimits(req *Request, res *Response,
+	br *bufio.Reader, limits ReadLimits) (interface{}, error) {
+	byts, err := br.Peek(len(rtspProtocol10))
+	if err != nil {
+		return nil, err
+	}
+
+	if string(byts) == rtspProtocol10 {
+		err := res.ReadWithLimits(br, limits)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+
+	err = req.ReadWithLimits(br, limits)
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// ReadInterleavedFrameOrRequestOrResponse reads an InterleavedFrame, a Request or a Response.
+func ReadInterleavedFrameOrRequestOrResponse(frame *InterleavedFrame, req *Request,
+	res *Response, br *bufio.Reader) (interface{}, error) {
+	return ReadInterleavedFrameOrRequestOrResponseWithLimits(frame, req, res, br, ReadLimits{})
+}
+
+// ReadInterleavedFrameOrRequestOrResponseWithLimits reads an InterleavedFrame,
+// a Request or a Response, by applying the given limits.
+func ReadInterleavedFrameOrRequestOrResponseWithLimits(frame *InterleavedFrame, req *Request,
+	res *Response, br *bufio.Reader, limits ReadLimits) (interface{}, error) {
+	b, err := br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	br.UnreadByte()
+
+	if b == interleavedFrameMagicByte {
+		err := frame.Read(br)
+		if err != nil {
+			return nil, err
+		}
+		return frame, err
+	}
+
+	return ReadRequestOrResponseWithLimits(req, res, br, limits)
+}
+
 // InterleavedFrame is an interleaved frame, and allows to transfer binary data
 // within RTSP/TCP connections. It is used to send and receive RTP and RTCP packets with TCP.
 type InterleavedFrame struct {
-	// track id
+	// interleaved channel
+	Channel int
+
+	// track id, filled by Read() by assuming that the channels of a track
+	// are 2*TrackID (RTP) and 2*TrackID+1 (RTCP). It is not used by Write().
 	TrackID int
 
-	// stream type
+	// stream type, filled by Read() with the same assumption of TrackID.
+	// It is not used by Write().
 	StreamType StreamType
 
 	// frame payload
@@ -72,29 +145,32 @@ type InterleavedFrame struct {
 
 // Read reads an interleaved frame.
 func (f *InterleavedFrame) Read(br *bufio.Reader) error {
-	var header [4]byte
-	_, err := io.ReadFull(br, header[:])
+	// use Peek() and Discard() instead of reading into a local array,
+	// in order to avoid an allocation for every frame
+	header, err := br.Peek(4)
 	if err != nil {
 		return err
 	}
 
 	if header[0] != interleavedFrameMagicByte {
-		return fmt.Errorf("wrong magic byte (0x%.2x)", header[0])
+		return ErrMalformed{fmt.Sprintf("wrong magic byte (0x%.2x)", header[0])}
 	}
 
 	framelen := int(binary.BigEndian.Uint16(header[2:]))
+	// the maximum payload size is the size of the provided buffer
 	if framelen > len(f.Payload) {
-		return fmt.Errorf("frame length greater than maximum allowed (%d vs %d)",
-			framelen, len(f.Payload))
+		return ErrLimitExceeded{"frame length", len(f.Payload)}
 	}
 
+	f.Channel = int(header[1])
+	br.Discard(4)
+
 	// convert channel into TrackID and StreamType
-	channel := header[1]
 	f.TrackID, f.StreamType = func() (int, StreamType) {
-		if (channel % 2) == 0 {
-			return int(channel / 2), StreamTypeRTP
+		if (f.Channel % 2) == 0 {
+			return f.Channel / 2, StreamTypeRTP
 		}
-		return int((channel - 1) / 2), StreamTypeRTCP
+		return (f.Channel - 1) / 2, StreamTypeRTCP
 	}()
 
 	f.Payload = f.Payload[:framelen]
@@ -106,31 +182,37 @@ func (f *InterleavedFrame) Read(br *bufio.Reader) error {
 	return nil
 }
 
-// Write writes an InterleavedFrame into a buffered writer.
-func (f InterleavedFrame) Write(bw *bufio.Writer) error {
-	// convert TrackID and StreamType into channel
-	channel := func() uint8 {
-		if f.StreamType == StreamTypeRTP {
-			return uint8(f.TrackID * 2)
-		}
-		return uint8((f.TrackID * 2) + 1)
-	}()
-
-	_, err := bw.Write([]byte{0x24, channel})
+func (f InterleavedFrame) writeNoFlush(bw *bufio.Writer) error {
+	header := [4]byte{0x24, uint8(f.Channel)}
+	binary.BigEndian.PutUint16(header[2:], uint16(len(f.Payload)))
+	_, err := bw.Write(header[:])
 	if err != nil {
 		return err
 	}
 
-	buf := make([]byte, 2)
-	binary.BigEndian.PutUint16(buf, uint16(len(f.Payload)))
-	_, err = bw.Write(buf)
+	_, err = bw.Write(f.Payload)
+	return err
+}
+
+// Write writes an InterleavedFrame into a buffered writer.
+func (f InterleavedFrame) Write(bw *bufio.Writer) error {
+	err := f.writeNoFlush(bw)
 	if err != nil {
 		return err
 	}
 
-	_, err = bw.Write(f.Payload)
-	if err != nil {
-		return err
+	return bw.Flush()
+}
+
+// WriteInterleavedFrames writes multiple InterleavedFrames into a buffered writer.
+// The writer is flushed only once, at the end, in order to group frames
+// into as few write operations as possible.
+func WriteInterleavedFrames(frames []InterleavedFrame, bw *bufio.Writer) error {
+	for _, f := range frames {
+		err := f.writeNoFlush(bw)
+		if err != nil {
+			return err
+		}
 	}
 
 	return bw.Flush()
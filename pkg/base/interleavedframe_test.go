@@ -0,0 +1,122 @@
+package base
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterleavedFrameReadWrite(t *testing.T) {
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+
+	err := WriteInterleavedFrames([]InterleavedFrame{
+		{
+			Channel: 0,
+			Payload: []byte{0x01, 0x02, 0x03},
+		},
+		{
+			Channel: 3,
+			Payload: []byte{0x04, 0x05},
+		},
+	}, bw)
+	require.NoError(t, err)
+	require.Equal(t, []byte{
+		0x24, 0x00, 0x00, 0x03, 0x01, 0x02, 0x03,
+		0x24, 0x03, 0x00, 0x02, 0x04, 0x05,
+	}, buf.Bytes())
+
+	br := bufio.NewReader(&buf)
+
+	f := InterleavedFrame{Payload: make([]byte, 1024)}
+	err = f.Read(br)
+	require.NoError(t, err)
+	require.Equal(t, InterleavedFrame{
+		Channel:    0,
+		TrackID:    0,
+		StreamType: StreamTypeRTP,
+		Payload:    []byte{0x01, 0x02, 0x03},
+	}, f)
+
+	f = InterleavedFrame{Payload: make([]byte, 1024)}
+	err = f.Read(br)
+	require.NoError(t, err)
+	require.Equal(t, InterleavedFrame{
+		Channel:    3,
+		TrackID:    1,
+		StreamType: StreamTypeRTCP,
+		Payload:    []byte{0x04, 0x05},
+	}, f)
+}
+
+func TestInterleavedFrameReadAllocs(t *testing.T) {
+	byts := bytes.Repeat([]byte{0x24, 0x00, 0x00, 0x03, 0x01, 0x02, 0x03}, 200)
+	br := bufio.NewReader(bytes.NewReader(byts))
+	f := InterleavedFrame{}
+	buf := make([]byte, 1024)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		f.Payload = buf
+		f.Read(br)
+	})
+	require.Equal(t, float64(0), allocs)
+}
+
+func TestInterleavedFrameReadErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		byts []byte
+		err  error
+	}{
+		{
+			"wrong magic byte",
+			[]byte{0x25, 0x00, 0x00, 0x03, 0x01, 0x02, 0x03},
+			ErrMalformed{"wrong magic byte (0x25)"},
+		},
+		{
+			"payload too big",
+			[]byte{0x24, 0x00, 0xFF, 0xFF, 0x01, 0x02, 0x03},
+			ErrLimitExceeded{"frame length", 1024},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			f := InterleavedFrame{Payload: make([]byte, 1024)}
+			err := f.Read(bufio.NewReader(bytes.NewReader(ca.byts)))
+			require.Equal(t, ca.err, err)
+		})
+	}
+}
+
+func TestReadInterleavedFrameOrRequestOrResponse(t *testing.T) {
+	byts := []byte{0x24, 0x00, 0x00, 0x03, 0x01, 0x02, 0x03}
+	byts = append(byts, []byte("REDIRECT rtsp://example.com/media.mp4 RTSP/1.0\r\n"+
+		"CSeq: 1\r\n"+
+		"Location: rtsp://example2.com/media.mp4\r\n"+
+		"\r\n")...)
+	byts = append(byts, []byte("RTSP/1.0 200 OK\r\n"+
+		"CSeq: 2\r\n"+
+		"\r\n")...)
+	br := bufio.NewReader(bytes.NewReader(byts))
+
+	f := InterleavedFrame{Payload: make([]byte, 1024)}
+	var req Request
+	var res Response
+
+	what, err := ReadInterleavedFrameOrRequestOrResponse(&f, &req, &res, br)
+	require.NoError(t, err)
+	require.Equal(t, &f, what)
+	require.Equal(t, []byte{0x01, 0x02, 0x03}, f.Payload)
+
+	what, err = ReadInterleavedFrameOrRequestOrResponse(&f, &req, &res, br)
+	require.NoError(t, err)
+	require.Equal(t, &req, what)
+	require.Equal(t, Redirect, req.Method)
+	require.Equal(t, HeaderValue{"rtsp://example2.com/media.mp4"}, req.Header["Location"])
+
+	what, err = ReadInterleavedFrameOrRequestOrResponse(&f, &req, &res, br)
+	require.NoError(t, err)
+	require.Equal(t, &res, what)
+	require.Equal(t, StatusOK, res.StatusCode)
+}
@@ -10,7 +10,7 @@ import (
 
 const (
 	rtspProtocol10           = "RTSP/1.0"
-	requestMaxLethodLength   = 128
+	requestMaxMethodLength   = 128
 	requestMaxPathLength     = 1024
 	requestMaxProtocolLength = 128
 )
@@ -27,6 +27,7 @@ const (
 	Pause        Method = "PAUSE"
 	Play         Method = "PLAY"
 	Record       Method = "RECORD"
+	Redirect     Method = "REDIRECT"
 	Setup        Method = "SETUP"
 	SetParameter Method = "SET_PARAMETER"
 	Teardown     Method = "TEARDOWN"
@@ -53,40 +54,50 @@ type Request struct {
 
 // Read reads a request.
 func (req *Request) Read(rb *bufio.Reader) error {
-	byts, err := readBytesLimited(rb, ' ', requestMaxLethodLength)
+	return req.ReadWithLimits(rb, ReadLimits{})
+}
+
+// ReadWithLimits reads a request, by applying the given limits.
+func (req *Request) ReadWithLimits(rb *bufio.Reader, limits ReadLimits) error {
+	maxMethodLength := limits.MaxMethodLength
+	if maxMethodLength == 0 {
+		maxMethodLength = requestMaxMethodLength
+	}
+
+	byts, err := readBytesLimited(rb, ' ', maxMethodLength, "method length")
 	if err != nil {
 		return err
 	}
 	req.Method = Method(byts[:len(byts)-1])
 
 	if req.Method == "" {
-		return fmt.Errorf("empty method")
+		return ErrMalformed{"empty method"}
 	}
 
-	byts, err = readBytesLimited(rb, ' ', requestMaxPathLength)
+	byts, err = readBytesLimited(rb, ' ', requestMaxPathLength, "url length")
 	if err != nil {
 		return err
 	}
 	rawURL := string(byts[:len(byts)-1])
 
 	if rawURL == "" {
-		return fmt.Errorf("empty url")
+		return ErrMalformed{"empty url"}
 	}
 
 	ur, err := ParseURL(rawURL)
 	if err != nil {
-		return fmt.Errorf("unable to parse url (%v)", rawURL)
+		return ErrMalformed{fmt.Sprintf("unable to parse url (%v)", rawURL)}
 	}
 	req.URL = ur
 
-	byts, err = readBytesLimited(rb, '\r', requestMaxProtocolLength)
+	byts, err = readBytesLimited(rb, '\r', requestMaxProtocolLength, "protocol length")
 	if err != nil {
 		return err
 	}
 	proto := string(byts[:len(byts)-1])
 
 	if proto != rtspProtocol10 {
-		return fmt.Errorf("expected '%s', got '%s'", rtspProtocol10, proto)
+		return ErrMalformed{fmt.Sprintf("expected '%s', got '%s'", rtspProtocol10, proto)}
 	}
 
 	err = readByteEqual(rb, '\n')
@@ -94,12 +105,12 @@ func (req *Request) Read(rb *bufio.Reader) error {
 		return err
 	}
 
-	err = req.Header.read(rb)
+	err = req.Header.read(rb, limits.MaxHeaderSize, limits.MaxHeaderCount)
 	if err != nil {
 		return err
 	}
 
-	err = (*payload)(&req.Body).read(rb, req.Header)
+	err = (*payload)(&req.Body).read(rb, req.Header, limits.MaxBodySize)
 	if err != nil {
 		return err
 	}
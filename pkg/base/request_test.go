@@ -153,3 +153,123 @@ func TestRequestWrite(t *testing.T) {
 		})
 	}
 }
+
+func TestRequestReadLimits(t *testing.T) {
+	byts := []byte("ANNOUNCE rtsp://example.com/media.mp4 RTSP/1.0\r\n" +
+		"CSeq: 7\r\n" +
+		"Content-Length: 10\r\n" +
+		"\r\n" +
+		"0123456789")
+
+	for _, ca := range []struct {
+		name            string
+		maxMethodLength int
+		maxHeaderSize   int
+		maxHeaderCount  int
+		maxBodySize     int
+		err             error
+	}{
+		{
+			"no limits",
+			0,
+			0,
+			0,
+			0,
+			nil,
+		},
+		{
+			"method too long",
+			7,
+			0,
+			0,
+			0,
+			ErrLimitExceeded{"method length", 7},
+		},
+		{
+			"header too big",
+			0,
+			20,
+			0,
+			0,
+			ErrLimitExceeded{"header size", 20},
+		},
+		{
+			"too many header entries",
+			0,
+			0,
+			1,
+			0,
+			ErrLimitExceeded{"header count", 1},
+		},
+		{
+			"body too big",
+			0,
+			0,
+			0,
+			5,
+			ErrLimitExceeded{"Content-Length", 5},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var req Request
+			err := req.ReadWithLimits(bufio.NewReader(bytes.NewBuffer(byts)), ReadLimits{
+				MaxMethodLength: ca.maxMethodLength,
+				MaxHeaderSize:   ca.maxHeaderSize,
+				MaxHeaderCount:  ca.maxHeaderCount,
+				MaxBodySize:     ca.maxBodySize,
+			})
+			if ca.err == nil {
+				require.NoError(t, err)
+				require.Equal(t, []byte("0123456789"), req.Body)
+			} else {
+				require.Equal(t, ca.err, err)
+			}
+		})
+	}
+}
+
+func TestRequestReadErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		byts []byte
+		err  error
+	}{
+		{
+			"empty method",
+			[]byte(" rtsp://example.com/media.mp4 RTSP/1.0\r\n\r\n"),
+			ErrMalformed{"empty method"},
+		},
+		{
+			"invalid protocol",
+			[]byte("OPTIONS rtsp://example.com/media.mp4 RTSP/2.0\r\n\r\n"),
+			ErrMalformed{"expected 'RTSP/1.0', got 'RTSP/2.0'"},
+		},
+		{
+			"negative Content-Length",
+			[]byte("ANNOUNCE rtsp://example.com/media.mp4 RTSP/1.0\r\n" +
+				"Content-Length: -10\r\n" +
+				"\r\n"),
+			ErrMalformed{"negative Content-Length"},
+		},
+		{
+			"repeated header entries",
+			append([]byte("OPTIONS rtsp://example.com/media.mp4 RTSP/1.0\r\n"),
+				bytes.Repeat([]byte("Key: value\r\n"), 300)...),
+			ErrLimitExceeded{"header count", 255},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var req Request
+			err := req.Read(bufio.NewReader(bytes.NewBuffer(ca.byts)))
+			require.Equal(t, ca.err, err)
+		})
+	}
+}
+
+func TestRequestReadBufferFull(t *testing.T) {
+	// elements can't be bigger than the read buffer, regardless of the limits
+	var req Request
+	err := req.ReadWithLimits(bufio.NewReaderSize(bytes.NewBuffer(bytes.Repeat([]byte("A"), 8192)), 16),
+		ReadLimits{MaxMethodLength: 8192})
+	require.Equal(t, ErrLimitExceeded{"method length", 16}, err)
+}
@@ -135,17 +135,23 @@ type Response struct {
 
 // Read reads a response.
 func (res *Response) Read(rb *bufio.Reader) error {
-	byts, err := readBytesLimited(rb, ' ', 255)
+	return res.ReadWithLimits(rb, ReadLimits{})
+}
+
+// ReadWithLimits reads a response, by applying the given limits.
+// MaxMethodLength is not used.
+func (res *Response) ReadWithLimits(rb *bufio.Reader, limits ReadLimits) error {
+	byts, err := readBytesLimited(rb, ' ', 255, "protocol length")
 	if err != nil {
 		return err
 	}
 	proto := string(byts[:len(byts)-1])
 
 	if proto != rtspProtocol10 {
-		return fmt.Errorf("expected '%s', got '%s'", rtspProtocol10, proto)
+		return ErrMalformed{fmt.Sprintf("expected '%s', got '%s'", rtspProtocol10, proto)}
 	}
 
-	byts, err = readBytesLimited(rb, ' ', 4)
+	byts, err = readBytesLimited(rb, ' ', 3, "status code length")
 	if err != nil {
 		return err
 	}
@@ -153,18 +159,18 @@ func (res *Response) Read(rb *bufio.Reader) error {
 
 	statusCode64, err := strconv.ParseInt(statusCodeStr, 10, 32)
 	if err != nil {
-		return fmt.Errorf("unable to parse status code")
+		return ErrMalformed{"unable to parse status code"}
 	}
 	res.StatusCode = StatusCode(statusCode64)
 
-	byts, err = readBytesLimited(rb, '\r', 255)
+	byts, err = readBytesLimited(rb, '\r', 255, "status message length")
 	if err != nil {
 		return err
 	}
 	res.StatusMessage = string(byts[:len(byts)-1])
 
 	if len(res.StatusMessage) == 0 {
-		return fmt.Errorf("empty status")
+		return ErrMalformed{"empty status"}
 	}
 
 	err = readByteEqual(rb, '\n')
@@ -172,12 +178,12 @@ func (res *Response) Read(rb *bufio.Reader) error {
 		return err
 	}
 
-	err = res.Header.read(rb)
+	err = res.Header.read(rb, limits.MaxHeaderSize, limits.MaxHeaderCount)
 	if err != nil {
 		return err
 	}
 
-	err = (*payload)(&res.Body).read(rb, res.Header)
+	err = (*payload)(&res.Body).read(rb, res.Header, limits.MaxBodySize)
 	if err != nil {
 		return err
 	}
@@ -188,6 +194,12 @@ func (res *Response) Read(rb *bufio.Reader) error {
 // ReadIgnoreFrames reads a response and ignores any interleaved frame sent
 // before the response.
 func (res *Response) ReadIgnoreFrames(rb *bufio.Reader, buf []byte) error {
+	return res.ReadIgnoreFramesWithLimits(rb, buf, ReadLimits{})
+}
+
+// ReadIgnoreFramesWithLimits reads a response, by applying the given limits,
+// and ignores any interleaved frame sent before the response.
+func (res *Response) ReadIgnoreFramesWithLimits(rb *bufio.Reader, buf []byte, limits ReadLimits) error {
 	buflen := len(buf)
 	f := InterleavedFrame{
 		Payload: buf,
@@ -195,7 +207,7 @@ func (res *Response) ReadIgnoreFrames(rb *bufio.Reader, buf []byte) error {
 
 	for {
 		f.Payload = f.Payload[:buflen]
-		recv, err := ReadInterleavedFrameOrResponse(&f, res, rb)
+		recv, err := readInterleavedFrameOrResponse(&f, res, rb, limits)
 		if err != nil {
 			return err
 		}
@@ -160,3 +160,63 @@ func TestResponseWriteStatusAutofill(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, byts, buf.Bytes())
 }
+
+func TestResponseReadLimits(t *testing.T) {
+	byts := []byte("RTSP/1.0 200 OK\r\n" +
+		"CSeq: 2\r\n" +
+		"Content-Length: 10\r\n" +
+		"\r\n" +
+		"0123456789")
+
+	for _, ca := range []struct {
+		name           string
+		maxHeaderSize  int
+		maxHeaderCount int
+		maxBodySize    int
+		err            error
+	}{
+		{
+			"no limits",
+			0,
+			0,
+			0,
+			nil,
+		},
+		{
+			"header too big",
+			20,
+			0,
+			0,
+			ErrLimitExceeded{"header size", 20},
+		},
+		{
+			"too many header entries",
+			0,
+			1,
+			0,
+			ErrLimitExceeded{"header count", 1},
+		},
+		{
+			"body too big",
+			0,
+			0,
+			5,
+			ErrLimitExceeded{"Content-Length", 5},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var res Response
+			err := res.ReadWithLimits(bufio.NewReader(bytes.NewBuffer(byts)), ReadLimits{
+				MaxHeaderSize:  ca.maxHeaderSize,
+				MaxHeaderCount: ca.maxHeaderCount,
+				MaxBodySize:    ca.maxBodySize,
+			})
+			if ca.err == nil {
+				require.NoError(t, err)
+				require.Equal(t, []byte("0123456789"), res.Body)
+			} else {
+				require.Equal(t, ca.err, err)
+			}
+		})
+	}
+}
@@ -20,14 +20,125 @@ func stringsReverseIndexByte(s string, c byte) int {
 // control attributes.
 type URL url.URL
 
+// parseUserinfo parses credentials that may contain reserved characters
+// that are not percent-encoded.
+func parseUserinfo(s string) *url.Userinfo {
+	unescape := func(v string) string {
+		uv, err := url.PathUnescape(v)
+		if err != nil {
+			return v
+		}
+		return uv
+	}
+
+	i := strings.IndexByte(s, ':')
+	if i < 0 {
+		return url.User(unescape(s))
+	}
+	return url.UserPassword(unescape(s[:i]), unescape(s[i+1:]))
+}
+
+// escapeZone percent-encodes the zone identifier of an IPv6 host,
+// like in [fe80::1%eth0], that is required to be encoded by url.Parse().
+func escapeZone(s string) string {
+	if !strings.HasPrefix(s, "[") {
+		return s
+	}
+
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return s
+	}
+
+	i := strings.IndexByte(s[:end], '%')
+	if i < 0 || strings.HasPrefix(s[i:end], "%25") {
+		return s
+	}
+
+	return s[:i] + "%25" + s[i+1:]
+}
+
+// parseURLLenient parses URLs that are refused by url.Parse() since
+// credentials or IPv6 zone identifiers are not percent-encoded.
+func parseURLLenient(s string) (*url.URL, error) {
+	i := strings.Index(s, "://")
+	if i < 0 {
+		return nil, fmt.Errorf("invalid URL")
+	}
+	scheme, rest := s[:i], s[i+3:]
+
+	// credentials and host are separated by a '@', that can also be part
+	// of the credentials. Try every '@', starting from the last one.
+	seps := []int{-1}
+	for j := 0; j < len(rest); j++ {
+		if rest[j] == '@' {
+			seps = append(seps, j)
+		}
+	}
+
+	for j := len(seps) - 1; j >= 0; j-- {
+		var user *url.Userinfo
+		hostAndPath := rest
+		if seps[j] >= 0 {
+			user = parseUserinfo(rest[:seps[j]])
+			hostAndPath = rest[seps[j]+1:]
+		}
+
+		u, err := url.Parse(scheme + "://" + escapeZone(hostAndPath))
+		if err != nil || u.Host == "" {
+			continue
+		}
+
+		u.User = user
+		return u, nil
+	}
+
+	return nil, fmt.Errorf("invalid URL")
+}
+
+// hasAtAfterAuthority checks whether a URL without credentials contains
+// a '@' after the authority, that is, in the path, in the query or in the fragment.
+func hasAtAfterAuthority(s string, u *url.URL) bool {
+	if u.User != nil {
+		return false
+	}
+
+	i := strings.Index(s, "://")
+	if i < 0 {
+		return false
+	}
+	rest := s[i+3:]
+
+	end := strings.IndexAny(rest, "/?#")
+	if end < 0 {
+		return false
+	}
+
+	return strings.IndexByte(rest[end:], '@') >= 0
+}
+
 // ParseURL parses a RTSP URL.
+// Credentials can contain reserved characters (@ : / ? # %) even when they
+// are not percent-encoded, and the same holds for IPv6 zone identifiers,
+// like in rtsp://user:pa/ss@[fe80::1%eth0]:8554/path.
+// Therefore a '@' is considered the separator between credentials and host,
+// and must be percent-encoded when it is part of the path or of the query.
 func ParseURL(s string) (*URL, error) {
 	u, err := url.Parse(s)
-	if err != nil {
-		return nil, err
+
+	// url.Parse() interprets credentials that contain a '/', a '?' or a '#'
+	// as host, and the remaining part as path, query or fragment.
+	if err != nil || hasAtAfterAuthority(s, u) {
+		u2, err2 := parseURLLenient(s)
+		if err2 == nil {
+			u = u2
+		} else if err != nil {
+			return nil, err
+		}
 	}
 
-	if u.Scheme != "rtsp" && u.Scheme != "rtsps" {
+	// rtspt is RTSP tunneled over HTTP
+	if u.Scheme != "rtsp" && u.Scheme != "rtsps" && u.Scheme != "rtspt" {
 		return nil, fmt.Errorf("unsupported scheme '%s'", u.Scheme)
 	}
 
@@ -75,6 +186,22 @@ func (u *URL) CloneWithoutCredentials() *URL {
 	})
 }
 
+// Redacted returns the URL as a string, with the password replaced
+// by "xxxxx", in order to be safely logged.
+func (u *URL) Redacted() string {
+	if u.User == nil {
+		return u.String()
+	}
+
+	if _, ok := u.User.Password(); !ok {
+		return u.String()
+	}
+
+	ru := u.Clone()
+	ru.User = url.UserPassword(u.User.Username(), "xxxxx")
+	return ru.String()
+}
+
 // BasePath returns the base path of a RTSP URL.
 // We assume that the URL doesn't contain a control attribute.
 func (u *URL) BasePath() (string, bool) {
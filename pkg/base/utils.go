@@ -9,6 +9,25 @@ const (
 	rtspMaxContentLength = 128 * 1024
 )
 
+// ReadLimits contains the limits that are applied when reading requests and responses.
+type ReadLimits struct {
+	// maximum length of the method of requests
+	// if zero, it defaults to 128
+	MaxMethodLength int
+
+	// maximum size of the header, without the first line
+	// if zero, only the size of single entries is limited
+	MaxHeaderSize int
+
+	// maximum number of header entries
+	// if zero, it defaults to 255
+	MaxHeaderCount int
+
+	// maximum size of the body
+	// if zero, it defaults to 128KB
+	MaxBodySize int
+}
+
 func readByteEqual(rb *bufio.Reader, cmp byte) error {
 	byt, err := rb.ReadByte()
 	if err != nil {
@@ -16,16 +35,23 @@ func readByteEqual(rb *bufio.Reader, cmp byte) error {
 	}
 
 	if byt != cmp {
-		return fmt.Errorf("expected '%c', got '%c'", cmp, byt)
+		return ErrMalformed{fmt.Sprintf("expected '%c', got '%c'", cmp, byt)}
 	}
 
 	return nil
 }
 
-func readBytesLimited(rb *bufio.Reader, delim byte, n int) ([]byte, error) {
-	for i := 1; i <= n; i++ {
+// readBytesLimited reads an element followed by a delimiter.
+// n is the maximum length of the element, without the delimiter.
+// element is the name of the element, and is used in errors.
+func readBytesLimited(rb *bufio.Reader, delim byte, n int, element string) ([]byte, error) {
+	for i := 1; i <= (n + 1); i++ {
 		byts, err := rb.Peek(i)
 		if err != nil {
+			// the element is bigger than the read buffer
+			if err == bufio.ErrBufferFull {
+				return nil, ErrLimitExceeded{element, i - 1}
+			}
 			return nil, err
 		}
 
@@ -34,5 +60,5 @@ func readBytesLimited(rb *bufio.Reader, delim byte, n int) ([]byte, error) {
 			return byts, nil
 		}
 	}
-	return nil, fmt.Errorf("buffer length exceeds %d", n)
+	return nil, ErrLimitExceeded{element, n}
 }
@@ -0,0 +1,82 @@
+package h264
+
+import (
+	"fmt"
+)
+
+// AnnexBUnmarshal splits a byte stream in the Annex-B format into NALUs.
+// NALUs are separated by start codes (0x00 0x00 0x01 or 0x00 0x00 0x00 0x01),
+// and the stream must begin with a start code.
+func AnnexBUnmarshal(byts []byte) ([][]byte, error) {
+	zeros := 0
+
+	// skip the initial start code
+	i := 0
+	for ; i < len(byts); i++ {
+		if byts[i] == 0x00 {
+			zeros++
+			continue
+		}
+
+		if byts[i] != 0x01 || zeros < 2 {
+			return nil, fmt.Errorf("initial start code not found")
+		}
+		break
+	}
+
+	if i >= len(byts) {
+		return nil, fmt.Errorf("initial start code not found")
+	}
+
+	byts = byts[i+1:]
+
+	var ret [][]byte
+	start := 0
+	zeros = 0
+
+	for i, b := range byts {
+		switch b {
+		case 0x00:
+			zeros++
+			continue
+
+		case 0x01:
+			if zeros >= 2 {
+				// zeros that precede a start code do not belong to the NALU
+				nalu := byts[start : i-zeros]
+				if len(nalu) == 0 {
+					return nil, fmt.Errorf("empty NALU")
+				}
+
+				ret = append(ret, nalu)
+				start = i + 1
+			}
+		}
+
+		zeros = 0
+	}
+
+	nalu := byts[start:]
+	if len(nalu) == 0 {
+		return nil, fmt.Errorf("empty NALU")
+	}
+
+	return append(ret, nalu), nil
+}
+
+// AnnexBMarshal joins NALUs into a byte stream in the Annex-B format,
+// by prefixing every NALU with the 0x00 0x00 0x00 0x01 start code.
+func AnnexBMarshal(nalus [][]byte) []byte {
+	n := 0
+	for _, nalu := range nalus {
+		n += 4 + len(nalu)
+	}
+
+	ret := make([]byte, 0, n)
+	for _, nalu := range nalus {
+		ret = append(ret, 0x00, 0x00, 0x00, 0x01)
+		ret = append(ret, nalu...)
+	}
+
+	return ret
+}
@@ -0,0 +1,79 @@
+package h264
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnexBUnmarshal(t *testing.T) {
+	for _, ca := range []struct {
+		name  string
+		byts  []byte
+		nalus [][]byte
+	}{
+		{
+			"2 zeros",
+			[]byte{
+				0x00, 0x00, 0x01, 0xaa, 0xbb,
+				0x00, 0x00, 0x01, 0xcc, 0xdd,
+			},
+			[][]byte{{0xaa, 0xbb}, {0xcc, 0xdd}},
+		},
+		{
+			"3 zeros",
+			[]byte{
+				0x00, 0x00, 0x00, 0x01, 0xaa, 0xbb,
+				0x00, 0x00, 0x00, 0x01, 0xcc, 0xdd,
+			},
+			[][]byte{{0xaa, 0xbb}, {0xcc, 0xdd}},
+		},
+		{
+			"mixed",
+			[]byte{
+				0x00, 0x00, 0x00, 0x01, 0xaa, 0x00, 0xbb,
+				0x00, 0x00, 0x01, 0xcc, 0x00, 0x01,
+			},
+			[][]byte{{0xaa, 0x00, 0xbb}, {0xcc, 0x00, 0x01}},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			nalus, err := AnnexBUnmarshal(ca.byts)
+			require.NoError(t, err)
+			require.Equal(t, ca.nalus, nalus)
+		})
+	}
+}
+
+func TestAnnexBUnmarshalErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		byts []byte
+		err  string
+	}{
+		{"empty", []byte{}, "initial start code not found"},
+		{"missing start code", []byte{0xaa, 0x00, 0x00, 0x01, 0xbb}, "initial start code not found"},
+		{"only zeros", []byte{0x00, 0x00, 0x00}, "initial start code not found"},
+		{"empty NALU", []byte{0x00, 0x00, 0x01, 0x00, 0x00, 0x01, 0xaa}, "empty NALU"},
+		{"empty last NALU", []byte{0x00, 0x00, 0x01}, "empty NALU"},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			_, err := AnnexBUnmarshal(ca.byts)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func TestAnnexBMarshal(t *testing.T) {
+	nalus := [][]byte{{0xaa, 0xbb}, {0xcc, 0xdd}}
+
+	byts := AnnexBMarshal(nalus)
+	require.Equal(t, []byte{
+		0x00, 0x00, 0x00, 0x01, 0xaa, 0xbb,
+		0x00, 0x00, 0x00, 0x01, 0xcc, 0xdd,
+	}, byts)
+
+	dec, err := AnnexBUnmarshal(byts)
+	require.NoError(t, err)
+	require.Equal(t, nalus, dec)
+}
@@ -0,0 +1,58 @@
+package h264
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// AVCCUnmarshal splits a buffer in the AVCC format into NALUs.
+// Every NALU is prefixed by its length, encoded with 4 bytes,
+// as in MP4 and Matroska files.
+func AVCCUnmarshal(buf []byte) ([][]byte, error) {
+	var ret [][]byte
+
+	for len(buf) > 0 {
+		if len(buf) < 4 {
+			return nil, fmt.Errorf("invalid length")
+		}
+
+		size := binary.BigEndian.Uint32(buf)
+		buf = buf[4:]
+
+		if size == 0 {
+			return nil, fmt.Errorf("empty NALU")
+		}
+
+		if uint32(len(buf)) < size {
+			return nil, fmt.Errorf("invalid length")
+		}
+
+		ret = append(ret, buf[:size])
+		buf = buf[size:]
+	}
+
+	if ret == nil {
+		return nil, fmt.Errorf("buffer doesn't contain any NALU")
+	}
+
+	return ret, nil
+}
+
+// AVCCMarshal joins NALUs into a buffer in the AVCC format.
+func AVCCMarshal(nalus [][]byte) []byte {
+	n := 0
+	for _, nalu := range nalus {
+		n += 4 + len(nalu)
+	}
+
+	ret := make([]byte, n)
+	pos := 0
+
+	for _, nalu := range nalus {
+		binary.BigEndian.PutUint32(ret[pos:], uint32(len(nalu)))
+		pos += 4
+		pos += copy(ret[pos:], nalu)
+	}
+
+	return ret
+}
@@ -0,0 +1,45 @@
+package h264
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAVCC(t *testing.T) {
+	nalus := [][]byte{{0x67, 0x01, 0x02}, {0x65, 0x03}}
+	byts := []byte{
+		0x00, 0x00, 0x00, 0x03, 0x67, 0x01, 0x02,
+		0x00, 0x00, 0x00, 0x02, 0x65, 0x03,
+	}
+
+	require.Equal(t, byts, AVCCMarshal(nalus))
+
+	dec, err := AVCCUnmarshal(byts)
+	require.NoError(t, err)
+	require.Equal(t, nalus, dec)
+
+	require.Equal(t, true, IDRPresent(dec))
+	require.Equal(t, false, IDRPresent(dec[:1]))
+	require.Equal(t, NALUTypeSPS, TypeOf(dec[0]))
+	require.Equal(t, "SPS", TypeOf(dec[0]).String())
+	require.Equal(t, "unknown (28)", NALUType(28).String())
+}
+
+func TestAVCCUnmarshalErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		byts []byte
+		err  string
+	}{
+		{"empty", []byte{}, "buffer doesn't contain any NALU"},
+		{"short length", []byte{0x00, 0x00, 0x01}, "invalid length"},
+		{"invalid length", []byte{0x00, 0x00, 0x00, 0x03, 0x01}, "invalid length"},
+		{"empty NALU", []byte{0x00, 0x00, 0x00, 0x00}, "empty NALU"},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			_, err := AVCCUnmarshal(ca.byts)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package h264
+
+import (
+	"fmt"
+)
+
+type bitReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *bitReader) readFlag() (bool, error) {
+	v, err := r.readBits(1)
+	return (v == 1), err
+}
+
+func (r *bitReader) readBits(n int) (uint32, error) {
+	if (len(r.buf)*8 - r.pos) < n {
+		return 0, fmt.Errorf("not enough bits")
+	}
+
+	var v uint32
+	for i := 0; i < n; i++ {
+		b := (r.buf[r.pos/8] >> (7 - uint(r.pos%8))) & 0x01
+		v = (v << 1) | uint32(b)
+		r.pos++
+	}
+
+	return v, nil
+}
+
+// readGolombUnsigned reads an unsigned Exp-Golomb code.
+func (r *bitReader) readGolombUnsigned() (uint32, error) {
+	leadingZeros := 0
+	for {
+		b, err := r.readBits(1)
+		if err != nil {
+			return 0, err
+		}
+
+		if b != 0 {
+			break
+		}
+
+		leadingZeros++
+		if leadingZeros > 31 {
+			return 0, fmt.Errorf("invalid Exp-Golomb code")
+		}
+	}
+
+	v, err := r.readBits(leadingZeros)
+	if err != nil {
+		return 0, err
+	}
+
+	return (1 << uint(leadingZeros)) - 1 + v, nil
+}
+
+// readGolombSigned reads a signed Exp-Golomb code.
+func (r *bitReader) readGolombSigned() (int32, error) {
+	v, err := r.readGolombUnsigned()
+	if err != nil {
+		return 0, err
+	}
+
+	if (v & 0x01) == 0 {
+		return -int32(v / 2), nil
+	}
+	return int32((v + 1) / 2), nil
+}
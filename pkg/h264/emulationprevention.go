@@ -0,0 +1,29 @@
+package h264
+
+// EmulationPreventionRemove removes emulation prevention bytes from a NALU.
+func EmulationPreventionRemove(nalu []byte) []byte {
+	// 0x00 0x00 0x03 0x00 -> 0x00 0x00 0x00
+	// 0x00 0x00 0x03 0x01 -> 0x00 0x00 0x01
+	// 0x00 0x00 0x03 0x02 -> 0x00 0x00 0x02
+	// 0x00 0x00 0x03 0x03 -> 0x00 0x00 0x03
+
+	ret := make([]byte, 0, len(nalu))
+	zeros := 0
+
+	for _, b := range nalu {
+		if zeros == 2 && b == 0x03 {
+			zeros = 0
+			continue
+		}
+
+		if b == 0x00 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+
+		ret = append(ret, b)
+	}
+
+	return ret
+}
@@ -0,0 +1,37 @@
+package h264
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeOf(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		nalu []byte
+		typ  NALUType
+	}{
+		{
+			"sps",
+			[]byte{0x67, 0x01},
+			NALUTypeSPS,
+		},
+		{
+			"idr",
+			[]byte{0x65},
+			NALUTypeIDR,
+		},
+		{
+			"empty",
+			[]byte{},
+			0,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, ca.typ, TypeOf(ca.nalu))
+		})
+	}
+
+	require.Equal(t, false, IDRPresent([][]byte{{}, {0x41}}))
+}
@@ -0,0 +1,447 @@
+package h264
+
+import (
+	"fmt"
+)
+
+func readScalingList(r *bitReader, size int) error {
+	lastScale := int32(8)
+	nextScale := int32(8)
+
+	for j := 0; j < size; j++ {
+		if nextScale != 0 {
+			deltaScale, err := r.readGolombSigned()
+			if err != nil {
+				return err
+			}
+
+			nextScale = (lastScale + deltaScale + 256) % 256
+		}
+
+		if nextScale != 0 {
+			lastScale = nextScale
+		}
+	}
+
+	return nil
+}
+
+// SPSFrameCropping is the frame cropping part of a SPS.
+type SPSFrameCropping struct {
+	LeftOffset   uint32
+	RightOffset  uint32
+	TopOffset    uint32
+	BottomOffset uint32
+}
+
+// SPSTimingInfo is the timing info part of a SPS.
+type SPSTimingInfo struct {
+	NumUnitsInTick     uint32
+	TimeScale          uint32
+	FixedFrameRateFlag bool
+}
+
+// SPS is a H264 sequence parameter set.
+type SPS struct {
+	ProfileIdc         uint8
+	ConstraintSetFlags uint8
+	LevelIdc           uint8
+	ID                 uint32
+
+	// only for high profiles
+	ChromaFormatIdc         uint32
+	SeparateColourPlaneFlag bool
+
+	FrameMbsOnlyFlag          bool
+	PicWidthInMbsMinus1       uint32
+	PicHeightInMapUnitsMinus1 uint32
+
+	// filled when frame_cropping_flag is true
+	FrameCropping *SPSFrameCropping
+
+	// filled when the VUI contains timing info
+	TimingInfo *SPSTimingInfo
+}
+
+// Unmarshal decodes a SPS from a NALU.
+func (s *SPS) Unmarshal(nalu []byte) error {
+	if len(nalu) < 1 {
+		return fmt.Errorf("not enough bytes")
+	}
+
+	typ := TypeOf(nalu)
+	if typ != NALUTypeSPS {
+		return fmt.Errorf("not a SPS (NALU type %d)", typ)
+	}
+
+	buf := EmulationPreventionRemove(nalu[1:])
+	if len(buf) < 4 {
+		return fmt.Errorf("not enough bytes")
+	}
+
+	s.ProfileIdc = buf[0]
+	s.ConstraintSetFlags = buf[1]
+	s.LevelIdc = buf[2]
+
+	r := &bitReader{buf: buf[3:]}
+
+	var err error
+	s.ID, err = r.readGolombUnsigned()
+	if err != nil {
+		return err
+	}
+
+	switch s.ProfileIdc {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		s.ChromaFormatIdc, err = r.readGolombUnsigned()
+		if err != nil {
+			return err
+		}
+
+		if s.ChromaFormatIdc == 3 {
+			s.SeparateColourPlaneFlag, err = r.readFlag()
+			if err != nil {
+				return err
+			}
+		} else {
+			s.SeparateColourPlaneFlag = false
+		}
+
+		// bit_depth_luma_minus8
+		_, err = r.readGolombUnsigned()
+		if err != nil {
+			return err
+		}
+
+		// bit_depth_chroma_minus8
+		_, err = r.readGolombUnsigned()
+		if err != nil {
+			return err
+		}
+
+		// qpprime_y_zero_transform_bypass_flag
+		_, err = r.readFlag()
+		if err != nil {
+			return err
+		}
+
+		seqScalingMatrixPresentFlag, err := r.readFlag()
+		if err != nil {
+			return err
+		}
+
+		if seqScalingMatrixPresentFlag {
+			count := 8
+			if s.ChromaFormatIdc == 3 {
+				count = 12
+			}
+
+			for i := 0; i < count; i++ {
+				seqScalingListPresentFlag, err := r.readFlag()
+				if err != nil {
+					return err
+				}
+
+				if seqScalingListPresentFlag {
+					size := 16
+					if i >= 6 {
+						size = 64
+					}
+
+					err := readScalingList(r, size)
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+	default:
+		s.ChromaFormatIdc = 1
+		s.SeparateColourPlaneFlag = false
+	}
+
+	// log2_max_frame_num_minus4
+	_, err = r.readGolombUnsigned()
+	if err != nil {
+		return err
+	}
+
+	picOrderCntType, err := r.readGolombUnsigned()
+	if err != nil {
+		return err
+	}
+
+	switch picOrderCntType {
+	case 0:
+		// log2_max_pic_order_cnt_lsb_minus4
+		_, err = r.readGolombUnsigned()
+		if err != nil {
+			return err
+		}
+
+	case 1:
+		// delta_pic_order_always_zero_flag
+		_, err = r.readFlag()
+		if err != nil {
+			return err
+		}
+
+		// offset_for_non_ref_pic
+		_, err = r.readGolombSigned()
+		if err != nil {
+			return err
+		}
+
+		// offset_for_top_to_bottom_field
+		_, err = r.readGolombSigned()
+		if err != nil {
+			return err
+		}
+
+		numRefFramesInPicOrderCntCycle, err := r.readGolombUnsigned()
+		if err != nil {
+			return err
+		}
+
+		for i := uint32(0); i < numRefFramesInPicOrderCntCycle; i++ {
+			// offset_for_ref_frame
+			_, err = r.readGolombSigned()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// max_num_ref_frames
+	_, err = r.readGolombUnsigned()
+	if err != nil {
+		return err
+	}
+
+	// gaps_in_frame_num_value_allowed_flag
+	_, err = r.readFlag()
+	if err != nil {
+		return err
+	}
+
+	s.PicWidthInMbsMinus1, err = r.readGolombUnsigned()
+	if err != nil {
+		return err
+	}
+
+	s.PicHeightInMapUnitsMinus1, err = r.readGolombUnsigned()
+	if err != nil {
+		return err
+	}
+
+	s.FrameMbsOnlyFlag, err = r.readFlag()
+	if err != nil {
+		return err
+	}
+
+	if !s.FrameMbsOnlyFlag {
+		// mb_adaptive_frame_field_flag
+		_, err = r.readFlag()
+		if err != nil {
+			return err
+		}
+	}
+
+	// direct_8x8_inference_flag
+	_, err = r.readFlag()
+	if err != nil {
+		return err
+	}
+
+	frameCroppingFlag, err := r.readFlag()
+	if err != nil {
+		return err
+	}
+
+	if frameCroppingFlag {
+		var c SPSFrameCropping
+
+		for _, v := range []*uint32{&c.LeftOffset, &c.RightOffset, &c.TopOffset, &c.BottomOffset} {
+			*v, err = r.readGolombUnsigned()
+			if err != nil {
+				return err
+			}
+		}
+
+		s.FrameCropping = &c
+	} else {
+		s.FrameCropping = nil
+	}
+
+	s.TimingInfo = nil
+
+	vuiParametersPresentFlag, err := r.readFlag()
+	if err != nil {
+		return err
+	}
+
+	if vuiParametersPresentFlag {
+		err := s.readVUI(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readVUI reads the VUI parameters, until the timing info.
+func (s *SPS) readVUI(r *bitReader) error {
+	aspectRatioInfoPresentFlag, err := r.readFlag()
+	if err != nil {
+		return err
+	}
+
+	if aspectRatioInfoPresentFlag {
+		aspectRatioIdc, err := r.readBits(8)
+		if err != nil {
+			return err
+		}
+
+		// extended SAR
+		if aspectRatioIdc == 255 {
+			// sar_width, sar_height
+			_, err := r.readBits(32)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	overscanInfoPresentFlag, err := r.readFlag()
+	if err != nil {
+		return err
+	}
+
+	if overscanInfoPresentFlag {
+		// overscan_appropriate_flag
+		_, err := r.readFlag()
+		if err != nil {
+			return err
+		}
+	}
+
+	videoSignalTypePresentFlag, err := r.readFlag()
+	if err != nil {
+		return err
+	}
+
+	if videoSignalTypePresentFlag {
+		// video_format, video_full_range_flag
+		_, err := r.readBits(4)
+		if err != nil {
+			return err
+		}
+
+		colourDescriptionPresentFlag, err := r.readFlag()
+		if err != nil {
+			return err
+		}
+
+		if colourDescriptionPresentFlag {
+			// colour_primaries, transfer_characteristics, matrix_coefficients
+			_, err := r.readBits(24)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	chromaLocInfoPresentFlag, err := r.readFlag()
+	if err != nil {
+		return err
+	}
+
+	if chromaLocInfoPresentFlag {
+		// chroma_sample_loc_type_top_field, chroma_sample_loc_type_bottom_field
+		for i := 0; i < 2; i++ {
+			_, err := r.readGolombUnsigned()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	timingInfoPresentFlag, err := r.readFlag()
+	if err != nil {
+		return err
+	}
+
+	if timingInfoPresentFlag {
+		var t SPSTimingInfo
+
+		t.NumUnitsInTick, err = r.readBits(32)
+		if err != nil {
+			return err
+		}
+
+		t.TimeScale, err = r.readBits(32)
+		if err != nil {
+			return err
+		}
+
+		t.FixedFrameRateFlag, err = r.readFlag()
+		if err != nil {
+			return err
+		}
+
+		s.TimingInfo = &t
+	}
+
+	return nil
+}
+
+// Width returns the video width.
+func (s SPS) Width() int {
+	width := int(s.PicWidthInMbsMinus1+1) * 16
+
+	if s.FrameCropping != nil {
+		cropUnitX := 1
+		if s.ChromaFormatIdc == 1 || s.ChromaFormatIdc == 2 {
+			if !s.SeparateColourPlaneFlag {
+				cropUnitX = 2
+			}
+		}
+
+		width -= int(s.FrameCropping.LeftOffset+s.FrameCropping.RightOffset) * cropUnitX
+	}
+
+	return width
+}
+
+// Height returns the video height.
+func (s SPS) Height() int {
+	frameMbsOnly := 0
+	if s.FrameMbsOnlyFlag {
+		frameMbsOnly = 1
+	}
+
+	height := (2 - frameMbsOnly) * int(s.PicHeightInMapUnitsMinus1+1) * 16
+
+	if s.FrameCropping != nil {
+		cropUnitY := 2 - frameMbsOnly
+		if s.ChromaFormatIdc == 1 && !s.SeparateColourPlaneFlag {
+			cropUnitY *= 2
+		}
+
+		height -= int(s.FrameCropping.TopOffset+s.FrameCropping.BottomOffset) * cropUnitY
+	}
+
+	return height
+}
+
+// FPS returns the frame rate of the video.
+// It returns zero if the SPS doesn't contain timing info.
+func (s SPS) FPS() float64 {
+	if s.TimingInfo == nil || s.TimingInfo.NumUnitsInTick == 0 {
+		return 0
+	}
+
+	return float64(s.TimingInfo.TimeScale) / (2 * float64(s.TimingInfo.NumUnitsInTick))
+}
@@ -0,0 +1,95 @@
+package h264
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSPSUnmarshal(t *testing.T) {
+	for _, ca := range []struct {
+		name   string
+		byts   []byte
+		sps    SPS
+		width  int
+		height int
+		fps    float64
+	}{
+		{
+			"352x288",
+			[]byte{
+				0x67, 0x64, 0x00, 0x0c, 0xac, 0x3b, 0x50, 0xb0,
+				0x4b, 0x42, 0x00, 0x00, 0x03, 0x00, 0x02, 0x00,
+				0x00, 0x03, 0x00, 0x3d, 0x08,
+			},
+			SPS{
+				ProfileIdc:                100,
+				LevelIdc:                  12,
+				ChromaFormatIdc:           1,
+				FrameMbsOnlyFlag:          true,
+				PicWidthInMbsMinus1:       21,
+				PicHeightInMapUnitsMinus1: 17,
+				TimingInfo: &SPSTimingInfo{
+					NumUnitsInTick:     1,
+					TimeScale:          30,
+					FixedFrameRateFlag: true,
+				},
+			},
+			352,
+			288,
+			15,
+		},
+		{
+			"1920x1080",
+			[]byte{
+				0x67, 0x64, 0x00, 0x28, 0xac, 0xd9, 0x40, 0x78,
+				0x02, 0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00,
+				0x04, 0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60,
+				0xc6, 0x58,
+			},
+			SPS{
+				ProfileIdc:                100,
+				LevelIdc:                  40,
+				ChromaFormatIdc:           1,
+				FrameMbsOnlyFlag:          true,
+				PicWidthInMbsMinus1:       119,
+				PicHeightInMapUnitsMinus1: 67,
+				FrameCropping: &SPSFrameCropping{
+					BottomOffset: 4,
+				},
+				TimingInfo: &SPSTimingInfo{
+					NumUnitsInTick: 1,
+					TimeScale:      60,
+				},
+			},
+			1920,
+			1080,
+			30,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var sps SPS
+			err := sps.Unmarshal(ca.byts)
+			require.NoError(t, err)
+			require.Equal(t, ca.sps, sps)
+			require.Equal(t, ca.width, sps.Width())
+			require.Equal(t, ca.height, sps.Height())
+			require.Equal(t, ca.fps, sps.FPS())
+		})
+	}
+}
+
+func TestSPSUnmarshalErrors(t *testing.T) {
+	var sps SPS
+	err := sps.Unmarshal([]byte{0x68, 0x01, 0x02, 0x03})
+	require.Error(t, err)
+
+	err = sps.Unmarshal([]byte{0x67, 0x64, 0x00})
+	require.Error(t, err)
+}
+
+func TestEmulationPreventionRemove(t *testing.T) {
+	require.Equal(t,
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x03},
+		EmulationPreventionRemove([]byte{0x00, 0x00, 0x03, 0x00, 0x00, 0x03, 0x01, 0x00, 0x00, 0x03, 0x03}))
+}
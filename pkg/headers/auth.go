@@ -47,6 +47,15 @@ type Auth struct {
 
 	// (optional) algorithm
 	Algorithm *string
+
+	// (optional) quality of protection
+	QOP *string
+
+	// (optional) nonce count
+	NC *string
+
+	// (optional) client nonce
+	CNonce *string
 }
 
 func findValue(v0 string) (string, string, error) {
@@ -150,6 +159,15 @@ func ReadAuth(v base.HeaderValue) (*Auth, error) {
 		case "algorithm":
 			ha.Algorithm = &val
 
+		case "qop":
+			ha.QOP = &val
+
+		case "nc":
+			ha.NC = &val
+
+		case "cnonce":
+			ha.CNonce = &val
+
 			// ignore non-standard keys
 		}
 
@@ -215,6 +233,23 @@ func (ha Auth) Write() base.HeaderValue {
 		vals = append(vals, "algorithm=\""+*ha.Algorithm+"\"")
 	}
 
+	if ha.QOP != nil {
+		// qop is a quoted list in challenges and a token in responses
+		if ha.Response == nil {
+			vals = append(vals, "qop=\""+*ha.QOP+"\"")
+		} else {
+			vals = append(vals, "qop="+*ha.QOP)
+		}
+	}
+
+	if ha.NC != nil {
+		vals = append(vals, "nc="+*ha.NC)
+	}
+
+	if ha.CNonce != nil {
+		vals = append(vals, "cnonce=\""+*ha.CNonce+"\"")
+	}
+
 	ret += strings.Join(vals, ", ")
 
 	return base.HeaderValue{ret}
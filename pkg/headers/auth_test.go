@@ -170,6 +170,70 @@ var casesAuth = []struct {
 			}(),
 		},
 	},
+	{
+		"digest request with qop",
+		base.HeaderValue{`Digest realm="IPCAM", nonce="abcd", algorithm=SHA-256, qop="auth,auth-int"`},
+		base.HeaderValue{`Digest realm="IPCAM", nonce="abcd", algorithm="SHA-256", qop="auth,auth-int"`},
+		&Auth{
+			Method: AuthDigest,
+			Realm: func() *string {
+				v := "IPCAM"
+				return &v
+			}(),
+			Nonce: func() *string {
+				v := "abcd"
+				return &v
+			}(),
+			Algorithm: func() *string {
+				v := "SHA-256"
+				return &v
+			}(),
+			QOP: func() *string {
+				v := "auth,auth-int"
+				return &v
+			}(),
+		},
+	},
+	{
+		"digest response with qop",
+		base.HeaderValue{`Digest username="aa", realm="bb", nonce="cc", uri="dd", response="ee", qop=auth, nc=00000001, cnonce="ff"`},
+		base.HeaderValue{`Digest username="aa", realm="bb", nonce="cc", uri="dd", response="ee", qop=auth, nc=00000001, cnonce="ff"`},
+		&Auth{
+			Method: AuthDigest,
+			Username: func() *string {
+				v := "aa"
+				return &v
+			}(),
+			Realm: func() *string {
+				v := "bb"
+				return &v
+			}(),
+			Nonce: func() *string {
+				v := "cc"
+				return &v
+			}(),
+			URI: func() *string {
+				v := "dd"
+				return &v
+			}(),
+			Response: func() *string {
+				v := "ee"
+				return &v
+			}(),
+			QOP: func() *string {
+				v := "auth"
+				return &v
+			}(),
+			NC: func() *string {
+				v := "00000001"
+				return &v
+			}(),
+			CNonce: func() *string {
+				v := "ff"
+				return &v
+			}(),
+		},
+	},
 }
 
 func TestAuthRead(t *testing.T) {
@@ -0,0 +1,423 @@
+package headers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aler9/gortsplib/pkg/base"
+)
+
+// RangeValue is a value of a Range header.
+// It can be *RangeSMPTE, *RangeNPT or *RangeUTC.
+type RangeValue interface {
+	read(string) error
+	write() string
+}
+
+// RangeSMPTEType is the type of a SMPTE range.
+type RangeSMPTEType int
+
+const (
+	// RangeSMPTETypeSMPTE is the "smpte" type (30 frames per second).
+	RangeSMPTETypeSMPTE RangeSMPTEType = iota
+
+	// RangeSMPTEType30Drop is the "smpte-30-drop" type (29.97 frames per second).
+	RangeSMPTEType30Drop
+
+	// RangeSMPTEType25 is the "smpte-25" type (25 frames per second).
+	RangeSMPTEType25
+)
+
+// String implements fmt.Stringer.
+func (t RangeSMPTEType) String() string {
+	switch t {
+	case RangeSMPTETypeSMPTE:
+		return "smpte"
+
+	case RangeSMPTEType30Drop:
+		return "smpte-30-drop"
+
+	case RangeSMPTEType25:
+		return "smpte-25"
+	}
+	return "unknown"
+}
+
+// RangeSMPTETime is a time expressed in the SMPTE format.
+type RangeSMPTETime struct {
+	// hours, minutes and seconds
+	Time time.Duration
+
+	// frame
+	Frame uint
+
+	// subframe
+	Subframe uint
+}
+
+func (t *RangeSMPTETime) read(s string) error {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 && len(parts) != 4 {
+		return fmt.Errorf("invalid SMPTE time (%v)", s)
+	}
+
+	hours, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return err
+	}
+
+	mins, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return err
+	}
+
+	seconds, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return err
+	}
+
+	t.Time = time.Duration(hours)*time.Hour +
+		time.Duration(mins)*time.Minute +
+		time.Duration(seconds)*time.Second
+
+	if len(parts) == 4 {
+		frameParts := strings.Split(parts[3], ".")
+		if len(frameParts) > 2 {
+			return fmt.Errorf("invalid SMPTE time (%v)", s)
+		}
+
+		frame, err := strconv.ParseUint(frameParts[0], 10, 64)
+		if err != nil {
+			return err
+		}
+		t.Frame = uint(frame)
+
+		if len(frameParts) == 2 {
+			subframe, err := strconv.ParseUint(frameParts[1], 10, 64)
+			if err != nil {
+				return err
+			}
+			t.Subframe = uint(subframe)
+		}
+	}
+
+	return nil
+}
+
+func (t RangeSMPTETime) write() string {
+	d := uint64(t.Time.Seconds())
+	hours := d / 3600
+	d %= 3600
+	mins := d / 60
+	secs := d % 60
+
+	ret := fmt.Sprintf("%d:%02d:%02d", hours, mins, secs)
+
+	if t.Frame > 0 || t.Subframe > 0 {
+		ret += fmt.Sprintf(":%02d", t.Frame)
+
+		if t.Subframe > 0 {
+			ret += fmt.Sprintf(".%02d", t.Subframe)
+		}
+	}
+
+	return ret
+}
+
+// RangeSMPTE is a range expressed in the SMPTE format.
+type RangeSMPTE struct {
+	// type
+	Type RangeSMPTEType
+
+	// start
+	Start RangeSMPTETime
+
+	// (optional) end
+	End *RangeSMPTETime
+}
+
+func (r *RangeSMPTE) read(s string) error {
+	start, end, err := splitRange(s)
+	if err != nil {
+		return err
+	}
+
+	err = r.Start.read(start)
+	if err != nil {
+		return err
+	}
+
+	if end != "" {
+		var v RangeSMPTETime
+		err := v.read(end)
+		if err != nil {
+			return err
+		}
+		r.End = &v
+	}
+
+	return nil
+}
+
+func (r RangeSMPTE) write() string {
+	ret := r.Type.String() + "=" + r.Start.write() + "-"
+	if r.End != nil {
+		ret += r.End.write()
+	}
+	return ret
+}
+
+// RangeNPTTime is a time expressed in the Normal Play Time format.
+type RangeNPTTime time.Duration
+
+func (t *RangeNPTTime) read(s string) error {
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return fmt.Errorf("invalid NPT time (%v)", s)
+	}
+
+	var hours, mins uint64
+
+	if len(parts) == 3 {
+		var err error
+		hours, err = strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			return err
+		}
+		parts = parts[1:]
+	}
+
+	if len(parts) == 2 {
+		var err error
+		mins, err = strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			return err
+		}
+		parts = parts[1:]
+	}
+
+	seconds, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return err
+	}
+	if seconds < 0 {
+		return fmt.Errorf("invalid NPT time (%v)", s)
+	}
+
+	*t = RangeNPTTime(time.Duration(hours)*time.Hour +
+		time.Duration(mins)*time.Minute +
+		time.Duration(seconds*float64(time.Second)))
+
+	return nil
+}
+
+func (t RangeNPTTime) write() string {
+	return strconv.FormatFloat(time.Duration(t).Seconds(), 'f', -1, 64)
+}
+
+// RangeNPT is a range expressed in the Normal Play Time format.
+type RangeNPT struct {
+	// start
+	Start RangeNPTTime
+
+	// whether the range starts from the current position of a live stream
+	// ("npt=now-"). If true, Start is ignored.
+	StartNow bool
+
+	// (optional) end
+	End *RangeNPTTime
+}
+
+func (r *RangeNPT) read(s string) error {
+	start, end, err := splitRange(s)
+	if err != nil {
+		return err
+	}
+
+	if start == "now" {
+		r.StartNow = true
+	} else {
+		err = r.Start.read(start)
+		if err != nil {
+			return err
+		}
+	}
+
+	if end != "" {
+		var v RangeNPTTime
+		err := v.read(end)
+		if err != nil {
+			return err
+		}
+		r.End = &v
+	}
+
+	return nil
+}
+
+func (r RangeNPT) write() string {
+	ret := "npt="
+	if r.StartNow {
+		ret += "now-"
+	} else {
+		ret += r.Start.write() + "-"
+	}
+	if r.End != nil {
+		ret += r.End.write()
+	}
+	return ret
+}
+
+const rangeUTCLayout = "20060102T150405.999999999Z"
+
+// RangeUTC is a range expressed in the absolute (clock) format.
+type RangeUTC struct {
+	// start
+	Start time.Time
+
+	// (optional) end
+	End *time.Time
+}
+
+func (r *RangeUTC) read(s string) error {
+	start, end, err := splitRange(s)
+	if err != nil {
+		return err
+	}
+
+	r.Start, err = time.Parse(rangeUTCLayout, start)
+	if err != nil {
+		return err
+	}
+
+	if end != "" {
+		v, err := time.Parse(rangeUTCLayout, end)
+		if err != nil {
+			return err
+		}
+		r.End = &v
+	}
+
+	return nil
+}
+
+func (r RangeUTC) write() string {
+	ret := "clock=" + r.Start.UTC().Format(rangeUTCLayout) + "-"
+	if r.End != nil {
+		ret += r.End.UTC().Format(rangeUTCLayout)
+	}
+	return ret
+}
+
+func splitRange(s string) (string, string, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", fmt.Errorf("invalid range (%v)", s)
+	}
+	return parts[0], parts[1], nil
+}
+
+// Range is a Range header.
+type Range struct {
+	// range expressed in some measurement units
+	Value RangeValue
+
+	// (optional) time at which the operation is to be made effective
+	Time *time.Time
+}
+
+// ReadRange parses a Range header.
+func ReadRange(v base.HeaderValue) (*Range, error) {
+	if len(v) == 0 {
+		return nil, fmt.Errorf("value not provided")
+	}
+
+	if len(v) > 1 {
+		return nil, fmt.Errorf("value provided multiple times (%v)", v)
+	}
+
+	parts := strings.Split(v[0], ";")
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("invalid value (%v)", v)
+	}
+
+	h := &Range{}
+
+	specFound := false
+
+	for _, part := range parts {
+		// remove leading spaces
+		part = strings.TrimLeft(part, " ")
+
+		keyval := strings.SplitN(part, "=", 2)
+		if len(keyval) != 2 {
+			return nil, fmt.Errorf("invalid value (%v)", v)
+		}
+
+		key, strValue := keyval[0], keyval[1]
+
+		switch key {
+		case "smpte", "smpte-30-drop", "smpte-25":
+			s := &RangeSMPTE{}
+			switch key {
+			case "smpte-30-drop":
+				s.Type = RangeSMPTEType30Drop
+			case "smpte-25":
+				s.Type = RangeSMPTEType25
+			}
+			err := s.read(strValue)
+			if err != nil {
+				return nil, err
+			}
+			h.Value = s
+			specFound = true
+
+		case "npt":
+			s := &RangeNPT{}
+			err := s.read(strValue)
+			if err != nil {
+				return nil, err
+			}
+			h.Value = s
+			specFound = true
+
+		case "clock":
+			s := &RangeUTC{}
+			err := s.read(strValue)
+			if err != nil {
+				return nil, err
+			}
+			h.Value = s
+			specFound = true
+
+		case "time":
+			t, err := time.Parse(rangeUTCLayout, strValue)
+			if err != nil {
+				return nil, err
+			}
+			h.Time = &t
+
+		default:
+			// ignore non-standard keys
+		}
+	}
+
+	if !specFound {
+		return nil, fmt.Errorf("value not found (%v)", v[0])
+	}
+
+	return h, nil
+}
+
+// Write encodes a Range header.
+func (h Range) Write() base.HeaderValue {
+	v := h.Value.write()
+
+	if h.Time != nil {
+		v += ";time=" + h.Time.UTC().Format(rangeUTCLayout)
+	}
+
+	return base.HeaderValue{v}
+}
@@ -0,0 +1,191 @@
+package headers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aler9/gortsplib/pkg/base"
+)
+
+var casesRange = []struct {
+	name string
+	vin  base.HeaderValue
+	vout base.HeaderValue
+	h    *Range
+}{
+	{
+		"npt",
+		base.HeaderValue{`npt=123.45-`},
+		base.HeaderValue{`npt=123.45-`},
+		&Range{
+			Value: &RangeNPT{
+				Start: RangeNPTTime(123450 * time.Millisecond),
+			},
+		},
+	},
+	{
+		"npt with end",
+		base.HeaderValue{`npt=0-10`},
+		base.HeaderValue{`npt=0-10`},
+		&Range{
+			Value: &RangeNPT{
+				Start: RangeNPTTime(0),
+				End: func() *RangeNPTTime {
+					v := RangeNPTTime(10 * time.Second)
+					return &v
+				}(),
+			},
+		},
+	},
+	{
+		"npt with hours and minutes",
+		base.HeaderValue{`npt=1:02:03.5-`},
+		base.HeaderValue{`npt=3723.5-`},
+		&Range{
+			Value: &RangeNPT{
+				Start: RangeNPTTime(1*time.Hour + 2*time.Minute + 3500*time.Millisecond),
+			},
+		},
+	},
+	{
+		"npt now",
+		base.HeaderValue{`npt=now-`},
+		base.HeaderValue{`npt=now-`},
+		&Range{
+			Value: &RangeNPT{
+				StartNow: true,
+			},
+		},
+	},
+	{
+		"clock",
+		base.HeaderValue{`clock=19961108T142300Z-19961108T143520Z`},
+		base.HeaderValue{`clock=19961108T142300Z-19961108T143520Z`},
+		&Range{
+			Value: &RangeUTC{
+				Start: time.Date(1996, 11, 8, 14, 23, 0, 0, time.UTC),
+				End: func() *time.Time {
+					v := time.Date(1996, 11, 8, 14, 35, 20, 0, time.UTC)
+					return &v
+				}(),
+			},
+		},
+	},
+	{
+		"clock with fractions and time",
+		base.HeaderValue{`clock=19961108T142300.25Z-;time=19970123T143720Z`},
+		base.HeaderValue{`clock=19961108T142300.25Z-;time=19970123T143720Z`},
+		&Range{
+			Value: &RangeUTC{
+				Start: time.Date(1996, 11, 8, 14, 23, 0, 250000000, time.UTC),
+			},
+			Time: func() *time.Time {
+				v := time.Date(1997, 1, 23, 14, 37, 20, 0, time.UTC)
+				return &v
+			}(),
+		},
+	},
+	{
+		"smpte",
+		base.HeaderValue{`smpte=10:07:00-10:07:33:05.01`},
+		base.HeaderValue{`smpte=10:07:00-10:07:33:05.01`},
+		&Range{
+			Value: &RangeSMPTE{
+				Type: RangeSMPTETypeSMPTE,
+				Start: RangeSMPTETime{
+					Time: 10*time.Hour + 7*time.Minute,
+				},
+				End: &RangeSMPTETime{
+					Time:     10*time.Hour + 7*time.Minute + 33*time.Second,
+					Frame:    5,
+					Subframe: 1,
+				},
+			},
+		},
+	},
+	{
+		"smpte 25 with frame",
+		base.HeaderValue{`smpte-25=0:10:20:3-`},
+		base.HeaderValue{`smpte-25=0:10:20:03-`},
+		&Range{
+			Value: &RangeSMPTE{
+				Type: RangeSMPTEType25,
+				Start: RangeSMPTETime{
+					Time:  10*time.Minute + 20*time.Second,
+					Frame: 3,
+				},
+			},
+		},
+	},
+	{
+		"smpte 30 drop",
+		base.HeaderValue{`smpte-30-drop=01:00:00-`},
+		base.HeaderValue{`smpte-30-drop=1:00:00-`},
+		&Range{
+			Value: &RangeSMPTE{
+				Type: RangeSMPTEType30Drop,
+				Start: RangeSMPTETime{
+					Time: 1 * time.Hour,
+				},
+			},
+		},
+	},
+}
+
+func TestRangeRead(t *testing.T) {
+	for _, c := range casesRange {
+		t.Run(c.name, func(t *testing.T) {
+			req, err := ReadRange(c.vin)
+			require.NoError(t, err)
+			require.Equal(t, c.h, req)
+		})
+	}
+}
+
+func TestRangeWrite(t *testing.T) {
+	for _, c := range casesRange {
+		t.Run(c.name, func(t *testing.T) {
+			req := c.h.Write()
+			require.Equal(t, c.vout, req)
+		})
+	}
+}
+
+func TestRangeReadError(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		v    base.HeaderValue
+	}{
+		{
+			"empty",
+			base.HeaderValue{},
+		},
+		{
+			"missing spec",
+			base.HeaderValue{`time=19970123T143720Z`},
+		},
+		{
+			"invalid npt",
+			base.HeaderValue{`npt=a-`},
+		},
+		{
+			"invalid clock",
+			base.HeaderValue{`clock=19961108T142300-`},
+		},
+		{
+			"invalid smpte",
+			base.HeaderValue{`smpte=10:07-`},
+		},
+		{
+			"invalid smpte frame",
+			base.HeaderValue{`smpte=10:07:00:1.2.3-`},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			_, err := ReadRange(ca.v)
+			require.Error(t, err)
+		})
+	}
+}
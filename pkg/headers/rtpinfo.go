@@ -0,0 +1,103 @@
+package headers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aler9/gortsplib/pkg/base"
+)
+
+// RTPInfoEntry is an entry of a RTP-Info header.
+type RTPInfoEntry struct {
+	// url of the track
+	URL string
+
+	// (optional) sequence number of the first packet
+	SequenceNumber *uint16
+
+	// (optional) RTP timestamp of the first packet
+	Timestamp *uint32
+}
+
+// RTPInfo is a RTP-Info header.
+type RTPInfo []*RTPInfoEntry
+
+// ReadRTPInfo parses a RTP-Info header.
+func ReadRTPInfo(v base.HeaderValue) (*RTPInfo, error) {
+	if len(v) == 0 {
+		return nil, fmt.Errorf("value not provided")
+	}
+
+	if len(v) > 1 {
+		return nil, fmt.Errorf("value provided multiple times (%v)", v)
+	}
+
+	h := &RTPInfo{}
+
+	for _, tmp := range strings.Split(v[0], ",") {
+		e := &RTPInfoEntry{}
+
+		for _, kv := range strings.Split(tmp, ";") {
+			kv = strings.TrimLeft(kv, " ")
+
+			tmp := strings.SplitN(kv, "=", 2)
+			if len(tmp) != 2 {
+				return nil, fmt.Errorf("unable to parse key-value (%v)", kv)
+			}
+
+			k, v := tmp[0], tmp[1]
+			switch k {
+			case "url":
+				e.URL = v
+
+			case "seq":
+				vi, err := strconv.ParseUint(v, 10, 16)
+				if err != nil {
+					return nil, fmt.Errorf("invalid sequence number (%v)", v)
+				}
+				vi2 := uint16(vi)
+				e.SequenceNumber = &vi2
+
+			case "rtptime":
+				vi, err := strconv.ParseUint(v, 10, 32)
+				if err != nil {
+					return nil, fmt.Errorf("invalid timestamp (%v)", v)
+				}
+				vi2 := uint32(vi)
+				e.Timestamp = &vi2
+
+				// ignore non-standard keys
+			}
+		}
+
+		if e.URL == "" {
+			return nil, fmt.Errorf("URL is missing (%v)", tmp)
+		}
+
+		*h = append(*h, e)
+	}
+
+	return h, nil
+}
+
+// Write encodes a RTP-Info header.
+func (h RTPInfo) Write() base.HeaderValue {
+	rets := make([]string, len(h))
+
+	for i, e := range h {
+		tmp := "url=" + e.URL
+
+		if e.SequenceNumber != nil {
+			tmp += ";seq=" + strconv.FormatUint(uint64(*e.SequenceNumber), 10)
+		}
+
+		if e.Timestamp != nil {
+			tmp += ";rtptime=" + strconv.FormatUint(uint64(*e.Timestamp), 10)
+		}
+
+		rets[i] = tmp
+	}
+
+	return base.HeaderValue{strings.Join(rets, ",")}
+}
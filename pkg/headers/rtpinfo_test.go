@@ -0,0 +1,114 @@
+package headers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aler9/gortsplib/pkg/base"
+)
+
+var casesRTPInfo = []struct {
+	name string
+	vin  base.HeaderValue
+	vout base.HeaderValue
+	h    *RTPInfo
+}{
+	{
+		"single value",
+		base.HeaderValue{`url=rtsp://127.0.0.1/test.mkv/track1;seq=35243;rtptime=717574556`},
+		base.HeaderValue{`url=rtsp://127.0.0.1/test.mkv/track1;seq=35243;rtptime=717574556`},
+		&RTPInfo{
+			{
+				URL: "rtsp://127.0.0.1/test.mkv/track1",
+				SequenceNumber: func() *uint16 {
+					v := uint16(35243)
+					return &v
+				}(),
+				Timestamp: func() *uint32 {
+					v := uint32(717574556)
+					return &v
+				}(),
+			},
+		},
+	},
+	{
+		"multiple values",
+		base.HeaderValue{`url=rtsp://127.0.0.1/test.mkv/track1;seq=35243;rtptime=717574556,` +
+			` url=rtsp://127.0.0.1/test.mkv/track2;seq=13655`},
+		base.HeaderValue{`url=rtsp://127.0.0.1/test.mkv/track1;seq=35243;rtptime=717574556,` +
+			`url=rtsp://127.0.0.1/test.mkv/track2;seq=13655`},
+		&RTPInfo{
+			{
+				URL: "rtsp://127.0.0.1/test.mkv/track1",
+				SequenceNumber: func() *uint16 {
+					v := uint16(35243)
+					return &v
+				}(),
+				Timestamp: func() *uint32 {
+					v := uint32(717574556)
+					return &v
+				}(),
+			},
+			{
+				URL: "rtsp://127.0.0.1/test.mkv/track2",
+				SequenceNumber: func() *uint16 {
+					v := uint16(13655)
+					return &v
+				}(),
+			},
+		},
+	},
+}
+
+func TestRTPInfoRead(t *testing.T) {
+	for _, c := range casesRTPInfo {
+		t.Run(c.name, func(t *testing.T) {
+			req, err := ReadRTPInfo(c.vin)
+			require.NoError(t, err)
+			require.Equal(t, c.h, req)
+		})
+	}
+}
+
+func TestRTPInfoWrite(t *testing.T) {
+	for _, c := range casesRTPInfo {
+		t.Run(c.name, func(t *testing.T) {
+			req := c.h.Write()
+			require.Equal(t, c.vout, req)
+		})
+	}
+}
+
+func TestRTPInfoReadError(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		v    base.HeaderValue
+	}{
+		{
+			"empty",
+			base.HeaderValue{},
+		},
+		{
+			"missing url",
+			base.HeaderValue{`seq=35243`},
+		},
+		{
+			"invalid key-value",
+			base.HeaderValue{`url=rtsp://127.0.0.1/test.mkv/track1;seq`},
+		},
+		{
+			"invalid sequence number",
+			base.HeaderValue{`url=rtsp://127.0.0.1/test.mkv/track1;seq=70000`},
+		},
+		{
+			"invalid timestamp",
+			base.HeaderValue{`url=rtsp://127.0.0.1/test.mkv/track1;rtptime=a`},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			_, err := ReadRTPInfo(ca.v)
+			require.Error(t, err)
+		})
+	}
+}
@@ -57,6 +57,9 @@ type Transport struct {
 	// (optional) interleaved frame ids
 	InterleavedIds *[2]int
 
+	// (optional) SSRC of the packets of the stream
+	SSRC *uint32
+
 	// (optional) mode
 	Mode *TransportMode
 }
@@ -134,7 +137,10 @@ func ReadTransport(v base.HeaderValue) (*Transport, error) {
 
 	for _, t := range parts {
 		if strings.HasPrefix(t, "destination=") {
+			// IPv6 addresses can be enclosed in quotes and brackets
 			v := t[len("destination="):]
+			v = strings.TrimPrefix(strings.TrimSuffix(v, "\""), "\"")
+			v = strings.TrimPrefix(strings.TrimSuffix(v, "]"), "[")
 			ht.Destination = &v
 
 		} else if strings.HasPrefix(t, "ttl=") {
@@ -173,6 +179,17 @@ func ReadTransport(v base.HeaderValue) (*Transport, error) {
 			}
 			ht.InterleavedIds = ports
 
+		} else if strings.HasPrefix(t, "ssrc=") {
+			str := strings.TrimLeft(t[len("ssrc="):], " ")
+
+			// some servers send SSRCs shorter than 8 digits,
+			// others send invalid or longer ones, that are ignored.
+			v, err := strconv.ParseUint(str, 16, 32)
+			if err == nil {
+				vu := uint32(v)
+				ht.SSRC = &vu
+			}
+
 		} else if strings.HasPrefix(t, "mode=") {
 			str := strings.ToLower(t[len("mode="):])
 			str = strings.TrimPrefix(str, "\"")
@@ -218,6 +235,19 @@ func (ht Transport) Write() base.HeaderValue {
 		}
 	}
 
+	if ht.Destination != nil {
+		vals = append(vals, "destination="+*ht.Destination)
+	}
+
+	if ht.Ports != nil {
+		ports := *ht.Ports
+		vals = append(vals, "port="+strconv.FormatInt(int64(ports[0]), 10)+"-"+strconv.FormatInt(int64(ports[1]), 10))
+	}
+
+	if ht.TTL != nil {
+		vals = append(vals, "ttl="+strconv.FormatUint(uint64(*ht.TTL), 10))
+	}
+
 	if ht.ClientPorts != nil {
 		ports := *ht.ClientPorts
 		vals = append(vals, "client_port="+strconv.FormatInt(int64(ports[0]), 10)+"-"+strconv.FormatInt(int64(ports[1]), 10))
@@ -233,6 +263,10 @@ func (ht Transport) Write() base.HeaderValue {
 		vals = append(vals, "interleaved="+strconv.FormatInt(int64(ports[0]), 10)+"-"+strconv.FormatInt(int64(ports[1]), 10))
 	}
 
+	if ht.SSRC != nil {
+		vals = append(vals, "ssrc="+fmt.Sprintf("%08X", *ht.SSRC))
+	}
+
 	if ht.Mode != nil {
 		if *ht.Mode == TransportModePlay {
 			vals = append(vals, "mode=play")
@@ -48,7 +48,7 @@ var casesTransport = []struct {
 	{
 		"udp multicast play request / response",
 		base.HeaderValue{`RTP/AVP;multicast;destination=225.219.201.15;port=7000-7001;ttl=127`},
-		base.HeaderValue{`RTP/AVP;multicast`},
+		base.HeaderValue{`RTP/AVP;multicast;destination=225.219.201.15;port=7000-7001;ttl=127`},
 		&Transport{
 			Protocol: base.StreamProtocolUDP,
 			Delivery: func() *base.StreamDelivery {
@@ -66,6 +66,23 @@ var casesTransport = []struct {
 			Ports: &[2]int{7000, 7001},
 		},
 	},
+	{
+		"udp multicast play response with an ipv6 destination",
+		base.HeaderValue{`RTP/AVP;multicast;destination="[ff15::1%eth0]";port=7000-7001`},
+		base.HeaderValue{`RTP/AVP;multicast;destination=ff15::1%eth0;port=7000-7001`},
+		&Transport{
+			Protocol: base.StreamProtocolUDP,
+			Delivery: func() *base.StreamDelivery {
+				v := base.StreamDeliveryMulticast
+				return &v
+			}(),
+			Destination: func() *string {
+				v := "ff15::1%eth0"
+				return &v
+			}(),
+			Ports: &[2]int{7000, 7001},
+		},
+	},
 	{
 		"tcp play request / response",
 		base.HeaderValue{`RTP/AVP/TCP;interleaved=0-1`},
@@ -78,7 +95,7 @@ var casesTransport = []struct {
 	{
 		"udp unicast play response with a single port",
 		base.HeaderValue{`RTP/AVP/UDP;unicast;server_port=8052;client_port=14186;ssrc=39140788;mode=PLAY`},
-		base.HeaderValue{`RTP/AVP;unicast;client_port=14186-14187;server_port=8052-8053;mode=play`},
+		base.HeaderValue{`RTP/AVP;unicast;client_port=14186-14187;server_port=8052-8053;ssrc=39140788;mode=play`},
 		&Transport{
 			Protocol: base.StreamProtocolUDP,
 			Delivery: func() *base.StreamDelivery {
@@ -91,6 +108,10 @@ var casesTransport = []struct {
 			}(),
 			ClientPorts: &[2]int{14186, 14187},
 			ServerPorts: &[2]int{8052, 8053},
+			SSRC: func() *uint32 {
+				v := uint32(0x39140788)
+				return &v
+			}(),
 		},
 	},
 	{
@@ -111,6 +132,44 @@ var casesTransport = []struct {
 			ServerPorts: &[2]int{5000, 5001},
 		},
 	},
+	{
+		"tcp record with short ssrc",
+		base.HeaderValue{`RTP/AVP/TCP;unicast;interleaved=0-1;ssrc=A13C;mode=record`},
+		base.HeaderValue{`RTP/AVP/TCP;unicast;interleaved=0-1;ssrc=0000A13C;mode=record`},
+		&Transport{
+			Protocol: base.StreamProtocolTCP,
+			Delivery: func() *base.StreamDelivery {
+				v := base.StreamDeliveryUnicast
+				return &v
+			}(),
+			InterleavedIds: &[2]int{0, 1},
+			SSRC: func() *uint32 {
+				v := uint32(0xA13C)
+				return &v
+			}(),
+			Mode: func() *TransportMode {
+				v := TransportModeRecord
+				return &v
+			}(),
+		},
+	},
+	{
+		"tcp record with invalid ssrc",
+		base.HeaderValue{`RTP/AVP/TCP;unicast;interleaved=0-1;ssrc=1122334455;mode=record`},
+		base.HeaderValue{`RTP/AVP/TCP;unicast;interleaved=0-1;mode=record`},
+		&Transport{
+			Protocol: base.StreamProtocolTCP,
+			Delivery: func() *base.StreamDelivery {
+				v := base.StreamDeliveryUnicast
+				return &v
+			}(),
+			InterleavedIds: &[2]int{0, 1},
+			Mode: func() *TransportMode {
+				v := TransportModeRecord
+				return &v
+			}(),
+		},
+	},
 }
 
 func TestTransportRead(t *testing.T) {
@@ -0,0 +1,90 @@
+// Package onvifreplay contains utilities to handle the ONVIF replay mechanism.
+package onvifreplay
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/internal/ntp"
+)
+
+// ExtensionProfile is the profile of the RTP header extension
+// that is added by ONVIF recorders to every packet of a replay.
+const ExtensionProfile = 0xABAC
+
+// length of the extension, in bytes.
+const extensionSize = 12
+
+// Extension is the RTP header extension of the ONVIF replay mechanism.
+// Specification: ONVIF Streaming Specification, section 6.3
+type Extension struct {
+	// absolute time of the packet
+	NTPTime time.Time
+
+	// whether the packet belongs to an access unit that can be decoded
+	// without depending on previous ones (i.e. a key frame)
+	CleanPoint bool
+
+	// whether the packet is the last one of an access unit
+	End bool
+
+	// whether there's a discontinuity between this packet and the previous one
+	Discontinuity bool
+
+	// whether the packet is the last one of the track in the requested range
+	Terminal bool
+
+	// lower 8 bits of the CSeq of the PLAY request that triggered the transmission
+	CSeq uint8
+}
+
+// Unmarshal decodes the extension from the header of a RTP packet.
+func (e *Extension) Unmarshal(h *rtp.Header) error {
+	if !h.Extension || h.ExtensionProfile != ExtensionProfile {
+		return fmt.Errorf("replay extension not found")
+	}
+
+	buf := h.GetExtension(0)
+	if len(buf) < extensionSize {
+		return fmt.Errorf("invalid replay extension size (%d)", len(buf))
+	}
+
+	e.NTPTime = ntp.Decode(binary.BigEndian.Uint64(buf))
+	e.CleanPoint = (buf[8] & 0x80) != 0
+	e.End = (buf[8] & 0x40) != 0
+	e.Discontinuity = (buf[8] & 0x20) != 0
+	e.Terminal = (buf[8] & 0x10) != 0
+	e.CSeq = buf[9]
+
+	return nil
+}
+
+// Marshal encodes the extension into the header of a RTP packet.
+// Existing extensions are replaced.
+func (e Extension) Marshal(h *rtp.Header) {
+	buf := make([]byte, extensionSize)
+
+	binary.BigEndian.PutUint64(buf, ntp.Encode(e.NTPTime))
+
+	if e.CleanPoint {
+		buf[8] |= 0x80
+	}
+	if e.End {
+		buf[8] |= 0x40
+	}
+	if e.Discontinuity {
+		buf[8] |= 0x20
+	}
+	if e.Terminal {
+		buf[8] |= 0x10
+	}
+	buf[9] = e.CSeq
+
+	h.Extension = true
+	h.ExtensionProfile = ExtensionProfile
+	h.Extensions = nil
+	h.SetExtension(0, buf) //nolint:errcheck
+}
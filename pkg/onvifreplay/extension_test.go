@@ -0,0 +1,90 @@
+package onvifreplay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+var casesExtension = []struct {
+	name string
+	byts []byte
+	ext  Extension
+}{
+	{
+		"key frame",
+		[]byte{
+			0x90, 0x60, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+			0x9d, 0xbb, 0x78, 0x12, 0xab, 0xac, 0x00, 0x03,
+			0xe2, 0x6c, 0xdb, 0xc4, 0x80, 0x00, 0x00, 0x00,
+			0xc0, 0x05, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04,
+		},
+		Extension{
+			NTPTime:    time.Date(2020, 5, 18, 10, 2, 44, 500000000, time.UTC),
+			CleanPoint: true,
+			End:        true,
+			CSeq:       5,
+		},
+	},
+	{
+		"discontinuity and terminal",
+		[]byte{
+			0x90, 0x60, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+			0x9d, 0xbb, 0x78, 0x12, 0xab, 0xac, 0x00, 0x03,
+			0xe2, 0x6c, 0xdb, 0xc4, 0x00, 0x00, 0x00, 0x00,
+			0x30, 0xff, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04,
+		},
+		Extension{
+			NTPTime:       time.Date(2020, 5, 18, 10, 2, 44, 0, time.UTC),
+			Discontinuity: true,
+			Terminal:      true,
+			CSeq:          255,
+		},
+	},
+}
+
+func TestExtensionUnmarshal(t *testing.T) {
+	for _, ca := range casesExtension {
+		t.Run(ca.name, func(t *testing.T) {
+			var pkt rtp.Packet
+			err := pkt.Unmarshal(ca.byts)
+			require.NoError(t, err)
+
+			var ext Extension
+			err = ext.Unmarshal(&pkt.Header)
+			require.NoError(t, err)
+			require.True(t, ca.ext.NTPTime.Equal(ext.NTPTime))
+			ext.NTPTime = ca.ext.NTPTime
+			require.Equal(t, ca.ext, ext)
+		})
+	}
+}
+
+func TestExtensionMarshal(t *testing.T) {
+	for _, ca := range casesExtension {
+		t.Run(ca.name, func(t *testing.T) {
+			pkt := rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 1,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{0x01, 0x02, 0x03, 0x04},
+			}
+			ca.ext.Marshal(&pkt.Header)
+
+			byts, err := pkt.Marshal()
+			require.NoError(t, err)
+			require.Equal(t, ca.byts, byts)
+		})
+	}
+}
+
+func TestExtensionUnmarshalError(t *testing.T) {
+	var ext Extension
+	err := ext.Unmarshal(&rtp.Header{})
+	require.Error(t, err)
+}
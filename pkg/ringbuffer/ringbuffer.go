@@ -6,62 +6,184 @@ import (
 	"unsafe"
 )
 
-// RingBuffer is a ring buffer.
+// Policy is the behavior of Push() when the buffer is full.
+type Policy int
+
+// standard policies.
+const (
+	// PolicyOverwrite overwrites an item that was not pulled yet.
+	PolicyOverwrite Policy = iota
+
+	// PolicyDrop discards the pushed item.
+	PolicyDrop
+
+	// PolicyBlock waits until there's space in the buffer, or until the buffer is closed.
+	PolicyBlock
+)
+
+// Stats are the statistics of a RingBuffer.
+type Stats struct {
+	// size of the buffer.
+	Size uint64
+
+	// number of items that were pushed and not pulled yet.
+	Len uint64
+
+	// maximum number of items that were in the buffer at the same time.
+	HighWaterMark uint64
+
+	// number of items that were pushed.
+	Pushed uint64
+
+	// number of items that were discarded or overwritten because the buffer was full.
+	Dropped uint64
+}
+
+// RingBuffer is a ring buffer with a single reader and multiple writers.
 type RingBuffer struct {
-	bufferSize uint64
-	readIndex  uint64
-	writeIndex uint64
-	closed     int64
-	buffer     []unsafe.Pointer
+	// 64-bit fields must be at the beginning of the struct, since they are
+	// accessed atomically.
+	bufferSize    uint64
+	readIndex     uint64
+	writeIndex    uint64
+	closed        int64
+	highWaterMark uint64
+	pushed        uint64
+	dropped       uint64
+	policy        Policy
+	buffer        []unsafe.Pointer
 }
 
-// New allocates a RingBuffer.
+// New allocates a RingBuffer that overwrites items when it is full.
 func New(size uint64) *RingBuffer {
+	return NewWithPolicy(size, PolicyOverwrite)
+}
+
+// NewWithPolicy allocates a RingBuffer with the given policy.
+func NewWithPolicy(size uint64, policy Policy) *RingBuffer {
 	return &RingBuffer{
 		bufferSize: size,
+		policy:     policy,
 		readIndex:  1,
 		writeIndex: 0,
 		buffer:     make([]unsafe.Pointer, size),
 	}
 }
 
-// Close makes Pull() return false.
+// Close makes Pull() return false, and makes Push() return false
+// if the policy is PolicyBlock and the buffer is full.
 func (r *RingBuffer) Close() {
 	atomic.StoreInt64(&r.closed, 1)
 }
 
-// Reset restores Pull().
+// Reset restores Pull() and Push(), removes all items and clears the statistics.
 func (r *RingBuffer) Reset() {
 	for i := uint64(0); i < r.bufferSize; i++ {
 		atomic.SwapPointer(&r.buffer[i], nil)
 	}
-	atomic.SwapUint64(&r.writeIndex, 0)
-	r.readIndex = 1
+	atomic.StoreUint64(&r.writeIndex, 0)
+	atomic.StoreUint64(&r.readIndex, 1)
+	atomic.StoreUint64(&r.highWaterMark, 0)
+	atomic.StoreUint64(&r.pushed, 0)
+	atomic.StoreUint64(&r.dropped, 0)
 	atomic.StoreInt64(&r.closed, 0)
 }
 
+// len returns the number of items in the buffer, given the write index.
+func (r *RingBuffer) len(writeIndex uint64) uint64 {
+	readIndex := atomic.LoadUint64(&r.readIndex)
+	if writeIndex < readIndex {
+		return 0
+	}
+
+	l := writeIndex - readIndex + 1
+	if l > r.bufferSize {
+		return r.bufferSize
+	}
+	return l
+}
+
+func (r *RingBuffer) updateHighWaterMark(l uint64) {
+	for {
+		cur := atomic.LoadUint64(&r.highWaterMark)
+		if l <= cur || atomic.CompareAndSwapUint64(&r.highWaterMark, cur, l) {
+			return
+		}
+	}
+}
+
 // Push pushes some data at the end of the buffer.
-func (r *RingBuffer) Push(data interface{}) {
-	writeIndex := atomic.AddUint64(&r.writeIndex, 1)
-	i := writeIndex % r.bufferSize
-	atomic.SwapPointer(&r.buffer[i], unsafe.Pointer(&data))
+// It returns false if the buffer was full and, depending on the policy,
+// an item that was not pulled yet has been overwritten or the data has
+// been discarded.
+func (r *RingBuffer) Push(data interface{}) bool {
+	atomic.AddUint64(&r.pushed, 1)
+
+	if r.policy == PolicyOverwrite {
+		writeIndex := atomic.AddUint64(&r.writeIndex, 1)
+		i := writeIndex % r.bufferSize
+		old := atomic.SwapPointer(&r.buffer[i], unsafe.Pointer(&data))
+		r.updateHighWaterMark(r.len(writeIndex))
+
+		if old != nil {
+			atomic.AddUint64(&r.dropped, 1)
+			return false
+		}
+		return true
+	}
+
+	for {
+		writeIndex := atomic.LoadUint64(&r.writeIndex)
+
+		if r.len(writeIndex) >= r.bufferSize {
+			if r.policy == PolicyDrop || atomic.LoadInt64(&r.closed) == 1 {
+				atomic.AddUint64(&r.dropped, 1)
+				return false
+			}
+
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+
+		// reserve the slot
+		if !atomic.CompareAndSwapUint64(&r.writeIndex, writeIndex, writeIndex+1) {
+			continue
+		}
+
+		i := (writeIndex + 1) % r.bufferSize
+		atomic.StorePointer(&r.buffer[i], unsafe.Pointer(&data))
+		r.updateHighWaterMark(r.len(writeIndex + 1))
+		return true
+	}
 }
 
 // Pull pulls some data from the beginning of the buffer.
 func (r *RingBuffer) Pull() (interface{}, bool) {
 	for {
-		if atomic.SwapInt64(&r.closed, 0) == 1 {
+		if atomic.LoadInt64(&r.closed) == 1 {
 			return nil, false
 		}
 
-		i := r.readIndex % r.bufferSize
+		readIndex := atomic.LoadUint64(&r.readIndex)
+		i := readIndex % r.bufferSize
 		res := (*interface{})(atomic.SwapPointer(&r.buffer[i], nil))
 		if res == nil {
 			time.Sleep(10 * time.Millisecond)
 			continue
 		}
 
-		r.readIndex++
+		atomic.StoreUint64(&r.readIndex, readIndex+1)
 		return *res, true
 	}
 }
+
+// Stats returns the statistics of the buffer.
+func (r *RingBuffer) Stats() Stats {
+	return Stats{
+		Size:          r.bufferSize,
+		Len:           r.len(atomic.LoadUint64(&r.writeIndex)),
+		HighWaterMark: atomic.LoadUint64(&r.highWaterMark),
+		Pushed:        atomic.LoadUint64(&r.pushed),
+		Dropped:       atomic.LoadUint64(&r.dropped),
+	}
+}
@@ -0,0 +1,99 @@
+package ringbuffer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicies(t *testing.T) {
+	for _, ca := range []struct {
+		name   string
+		policy Policy
+		pushed []bool
+		pulled []interface{}
+		stats  Stats
+	}{
+		{
+			"overwrite",
+			PolicyOverwrite,
+			[]bool{true, true, true, false},
+			[]interface{}{4, 2, 3},
+			Stats{
+				Size:          3,
+				Len:           3,
+				HighWaterMark: 3,
+				Pushed:        4,
+				Dropped:       1,
+			},
+		},
+		{
+			"drop",
+			PolicyDrop,
+			[]bool{true, true, true, false},
+			[]interface{}{1, 2, 3},
+			Stats{
+				Size:          3,
+				Len:           3,
+				HighWaterMark: 3,
+				Pushed:        4,
+				Dropped:       1,
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			r := NewWithPolicy(3, ca.policy)
+
+			for i, expected := range ca.pushed {
+				require.Equal(t, expected, r.Push(i+1))
+			}
+
+			require.Equal(t, ca.stats, r.Stats())
+
+			for _, expected := range ca.pulled {
+				item, ok := r.Pull()
+				require.True(t, ok)
+				require.Equal(t, expected, item)
+			}
+
+			r.Reset()
+			require.Equal(t, Stats{Size: 3}, r.Stats())
+		})
+	}
+}
+
+func TestPolicyBlock(t *testing.T) {
+	r := NewWithPolicy(2, PolicyBlock)
+
+	require.True(t, r.Push(1))
+	require.True(t, r.Push(2))
+
+	pushed := make(chan bool)
+	go func() {
+		pushed <- r.Push(3)
+	}()
+
+	select {
+	case <-pushed:
+		t.Errorf("should not happen")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	item, ok := r.Pull()
+	require.True(t, ok)
+	require.Equal(t, 1, item)
+	require.True(t, <-pushed)
+
+	go func() {
+		pushed <- r.Push(4)
+	}()
+
+	r.Close()
+	require.False(t, <-pushed)
+
+	_, ok = r.Pull()
+	require.False(t, ok)
+
+	require.Equal(t, uint64(1), r.Stats().Dropped)
+}
@@ -0,0 +1,47 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+// Typed is a RingBuffer that contains items of a given type.
+type Typed[T any] struct {
+	r *RingBuffer
+}
+
+// NewTyped allocates a Typed with the given policy.
+func NewTyped[T any](size uint64, policy Policy) *Typed[T] {
+	return &Typed[T]{
+		r: NewWithPolicy(size, policy),
+	}
+}
+
+// Close makes Pull() return false.
+func (t *Typed[T]) Close() {
+	t.r.Close()
+}
+
+// Reset restores Pull() and Push(), removes all items and clears the statistics.
+func (t *Typed[T]) Reset() {
+	t.r.Reset()
+}
+
+// Push pushes an item at the end of the buffer.
+// It returns false if the buffer was full.
+func (t *Typed[T]) Push(item T) bool {
+	return t.r.Push(item)
+}
+
+// Pull pulls an item from the beginning of the buffer.
+func (t *Typed[T]) Pull() (T, bool) {
+	item, ok := t.r.Pull()
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return item.(T), true
+}
+
+// Stats returns the statistics of the buffer.
+func (t *Typed[T]) Stats() Stats {
+	return t.r.Stats()
+}
@@ -0,0 +1,33 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTyped(t *testing.T) {
+	r := NewTyped[[]byte](4, PolicyDrop)
+
+	require.True(t, r.Push([]byte{0x01, 0x02}))
+	require.True(t, r.Push([]byte{0x03, 0x04}))
+
+	item, ok := r.Pull()
+	require.True(t, ok)
+	require.Equal(t, []byte{0x01, 0x02}, item)
+
+	require.Equal(t, Stats{
+		Size:          4,
+		Len:           1,
+		HighWaterMark: 2,
+		Pushed:        2,
+	}, r.Stats())
+
+	r.Close()
+	item, ok = r.Pull()
+	require.False(t, ok)
+	require.Nil(t, item)
+}
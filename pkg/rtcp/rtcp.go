@@ -0,0 +1,173 @@
+// Package rtcp contains the RTCP packets that are sent and received
+// through frames of type StreamTypeRTCP.
+package rtcp
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	prtcp "github.com/pion/rtcp"
+)
+
+// Packet is a RTCP packet.
+type Packet = prtcp.Packet
+
+// SenderReport is a sender report (SR) packet.
+type SenderReport = prtcp.SenderReport
+
+// ReceiverReport is a receiver report (RR) packet.
+type ReceiverReport = prtcp.ReceiverReport
+
+// ReceptionReport is a reception report block, contained in SR and RR packets.
+type ReceptionReport = prtcp.ReceptionReport
+
+// SourceDescription is a source description (SDES) packet.
+type SourceDescription = prtcp.SourceDescription
+
+// SourceDescriptionChunk is a chunk of a SDES packet.
+type SourceDescriptionChunk = prtcp.SourceDescriptionChunk
+
+// SourceDescriptionItem is an item of a SDES chunk.
+type SourceDescriptionItem = prtcp.SourceDescriptionItem
+
+// Goodbye is a goodbye (BYE) packet.
+type Goodbye = prtcp.Goodbye
+
+// App is an application-defined (APP) packet.
+type App struct {
+	// subtype
+	SubType uint8
+
+	// SSRC or CSRC of the source
+	SSRC uint32
+
+	// name, made of four ASCII characters
+	Name [4]byte
+
+	// application-dependent data, whose length must be a multiple of 4
+	Data []byte
+}
+
+const (
+	rtcpVersion       = 2
+	rtcpHeaderSize    = 4
+	appHeaderSize     = rtcpHeaderSize + 8
+	appSubTypeMaxSize = 0x1F
+)
+
+// Marshal encodes an App packet.
+func (p App) Marshal() ([]byte, error) {
+	if p.SubType > appSubTypeMaxSize {
+		return nil, fmt.Errorf("invalid subtype (%d)", p.SubType)
+	}
+
+	if (len(p.Data) % 4) != 0 {
+		return nil, fmt.Errorf("data length must be a multiple of 4")
+	}
+
+	buf := make([]byte, appHeaderSize+len(p.Data))
+	buf[0] = rtcpVersion<<6 | p.SubType
+	buf[1] = uint8(prtcp.TypeApplicationDefined)
+	binary.BigEndian.PutUint16(buf[2:], uint16((len(buf)/4)-1))
+	binary.BigEndian.PutUint32(buf[4:], p.SSRC)
+	copy(buf[8:], p.Name[:])
+	copy(buf[appHeaderSize:], p.Data)
+
+	return buf, nil
+}
+
+// Unmarshal decodes an App packet.
+func (p *App) Unmarshal(buf []byte) error {
+	var h prtcp.Header
+	err := h.Unmarshal(buf)
+	if err != nil {
+		return err
+	}
+
+	if h.Type != prtcp.TypeApplicationDefined {
+		return fmt.Errorf("wrong packet type (%d)", h.Type)
+	}
+
+	size := (int(h.Length) + 1) * 4
+	if size < appHeaderSize || size > len(buf) {
+		return fmt.Errorf("invalid length")
+	}
+
+	data := buf[appHeaderSize:size]
+
+	if h.Padding {
+		if len(data) == 0 || int(data[len(data)-1]) > len(data) {
+			return fmt.Errorf("invalid padding")
+		}
+		data = data[:len(data)-int(data[len(data)-1])]
+	}
+
+	p.SubType = h.Count
+	p.SSRC = binary.BigEndian.Uint32(buf[4:])
+	copy(p.Name[:], buf[8:12])
+	p.Data = append([]byte(nil), data...)
+
+	return nil
+}
+
+// DestinationSSRC implements Packet.
+func (p *App) DestinationSSRC() []uint32 {
+	return []uint32{p.SSRC}
+}
+
+// Unmarshal decodes the RTCP packets contained in a frame.
+func Unmarshal(buf []byte) ([]Packet, error) {
+	var ret []Packet
+
+	for len(buf) > 0 {
+		var h prtcp.Header
+		err := h.Unmarshal(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		size := (int(h.Length) + 1) * 4
+		if size > len(buf) {
+			return nil, fmt.Errorf("packet is too short")
+		}
+
+		if h.Type == prtcp.TypeApplicationDefined {
+			p := &App{}
+			err := p.Unmarshal(buf[:size])
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, p)
+
+		} else {
+			pkts, err := prtcp.Unmarshal(buf[:size])
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, pkts...)
+		}
+
+		buf = buf[size:]
+	}
+
+	if ret == nil {
+		return nil, fmt.Errorf("frame doesn't contain any packet")
+	}
+
+	return ret, nil
+}
+
+// Marshal encodes RTCP packets into a frame.
+func Marshal(pkts []Packet) ([]byte, error) {
+	var ret []byte
+
+	for _, p := range pkts {
+		byts, err := p.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, byts...)
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,67 @@
+package rtcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp(t *testing.T) {
+	p := &App{
+		SubType: 3,
+		SSRC:    0x902f9e2e,
+		Name:    [4]byte{'T', 'E', 'S', 'T'},
+		Data:    []byte{0x01, 0x02, 0x03, 0x04},
+	}
+
+	byts, err := p.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, []byte{
+		0x83, 0xcc, 0x00, 0x03,
+		0x90, 0x2f, 0x9e, 0x2e,
+		'T', 'E', 'S', 'T',
+		0x01, 0x02, 0x03, 0x04,
+	}, byts)
+
+	var dec App
+	err = dec.Unmarshal(byts)
+	require.NoError(t, err)
+	require.Equal(t, p, &dec)
+}
+
+func TestUnmarshalCompound(t *testing.T) {
+	sr := &SenderReport{
+		SSRC:        0x902f9e2e,
+		NTPTime:     0xda8bd1fcdddda05a,
+		RTPTime:     0xaaf4edd5,
+		PacketCount: 1,
+		OctetCount:  2,
+	}
+	sdes := &SourceDescription{
+		Chunks: []SourceDescriptionChunk{{
+			Source: 0x902f9e2e,
+			Items: []SourceDescriptionItem{{
+				Type: 1,
+				Text: "{9c00eb92-1afb-9d49-a47d-91f64eee69f5}",
+			}},
+		}},
+	}
+	app := &App{
+		SSRC: 0x902f9e2e,
+		Name: [4]byte{'T', 'E', 'S', 'T'},
+	}
+	bye := &Goodbye{
+		Sources: []uint32{0x902f9e2e},
+	}
+
+	byts, err := Marshal([]Packet{sr, sdes, app, bye})
+	require.NoError(t, err)
+
+	pkts, err := Unmarshal(byts)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(pkts))
+	require.Equal(t, sr, pkts[0])
+	require.Equal(t, sdes, pkts[1])
+	require.Equal(t, app, pkts[2])
+	require.Equal(t, bye, pkts[3])
+}
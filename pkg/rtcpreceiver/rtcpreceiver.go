@@ -1,28 +1,43 @@
-// Package rtcpreceiver implements a utility to generate RTCP receiver reports.
+// Package rtcpreceiver implements a utility to generate RTCP receiver reports
+// and to compute the absolute time of RTP packets.
 package rtcpreceiver
 
 import (
+	"math"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pion/rtcp"
 
+	"github.com/aler9/gortsplib/internal/ntp"
 	"github.com/aler9/gortsplib/pkg/base"
 )
 
 // RTCPReceiver is a utility to generate RTCP receiver reports.
 type RTCPReceiver struct {
+	// statistics, that are read by Stats() without locking the mutex.
+	// 64-bit fields are placed first, in order to be aligned on 32-bit platforms.
+	statsReceived           uint64
+	statsLost               uint64
+	statsBytes              uint64
+	statsJitter             uint64 // bits of a float64
+	statsLastSequenceNumber uint32
+	statsLastRTPTime        uint32
+
 	receiverSSRC uint32
 	clockRate    float64
 	mutex        sync.Mutex
 
 	// data from rtp packets
 	firstRTPReceived     bool
+	ssrc                 uint32
 	sequenceNumberCycles uint16
 	lastSequenceNumber   uint16
 	lastRTPTimeRTP       uint32
 	lastRTPTimeTime      time.Time
+	ptsTicks             int64
 	totalLost            uint32
 	totalLostSinceReport uint32
 	totalSinceReport     uint32
@@ -32,6 +47,9 @@ type RTCPReceiver struct {
 	senderSSRC           uint32
 	lastSenderReport     uint32
 	lastSenderReportTime time.Time
+	senderReportReceived bool
+	senderReportNTP      time.Time
+	senderReportRTP      uint32
 }
 
 // New allocates a RTCPReceiver.
@@ -48,23 +66,52 @@ func New(receiverSSRC *uint32, clockRate int) *RTCPReceiver {
 }
 
 // ProcessFrame extracts the needed data from RTP or RTCP frames.
-func (rr *RTCPReceiver) ProcessFrame(ts time.Time, streamType base.StreamType, buf []byte) {
+// It returns the number of RTP packets that have been lost before the frame,
+// computed from gaps in sequence numbers.
+// When the SSRC of RTP packets changes, the sequence numbers of the new source
+// are not compared with the ones of the previous source, and the mapping between
+// RTP and NTP time is discarded until a new sender report is received.
+func (rr *RTCPReceiver) ProcessFrame(ts time.Time, streamType base.StreamType, buf []byte) int {
 	rr.mutex.Lock()
 	defer rr.mutex.Unlock()
 
+	lost := 0
+
 	if streamType == base.StreamTypeRTP {
 		// do not parse the entire packet, extract only the fields we need
-		if len(buf) >= 8 {
+		if len(buf) >= 12 {
+			atomic.AddUint64(&rr.statsReceived, 1)
+			atomic.AddUint64(&rr.statsBytes, uint64(len(buf)))
+
 			sequenceNumber := uint16(buf[2])<<8 | uint16(buf[3])
 			rtpTime := uint32(buf[4])<<24 | uint32(buf[5])<<16 | uint32(buf[6])<<8 | uint32(buf[7])
+			ssrc := uint32(buf[8])<<24 | uint32(buf[9])<<16 | uint32(buf[10])<<8 | uint32(buf[11])
 
 			// first frame
 			if !rr.firstRTPReceived {
 				rr.firstRTPReceived = true
+				rr.ssrc = ssrc
+				rr.senderSSRC = ssrc
 				rr.totalSinceReport = 1
 				rr.lastSequenceNumber = sequenceNumber
 				rr.lastRTPTimeRTP = rtpTime
 				rr.lastRTPTimeTime = ts
+				rr.ptsTicks = 0
+
+				// source changed, restart from this frame
+				// while keeping the presentation time continuous
+			} else if ssrc != rr.ssrc {
+				rr.ssrc = ssrc
+				rr.senderSSRC = ssrc
+				rr.senderReportReceived = false
+				rr.sequenceNumberCycles = 0
+				rr.totalLost = 0
+				rr.totalLostSinceReport = 0
+				rr.totalSinceReport = 1
+				rr.jitter = 0
+				rr.lastSequenceNumber = sequenceNumber
+				rr.lastRTPTimeRTP = rtpTime
+				rr.lastRTPTimeTime = ts
 
 				// subsequent frames
 			} else {
@@ -79,7 +126,9 @@ func (rr *RTCPReceiver) ProcessFrame(ts time.Time, streamType base.StreamType, b
 
 					// detect lost frames
 					if sequenceNumber != (rr.lastSequenceNumber + 1) {
+						lost = int(uint16(diff) - 1)
 						rr.totalLost += uint32(uint16(diff) - 1)
+						atomic.AddUint64(&rr.statsLost, uint64(uint16(diff)-1))
 						rr.totalLostSinceReport += uint32(uint16(diff) - 1)
 
 						// allow up to 24 bits
@@ -100,6 +149,7 @@ func (rr *RTCPReceiver) ProcessFrame(ts time.Time, streamType base.StreamType, b
 					}
 					rr.jitter += (D - rr.jitter) / 16
 
+					rr.ptsTicks += int64(int32(rtpTime - rr.lastRTPTimeRTP))
 					rr.totalSinceReport += uint32(uint16(diff))
 					rr.lastSequenceNumber = sequenceNumber
 					rr.lastRTPTimeRTP = rtpTime
@@ -107,6 +157,10 @@ func (rr *RTCPReceiver) ProcessFrame(ts time.Time, streamType base.StreamType, b
 				}
 				// ignore invalid frames (diff = 0) or reordered frames (diff < 0)
 			}
+
+			atomic.StoreUint64(&rr.statsJitter, math.Float64bits(rr.jitter))
+			atomic.StoreUint32(&rr.statsLastSequenceNumber, uint32(rr.lastSequenceNumber))
+			atomic.StoreUint32(&rr.statsLastRTPTime, rr.lastRTPTimeRTP)
 		}
 
 	} else {
@@ -119,10 +173,15 @@ func (rr *RTCPReceiver) ProcessFrame(ts time.Time, streamType base.StreamType, b
 					rr.senderSSRC = sr.SSRC
 					rr.lastSenderReport = uint32(sr.NTPTime >> 16)
 					rr.lastSenderReportTime = ts
+					rr.senderReportReceived = true
+					rr.senderReportNTP = ntp.Decode(sr.NTPTime)
+					rr.senderReportRTP = sr.RTPTime
 				}
 			}
 		}
 	}
+
+	return lost
 }
 
 // Report generates a RTCP receiver report.
@@ -139,8 +198,13 @@ func (rr *RTCPReceiver) Report(ts time.Time) []byte {
 				LastSenderReport:   rr.lastSenderReport,
 				// equivalent to taking the integer part after multiplying the
 				// loss fraction by 256
-				FractionLost: uint8(float64(rr.totalLostSinceReport*256) / float64(rr.totalSinceReport)),
-				TotalLost:    rr.totalLost,
+				FractionLost: func() uint8 {
+					if rr.totalSinceReport == 0 {
+						return 0
+					}
+					return uint8(float64(rr.totalLostSinceReport*256) / float64(rr.totalSinceReport))
+				}(),
+				TotalLost: rr.totalLost,
 				// delay, expressed in units of 1/65536 seconds, between
 				// receiving the last SR packet from source SSRC_n and sending this
 				// reception report block
@@ -160,3 +224,85 @@ func (rr *RTCPReceiver) Report(ts time.Time) []byte {
 
 	return byts
 }
+
+// Stats are statistics about the received RTP packets.
+type Stats struct {
+	// number of received packets.
+	PacketsReceived uint64
+
+	// number of lost packets, computed from gaps in sequence numbers.
+	PacketsLost uint64
+
+	// number of received bytes, including RTP headers.
+	BytesReceived uint64
+
+	// interarrival jitter.
+	Jitter time.Duration
+
+	// sequence number of the last received packet.
+	LastSequenceNumber uint16
+
+	// timestamp of the last received packet.
+	LastRTPTime uint32
+}
+
+// Stats returns statistics about the received RTP packets.
+// It doesn't lock the RTCPReceiver, therefore it can be called frequently
+// without slowing down ProcessFrame(); fields are read one by one and
+// are not guaranteed to be consistent with each other.
+func (rr *RTCPReceiver) Stats() Stats {
+	jitter := math.Float64frombits(atomic.LoadUint64(&rr.statsJitter))
+
+	return Stats{
+		PacketsReceived:    atomic.LoadUint64(&rr.statsReceived),
+		PacketsLost:        atomic.LoadUint64(&rr.statsLost),
+		BytesReceived:      atomic.LoadUint64(&rr.statsBytes),
+		Jitter:             time.Duration(jitter * float64(time.Second) / rr.clockRate),
+		LastSequenceNumber: uint16(atomic.LoadUint32(&rr.statsLastSequenceNumber)),
+		LastRTPTime:        atomic.LoadUint32(&rr.statsLastRTPTime),
+	}
+}
+
+// SSRC returns the SSRC of the last received RTP packet.
+// It returns false if no RTP packet has been received yet.
+func (rr *RTCPReceiver) SSRC() (uint32, bool) {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+
+	return rr.ssrc, rr.firstRTPReceived
+}
+
+// PacketNTP returns the absolute time of a RTP packet with the given timestamp,
+// computed by using the mapping between RTP and NTP time provided by the last
+// RTCP sender report.
+// It returns false if no sender report has been received yet.
+func (rr *RTCPReceiver) PacketNTP(rtpTime uint32) (time.Time, bool) {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+
+	if !rr.senderReportReceived {
+		return time.Time{}, false
+	}
+
+	ticks := int32(rtpTime - rr.senderReportRTP)
+	return rr.senderReportNTP.Add(rr.ticksToDuration(int64(ticks))), true
+}
+
+// PacketPTS returns the presentation time of a RTP packet with the given timestamp,
+// relative to the first received RTP packet.
+// It returns false if no RTP packet has been received yet.
+func (rr *RTCPReceiver) PacketPTS(rtpTime uint32) (time.Duration, bool) {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+
+	if !rr.firstRTPReceived {
+		return 0, false
+	}
+
+	ticks := rr.ptsTicks + int64(int32(rtpTime-rr.lastRTPTimeRTP))
+	return rr.ticksToDuration(ticks), true
+}
+
+func (rr *RTCPReceiver) ticksToDuration(ticks int64) time.Duration {
+	return time.Duration(float64(ticks) * float64(time.Second) / rr.clockRate)
+}
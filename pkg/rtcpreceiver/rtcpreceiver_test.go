@@ -387,3 +387,238 @@ func TestRTCPReceiverJitter(t *testing.T) {
 	ts = time.Date(2008, 05, 20, 22, 15, 22, 0, time.UTC)
 	require.Equal(t, expected, rr.Report(ts))
 }
+
+func TestRTCPReceiverNoRTPPackets(t *testing.T) {
+	v := uint32(0x65f83afb)
+	rr := New(&v, 90000)
+
+	srPkt := rtcp.SenderReport{
+		SSRC:        0xba9da416,
+		NTPTime:     0xe363887a17ced916,
+		RTPTime:     0xafb45733,
+		PacketCount: 714,
+		OctetCount:  859127,
+	}
+	byts, _ := srPkt.Marshal()
+	ts := time.Date(2008, 05, 20, 22, 15, 20, 0, time.UTC)
+	rr.ProcessFrame(ts, base.StreamTypeRTCP, byts)
+
+	expectedPkt := rtcp.ReceiverReport{
+		SSRC: 0x65f83afb,
+		Reports: []rtcp.ReceptionReport{
+			{
+				SSRC:             0xba9da416,
+				LastSenderReport: 0x887a17ce,
+				Delay:            1 * 65536,
+			},
+		},
+	}
+	expected, _ := expectedPkt.Marshal()
+	ts = time.Date(2008, 05, 20, 22, 15, 21, 0, time.UTC)
+	require.Equal(t, expected, rr.Report(ts))
+}
+
+func TestRTCPReceiverPacketTime(t *testing.T) {
+	rr := New(nil, 90000)
+
+	_, ok := rr.PacketNTP(0xafb45733)
+	require.Equal(t, false, ok)
+
+	_, ok = rr.PacketPTS(0xafb45733)
+	require.Equal(t, false, ok)
+
+	rtpPkt := rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 946,
+			Timestamp:      0xafb45733 - 90000,
+			SSRC:           0xba9da416,
+		},
+		Payload: []byte("\x00\x00"),
+	}
+	byts, _ := rtpPkt.Marshal()
+	ts := time.Date(2008, 05, 20, 22, 15, 20, 0, time.UTC)
+	rr.ProcessFrame(ts, base.StreamTypeRTP, byts)
+
+	srPkt := rtcp.SenderReport{
+		SSRC:        0xba9da416,
+		NTPTime:     0xe363887a17ced916,
+		RTPTime:     0xafb45733,
+		PacketCount: 714,
+		OctetCount:  859127,
+	}
+	byts, _ = srPkt.Marshal()
+	ts = time.Date(2008, 05, 20, 22, 15, 21, 0, time.UTC)
+	rr.ProcessFrame(ts, base.StreamTypeRTCP, byts)
+
+	ntp, ok := rr.PacketNTP(0xafb45733 + 45000)
+	require.Equal(t, true, ok)
+	require.Equal(t, time.Date(2020, 11, 21, 12, 37, 14, 592999999, time.UTC), ntp.UTC())
+
+	rtpPkt.SequenceNumber = 947
+	rtpPkt.Timestamp = 0xafb45733 + 90000
+	byts, _ = rtpPkt.Marshal()
+	ts = time.Date(2008, 05, 20, 22, 15, 22, 0, time.UTC)
+	rr.ProcessFrame(ts, base.StreamTypeRTP, byts)
+
+	pts, ok := rr.PacketPTS(0xafb45733 + 90000)
+	require.Equal(t, true, ok)
+	require.Equal(t, 2*time.Second, pts)
+
+	pts, ok = rr.PacketPTS(0xafb45733 + 45000)
+	require.Equal(t, true, ok)
+	require.Equal(t, 1500*time.Millisecond, pts)
+}
+
+func TestRTCPReceiverStats(t *testing.T) {
+	rr := New(nil, 90000)
+
+	for i, seq := range []uint16{946, 947, 950} {
+		rtpPkt := rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    96,
+				SequenceNumber: seq,
+				Timestamp:      0xafb45733 + uint32(i)*90000,
+				SSRC:           0xba9da416,
+			},
+			Payload: []byte("\x00\x00"),
+		}
+		byts, _ := rtpPkt.Marshal()
+		ts := time.Date(2008, 05, 20, 22, 15, 20+i, 0, time.UTC)
+		lost := rr.ProcessFrame(ts, base.StreamTypeRTP, byts)
+		require.Equal(t, []int{0, 0, 2}[i], lost)
+	}
+
+	require.Equal(t, Stats{
+		PacketsReceived:    3,
+		PacketsLost:        2,
+		BytesReceived:      42,
+		LastSequenceNumber: 950,
+		LastRTPTime:        0xafb45733 + 2*90000,
+	}, rr.Stats())
+}
+
+func TestRTCPReceiverStatsConcurrent(t *testing.T) {
+	rr := New(nil, 90000)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 1000; i++ {
+			rtpPkt := rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: uint16(i),
+					Timestamp:      uint32(i) * 3000,
+					SSRC:           0xba9da416,
+				},
+				Payload: []byte("\x00\x00"),
+			}
+			byts, _ := rtpPkt.Marshal()
+			rr.ProcessFrame(time.Now(), base.StreamTypeRTP, byts)
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			require.Equal(t, uint64(1000), rr.Stats().PacketsReceived)
+			require.Equal(t, uint16(999), rr.Stats().LastSequenceNumber)
+			return
+
+		default:
+			rr.Stats()
+		}
+	}
+}
+
+func TestRTCPReceiverSSRCChange(t *testing.T) {
+	v := uint32(0x65f83afb)
+	rr := New(&v, 90000)
+
+	_, ok := rr.SSRC()
+	require.Equal(t, false, ok)
+
+	srPkt := rtcp.SenderReport{
+		SSRC:        0xba9da416,
+		NTPTime:     0xe363887a17ced916,
+		RTPTime:     1287981738,
+		PacketCount: 714,
+		OctetCount:  859127,
+	}
+	byts, _ := srPkt.Marshal()
+	ts := time.Date(2008, 05, 20, 22, 15, 20, 0, time.UTC)
+	rr.ProcessFrame(ts, base.StreamTypeRTCP, byts)
+
+	rtpPkt := rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 0x0120,
+			Timestamp:      0xafb45733,
+			SSRC:           0xba9da416,
+		},
+		Payload: []byte("\x00\x00"),
+	}
+	byts, _ = rtpPkt.Marshal()
+	rr.ProcessFrame(ts, base.StreamTypeRTP, byts)
+
+	ssrc, ok := rr.SSRC()
+	require.Equal(t, true, ok)
+	require.Equal(t, uint32(0xba9da416), ssrc)
+
+	_, ok = rr.PacketNTP(0xafb45733)
+	require.Equal(t, true, ok)
+
+	// the source restarts with different sequence numbers and timestamps
+	rtpPkt = rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 0x5000,
+			Timestamp:      0x10000000,
+			SSRC:           0x12345678,
+		},
+		Payload: []byte("\x00\x00"),
+	}
+	byts, _ = rtpPkt.Marshal()
+	ts = time.Date(2008, 05, 20, 22, 15, 21, 0, time.UTC)
+	lost := rr.ProcessFrame(ts, base.StreamTypeRTP, byts)
+	require.Equal(t, 0, lost)
+
+	ssrc, ok = rr.SSRC()
+	require.Equal(t, true, ok)
+	require.Equal(t, uint32(0x12345678), ssrc)
+
+	_, ok = rr.PacketNTP(0x10000000)
+	require.Equal(t, false, ok)
+
+	pts, ok := rr.PacketPTS(0x10000000)
+	require.Equal(t, true, ok)
+	require.Equal(t, time.Duration(0), pts)
+
+	require.Equal(t, uint64(0), rr.Stats().PacketsLost)
+
+	expectedPkt := rtcp.ReceiverReport{
+		SSRC: 0x65f83afb,
+		Reports: []rtcp.ReceptionReport{
+			{
+				SSRC:               0x12345678,
+				LastSequenceNumber: 0x5000,
+				LastSenderReport:   0x887a17ce,
+				Delay:              2 * 65536,
+			},
+		},
+	}
+	expected, _ := expectedPkt.Marshal()
+	ts = time.Date(2008, 05, 20, 22, 15, 22, 0, time.UTC)
+	require.Equal(t, expected, rr.Report(ts))
+}
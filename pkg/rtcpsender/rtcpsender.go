@@ -8,6 +8,7 @@ import (
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
 
+	"github.com/aler9/gortsplib/internal/ntp"
 	"github.com/aler9/gortsplib/pkg/base"
 )
 
@@ -23,6 +24,10 @@ type RTCPSender struct {
 	lastRTPTimeTime  time.Time
 	packetCount      uint32
 	octetCount       uint32
+
+	// data from rtcp receiver reports
+	receiverReportReceived bool
+	remoteStats            RemoteStats
 }
 
 // New allocates a RTCPSender.
@@ -67,16 +72,8 @@ func (rs *RTCPSender) Report(ts time.Time) []byte {
 	}
 
 	report := &rtcp.SenderReport{
-		SSRC: rs.senderSSRC,
-		NTPTime: func() uint64 {
-			// seconds since 1st January 1900
-			s := (float64(ts.UnixNano()) / 1000000000) + 2208988800
-
-			// higher 32 bits are the integer part, lower 32 bits are the fractional part
-			integerPart := uint32(s)
-			fractionalPart := uint32((s - float64(integerPart)) * 0xFFFFFFFF)
-			return uint64(integerPart)<<32 | uint64(fractionalPart)
-		}(),
+		SSRC:        rs.senderSSRC,
+		NTPTime:     ntp.Encode(ts),
 		RTPTime:     rs.lastRTPTimeRTP + uint32((ts.Sub(rs.lastRTPTimeTime)).Seconds()*rs.clockRate),
 		PacketCount: rs.packetCount,
 		OctetCount:  rs.octetCount,
@@ -89,3 +86,87 @@ func (rs *RTCPSender) Report(ts time.Time) []byte {
 
 	return byts
 }
+
+// ProcessReceiverReport extracts the needed data from RTCP frames sent by
+// the receiver of the stream, that contain receiver reports.
+func (rs *RTCPSender) ProcessReceiverReport(ts time.Time, buf []byte) {
+	frames, err := rtcp.Unmarshal(buf)
+	if err != nil {
+		return
+	}
+
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	if !rs.firstRTPReceived {
+		return
+	}
+
+	for _, frame := range frames {
+		var reports []rtcp.ReceptionReport
+
+		switch tframe := frame.(type) {
+		case *rtcp.ReceiverReport:
+			reports = tframe.Reports
+
+		case *rtcp.SenderReport:
+			reports = tframe.Reports
+		}
+
+		for _, report := range reports {
+			if report.SSRC != rs.senderSSRC {
+				continue
+			}
+
+			rs.receiverReportReceived = true
+			rs.remoteStats = RemoteStats{
+				FractionLost:       float64(report.FractionLost) / 256,
+				PacketsLost:        report.TotalLost,
+				Jitter:             time.Duration(float64(report.Jitter) * float64(time.Second) / rs.clockRate),
+				LastSequenceNumber: report.LastSequenceNumber,
+			}
+
+			// the round-trip time can be computed only when the receiver
+			// has received a sender report.
+			// https://tools.ietf.org/html/rfc3550#page-40
+			if report.LastSenderReport != 0 {
+				now := uint32(ntp.Encode(ts) >> 16)
+				rtt := int32(now - report.LastSenderReport - report.Delay)
+				if rtt > 0 {
+					// rtt is expressed in units of 1/65536 seconds
+					rs.remoteStats.RTT = time.Duration(int64(rtt) * int64(time.Second) / 65536)
+				}
+			}
+		}
+	}
+}
+
+// RemoteStats are statistics about the sent RTP packets, provided by
+// the receiver of the stream through RTCP receiver reports.
+type RemoteStats struct {
+	// fraction of packets lost since the previous report, between 0 and 1.
+	FractionLost float64
+
+	// cumulative number of lost packets.
+	PacketsLost uint32
+
+	// interarrival jitter.
+	Jitter time.Duration
+
+	// round-trip time between the sender and the receiver.
+	// It is zero if it can't be computed, since the receiver has not
+	// received any sender report yet.
+	RTT time.Duration
+
+	// extended highest sequence number received.
+	LastSequenceNumber uint32
+}
+
+// RemoteStats returns the statistics contained in the last receiver report.
+// It returns false if no receiver report has been received yet.
+func (rs *RTCPSender) RemoteStats() (RemoteStats, bool) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	return rs.remoteStats, rs.receiverReportReceived
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/pion/rtp"
 	"github.com/stretchr/testify/require"
 
+	"github.com/aler9/gortsplib/internal/ntp"
 	"github.com/aler9/gortsplib/pkg/base"
 )
 
@@ -46,7 +47,7 @@ func TestRTCPSender(t *testing.T) {
 
 	expectedPkt := rtcp.SenderReport{
 		SSRC:        0xba9da416,
-		NTPTime:     0xcbddcc34999997ff,
+		NTPTime:     0xcbddcc349999999a,
 		RTPTime:     0x4d185ae8,
 		PacketCount: 2,
 		OctetCount:  4,
@@ -55,3 +56,59 @@ func TestRTCPSender(t *testing.T) {
 	ts = time.Date(2008, 05, 20, 22, 16, 20, 600000000, time.UTC)
 	require.Equal(t, expected, rs.Report(ts))
 }
+
+func TestRTCPSenderRemoteStats(t *testing.T) {
+	rs := New(90000)
+
+	_, ok := rs.RemoteStats()
+	require.Equal(t, false, ok)
+
+	rtpPkt := rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 946,
+			Timestamp:      1287987768,
+			SSRC:           0xba9da416,
+		},
+		Payload: []byte("\x00\x00"),
+	}
+	byts, _ := rtpPkt.Marshal()
+	ts := time.Date(2008, 05, 20, 22, 15, 20, 0, time.UTC)
+	rs.ProcessFrame(ts, base.StreamTypeRTP, byts)
+
+	// sender report sent at 22:15:20, receiver report sent 1 second after
+	// receiving it and received at 22:15:21.5
+	rrPkt := rtcp.ReceiverReport{
+		SSRC: 0x65f83afb,
+		Reports: []rtcp.ReceptionReport{
+			{
+				SSRC:               0xba9da416,
+				FractionLost:       64,
+				TotalLost:          12,
+				LastSequenceNumber: 0x103b2,
+				Jitter:             900,
+				LastSenderReport:   uint32(ntp.Encode(ts) >> 16),
+				Delay:              65536,
+			},
+			{
+				SSRC:      0x12345678,
+				TotalLost: 100,
+			},
+		},
+	}
+	byts, _ = rrPkt.Marshal()
+	rs.ProcessReceiverReport(ts.Add(1500*time.Millisecond), byts)
+
+	stats, ok := rs.RemoteStats()
+	require.Equal(t, true, ok)
+	require.InDelta(t, float64(500*time.Millisecond), float64(stats.RTT), float64(100*time.Microsecond))
+	stats.RTT = 0
+	require.Equal(t, RemoteStats{
+		FractionLost:       0.25,
+		PacketsLost:        12,
+		Jitter:             10 * time.Millisecond,
+		LastSequenceNumber: 0x103b2,
+	}, stats)
+}
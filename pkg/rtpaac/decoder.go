@@ -0,0 +1,125 @@
+package rtpaac
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/rtptime"
+)
+
+// Decoder is a RTP/AAC decoder.
+// It supports the AAC-hbr mode, with sizelength=13 and indexlength=3,
+// that is the one used by NewTrackAAC.
+type Decoder struct {
+	timeDecoder *rtptime.Decoder
+
+	// for fragmented AUs
+	fragmentedBuf  []byte
+	fragmentedSize int
+}
+
+// NewDecoder allocates a Decoder.
+func NewDecoder(clockRate int) *Decoder {
+	return &Decoder{
+		timeDecoder: rtptime.NewDecoder(clockRate),
+	}
+}
+
+// Decode decodes AUs from a RTP/AAC packet.
+// It returns the AUs and the timestamp of the first AU, relative to the
+// first decoded packet.
+// If an AU is fragmented into multiple packets, it returns no AUs
+// until the last fragment is received.
+func (d *Decoder) Decode(byts []byte) ([][]byte, time.Duration, error) {
+	pkt := rtp.Packet{}
+	err := pkt.Unmarshal(byts)
+	if err != nil {
+		d.fragmentedBuf = nil
+		return nil, 0, err
+	}
+
+	ts := d.timeDecoder.Decode(pkt.Timestamp)
+
+	payload := pkt.Payload
+
+	if len(payload) < 2 {
+		d.fragmentedBuf = nil
+		return nil, 0, fmt.Errorf("payload is too short")
+	}
+
+	// AU-headers-length, in bits
+	headersLen := int(binary.BigEndian.Uint16(payload))
+	if (headersLen % 16) != 0 {
+		d.fragmentedBuf = nil
+		return nil, 0, fmt.Errorf("invalid AU-headers-length (%d)", headersLen)
+	}
+	payload = payload[2:]
+
+	// 13 bits payload size
+	// 3 bits AU-Index(-delta)
+	headerCount := headersLen / 16
+	if headerCount == 0 || len(payload) < (headerCount*2) {
+		d.fragmentedBuf = nil
+		return nil, 0, fmt.Errorf("invalid AU-headers-length (%d)", headersLen)
+	}
+
+	sizes := make([]int, headerCount)
+	for i := 0; i < headerCount; i++ {
+		sizes[i] = int(binary.BigEndian.Uint16(payload[i*2:]) >> 3)
+	}
+	payload = payload[headerCount*2:]
+
+	// continuation of a fragmented AU
+	if d.fragmentedBuf != nil {
+		if headerCount != 1 || sizes[0] != d.fragmentedSize {
+			d.fragmentedBuf = nil
+			return nil, 0, fmt.Errorf("invalid fragmented AU")
+		}
+
+		d.fragmentedBuf = append(d.fragmentedBuf, payload...)
+
+		if len(d.fragmentedBuf) > d.fragmentedSize {
+			d.fragmentedBuf = nil
+			return nil, 0, fmt.Errorf("invalid fragmented AU")
+		}
+
+		if !pkt.Marker {
+			return nil, 0, nil
+		}
+
+		if len(d.fragmentedBuf) != d.fragmentedSize {
+			d.fragmentedBuf = nil
+			return nil, 0, fmt.Errorf("invalid fragmented AU")
+		}
+
+		au := d.fragmentedBuf
+		d.fragmentedBuf = nil
+		return [][]byte{au}, ts, nil
+	}
+
+	// first fragment of a fragmented AU
+	if headerCount == 1 && sizes[0] > len(payload) {
+		if pkt.Marker {
+			return nil, 0, fmt.Errorf("payload is too short")
+		}
+
+		d.fragmentedBuf = append([]byte(nil), payload...)
+		d.fragmentedSize = sizes[0]
+		return nil, 0, nil
+	}
+
+	aus := make([][]byte, headerCount)
+	for i, size := range sizes {
+		if len(payload) < size {
+			return nil, 0, fmt.Errorf("payload is too short")
+		}
+
+		aus[i] = payload[:size]
+		payload = payload[size:]
+	}
+
+	return aus, ts, nil
+}
@@ -0,0 +1,94 @@
+package rtpaac
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	e, err := NewEncoder(96, 48000)
+	require.NoError(t, err)
+
+	d := NewDecoder(48000)
+
+	au1 := bytes.Repeat([]byte{0x01}, 200)
+	au2 := bytes.Repeat([]byte{0x02}, 300)
+
+	frames, err := e.Write(1*time.Second, au1)
+	require.NoError(t, err)
+	aus, ts, err := d.Decode(frames[0])
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{au1}, aus)
+	require.Equal(t, time.Duration(0), ts)
+
+	frames, err = e.Write(1*time.Second+20*time.Millisecond, au2)
+	require.NoError(t, err)
+	aus, ts, err = d.Decode(frames[0])
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{au2}, aus)
+	require.Equal(t, 20*time.Millisecond, ts)
+}
+
+func TestDecodeMultipleAUs(t *testing.T) {
+	pkt := rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 17645,
+			Timestamp:      2289526357,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: []byte{
+			0x00, 0x20,
+			0x00, 0x18,
+			0x00, 0x10,
+			0xaa, 0xbb, 0xcc,
+			0xdd, 0xee,
+		},
+	}
+	byts, _ := pkt.Marshal()
+
+	d := NewDecoder(48000)
+	aus, _, err := d.Decode(byts)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{0xaa, 0xbb, 0xcc}, {0xdd, 0xee}}, aus)
+}
+
+func TestDecodeFragmented(t *testing.T) {
+	d := NewDecoder(48000)
+
+	for i, ca := range []struct {
+		marker  bool
+		payload []byte
+	}{
+		{false, []byte{0x00, 0x10, 0x00, 0x28, 0x01, 0x02, 0x03}},
+		{true, []byte{0x00, 0x10, 0x00, 0x28, 0x04, 0x05}},
+	} {
+		pkt := rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         ca.marker,
+				PayloadType:    96,
+				SequenceNumber: 17645 + uint16(i),
+				Timestamp:      2289526357,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: ca.payload,
+		}
+		byts, _ := pkt.Marshal()
+
+		aus, _, err := d.Decode(byts)
+		require.NoError(t, err)
+
+		if i == 0 {
+			require.Equal(t, [][]byte(nil), aus)
+		} else {
+			require.Equal(t, [][]byte{{0x01, 0x02, 0x03, 0x04, 0x05}}, aus)
+		}
+	}
+}
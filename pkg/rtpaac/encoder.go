@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/rtptime"
 )
 
 const (
@@ -18,10 +20,9 @@ const (
 // Encoder is a RPT/AAC encoder.
 type Encoder struct {
 	payloadType    uint8
-	clockRate      float64
 	sequenceNumber uint16
 	ssrc           uint32
-	initialTs      uint32
+	timeEncoder    *rtptime.Encoder
 	started        time.Duration
 }
 
@@ -29,10 +30,9 @@ type Encoder struct {
 func NewEncoder(payloadType uint8, clockRate int) (*Encoder, error) {
 	return &Encoder{
 		payloadType:    payloadType,
-		clockRate:      float64(clockRate),
 		sequenceNumber: uint16(rand.Uint32()),
 		ssrc:           rand.Uint32(),
-		initialTs:      rand.Uint32(),
+		timeEncoder:    rtptime.NewEncoder(clockRate, rand.Uint32()),
 	}, nil
 }
 
@@ -46,7 +46,7 @@ func (e *Encoder) Write(ts time.Duration, data []byte) ([][]byte, error) {
 		return nil, fmt.Errorf("data is too big")
 	}
 
-	rtpTs := e.initialTs + uint32((ts-e.started).Seconds()*e.clockRate)
+	rtpTs := e.timeEncoder.Encode(ts - e.started)
 
 	// 13 bits payload size
 	// 3 bits AU-Index(-delta)
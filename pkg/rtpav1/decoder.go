@@ -0,0 +1,162 @@
+// Package rtpav1 contains a RTP/AV1 decoder and encoder.
+package rtpav1
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/rtptime"
+)
+
+const (
+	rtpClockRate = 90000
+)
+
+// Decoder is a RTP/AV1 decoder.
+// It reassembles temporal units that are split into multiple packets.
+type Decoder struct {
+	timeDecoder *rtptime.Decoder
+
+	// for temporal units that are split into multiple packets
+	obus          [][]byte
+	fragmentedOBU []byte
+	inProgress    bool
+	currentTs     uint32
+	nextSeq       uint16
+}
+
+// NewDecoder allocates a Decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{
+		timeDecoder: rtptime.NewDecoder(rtpClockRate),
+	}
+}
+
+func (d *Decoder) reset() {
+	d.obus = nil
+	d.fragmentedOBU = nil
+	d.inProgress = false
+}
+
+// Decode decodes a temporal unit from a RTP/AV1 packet.
+// It returns the OBUs of the temporal unit, without the obu_size field,
+// and their timestamp, relative to the first decoded packet.
+// If a temporal unit is split into multiple packets, it returns no OBUs
+// until the last packet is received.
+func (d *Decoder) Decode(byts []byte) ([][]byte, time.Duration, error) {
+	pkt := rtp.Packet{}
+	err := pkt.Unmarshal(byts)
+	if err != nil {
+		d.reset()
+		return nil, 0, err
+	}
+
+	ts := d.timeDecoder.Decode(pkt.Timestamp)
+
+	if len(pkt.Payload) < 2 {
+		d.reset()
+		return nil, 0, fmt.Errorf("payload is too short")
+	}
+
+	if d.inProgress && (pkt.Timestamp != d.currentTs || pkt.SequenceNumber != d.nextSeq) {
+		d.reset()
+		return nil, 0, fmt.Errorf("a packet has been lost")
+	}
+
+	// aggregation header
+	z := (pkt.Payload[0] & 0x80) != 0
+	y := (pkt.Payload[0] & 0x40) != 0
+	w := int((pkt.Payload[0] >> 4) & 0x03)
+
+	if z != (d.fragmentedOBU != nil) {
+		d.reset()
+		if z {
+			return nil, 0, fmt.Errorf("received a non-starting fragment")
+		}
+		return nil, 0, fmt.Errorf("a fragmented OBU has not been completed")
+	}
+
+	var elems [][]byte
+	payload := pkt.Payload[1:]
+
+	for i := 0; len(payload) > 0; i++ {
+		// when the element count is specified, the last element
+		// has no length field
+		if w != 0 && i == (w-1) {
+			elems = append(elems, payload)
+			break
+		}
+
+		size, n, err := readLEB128(payload)
+		if err != nil {
+			d.reset()
+			return nil, 0, err
+		}
+		payload = payload[n:]
+
+		if uint(len(payload)) < size {
+			d.reset()
+			return nil, 0, fmt.Errorf("invalid OBU element size (%d)", size)
+		}
+
+		elems = append(elems, payload[:size])
+		payload = payload[size:]
+	}
+
+	if len(elems) == 0 {
+		d.reset()
+		return nil, 0, fmt.Errorf("packet does not contain any OBU element")
+	}
+
+	for i, elem := range elems {
+		var obu []byte
+
+		if i == 0 && z {
+			obu = append(d.fragmentedOBU, elem...)
+			d.fragmentedOBU = nil
+		} else {
+			obu = append([]byte(nil), elem...)
+		}
+
+		if i == (len(elems)-1) && y {
+			d.fragmentedOBU = obu
+			continue
+		}
+
+		if len(obu) == 0 {
+			d.reset()
+			return nil, 0, fmt.Errorf("OBU is empty")
+		}
+
+		// temporal delimiters are implied by the RTP timestamp
+		if TypeOf(obu) == OBUTypeTemporalDelimiter {
+			continue
+		}
+
+		d.obus = append(d.obus, obu)
+	}
+
+	d.inProgress = true
+	d.currentTs = pkt.Timestamp
+	d.nextSeq = pkt.SequenceNumber + 1
+
+	if !pkt.Marker {
+		return nil, 0, nil
+	}
+
+	if d.fragmentedOBU != nil {
+		d.reset()
+		return nil, 0, fmt.Errorf("a fragmented OBU has not been completed")
+	}
+
+	obus := d.obus
+	d.reset()
+
+	if len(obus) == 0 {
+		return nil, 0, fmt.Errorf("temporal unit is empty")
+	}
+
+	return obus, ts, nil
+}
@@ -0,0 +1,200 @@
+package rtpav1
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func mustMarshal(pkt rtp.Packet) []byte {
+	byts, err := pkt.Marshal()
+	if err != nil {
+		panic(err)
+	}
+	return byts
+}
+
+func TestLEB128(t *testing.T) {
+	for _, ca := range []struct {
+		v    uint
+		byts []byte
+	}{
+		{0, []byte{0x00}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x80, 0x01}},
+		{300, []byte{0xac, 0x02}},
+	} {
+		require.Equal(t, ca.byts, appendLEB128(nil, ca.v))
+		require.Equal(t, len(ca.byts), leb128Size(ca.v))
+
+		v, n, err := readLEB128(ca.byts)
+		require.NoError(t, err)
+		require.Equal(t, ca.v, v)
+		require.Equal(t, len(ca.byts), n)
+	}
+
+	_, _, err := readLEB128([]byte{0x80})
+	require.EqualError(t, err, "LEB128 value is too short")
+}
+
+func TestEncodeDecode(t *testing.T) {
+	e, err := NewEncoderWithPayloadMaxSize(96, 100)
+	require.NoError(t, err)
+
+	d := NewDecoder()
+
+	sequenceHeader := []byte{0x08, 0x00, 0x00, 0x00, 0x42, 0xa7, 0xbf, 0xe4}
+	frame := append([]byte{0x30}, bytes.Repeat([]byte{0x01}, 250)...)
+	metadata := []byte{0x28, 0x02, 0x03}
+
+	for i, ca := range []struct {
+		in      [][]byte
+		out     [][]byte
+		packets int
+	}{
+		{
+			[][]byte{
+				// temporal delimiter with size field
+				{0x12, 0x00},
+				// sequence header with size field
+				append([]byte{0x0a, byte(len(sequenceHeader) - 1)}, sequenceHeader[1:]...),
+				frame,
+			},
+			[][]byte{sequenceHeader, frame},
+			3,
+		},
+		{
+			[][]byte{metadata, {0x30, 0x02}},
+			[][]byte{metadata, {0x30, 0x02}},
+			1,
+		},
+	} {
+		packets, err := e.Write(1*time.Second+time.Duration(i)*40*time.Millisecond, ca.in)
+		require.NoError(t, err)
+		require.Equal(t, ca.packets, len(packets))
+
+		for j, pkt := range packets {
+			var rpkt rtp.Packet
+			err := rpkt.Unmarshal(pkt)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(rpkt.Payload), 100)
+
+			// the first packet of a coded video sequence has the N bit
+			require.Equal(t, i == 0 && j == 0, (rpkt.Payload[0]&0x08) != 0)
+
+			obus, ts, err := d.Decode(pkt)
+			require.NoError(t, err)
+
+			if j != len(packets)-1 {
+				require.Nil(t, obus)
+				continue
+			}
+
+			require.Equal(t, ca.out, obus)
+			require.Equal(t, time.Duration(i)*40*time.Millisecond, ts)
+		}
+	}
+}
+
+func TestDecodeAggregation(t *testing.T) {
+	d := NewDecoder()
+
+	// W=0, all elements have a length field
+	obus, _, err := d.Decode(mustMarshal(rtp.Packet{
+		Header: rtp.Header{
+			Version: 2,
+			Marker:  true,
+		},
+		Payload: []byte{0x00, 0x02, 0x30, 0x01, 0x01, 0x28},
+	}))
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{0x30, 0x01}, {0x28}}, obus)
+
+	// W=2, the last element has no length field
+	obus, _, err = d.Decode(mustMarshal(rtp.Packet{
+		Header: rtp.Header{
+			Version: 2,
+			Marker:  true,
+		},
+		Payload: []byte{0x20, 0x02, 0x30, 0x01, 0x28, 0x05},
+	}))
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{0x30, 0x01}, {0x28, 0x05}}, obus)
+}
+
+func TestDecodePacketLost(t *testing.T) {
+	e, err := NewEncoderWithPayloadMaxSize(96, 100)
+	require.NoError(t, err)
+
+	d := NewDecoder()
+
+	packets, err := e.Write(0, [][]byte{append([]byte{0x30}, bytes.Repeat([]byte{0x01}, 250)...)})
+	require.NoError(t, err)
+
+	_, _, err = d.Decode(packets[0])
+	require.NoError(t, err)
+
+	_, _, err = d.Decode(packets[2])
+	require.EqualError(t, err, "a packet has been lost")
+
+	// the temporal unit is discarded until the next one starts
+	_, _, err = d.Decode(packets[2])
+	require.EqualError(t, err, "received a non-starting fragment")
+
+	packets, err = e.Write(40*time.Millisecond, [][]byte{{0x30, 0x02}})
+	require.NoError(t, err)
+
+	obus, _, err := d.Decode(packets[0])
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{0x30, 0x02}}, obus)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name    string
+		payload []byte
+		err     string
+	}{
+		{
+			"too short",
+			[]byte{0x00},
+			"payload is too short",
+		},
+		{
+			"non-starting fragment",
+			[]byte{0x90, 0x01},
+			"received a non-starting fragment",
+		},
+		{
+			"invalid element size",
+			[]byte{0x00, 0x05, 0x30},
+			"invalid OBU element size (5)",
+		},
+		{
+			"uncompleted fragment",
+			[]byte{0x50, 0x30},
+			"a fragmented OBU has not been completed",
+		},
+		{
+			"empty",
+			[]byte{0x10, 0x10},
+			"temporal unit is empty",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder()
+			_, _, err := d.Decode(mustMarshal(rtp.Packet{
+				Header: rtp.Header{
+					Version:     2,
+					Marker:      true,
+					PayloadType: 96,
+				},
+				Payload: ca.payload,
+			}))
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package rtpav1
+
+import (
+	"fmt"
+)
+
+// OBUType is the type of an OBU.
+type OBUType uint8
+
+// standard OBU types.
+const (
+	OBUTypeSequenceHeader       OBUType = 1
+	OBUTypeTemporalDelimiter    OBUType = 2
+	OBUTypeFrameHeader          OBUType = 3
+	OBUTypeTileGroup            OBUType = 4
+	OBUTypeMetadata             OBUType = 5
+	OBUTypeFrame                OBUType = 6
+	OBUTypeRedundantFrameHeader OBUType = 7
+	OBUTypeTileList             OBUType = 8
+	OBUTypePadding              OBUType = 15
+)
+
+// TypeOf returns the type of an OBU.
+// It returns 0 (reserved) if the OBU is empty.
+func TypeOf(obu []byte) OBUType {
+	if len(obu) == 0 {
+		return 0
+	}
+	return OBUType((obu[0] >> 3) & 0x0F)
+}
+
+// readLEB128 decodes an unsigned LEB128 integer.
+// It returns the value and its size.
+func readLEB128(buf []byte) (uint, int, error) {
+	var v uint
+
+	for i := 0; i < 8; i++ {
+		if len(buf) <= i {
+			return 0, 0, fmt.Errorf("LEB128 value is too short")
+		}
+
+		v |= uint(buf[i]&0x7F) << (7 * i)
+
+		if (buf[i] & 0x80) == 0 {
+			return v, i + 1, nil
+		}
+	}
+
+	return 0, 0, fmt.Errorf("LEB128 value is too long")
+}
+
+func leb128Size(v uint) int {
+	n := 1
+	for v >= 0x80 {
+		v >>= 7
+		n++
+	}
+	return n
+}
+
+func appendLEB128(buf []byte, v uint) []byte {
+	for v >= 0x80 {
+		buf = append(buf, byte(v&0x7F)|0x80)
+		v >>= 7
+	}
+	return append(buf, byte(v))
+}
+
+// stripSizeField removes the obu_size field from an OBU, since
+// it must not be present inside RTP packets.
+func stripSizeField(obu []byte) ([]byte, error) {
+	if len(obu) < 1 {
+		return nil, fmt.Errorf("OBU is empty")
+	}
+
+	// obu_has_size_field
+	if (obu[0] & 0x02) == 0 {
+		return obu, nil
+	}
+
+	headerLen := 1
+	// obu_extension_flag
+	if (obu[0] & 0x04) != 0 {
+		headerLen++
+	}
+
+	if len(obu) < headerLen {
+		return nil, fmt.Errorf("OBU header is too short")
+	}
+
+	size, n, err := readLEB128(obu[headerLen:])
+	if err != nil {
+		return nil, err
+	}
+
+	if uint(len(obu)-headerLen-n) < size {
+		return nil, fmt.Errorf("OBU size (%d) is greater than available data (%d)",
+			size, len(obu)-headerLen-n)
+	}
+
+	ret := make([]byte, 0, headerLen+int(size))
+	ret = append(ret, obu[0]&^0x02)
+	ret = append(ret, obu[1:headerLen]...)
+	ret = append(ret, obu[headerLen+n:headerLen+n+int(size)]...)
+	return ret, nil
+}
@@ -0,0 +1,35 @@
+package rtpav1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeOf(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		obu  []byte
+		typ  OBUType
+	}{
+		{
+			"sequence header",
+			[]byte{0x08, 0x00},
+			OBUTypeSequenceHeader,
+		},
+		{
+			"temporal delimiter",
+			[]byte{0x12, 0x00},
+			OBUTypeTemporalDelimiter,
+		},
+		{
+			"empty",
+			[]byte{},
+			0,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, ca.typ, TypeOf(ca.obu))
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package rtpav1
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/rtptime"
+)
+
+const (
+	rtpVersion = 0x02
+
+	// DefaultPayloadMaxSize is the default maximum size of RTP payloads.
+	DefaultPayloadMaxSize = 1460 // 1500 (mtu) - 20 (ip header) - 8 (udp header) - 12 (rtp header)
+)
+
+type encoderPacket struct {
+	elems [][]byte
+	size  int
+	z     bool
+	y     bool
+}
+
+// Encoder is a RTP/AV1 encoder.
+type Encoder struct {
+	payloadType    uint8
+	payloadMaxSize int
+	sequenceNumber uint16
+	ssrc           uint32
+	timeEncoder    *rtptime.Encoder
+	started        time.Duration
+}
+
+// NewEncoder allocates an Encoder.
+func NewEncoder(payloadType uint8) (*Encoder, error) {
+	return NewEncoderWithPayloadMaxSize(payloadType, DefaultPayloadMaxSize)
+}
+
+// NewEncoderWithPayloadMaxSize allocates an Encoder that produces RTP payloads
+// with the given maximum size.
+func NewEncoderWithPayloadMaxSize(payloadType uint8, payloadMaxSize int) (*Encoder, error) {
+	// a payload must contain the aggregation header, a length field
+	// and at least one byte of OBU
+	if payloadMaxSize < 3 {
+		return nil, fmt.Errorf("payload max size is too small (%d)", payloadMaxSize)
+	}
+
+	return &Encoder{
+		payloadType:    payloadType,
+		payloadMaxSize: payloadMaxSize,
+		sequenceNumber: uint16(rand.Uint32()),
+		ssrc:           rand.Uint32(),
+		timeEncoder:    rtptime.NewEncoder(rtpClockRate, rand.Uint32()),
+	}, nil
+}
+
+// Write encodes the OBUs of a temporal unit into RTP/AV1 packets.
+// OBUs are aggregated into packets when possible, and split into
+// multiple packets when they are bigger than the maximum payload size.
+// Temporal delimiters and tile lists are removed, as well as the obu_size field.
+func (e *Encoder) Write(ts time.Duration, obus [][]byte) ([][]byte, error) {
+	var filtered [][]byte
+	newSequence := false
+
+	for _, obu := range obus {
+		obu, err := stripSizeField(obu)
+		if err != nil {
+			return nil, err
+		}
+
+		switch TypeOf(obu) {
+		case OBUTypeTemporalDelimiter, OBUTypeTileList:
+			continue
+
+		case OBUTypeSequenceHeader:
+			newSequence = true
+		}
+
+		filtered = append(filtered, obu)
+	}
+
+	if len(filtered) == 0 {
+		return nil, fmt.Errorf("temporal unit is empty")
+	}
+
+	if e.started == 0 {
+		e.started = ts
+	}
+
+	// rtp/av1 uses a 90khz clock
+	rtpTime := e.timeEncoder.Encode(ts - e.started)
+
+	packets := e.packetize(filtered)
+
+	frames := make([][]byte, len(packets))
+
+	for i, p := range packets {
+		payload := e.marshalPayload(p, newSequence && i == 0)
+
+		rpkt := rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    e.payloadType,
+				SequenceNumber: e.sequenceNumber,
+				Timestamp:      rtpTime,
+				SSRC:           e.ssrc,
+				// the marker is set on the last packet of the temporal unit
+				Marker: (i == len(packets)-1),
+			},
+			Payload: payload,
+		}
+		e.sequenceNumber++
+
+		byts, err := rpkt.Marshal()
+		if err != nil {
+			return nil, err
+		}
+
+		frames[i] = byts
+	}
+
+	return frames, nil
+}
+
+// packetize distributes OBUs into packets.
+// Sizes are computed as if every element had a length field.
+func (e *Encoder) packetize(obus [][]byte) []*encoderPacket {
+	var packets []*encoderPacket
+	cur := &encoderPacket{size: 1}
+
+	for _, obu := range obus {
+		for len(obu) > 0 {
+			avail := e.payloadMaxSize - cur.size
+
+			// the entire OBU fits into the packet
+			if avail >= leb128Size(uint(len(obu)))+len(obu) {
+				cur.elems = append(cur.elems, obu)
+				cur.size += leb128Size(uint(len(obu))) + len(obu)
+				break
+			}
+
+			// a fragment of the OBU fits into the packet
+			n := avail - leb128Size(uint(avail))
+			if n > 0 {
+				cur.elems = append(cur.elems, obu[:n])
+				cur.size += leb128Size(uint(n)) + n
+				cur.y = true
+				obu = obu[n:]
+			}
+
+			packets = append(packets, cur)
+			cur = &encoderPacket{
+				size: 1,
+				z:    cur.y,
+			}
+		}
+	}
+
+	if len(cur.elems) != 0 {
+		packets = append(packets, cur)
+	}
+
+	return packets
+}
+
+func (e *Encoder) marshalPayload(p *encoderPacket, newSequence bool) []byte {
+	var header byte
+	if p.z {
+		header |= 0x80
+	}
+	if p.y {
+		header |= 0x40
+	}
+	if newSequence {
+		header |= 0x08
+	}
+
+	// up to 3 elements, the length of the last one can be omitted
+	w := 0
+	if len(p.elems) <= 3 {
+		w = len(p.elems)
+		header |= byte(w) << 4
+	}
+
+	payload := make([]byte, 0, p.size)
+	payload = append(payload, header)
+
+	for i, elem := range p.elems {
+		if w == 0 || i != (w-1) {
+			payload = appendLEB128(payload, uint(len(elem)))
+		}
+		payload = append(payload, elem...)
+	}
+
+	return payload
+}
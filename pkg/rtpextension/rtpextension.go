@@ -0,0 +1,96 @@
+// Package rtpextension contains utilities to decode and encode RTP header extensions.
+// Header extensions can be read from and written into RTP packets with
+// rtp.Header.GetExtension() and rtp.Header.SetExtension(), by using the IDs
+// that are associated to extensions by the "extmap" attribute of tracks.
+package rtpextension
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aler9/gortsplib/internal/ntp"
+)
+
+const (
+	// URIAbsSendTime is the URI of the abs-send-time extension.
+	URIAbsSendTime = "http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time"
+
+	// URITransmissionOffset is the URI of the transmission time offset extension (RFC 5450).
+	URITransmissionOffset = "urn:ietf:params:rtp-hdrext:toffset"
+)
+
+// AbsSendTime is the abs-send-time extension.
+// Specification: http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time
+type AbsSendTime struct {
+	// send time, in NTP format, as a 6.18 fixed point number of seconds.
+	// It wraps around every 64 seconds.
+	Timestamp uint32
+}
+
+// NewAbsSendTime allocates an AbsSendTime with the given send time.
+func NewAbsSendTime(t time.Time) AbsSendTime {
+	return AbsSendTime{
+		Timestamp: uint32(ntp.Encode(t)>>14) & 0xFFFFFF,
+	}
+}
+
+// Unmarshal decodes the extension.
+func (e *AbsSendTime) Unmarshal(buf []byte) error {
+	if len(buf) != 3 {
+		return fmt.Errorf("invalid abs-send-time size (%d)", len(buf))
+	}
+
+	e.Timestamp = uint32(buf[0])<<16 | uint32(buf[1])<<8 | uint32(buf[2])
+	return nil
+}
+
+// Marshal encodes the extension.
+func (e AbsSendTime) Marshal() []byte {
+	return []byte{
+		byte(e.Timestamp >> 16),
+		byte(e.Timestamp >> 8),
+		byte(e.Timestamp),
+	}
+}
+
+// Estimate returns the absolute send time, by assuming that
+// it precedes the given receive time by less than 64 seconds.
+func (e AbsSendTime) Estimate(receive time.Time) time.Time {
+	receiveNTP := ntp.Encode(receive)
+	sendNTP := receiveNTP&0xFFFFFFC000000000 | uint64(e.Timestamp&0xFFFFFF)<<14
+
+	// the send time can't follow the receive time
+	if sendNTP > receiveNTP {
+		sendNTP -= 0x1000000 << 14
+	}
+
+	return ntp.Decode(sendNTP)
+}
+
+// TransmissionOffset is the transmission time offset extension.
+// Specification: RFC 5450
+type TransmissionOffset struct {
+	// offset between the transmission time and the sampling time of the packet,
+	// in RTP timestamp units.
+	Offset int32
+}
+
+// Unmarshal decodes the extension.
+func (e *TransmissionOffset) Unmarshal(buf []byte) error {
+	if len(buf) != 3 {
+		return fmt.Errorf("invalid transmission offset size (%d)", len(buf))
+	}
+
+	// sign-extend the 24-bit value
+	e.Offset = int32(uint32(buf[0])<<24|uint32(buf[1])<<16|uint32(buf[2])<<8) >> 8
+	return nil
+}
+
+// Marshal encodes the extension.
+func (e TransmissionOffset) Marshal() []byte {
+	return []byte{
+		byte(e.Offset >> 16),
+		byte(e.Offset >> 8),
+		byte(e.Offset),
+	}
+}
@@ -0,0 +1,98 @@
+package rtpextension
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbsSendTime(t *testing.T) {
+	now := time.Date(2021, 3, 4, 10, 11, 12, 250000000, time.UTC)
+
+	e := NewAbsSendTime(now)
+	byts := e.Marshal()
+	require.Equal(t, 3, len(byts))
+
+	var e2 AbsSendTime
+	err := e2.Unmarshal(byts)
+	require.NoError(t, err)
+	require.Equal(t, e, e2)
+
+	est := e2.Estimate(now.Add(10 * time.Second))
+	require.True(t, est.Sub(now) < time.Millisecond && now.Sub(est) < time.Millisecond)
+
+	err = e2.Unmarshal([]byte{1, 2})
+	require.Error(t, err)
+}
+
+func TestTransmissionOffset(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		byts []byte
+		e    TransmissionOffset
+	}{
+		{
+			"positive",
+			[]byte{0x00, 0x01, 0x02},
+			TransmissionOffset{Offset: 258},
+		},
+		{
+			"negative",
+			[]byte{0xff, 0xff, 0xfe},
+			TransmissionOffset{Offset: -2},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var e TransmissionOffset
+			err := e.Unmarshal(ca.byts)
+			require.NoError(t, err)
+			require.Equal(t, ca.e, e)
+			require.Equal(t, ca.byts, ca.e.Marshal())
+		})
+	}
+}
+
+func TestHeaderExtensions(t *testing.T) {
+	for _, ca := range []struct {
+		name    string
+		profile uint16
+		payload []byte
+	}{
+		{
+			"one-byte",
+			0xBEDE,
+			[]byte{0x01, 0x02, 0x03},
+		},
+		{
+			"two-byte",
+			0x1000,
+			make([]byte, 20),
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			pkt := rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 1,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{0x01, 0x02, 0x03, 0x04},
+			}
+			err := pkt.Header.SetExtension(3, ca.payload)
+			require.NoError(t, err)
+
+			byts, err := pkt.Marshal()
+			require.NoError(t, err)
+
+			var pkt2 rtp.Packet
+			err = pkt2.Unmarshal(byts)
+			require.NoError(t, err)
+			require.Equal(t, ca.profile, pkt2.ExtensionProfile)
+			require.Equal(t, ca.payload, pkt2.GetExtension(3))
+			require.Equal(t, pkt.Payload, pkt2.Payload)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package rtpfec
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// number of received packets that are stored in order to perform recoveries.
+const decoderBufferSize = 64
+
+// Decoder is a RTP/FEC decoder.
+// It stores received RTP packets and uses FEC packets to recover
+// a lost packet for every group.
+type Decoder struct {
+	payloadType    uint8
+	fecPayloadType uint8
+
+	buffer    [decoderBufferSize][]byte
+	ssrc      uint32
+	ssrcKnown bool
+}
+
+// NewDecoder allocates a Decoder.
+// payloadType is the payload type of protected packets, while fecPayloadType
+// is the payload type of FEC packets.
+func NewDecoder(payloadType uint8, fecPayloadType uint8) *Decoder {
+	return &Decoder{
+		payloadType:    payloadType,
+		fecPayloadType: fecPayloadType,
+	}
+}
+
+func (d *Decoder) stored(sequenceNumber uint16) []byte {
+	pkt := d.buffer[sequenceNumber%decoderBufferSize]
+	if pkt == nil || binary.BigEndian.Uint16(pkt[2:]) != sequenceNumber {
+		return nil
+	}
+	return pkt
+}
+
+// Decode processes a received RTP packet.
+// Protected packets and packets with other payload types are returned as they are.
+// FEC packets are consumed; when they allow to recover a lost packet, the
+// recovered packet is returned, otherwise nil.
+func (d *Decoder) Decode(buf []byte) ([]byte, error) {
+	if len(buf) < rtpHeaderSize {
+		return buf, nil
+	}
+	payloadType := buf[1] & 0x7F
+
+	switch payloadType {
+	case d.payloadType:
+		sequenceNumber := binary.BigEndian.Uint16(buf[2:])
+		d.ssrc = binary.BigEndian.Uint32(buf[8:])
+		d.ssrcKnown = true
+
+		pos := sequenceNumber % decoderBufferSize
+		d.buffer[pos] = append(d.buffer[pos][:0], buf...)
+		return buf, nil
+
+	case d.fecPayloadType:
+		return d.recover(buf)
+	}
+
+	return buf, nil
+}
+
+func (d *Decoder) recover(buf []byte) ([]byte, error) {
+	if len(buf) < rtpHeaderSize+fecHeaderSize {
+		return nil, fmt.Errorf("FEC packet is too short")
+	}
+
+	// the E and L bits must be zero
+	if (buf[rtpHeaderSize] & 0xC0) != 0 {
+		return nil, fmt.Errorf("unsupported FEC packet")
+	}
+
+	var h fecHeader
+	h.unmarshal(buf[rtpHeaderSize:])
+	payload := buf[rtpHeaderSize+fecHeaderSize:]
+
+	if int(h.protectionLength) > len(payload) {
+		return nil, fmt.Errorf("FEC packet is too short")
+	}
+
+	if !d.ssrcKnown {
+		return nil, nil
+	}
+
+	// find the lost packet
+	var lost uint16
+	lostCount := 0
+	for i := 0; i < 16; i++ {
+		if (h.mask & (1 << (15 - i))) == 0 {
+			continue
+		}
+
+		sequenceNumber := h.sequenceNumberBase + uint16(i)
+		if d.stored(sequenceNumber) == nil {
+			lost = sequenceNumber
+			lostCount++
+		}
+	}
+
+	// only a single lost packet can be recovered
+	if lostCount != 1 {
+		return nil, nil
+	}
+
+	rec := fecHeader{
+		recoveryFlags:     h.recoveryFlags,
+		timestampRecovery: h.timestampRecovery,
+		lengthRecovery:    h.lengthRecovery,
+	}
+	recPayload := append([]byte(nil), payload[:h.protectionLength]...)
+
+	for i := 0; i < 16; i++ {
+		if (h.mask & (1 << (15 - i))) == 0 {
+			continue
+		}
+
+		sequenceNumber := h.sequenceNumberBase + uint16(i)
+		if sequenceNumber == lost {
+			continue
+		}
+
+		recPayload = xorPacket(&rec, recPayload, d.stored(sequenceNumber))
+	}
+
+	if int(rec.lengthRecovery) > len(recPayload) {
+		return nil, fmt.Errorf("invalid FEC packet")
+	}
+
+	ret := make([]byte, rtpHeaderSize, rtpHeaderSize+int(rec.lengthRecovery))
+	binary.BigEndian.PutUint16(ret, uint16(rtpVersion)<<14|rec.recoveryFlags)
+	binary.BigEndian.PutUint16(ret[2:], lost)
+	binary.BigEndian.PutUint32(ret[4:], rec.timestampRecovery)
+	binary.BigEndian.PutUint32(ret[8:], d.ssrc)
+	ret = append(ret, recPayload[:rec.lengthRecovery]...)
+
+	// the recovered packet can be used to recover other packets
+	pos := lost % decoderBufferSize
+	d.buffer[pos] = append(d.buffer[pos][:0], ret...)
+
+	return ret, nil
+}
@@ -0,0 +1,162 @@
+package rtpfec
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func mustMarshal(pkt rtp.Packet) []byte {
+	byts, err := pkt.Marshal()
+	if err != nil {
+		panic(err)
+	}
+	return byts
+}
+
+var testPackets = [][]byte{
+	mustMarshal(rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 65534,
+			Timestamp:      2289526357,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: []byte{0x01, 0x02, 0x03, 0x04},
+	}),
+	mustMarshal(rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 65535,
+			Timestamp:      2289526357,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: []byte{0x05, 0x06},
+	}),
+	mustMarshal(rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 0,
+			Timestamp:      2289529357,
+			SSRC:           0x9dbb7812,
+			CSRC:           []uint32{0x01020304},
+		},
+		Payload: []byte{0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D},
+	}),
+	mustMarshal(rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 1,
+			Timestamp:      2289532357,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: []byte{0x0E},
+	}),
+}
+
+func TestDecoderRecover(t *testing.T) {
+	for lost := range testPackets {
+		e, err := NewEncoder(127, 4)
+		require.NoError(t, err)
+
+		var fec []byte
+		for i, pkt := range testPackets {
+			fec, err = e.Encode(pkt)
+			require.NoError(t, err)
+			if i != len(testPackets)-1 {
+				require.Nil(t, fec)
+			}
+		}
+		require.NotNil(t, fec)
+
+		d := NewDecoder(96, 127)
+		for i, pkt := range testPackets {
+			if i == lost {
+				continue
+			}
+			ret, err := d.Decode(pkt)
+			require.NoError(t, err)
+			require.Equal(t, pkt, ret)
+		}
+
+		rec, err := d.Decode(fec)
+		require.NoError(t, err)
+		require.Equal(t, testPackets[lost], rec)
+	}
+}
+
+func TestDecoderNoRecover(t *testing.T) {
+	e, err := NewEncoder(127, 4)
+	require.NoError(t, err)
+
+	var fec []byte
+	for _, pkt := range testPackets {
+		fec, err = e.Encode(pkt)
+		require.NoError(t, err)
+	}
+
+	// no packets are lost
+	d := NewDecoder(96, 127)
+	for _, pkt := range testPackets {
+		d.Decode(pkt)
+	}
+	rec, err := d.Decode(fec)
+	require.NoError(t, err)
+	require.Nil(t, rec)
+
+	// two packets are lost
+	d = NewDecoder(96, 127)
+	for _, pkt := range testPackets[2:] {
+		d.Decode(pkt)
+	}
+	rec, err = d.Decode(fec)
+	require.NoError(t, err)
+	require.Nil(t, rec)
+
+	// packets with other payload types are returned as they are
+	other := mustMarshal(rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    97,
+			SequenceNumber: 300,
+		},
+		Payload: []byte{0x01},
+	})
+	ret, err := d.Decode(other)
+	require.NoError(t, err)
+	require.Equal(t, other, ret)
+}
+
+func TestEncoderErrors(t *testing.T) {
+	_, err := NewEncoder(127, 1)
+	require.Error(t, err)
+
+	_, err = NewEncoder(127, MaxGroupSize+1)
+	require.Error(t, err)
+
+	e, err := NewEncoder(127, 2)
+	require.NoError(t, err)
+
+	_, err = e.Encode([]byte{0x80, 0x60})
+	require.Error(t, err)
+
+	// non-consecutive packets restart the group
+	fec, err := e.Encode(testPackets[0])
+	require.NoError(t, err)
+	require.Nil(t, fec)
+
+	fec, err = e.Encode(testPackets[2])
+	require.NoError(t, err)
+	require.Nil(t, fec)
+
+	fec, err = e.Encode(testPackets[3])
+	require.NoError(t, err)
+	require.NotNil(t, fec)
+}
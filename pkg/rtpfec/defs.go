@@ -0,0 +1,84 @@
+// Package rtpfec contains a RTP/FEC decoder and encoder (RFC 5109).
+// FEC packets allow to recover a lost packet out of a group of packets
+// without retransmissions. Only the level 0 of protection is supported.
+package rtpfec
+
+import (
+	"encoding/binary"
+)
+
+const (
+	rtpVersion    = 0x02
+	rtpHeaderSize = 12
+
+	// FEC header + FEC level 0 header, with a 16-bit mask
+	fecHeaderSize = 10 + 4
+
+	// MaxGroupSize is the maximum number of packets that can be protected
+	// by a single FEC packet.
+	MaxGroupSize = 16
+)
+
+// fecHeader is the FEC header together with the FEC level 0 header
+// (RFC 5109, section 7.3 and 7.4).
+type fecHeader struct {
+	// XOR of the first two bytes of the protected packets, excluding the version
+	recoveryFlags uint16
+
+	// first sequence number of the protected packets
+	sequenceNumberBase uint16
+
+	// XOR of the timestamps of the protected packets
+	timestampRecovery uint32
+
+	// XOR of the lengths of the protected packets, excluding the fixed header
+	lengthRecovery uint16
+
+	// size of the protected part of the packets
+	protectionLength uint16
+
+	// protected packets, where the most significant bit
+	// corresponds to the sequence number base
+	mask uint16
+}
+
+func (h *fecHeader) unmarshal(buf []byte) {
+	h.recoveryFlags = binary.BigEndian.Uint16(buf) & 0x3FFF
+	h.sequenceNumberBase = binary.BigEndian.Uint16(buf[2:])
+	h.timestampRecovery = binary.BigEndian.Uint32(buf[4:])
+	h.lengthRecovery = binary.BigEndian.Uint16(buf[8:])
+	h.protectionLength = binary.BigEndian.Uint16(buf[10:])
+	h.mask = binary.BigEndian.Uint16(buf[12:])
+}
+
+func (h fecHeader) marshal() []byte {
+	buf := make([]byte, fecHeaderSize)
+	// E and L bits are zero
+	binary.BigEndian.PutUint16(buf, h.recoveryFlags&0x3FFF)
+	binary.BigEndian.PutUint16(buf[2:], h.sequenceNumberBase)
+	binary.BigEndian.PutUint32(buf[4:], h.timestampRecovery)
+	binary.BigEndian.PutUint16(buf[8:], h.lengthRecovery)
+	binary.BigEndian.PutUint16(buf[10:], h.protectionLength)
+	binary.BigEndian.PutUint16(buf[12:], h.mask)
+	return buf
+}
+
+// xorPacket adds a RTP packet to the recovery fields of a FEC header and
+// to a recovery payload, that is extended when it's shorter than the packet.
+func xorPacket(h *fecHeader, payload []byte, pkt []byte) []byte {
+	h.recoveryFlags ^= binary.BigEndian.Uint16(pkt) & 0x3FFF
+	h.timestampRecovery ^= binary.BigEndian.Uint32(pkt[4:])
+	h.lengthRecovery ^= uint16(len(pkt) - rtpHeaderSize)
+
+	// the protected part starts after the fixed header, and includes
+	// CSRCs, header extensions, payload and padding
+	body := pkt[rtpHeaderSize:]
+	if len(body) > len(payload) {
+		payload = append(payload, make([]byte, len(body)-len(payload))...)
+	}
+	for i, b := range body {
+		payload[i] ^= b
+	}
+
+	return payload
+}
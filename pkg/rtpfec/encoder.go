@@ -0,0 +1,84 @@
+package rtpfec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+)
+
+// Encoder is a RTP/FEC encoder.
+// It generates a FEC packet every time a group of RTP packets is completed.
+type Encoder struct {
+	payloadType    uint8
+	groupSize      int
+	sequenceNumber uint16
+	ssrc           uint32
+
+	// current group
+	count   int
+	header  fecHeader
+	payload []byte
+}
+
+// NewEncoder allocates an Encoder.
+// groupSize is the number of RTP packets that are protected by every FEC packet,
+// between 2 and MaxGroupSize. Smaller groups allow to recover more losses,
+// at the cost of a higher bandwidth.
+func NewEncoder(payloadType uint8, groupSize int) (*Encoder, error) {
+	if groupSize < 2 || groupSize > MaxGroupSize {
+		return nil, fmt.Errorf("invalid group size (%d)", groupSize)
+	}
+
+	return &Encoder{
+		payloadType:    payloadType,
+		groupSize:      groupSize,
+		sequenceNumber: uint16(rand.Uint32()),
+		ssrc:           rand.Uint32(),
+	}, nil
+}
+
+// Encode processes a RTP packet that is going to be sent.
+// It returns a FEC packet when the packet completes a group, otherwise nil.
+// Packets of a group must have consecutive sequence numbers; when they don't,
+// the current group is discarded.
+func (e *Encoder) Encode(buf []byte) ([]byte, error) {
+	if len(buf) < rtpHeaderSize {
+		return nil, fmt.Errorf("packet is too short")
+	}
+	sequenceNumber := binary.BigEndian.Uint16(buf[2:])
+
+	if e.count != 0 && sequenceNumber != e.header.sequenceNumberBase+uint16(e.count) {
+		e.count = 0
+	}
+
+	if e.count == 0 {
+		e.header = fecHeader{
+			sequenceNumberBase: sequenceNumber,
+		}
+		e.payload = e.payload[:0]
+	}
+
+	e.payload = xorPacket(&e.header, e.payload, buf)
+	e.header.mask |= 1 << (15 - e.count)
+	e.count++
+
+	if e.count < e.groupSize {
+		return nil, nil
+	}
+	e.count = 0
+
+	e.header.protectionLength = uint16(len(e.payload))
+
+	ret := make([]byte, rtpHeaderSize, rtpHeaderSize+fecHeaderSize+len(e.payload))
+	ret[0] = rtpVersion << 6
+	ret[1] = e.payloadType
+	binary.BigEndian.PutUint16(ret[2:], e.sequenceNumber)
+	// use the timestamp of the last protected packet
+	copy(ret[4:8], buf[4:8])
+	binary.BigEndian.PutUint32(ret[8:], e.ssrc)
+	ret = append(ret, e.header.marshal()...)
+	ret = append(ret, e.payload...)
+	e.sequenceNumber++
+
+	return ret, nil
+}
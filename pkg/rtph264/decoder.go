@@ -2,11 +2,20 @@
 package rtph264
 
 import (
+	"encoding/binary"
 	"fmt"
 	"io"
 	"net"
+	"time"
 
 	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/rtptime"
+)
+
+const (
+	// rtp/h264 uses a 90khz clock
+	rtpClockRate = 90000
 )
 
 type packetConnReader struct {
@@ -22,13 +31,22 @@ func (r packetConnReader) Read(p []byte) (int, error) {
 type Decoder struct {
 	r   io.Reader
 	buf []byte
+
+	// for fragmented NALUs
+	fragmentedBuf []byte
+
+	// for access units
+	timeDecoder *rtptime.Decoder
+	pendingPkt  *rtp.Packet
 }
 
 // NewDecoder creates a decoder around a Reader.
+// The Reader can be nil when packets are passed to Decode().
 func NewDecoder(r io.Reader) *Decoder {
 	return &Decoder{
-		r:   r,
-		buf: make([]byte, 2048),
+		r:           r,
+		buf:         make([]byte, 2048),
+		timeDecoder: rtptime.NewDecoder(rtpClockRate),
 	}
 }
 
@@ -37,22 +55,42 @@ func NewDecoderFromPacketConn(pc net.PacketConn) *Decoder {
 	return NewDecoder(packetConnReader{pc})
 }
 
-// Read decodes NALUs from RTP/H264 packets.
-func (d *Decoder) Read() ([][]byte, error) {
+func (d *Decoder) readPacket() (*rtp.Packet, error) {
+	if d.pendingPkt != nil {
+		pkt := d.pendingPkt
+		d.pendingPkt = nil
+		return pkt, nil
+	}
+
 	n, err := d.r.Read(d.buf)
 	if err != nil {
 		return nil, err
 	}
 
-	pkt := rtp.Packet{}
+	pkt := &rtp.Packet{}
 	err = pkt.Unmarshal(d.buf[:n])
 	if err != nil {
 		return nil, err
 	}
-	payload := pkt.Payload
+
+	return pkt, nil
+}
+
+// decodePayload decodes the NALUs contained in a RTP/H264 payload.
+// It returns no NALUs when a fragmented NALU is not complete yet.
+func (d *Decoder) decodePayload(payload []byte) ([][]byte, error) {
+	if len(payload) < 1 {
+		d.fragmentedBuf = nil
+		return nil, fmt.Errorf("payload is too short")
+	}
 
 	typ := NALUType(payload[0] & 0x1F)
 
+	if d.fragmentedBuf != nil && typ != NALUTypeFuA {
+		d.fragmentedBuf = nil
+		return nil, fmt.Errorf("non-starting NALU is not FU-A")
+	}
+
 	switch typ {
 	case NALUTypeNonIDR, NALUTypeDataPartitionA, NALUTypeDataPartitionB,
 		NALUTypeDataPartitionC, NALUTypeIDR, NALUTypeSei, NALUTypeSPS,
@@ -64,57 +102,165 @@ func (d *Decoder) Read() ([][]byte, error) {
 		NALUTypeReserved23:
 		return [][]byte{payload}, nil
 
+	case NALUTypeStapA:
+		return decodeAggregated(payload[1:])
+
 	case NALUTypeFuA:
-		return d.readFragmented(payload)
+		return d.decodeFragmented(payload)
 
-	case NALUTypeStapA, NALUTypeStapB, NALUTypeMtap16, NALUTypeMtap24, NALUTypeFuB:
+	case NALUTypeStapB, NALUTypeMtap16, NALUTypeMtap24, NALUTypeFuB:
 		return nil, fmt.Errorf("NALU type not supported (%d)", typ)
 	}
 
 	return nil, fmt.Errorf("invalid NALU type (%d)", typ)
 }
 
-func (d *Decoder) readFragmented(payload []byte) ([][]byte, error) {
-	// A NALU can have any size; we can't preallocate it
-	var ret []byte
+func decodeAggregated(payload []byte) ([][]byte, error) {
+	var ret [][]byte
+
+	for len(payload) > 0 {
+		if len(payload) < 2 {
+			return nil, fmt.Errorf("invalid STAP-A packet")
+		}
+
+		size := int(binary.BigEndian.Uint16(payload))
+		payload = payload[2:]
+
+		if size == 0 || len(payload) < size {
+			return nil, fmt.Errorf("invalid STAP-A packet")
+		}
+
+		ret = append(ret, payload[:size])
+		payload = payload[size:]
+	}
+
+	if ret == nil {
+		return nil, fmt.Errorf("STAP-A packet doesn't contain any NALU")
+	}
+
+	return ret, nil
+}
+
+func (d *Decoder) decodeFragmented(payload []byte) ([][]byte, error) {
+	if len(payload) < 2 {
+		d.fragmentedBuf = nil
+		return nil, fmt.Errorf("invalid FU-A packet")
+	}
 
-	// process first nalu
-	nri := (payload[0] >> 5) & 0x03
 	start := payload[1] >> 7
-	if start != 1 {
-		return nil, fmt.Errorf("first NALU does not contain the start bit")
+	end := (payload[1] >> 6) & 0x01
+
+	if d.fragmentedBuf == nil {
+		if start != 1 {
+			return nil, fmt.Errorf("first NALU does not contain the start bit")
+		}
+
+		// A NALU can have any size; we can't preallocate it
+		nri := (payload[0] >> 5) & 0x03
+		typ := payload[1] & 0x1F
+		d.fragmentedBuf = append([]byte{(nri << 5) | typ}, payload[2:]...)
+
+	} else {
+		d.fragmentedBuf = append(d.fragmentedBuf, payload[2:]...)
 	}
-	typ := payload[1] & 0x1F
-	ret = append([]byte{(nri << 5) | typ}, payload[2:]...)
 
-	// process other nalus
+	if end != 1 {
+		return nil, nil
+	}
+
+	nalu := d.fragmentedBuf
+	d.fragmentedBuf = nil
+	return [][]byte{nalu}, nil
+}
+
+// Decode decodes NALUs from a RTP/H264 packet.
+// It returns the NALUs and their timestamp, relative to the first decoded packet.
+// If a NALU is fragmented into multiple packets, it returns no NALUs
+// until the last fragment is received.
+func (d *Decoder) Decode(byts []byte) ([][]byte, time.Duration, error) {
+	pkt := rtp.Packet{}
+	err := pkt.Unmarshal(byts)
+	if err != nil {
+		d.fragmentedBuf = nil
+		return nil, 0, err
+	}
+
+	ts := d.timeDecoder.Decode(pkt.Timestamp)
+
+	nalus, err := d.decodePayload(pkt.Payload)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return nalus, ts, nil
+}
+
+// Read decodes NALUs from RTP/H264 packets.
+func (d *Decoder) Read() ([][]byte, error) {
 	for {
-		n, err := d.r.Read(d.buf)
+		pkt, err := d.readPacket()
 		if err != nil {
 			return nil, err
 		}
 
-		pkt := rtp.Packet{}
-		err = pkt.Unmarshal(d.buf[:n])
+		nalus, err := d.decodePayload(pkt.Payload)
 		if err != nil {
 			return nil, err
 		}
-		payload := pkt.Payload
 
-		typ := NALUType(payload[0] & 0x1F)
-		if typ != NALUTypeFuA {
-			return nil, fmt.Errorf("non-starting NALU is not FU-A")
+		if nalus != nil {
+			return nalus, nil
 		}
-		end := (payload[1] >> 6) & 0x01
+	}
+}
 
-		ret = append(ret, payload[2:]...)
+// ReadAccessUnit decodes an access unit from RTP/H264 packets.
+// It returns the NALUs of the access unit and its timestamp, relative to
+// the first decoded packet. An access unit ends when a packet with the
+// marker bit is received, or when a packet with a different timestamp
+// is received, in case the marker bit is not used by the sender.
+func (d *Decoder) ReadAccessUnit() ([][]byte, time.Duration, error) {
+	var nalus [][]byte
+	var auTs uint32
+	auStarted := false
 
-		if end == 1 {
-			break
+	for {
+		pkt, err := d.readPacket()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if auStarted && pkt.Timestamp != auTs {
+			// a fragmented NALU can't be completed with a packet of
+			// another access unit
+			d.fragmentedBuf = nil
+
+			if nalus != nil {
+				// the packet belongs to the next access unit.
+				// its payload must be copied since the buffer is reused.
+				pkt.Payload = append([]byte(nil), pkt.Payload...)
+				d.pendingPkt = pkt
+				return nalus, d.timeDecoder.Decode(auTs), nil
+			}
 		}
-	}
 
-	return [][]byte{ret}, nil
+		auStarted = true
+		auTs = pkt.Timestamp
+
+		pktNALUs, err := d.decodePayload(pkt.Payload)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// NALUs must be copied since the buffer is reused
+		for _, nalu := range pktNALUs {
+			nalus = append(nalus, append([]byte(nil), nalu...))
+		}
+
+		if pkt.Marker && nalus != nil && d.fragmentedBuf == nil {
+			return nalus, d.timeDecoder.Decode(auTs), nil
+		}
+	}
 }
 
 // ReadSPSPPS decodes NALUs until SPS and PPS are found.
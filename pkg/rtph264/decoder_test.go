@@ -0,0 +1,152 @@
+package rtph264
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+type packetReader struct {
+	packets [][]byte
+}
+
+func (r *packetReader) Read(p []byte) (int, error) {
+	if len(r.packets) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(p, r.packets[0])
+	r.packets = r.packets[1:]
+	return n, nil
+}
+
+func TestDecoderRead(t *testing.T) {
+	e, err := NewEncoderWithPayloadMaxSize(96, 10)
+	require.NoError(t, err)
+
+	nalus := [][]byte{
+		{0x67, 0x01},
+		{0x68, 0x02},
+		append([]byte{0x65}, bytes.Repeat([]byte{0x01}, 12)...),
+	}
+
+	frames, err := e.Write(1*time.Second, nalus)
+	require.NoError(t, err)
+
+	d := NewDecoder(&packetReader{frames})
+
+	// STAP-A
+	ret, err := d.Read()
+	require.NoError(t, err)
+	require.Equal(t, nalus[:2], ret)
+
+	// FU-A
+	ret, err = d.Read()
+	require.NoError(t, err)
+	require.Equal(t, nalus[2:], ret)
+}
+
+func TestDecoderReadAccessUnit(t *testing.T) {
+	e, err := NewEncoderWithPayloadMaxSize(96, 10)
+	require.NoError(t, err)
+
+	aus := [][][]byte{
+		{
+			{0x67, 0x01},
+			{0x68, 0x02},
+			append([]byte{0x65}, bytes.Repeat([]byte{0x01}, 12)...),
+		},
+		{
+			{0x41, 0x02},
+		},
+		{
+			append([]byte{0x41}, bytes.Repeat([]byte{0x03}, 20)...),
+		},
+	}
+
+	var packets [][]byte
+	for i, au := range aus {
+		frames, err := e.Write(1*time.Second+time.Duration(i)*40*time.Millisecond, au)
+		require.NoError(t, err)
+		packets = append(packets, frames...)
+	}
+
+	d := NewDecoder(&packetReader{packets})
+
+	for i, au := range aus {
+		nalus, ts, err := d.ReadAccessUnit()
+		require.NoError(t, err)
+		require.Equal(t, au, nalus)
+		require.Equal(t, time.Duration(i)*40*time.Millisecond, ts)
+	}
+
+	_, _, err = d.ReadAccessUnit()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestDecoderReadAccessUnitWithoutMarker(t *testing.T) {
+	var packets [][]byte
+	for i, payload := range [][]byte{
+		{0x67, 0x01},
+		{0x65, 0x02},
+		{0x41, 0x03},
+	} {
+		pkt := rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    96,
+				SequenceNumber: uint16(i),
+				Timestamp:      []uint32{0, 0, 9000}[i],
+			},
+			Payload: payload,
+		}
+		byts, err := pkt.Marshal()
+		require.NoError(t, err)
+		packets = append(packets, byts)
+	}
+
+	d := NewDecoder(&packetReader{packets})
+
+	nalus, ts, err := d.ReadAccessUnit()
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{0x67, 0x01}, {0x65, 0x02}}, nalus)
+	require.Equal(t, time.Duration(0), ts)
+
+	// the last access unit is never terminated
+	_, _, err = d.ReadAccessUnit()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestDecoderDecode(t *testing.T) {
+	e, err := NewEncoderWithPayloadMaxSize(96, 10)
+	require.NoError(t, err)
+
+	nalus := [][]byte{
+		{0x67, 0x01},
+		{0x68, 0x02},
+		append([]byte{0x65}, bytes.Repeat([]byte{0x01}, 12)...),
+	}
+
+	frames, err := e.Write(1*time.Second, nalus)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(frames))
+
+	d := NewDecoder(nil)
+
+	ret, ts, err := d.Decode(frames[0])
+	require.NoError(t, err)
+	require.Equal(t, nalus[:2], ret)
+	require.Equal(t, time.Duration(0), ts)
+
+	ret, _, err = d.Decode(frames[1])
+	require.NoError(t, err)
+	require.Nil(t, ret)
+
+	ret, _, err = d.Decode(frames[2])
+	require.NoError(t, err)
+	require.Equal(t, nalus[2:], ret)
+}
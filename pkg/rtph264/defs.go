@@ -1,9 +1,13 @@
 package rtph264
 
+import (
+	"github.com/aler9/gortsplib/pkg/h264"
+)
+
 // NALUType is the type of a NALU.
-type NALUType uint8
+type NALUType = h264.NALUType
 
-// standard NALU types.
+// standard NALU types, and the ones defined by RTP/H264 (RFC 6184).
 const (
 	NALUTypeNonIDR                        NALUType = 1
 	NALUTypeDataPartitionA                NALUType = 2
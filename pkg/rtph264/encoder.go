@@ -1,102 +1,173 @@
 package rtph264
 
 import (
+	"fmt"
 	"math/rand"
 	"time"
 
 	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/rtptime"
 )
 
 const (
-	rtpVersion        = 0x02
-	rtpPayloadMaxSize = 1460 // 1500 (mtu) - 20 (ip header) - 8 (udp header) - 12 (rtp header)
+	rtpVersion = 0x02
+
+	// DefaultPayloadMaxSize is the default maximum size of RTP payloads.
+	DefaultPayloadMaxSize = 1460 // 1500 (mtu) - 20 (ip header) - 8 (udp header) - 12 (rtp header)
 )
 
 // Encoder is a RTP/H264 encoder.
 type Encoder struct {
 	payloadType    uint8
+	payloadMaxSize int
 	sequenceNumber uint16
 	ssrc           uint32
-	initialTs      uint32
+	timeEncoder    *rtptime.Encoder
 	started        time.Duration
 }
 
 // NewEncoder allocates an Encoder.
 func NewEncoder(payloadType uint8) (*Encoder, error) {
+	return NewEncoderWithPayloadMaxSize(payloadType, DefaultPayloadMaxSize)
+}
+
+// NewEncoderWithPayloadMaxSize allocates an Encoder that produces RTP payloads
+// with the given maximum size.
+func NewEncoderWithPayloadMaxSize(payloadType uint8, payloadMaxSize int) (*Encoder, error) {
+	// a FU-A payload must contain at least one byte of NALU
+	if payloadMaxSize < 3 {
+		return nil, fmt.Errorf("payload max size is too small (%d)", payloadMaxSize)
+	}
+
 	return &Encoder{
 		payloadType:    payloadType,
+		payloadMaxSize: payloadMaxSize,
 		sequenceNumber: uint16(rand.Uint32()),
 		ssrc:           rand.Uint32(),
-		initialTs:      rand.Uint32(),
+		timeEncoder:    rtptime.NewEncoder(rtpClockRate, rand.Uint32()),
 	}, nil
 }
 
-// Write encodes NALUs into RTP/H264 packets.
+// Write encodes the NALUs of an access unit into RTP/H264 packets.
+// Small NALUs are aggregated into STAP-A payloads, while big NALUs are
+// split into FU-A payloads.
 func (e *Encoder) Write(ts time.Duration, nalus [][]byte) ([][]byte, error) {
 	if e.started == 0 {
 		e.started = ts
 	}
 
-	// rtp/h264 uses a 90khz clock
-	rtpTime := e.initialTs + uint32((ts-e.started).Seconds()*90000)
+	rtpTime := e.timeEncoder.Encode(ts - e.started)
 
-	var frames [][]byte
+	var payloads [][]byte
+	var batch [][]byte
+	batchSize := 1 // STAP-A header
 
-	for i, nalu := range nalus {
-		naluFrames, err := e.writeNALU(rtpTime, nalu, (i == len(nalus)-1))
-		if err != nil {
-			return nil, err
+	flush := func() {
+		switch len(batch) {
+		case 0:
+		case 1:
+			payloads = append(payloads, batch[0])
+		default:
+			payloads = append(payloads, e.writeAggregated(batch, batchSize))
 		}
-		frames = append(frames, naluFrames...)
+		batch = nil
+		batchSize = 1
 	}
 
-	return frames, nil
-}
+	for _, nalu := range nalus {
+		if len(nalu) == 0 {
+			return nil, fmt.Errorf("NALU is empty")
+		}
+
+		// the NALU can be aggregated with the previous ones
+		if (batchSize + 2 + len(nalu)) <= e.payloadMaxSize {
+			batch = append(batch, nalu)
+			batchSize += 2 + len(nalu)
+			continue
+		}
+
+		flush()
+
+		// the NALU can start a new aggregation
+		if (batchSize + 2 + len(nalu)) <= e.payloadMaxSize {
+			batch = append(batch, nalu)
+			batchSize += 2 + len(nalu)
+			continue
+		}
 
-func (e *Encoder) writeNALU(rtpTime uint32, nalu []byte, isFinal bool) ([][]byte, error) {
-	// if the NALU fits into a single RTP packet, use a single NALU payload
-	if len(nalu) < rtpPayloadMaxSize {
-		return e.writeSingle(rtpTime, nalu, isFinal)
+		// the NALU fits into a single RTP packet
+		if len(nalu) <= e.payloadMaxSize {
+			payloads = append(payloads, nalu)
+			continue
+		}
+
+		// otherwise, split the NALU into multiple fragmentation payloads
+		payloads = append(payloads, e.writeFragmented(nalu)...)
 	}
 
-	// otherwise, split the NALU into multiple fragmentation payloads
-	return e.writeFragmented(rtpTime, nalu, isFinal)
-}
+	flush()
+
+	frames := make([][]byte, len(payloads))
+
+	for i, payload := range payloads {
+		rpkt := rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    e.payloadType,
+				SequenceNumber: e.sequenceNumber,
+				Timestamp:      rtpTime,
+				SSRC:           e.ssrc,
+				// the marker is set on the last packet of the access unit
+				Marker: (i == len(payloads)-1),
+			},
+			Payload: payload,
+		}
+		e.sequenceNumber++
 
-func (e *Encoder) writeSingle(rtpTime uint32, nalu []byte, isFinal bool) ([][]byte, error) {
-	rpkt := rtp.Packet{
-		Header: rtp.Header{
-			Version:        rtpVersion,
-			PayloadType:    e.payloadType,
-			SequenceNumber: e.sequenceNumber,
-			Timestamp:      rtpTime,
-			SSRC:           e.ssrc,
-		},
-		Payload: nalu,
+		frame, err := rpkt.Marshal()
+		if err != nil {
+			return nil, err
+		}
+
+		frames[i] = frame
 	}
-	e.sequenceNumber++
 
-	if isFinal {
-		rpkt.Header.Marker = true
+	return frames, nil
+}
+
+func (e *Encoder) writeAggregated(nalus [][]byte, size int) []byte {
+	payload := make([]byte, 1, size)
+
+	// the F bit and the NRI field are the maximum among the aggregated NALUs
+	var f, nri uint8
+	for _, nalu := range nalus {
+		f |= nalu[0] & 0x80
+		if v := (nalu[0] >> 5) & 0x03; v > nri {
+			nri = v
+		}
 	}
+	payload[0] = f | (nri << 5) | uint8(NALUTypeStapA)
 
-	frame, err := rpkt.Marshal()
-	if err != nil {
-		return nil, err
+	for _, nalu := range nalus {
+		payload = append(payload, byte(len(nalu)>>8), byte(len(nalu)))
+		payload = append(payload, nalu...)
 	}
 
-	return [][]byte{frame}, nil
+	return payload
 }
 
-func (e *Encoder) writeFragmented(rtpTime uint32, nalu []byte, isFinal bool) ([][]byte, error) {
+func (e *Encoder) writeFragmented(nalu []byte) [][]byte {
 	// use only FU-A, not FU-B, since we always use non-interleaved mode
 	// (packetization-mode=1)
-	frameCount := (len(nalu) - 1) / (rtpPayloadMaxSize - 2)
-	lastFrameSize := (len(nalu) - 1) % (rtpPayloadMaxSize - 2)
+	frameCount := (len(nalu) - 1) / (e.payloadMaxSize - 2)
+	lastFrameSize := (len(nalu) - 1) % (e.payloadMaxSize - 2)
 	if lastFrameSize > 0 {
 		frameCount++
+	} else {
+		lastFrameSize = e.payloadMaxSize - 2
 	}
-	frames := make([][]byte, frameCount)
+	payloads := make([][]byte, frameCount)
 
 	nri := (nalu[0] >> 5) & 0x03
 	typ := nalu[0] & 0x1F
@@ -110,39 +181,16 @@ func (e *Encoder) writeFragmented(rtpTime uint32, nalu []byte, isFinal bool) ([]
 			start = 1
 		}
 		end := uint8(0)
-		le := rtpPayloadMaxSize - 2
-		if i == (len(frames) - 1) {
+		le := e.payloadMaxSize - 2
+		if i == (frameCount - 1) {
 			end = 1
 			le = lastFrameSize
 		}
 		header := (start << 7) | (end << 6) | typ
 
-		data := append([]byte{indicator, header}, nalu[:le]...)
+		payloads[i] = append([]byte{indicator, header}, nalu[:le]...)
 		nalu = nalu[le:]
-
-		rpkt := rtp.Packet{
-			Header: rtp.Header{
-				Version:        rtpVersion,
-				PayloadType:    e.payloadType,
-				SequenceNumber: e.sequenceNumber,
-				Timestamp:      rtpTime,
-				SSRC:           e.ssrc,
-			},
-			Payload: data,
-		}
-		e.sequenceNumber++
-
-		if isFinal && i == (len(frames)-1) {
-			rpkt.Header.Marker = true
-		}
-
-		frame, err := rpkt.Marshal()
-		if err != nil {
-			return nil, err
-		}
-
-		frames[i] = frame
 	}
 
-	return frames, nil
+	return payloads
 }
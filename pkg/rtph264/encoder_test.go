@@ -0,0 +1,89 @@
+package rtph264
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoder(t *testing.T) {
+	for _, ca := range []struct {
+		name     string
+		nalus    [][]byte
+		payloads [][]byte
+	}{
+		{
+			"single",
+			[][]byte{
+				bytes.Repeat([]byte{0x05}, 8),
+			},
+			[][]byte{
+				bytes.Repeat([]byte{0x05}, 8),
+			},
+		},
+		{
+			"aggregated",
+			[][]byte{
+				{0x67, 0x01},
+				{0x68, 0x02},
+				{0x05, 0x03},
+			},
+			[][]byte{
+				{
+					0x78,
+					0x00, 0x02, 0x67, 0x01,
+					0x00, 0x02, 0x68, 0x02,
+				},
+				{0x05, 0x03},
+			},
+		},
+		{
+			"fragmented",
+			[][]byte{
+				append([]byte{0x65}, bytes.Repeat([]byte{0x01}, 20)...),
+			},
+			[][]byte{
+				append([]byte{0x7c, 0x85}, bytes.Repeat([]byte{0x01}, 8)...),
+				append([]byte{0x7c, 0x05}, bytes.Repeat([]byte{0x01}, 8)...),
+				append([]byte{0x7c, 0x45}, bytes.Repeat([]byte{0x01}, 4)...),
+			},
+		},
+		{
+			"aggregated and fragmented",
+			[][]byte{
+				{0x67, 0x01},
+				{0x68, 0x02},
+				append([]byte{0x65}, bytes.Repeat([]byte{0x01}, 12)...),
+			},
+			[][]byte{
+				{
+					0x78,
+					0x00, 0x02, 0x67, 0x01,
+					0x00, 0x02, 0x68, 0x02,
+				},
+				append([]byte{0x7c, 0x85}, bytes.Repeat([]byte{0x01}, 8)...),
+				append([]byte{0x7c, 0x45}, bytes.Repeat([]byte{0x01}, 4)...),
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			e, err := NewEncoderWithPayloadMaxSize(96, 10)
+			require.NoError(t, err)
+
+			frames, err := e.Write(1*time.Second, ca.nalus)
+			require.NoError(t, err)
+			require.Equal(t, len(ca.payloads), len(frames))
+
+			for i, frame := range frames {
+				var pkt rtp.Packet
+				err := pkt.Unmarshal(frame)
+				require.NoError(t, err)
+				require.Equal(t, ca.payloads[i], pkt.Payload)
+				require.Equal(t, i == len(frames)-1, pkt.Marker)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+// Package rtph265 contains a RTP/H265 decoder.
+package rtph265
+
+import (
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/pion/rtp"
+)
+
+type packetConnReader struct {
+	inner net.PacketConn
+}
+
+func (r packetConnReader) Read(p []byte) (int, error) {
+	n, _, err := r.inner.ReadFrom(p)
+	return n, err
+}
+
+// Decoder is a RTP/H265 decoder.
+type Decoder struct {
+	r   io.Reader
+	buf []byte
+}
+
+// NewDecoder creates a decoder around a Reader.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		r:   r,
+		buf: make([]byte, 2048),
+	}
+}
+
+// NewDecoderFromPacketConn creates a decoder around a net.PacketConn.
+func NewDecoderFromPacketConn(pc net.PacketConn) *Decoder {
+	return NewDecoder(packetConnReader{pc})
+}
+
+// Read decodes NALUs from RTP/H265 packets.
+func (d *Decoder) Read() ([][]byte, error) {
+	payload, err := d.readPayload()
+	if err != nil {
+		return nil, err
+	}
+
+	typ := NALUType((payload[0] >> 1) & 0x3F)
+
+	switch typ {
+	case NALUTypeAP:
+		return d.readAggregated(payload)
+
+	case NALUTypeFU:
+		return d.readFragmented(payload)
+
+	case NALUTypePACI:
+		return nil, fmt.Errorf("NALU type not supported (%d)", typ)
+	}
+
+	if typ > NALUTypePACI {
+		return nil, fmt.Errorf("invalid NALU type (%d)", typ)
+	}
+
+	return [][]byte{payload}, nil
+}
+
+func (d *Decoder) readPayload() ([]byte, error) {
+	n, err := d.r.Read(d.buf)
+	if err != nil {
+		return nil, err
+	}
+
+	pkt := rtp.Packet{}
+	err = pkt.Unmarshal(d.buf[:n])
+	if err != nil {
+		return nil, err
+	}
+
+	// the NALU header is two bytes long
+	if len(pkt.Payload) < 2 {
+		return nil, fmt.Errorf("payload is too short")
+	}
+
+	return pkt.Payload, nil
+}
+
+func (d *Decoder) readAggregated(payload []byte) ([][]byte, error) {
+	// we assume that sprop-max-don-diff is zero, therefore DONL and DOND
+	// fields are not present
+	payload = payload[2:] // remove header
+
+	var ret [][]byte
+
+	for len(payload) > 0 {
+		if len(payload) < 2 {
+			return nil, fmt.Errorf("invalid aggregation unit (invalid size)")
+		}
+
+		size := int(uint16(payload[0])<<8 | uint16(payload[1]))
+		payload = payload[2:]
+
+		if size == 0 || size > len(payload) {
+			return nil, fmt.Errorf("invalid aggregation unit (invalid size)")
+		}
+
+		// copy the NALU, since the buffer is reused
+		ret = append(ret, append([]byte(nil), payload[:size]...))
+		payload = payload[size:]
+	}
+
+	if ret == nil {
+		return nil, fmt.Errorf("aggregation unit doesn't contain any NALU")
+	}
+
+	return ret, nil
+}
+
+func (d *Decoder) readFragmented(payload []byte) ([][]byte, error) {
+	if len(payload) < 3 {
+		return nil, fmt.Errorf("payload is too short")
+	}
+
+	// A NALU can have any size; we can't preallocate it
+	var ret []byte
+
+	// process first nalu
+	start := payload[2] >> 7
+	if start != 1 {
+		return nil, fmt.Errorf("first NALU does not contain the start bit")
+	}
+	typ := payload[2] & 0x3F
+	ret = append([]byte{(payload[0] & 0x81) | (typ << 1), payload[1]}, payload[3:]...)
+
+	// process other nalus
+	for {
+		payload, err := d.readPayload()
+		if err != nil {
+			return nil, err
+		}
+
+		typ := NALUType((payload[0] >> 1) & 0x3F)
+		if typ != NALUTypeFU || len(payload) < 3 {
+			return nil, fmt.Errorf("non-starting NALU is not FU")
+		}
+		end := (payload[2] >> 6) & 0x01
+
+		ret = append(ret, payload[3:]...)
+
+		if end == 1 {
+			break
+		}
+	}
+
+	return [][]byte{ret}, nil
+}
+
+// ReadVPSSPSPPS decodes NALUs until VPS, SPS and PPS are found.
+func (d *Decoder) ReadVPSSPSPPS() ([]byte, []byte, []byte, error) {
+	var vps []byte
+	var sps []byte
+	var pps []byte
+
+	for {
+		nalus, err := d.Read()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		for _, nalu := range nalus {
+			switch NALUType((nalu[0] >> 1) & 0x3F) {
+			case NALUTypeVPS:
+				vps = append([]byte(nil), nalu...)
+
+			case NALUTypeSPS:
+				sps = append([]byte(nil), nalu...)
+
+			case NALUTypePPS:
+				pps = append([]byte(nil), nalu...)
+			}
+
+			if vps != nil && sps != nil && pps != nil {
+				return vps, sps, pps, nil
+			}
+		}
+	}
+}
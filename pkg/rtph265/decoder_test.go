@@ -0,0 +1,105 @@
+package rtph265
+
+import (
+	"io"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+type packetReader struct {
+	packets [][]byte
+}
+
+func (r *packetReader) Read(p []byte) (int, error) {
+	if len(r.packets) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.packets[0])
+	r.packets = r.packets[1:]
+	return n, nil
+}
+
+func mustMarshal(payload []byte) []byte {
+	pkt := rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 17645,
+			Timestamp:      2289526357,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: payload,
+	}
+	byts, err := pkt.Marshal()
+	if err != nil {
+		panic(err)
+	}
+	return byts
+}
+
+func TestDecoder(t *testing.T) {
+	for _, ca := range []struct {
+		name    string
+		packets [][]byte
+		nalus   [][]byte
+	}{
+		{
+			"single",
+			[][]byte{
+				mustMarshal([]byte{0x02, 0x01, 0xaa, 0xbb}),
+			},
+			[][]byte{
+				{0x02, 0x01, 0xaa, 0xbb},
+			},
+		},
+		{
+			"aggregated",
+			[][]byte{
+				mustMarshal([]byte{
+					0x60, 0x01,
+					0x00, 0x03, 0x40, 0x01, 0x0c,
+					0x00, 0x04, 0x42, 0x01, 0x01, 0x60,
+					0x00, 0x03, 0x44, 0x01, 0xc0,
+				}),
+			},
+			[][]byte{
+				{0x40, 0x01, 0x0c},
+				{0x42, 0x01, 0x01, 0x60},
+				{0x44, 0x01, 0xc0},
+			},
+		},
+		{
+			"fragmented",
+			[][]byte{
+				mustMarshal([]byte{0x62, 0x01, 0x93, 0xaf, 0xaa}),
+				mustMarshal([]byte{0x62, 0x01, 0x13, 0xbb}),
+				mustMarshal([]byte{0x62, 0x01, 0x53, 0xcc, 0xdd}),
+			},
+			[][]byte{
+				{0x26, 0x01, 0xaf, 0xaa, 0xbb, 0xcc, 0xdd},
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder(&packetReader{ca.packets})
+			nalus, err := d.Read()
+			require.NoError(t, err)
+			require.Equal(t, ca.nalus, nalus)
+		})
+	}
+}
+
+func TestDecoderReadVPSSPSPPS(t *testing.T) {
+	d := NewDecoder(&packetReader{[][]byte{
+		mustMarshal([]byte{0x40, 0x01, 0x0c}),
+		mustMarshal([]byte{0x42, 0x01, 0x01, 0x60}),
+		mustMarshal([]byte{0x44, 0x01, 0xc0}),
+	}})
+	vps, sps, pps, err := d.ReadVPSSPSPPS()
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x40, 0x01, 0x0c}, vps)
+	require.Equal(t, []byte{0x42, 0x01, 0x01, 0x60}, sps)
+	require.Equal(t, []byte{0x44, 0x01, 0xc0}, pps)
+}
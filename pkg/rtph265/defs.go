@@ -0,0 +1,36 @@
+package rtph265
+
+// NALUType is the type of a NALU.
+type NALUType uint8
+
+// standard NALU types.
+const (
+	NALUTypeTrailN        NALUType = 0
+	NALUTypeTrailR        NALUType = 1
+	NALUTypeTsaN          NALUType = 2
+	NALUTypeTsaR          NALUType = 3
+	NALUTypeStsaN         NALUType = 4
+	NALUTypeStsaR         NALUType = 5
+	NALUTypeRadlN         NALUType = 6
+	NALUTypeRadlR         NALUType = 7
+	NALUTypeRaslN         NALUType = 8
+	NALUTypeRaslR         NALUType = 9
+	NALUTypeBlaWLp        NALUType = 16
+	NALUTypeBlaWRadl      NALUType = 17
+	NALUTypeBlaNLp        NALUType = 18
+	NALUTypeIdrWRadl      NALUType = 19
+	NALUTypeIdrNLp        NALUType = 20
+	NALUTypeCraNut        NALUType = 21
+	NALUTypeVPS           NALUType = 32
+	NALUTypeSPS           NALUType = 33
+	NALUTypePPS           NALUType = 34
+	NALUTypeAccessUnitDel NALUType = 35
+	NALUTypeEOS           NALUType = 36
+	NALUTypeEOB           NALUType = 37
+	NALUTypeFD            NALUType = 38
+	NALUTypePrefixSEI     NALUType = 39
+	NALUTypeSuffixSEI     NALUType = 40
+	NALUTypeAP            NALUType = 48
+	NALUTypeFU            NALUType = 49
+	NALUTypePACI          NALUType = 50
+)
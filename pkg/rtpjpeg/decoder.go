@@ -0,0 +1,312 @@
+// Package rtpjpeg contains a RTP/JPEG decoder.
+package rtpjpeg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/rtptime"
+)
+
+const (
+	rtpClockRate = 90000
+)
+
+type quantTable struct {
+	// 0 for 8-bit tables, 1 for 16-bit tables
+	precision byte
+	data      []byte
+}
+
+// Decoder is a RTP/JPEG decoder (RFC 2435).
+// It reassembles JPEG images fragmented into multiple packets, and rebuilds
+// the JPEG headers, that are not transmitted.
+// It supports types 0 and 1 (YUV 4:2:2 and 4:2:0), with or without restart
+// markers (types 64 and 65), and quantization tables that are either computed
+// from the Q factor or transmitted in-band.
+type Decoder struct {
+	timeDecoder *rtptime.Decoder
+
+	// quantization tables transmitted in-band, by Q factor
+	quantTables map[uint8][]quantTable
+
+	// for fragmented images
+	fragmentedBuf    []byte
+	fragmentedTs     uint32
+	fragmentedHeader []byte
+}
+
+// NewDecoder allocates a Decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{
+		timeDecoder: rtptime.NewDecoder(rtpClockRate),
+		quantTables: make(map[uint8][]quantTable),
+	}
+}
+
+// Decode decodes a JPEG image from a RTP/JPEG packet.
+// It returns the image, including headers, and its timestamp, relative to the
+// first decoded packet.
+// Since images are usually fragmented into multiple packets, it returns no image
+// until the last fragment is received.
+func (d *Decoder) Decode(byts []byte) ([]byte, time.Duration, error) {
+	pkt := rtp.Packet{}
+	err := pkt.Unmarshal(byts)
+	if err != nil {
+		d.fragmentedBuf = nil
+		return nil, 0, err
+	}
+
+	ts := d.timeDecoder.Decode(pkt.Timestamp)
+
+	payload := pkt.Payload
+
+	// main JPEG header
+	if len(payload) < 8 {
+		d.fragmentedBuf = nil
+		return nil, 0, fmt.Errorf("payload is too short")
+	}
+
+	fragmentOffset := int(payload[1])<<16 | int(payload[2])<<8 | int(payload[3])
+	typ := payload[4]
+	q := payload[5]
+	width := int(payload[6]) * 8
+	height := int(payload[7]) * 8
+	payload = payload[8:]
+
+	// restart marker header
+	var restartInterval uint16
+	if typ >= 64 && typ <= 127 {
+		if len(payload) < 4 {
+			d.fragmentedBuf = nil
+			return nil, 0, fmt.Errorf("payload is too short")
+		}
+
+		restartInterval = binary.BigEndian.Uint16(payload)
+		payload = payload[4:]
+		typ -= 64
+	}
+
+	if typ > 1 {
+		d.fragmentedBuf = nil
+		return nil, 0, fmt.Errorf("unsupported type (%d)", typ)
+	}
+
+	if fragmentOffset == 0 {
+		var tables []quantTable
+		if q >= 128 {
+			tables, payload, err = d.readQuantTables(q, payload)
+			if err != nil {
+				d.fragmentedBuf = nil
+				return nil, 0, err
+			}
+		} else {
+			// 0 and 100-127 are reserved
+			if q == 0 || q >= 100 {
+				d.fragmentedBuf = nil
+				return nil, 0, fmt.Errorf("invalid Q factor (%d)", q)
+			}
+			tables = makeQuantTables(q)
+		}
+
+		if width == 0 || height == 0 {
+			d.fragmentedBuf = nil
+			return nil, 0, fmt.Errorf("invalid image size (%dx%d)", width, height)
+		}
+
+		// an image that was not completed is discarded
+		d.fragmentedHeader = writeHeaders(typ, width, height, restartInterval, tables)
+		d.fragmentedBuf = append([]byte(nil), payload...)
+		d.fragmentedTs = pkt.Timestamp
+
+	} else {
+		if d.fragmentedBuf == nil {
+			return nil, 0, fmt.Errorf("received a non-starting fragment")
+		}
+
+		if pkt.Timestamp != d.fragmentedTs || fragmentOffset != len(d.fragmentedBuf) {
+			err := fmt.Errorf("invalid fragment offset (%d vs %d), a packet has been lost",
+				fragmentOffset, len(d.fragmentedBuf))
+			d.fragmentedBuf = nil
+			return nil, 0, err
+		}
+
+		d.fragmentedBuf = append(d.fragmentedBuf, payload...)
+	}
+
+	if !pkt.Marker {
+		return nil, 0, nil
+	}
+
+	data := d.fragmentedBuf
+	d.fragmentedBuf = nil
+
+	image := make([]byte, 0, len(d.fragmentedHeader)+len(data)+2)
+	image = append(image, d.fragmentedHeader...)
+	image = append(image, data...)
+
+	// the EOI marker is optional
+	if !bytes.HasSuffix(data, []byte{0xFF, markerEOI}) {
+		image = append(image, 0xFF, markerEOI)
+	}
+
+	return image, ts, nil
+}
+
+func (d *Decoder) readQuantTables(q uint8, payload []byte) ([]quantTable, []byte, error) {
+	if len(payload) < 4 {
+		return nil, nil, fmt.Errorf("payload is too short")
+	}
+
+	precision := payload[1]
+	length := int(binary.BigEndian.Uint16(payload[2:]))
+	payload = payload[4:]
+
+	// tables are not included, use the ones previously received with the same Q
+	if length == 0 {
+		tables, ok := d.quantTables[q]
+		if !ok {
+			return nil, nil, fmt.Errorf("quantization tables of Q factor %d have not been received yet", q)
+		}
+		return tables, payload, nil
+	}
+
+	if len(payload) < length {
+		return nil, nil, fmt.Errorf("payload is too short")
+	}
+
+	data := payload[:length]
+	payload = payload[length:]
+
+	var tables []quantTable
+	for i := 0; len(data) > 0; i++ {
+		t := quantTable{
+			precision: (precision >> i) & 0x01,
+		}
+
+		size := 64
+		if t.precision == 1 {
+			size = 128
+		}
+
+		if len(data) < size {
+			return nil, nil, fmt.Errorf("invalid quantization tables")
+		}
+
+		// tables are stored, therefore they are copied
+		t.data = append([]byte(nil), data[:size]...)
+		data = data[size:]
+
+		tables = append(tables, t)
+	}
+
+	// a table for luminance and one for chrominance are needed
+	if len(tables) < 2 {
+		return nil, nil, fmt.Errorf("invalid quantization tables")
+	}
+
+	d.quantTables[q] = tables
+
+	return tables, payload, nil
+}
+
+// makeQuantTables computes the quantization tables of a Q factor between 1 and 99,
+// as described in RFC 2435, Appendix A.
+func makeQuantTables(q uint8) []quantTable {
+	factor := int(q)
+
+	var scale int
+	if factor < 50 {
+		scale = 5000 / factor
+	} else {
+		scale = 200 - factor*2
+	}
+
+	clamp := func(v int) byte {
+		if v < 1 {
+			return 1
+		}
+		if v > 255 {
+			return 255
+		}
+		return byte(v)
+	}
+
+	luma := make([]byte, 64)
+	chroma := make([]byte, 64)
+	for i := 0; i < 64; i++ {
+		luma[i] = clamp((lumaQuantizer[i]*scale + 50) / 100)
+		chroma[i] = clamp((chromaQuantizer[i]*scale + 50) / 100)
+	}
+
+	return []quantTable{{data: luma}, {data: chroma}}
+}
+
+func appendUint16(buf []byte, v uint16) []byte {
+	return append(buf, byte(v>>8), byte(v))
+}
+
+func appendHuffmanTable(buf []byte, classAndID byte, codeLens []byte, symbols []byte) []byte {
+	buf = append(buf, 0xFF, markerDHT)
+	buf = appendUint16(buf, uint16(2+1+len(codeLens)+len(symbols)))
+	buf = append(buf, classAndID)
+	buf = append(buf, codeLens...)
+	return append(buf, symbols...)
+}
+
+// writeHeaders generates the JPEG headers, as described in RFC 2435, Appendix B.
+func writeHeaders(typ uint8, width int, height int, restartInterval uint16, tables []quantTable) []byte {
+	buf := []byte{0xFF, markerSOI}
+
+	// the first table is used by luminance, the second one by chrominance
+	for i, t := range tables[:2] {
+		buf = append(buf, 0xFF, markerDQT)
+		buf = appendUint16(buf, uint16(2+1+len(t.data)))
+		buf = append(buf, (t.precision<<4)|byte(i))
+		buf = append(buf, t.data...)
+	}
+
+	buf = append(buf, 0xFF, markerSOF)
+	buf = appendUint16(buf, 17)
+	buf = append(buf, 8) // sample precision
+	buf = appendUint16(buf, uint16(height))
+	buf = appendUint16(buf, uint16(width))
+	buf = append(buf, 3) // component count
+
+	// component id, sampling factors, quantization table
+	if typ == 0 {
+		buf = append(buf, 0, 0x21, 0) // 4:2:2
+	} else {
+		buf = append(buf, 0, 0x22, 0) // 4:2:0
+	}
+	buf = append(buf, 1, 0x11, 1)
+	buf = append(buf, 2, 0x11, 1)
+
+	buf = appendHuffmanTable(buf, 0x00, lumDCCodeLens, lumDCSymbols)
+	buf = appendHuffmanTable(buf, 0x10, lumACCodeLens, lumACSymbols)
+	buf = appendHuffmanTable(buf, 0x01, chmDCCodeLens, chmDCSymbols)
+	buf = appendHuffmanTable(buf, 0x11, chmACCodeLens, chmACSymbols)
+
+	if restartInterval != 0 {
+		buf = append(buf, 0xFF, markerDRI)
+		buf = appendUint16(buf, 4)
+		buf = appendUint16(buf, restartInterval)
+	}
+
+	buf = append(buf, 0xFF, markerSOS)
+	buf = appendUint16(buf, 12)
+	buf = append(buf, 3) // component count
+
+	// component id, huffman tables
+	buf = append(buf, 0, 0x00)
+	buf = append(buf, 1, 0x11)
+	buf = append(buf, 2, 0x11)
+
+	buf = append(buf, 0, 63, 0) // spectral selection, successive approximation
+
+	return buf
+}
@@ -0,0 +1,270 @@
+package rtpjpeg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+type testJPEG struct {
+	image       image.Image
+	quantTables []byte
+	scan        []byte
+}
+
+// newTestJPEG encodes an image with the standard library, that uses
+// the 4:2:0 subsampling and the standard huffman tables, and extracts
+// the quantization tables and the scan data.
+func newTestJPEG(t *testing.T, quality int) testJPEG {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 4), uint8(y * 5), uint8(x + y), 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	require.NoError(t, err)
+	byts := buf.Bytes()
+
+	ret := testJPEG{}
+
+	ret.image, err = jpeg.Decode(bytes.NewReader(byts))
+	require.NoError(t, err)
+
+	// skip SOI
+	byts = byts[2:]
+
+	for {
+		marker := byts[1]
+		length := int(binary.BigEndian.Uint16(byts[2:]))
+		segment := byts[4 : 2+length]
+		byts = byts[2+length:]
+
+		switch marker {
+		case markerDQT:
+			// skip the precision and destination of each table
+			for len(segment) > 0 {
+				ret.quantTables = append(ret.quantTables, segment[1:65]...)
+				segment = segment[65:]
+			}
+
+		case markerSOS:
+			// scan data is followed by EOI
+			ret.scan = byts[:len(byts)-2]
+			return ret
+		}
+	}
+}
+
+func mustMarshal(pkt rtp.Packet) []byte {
+	byts, err := pkt.Marshal()
+	if err != nil {
+		panic(err)
+	}
+	return byts
+}
+
+// packetize splits a scan into RTP/JPEG packets.
+func packetize(typ uint8, q uint8, restartInterval uint16, quantTables []byte,
+	scan []byte, timestamp uint32, maxSize int) [][]byte {
+	var ret [][]byte
+	offset := 0
+
+	for {
+		payload := []byte{
+			0, byte(offset >> 16), byte(offset >> 8), byte(offset),
+			typ, q, 64 / 8, 48 / 8,
+		}
+
+		if typ >= 64 {
+			payload = append(payload, byte(restartInterval>>8), byte(restartInterval), 0xFF, 0xFF)
+		}
+
+		if offset == 0 && q >= 128 {
+			payload = append(payload, 0, 0, byte(len(quantTables)>>8), byte(len(quantTables)))
+			payload = append(payload, quantTables...)
+		}
+
+		n := maxSize
+		if n > len(scan)-offset {
+			n = len(scan) - offset
+		}
+		payload = append(payload, scan[offset:offset+n]...)
+		offset += n
+
+		ret = append(ret, mustMarshal(rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         offset == len(scan),
+				PayloadType:    26,
+				SequenceNumber: 17645 + uint16(len(ret)),
+				Timestamp:      timestamp,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: payload,
+		}))
+
+		if offset == len(scan) {
+			return ret
+		}
+	}
+}
+
+func decodeAll(t *testing.T, d *Decoder, packets [][]byte) ([]byte, time.Duration) {
+	for i, pkt := range packets {
+		img, ts, err := d.Decode(pkt)
+		require.NoError(t, err)
+
+		if i != len(packets)-1 {
+			require.Nil(t, img)
+			continue
+		}
+
+		require.NotNil(t, img)
+		return img, ts
+	}
+	return nil, 0
+}
+
+func TestDecode(t *testing.T) {
+	for _, ca := range []struct {
+		name            string
+		typ             uint8
+		q               uint8
+		restartInterval uint16
+	}{
+		{
+			"q factor",
+			1,
+			50,
+			0,
+		},
+		{
+			"in-band tables",
+			1,
+			255,
+			0,
+		},
+		{
+			"restart markers",
+			65,
+			50,
+			100,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			ref := newTestJPEG(t, 50)
+
+			d := NewDecoder()
+
+			byts, ts := decodeAll(t, d, packetize(ca.typ, ca.q, ca.restartInterval,
+				ref.quantTables, ref.scan, 2289526357, 200))
+			require.Equal(t, time.Duration(0), ts)
+
+			img, err := jpeg.Decode(bytes.NewReader(byts))
+			require.NoError(t, err)
+			require.Equal(t, ref.image, img)
+
+			if ca.restartInterval != 0 {
+				require.True(t, bytes.Contains(byts, []byte{0xFF, markerDRI, 0x00, 0x04, 0x00, 100}))
+			}
+		})
+	}
+}
+
+func TestDecodeCachedQuantTables(t *testing.T) {
+	ref := newTestJPEG(t, 80)
+
+	d := NewDecoder()
+
+	_, _, err := d.Decode(packetize(1, 255, 0, nil, ref.scan, 2289526357, 1000)[0])
+	require.EqualError(t, err, "quantization tables of Q factor 255 have not been received yet")
+
+	decodeAll(t, d, packetize(1, 255, 0, ref.quantTables, ref.scan, 2289526357, 1000))
+
+	// tables are not sent again
+	byts, ts := decodeAll(t, d, packetize(1, 255, 0, nil, ref.scan, 2289526357+9000, 1000))
+	require.Equal(t, 100*time.Millisecond, ts)
+
+	img, err := jpeg.Decode(bytes.NewReader(byts))
+	require.NoError(t, err)
+	require.Equal(t, ref.image, img)
+}
+
+func TestDecodePacketLost(t *testing.T) {
+	ref := newTestJPEG(t, 50)
+
+	d := NewDecoder()
+
+	packets := packetize(1, 50, 0, nil, ref.scan, 2289526357, 50)
+	require.Greater(t, len(packets), 2)
+
+	_, _, err := d.Decode(packets[0])
+	require.NoError(t, err)
+
+	_, _, err = d.Decode(packets[2])
+	require.EqualError(t, err, "invalid fragment offset (100 vs 50), a packet has been lost")
+
+	// the image is discarded until the next one starts
+	_, _, err = d.Decode(packets[len(packets)-1])
+	require.EqualError(t, err, "received a non-starting fragment")
+
+	decodeAll(t, d, packets)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name    string
+		payload []byte
+		err     string
+	}{
+		{
+			"too short",
+			[]byte{0x00, 0x00, 0x00},
+			"payload is too short",
+		},
+		{
+			"unsupported type",
+			[]byte{0x00, 0x00, 0x00, 0x00, 0x02, 50, 8, 6},
+			"unsupported type (2)",
+		},
+		{
+			"invalid q",
+			[]byte{0x00, 0x00, 0x00, 0x00, 0x01, 110, 8, 6},
+			"invalid Q factor (110)",
+		},
+		{
+			"invalid size",
+			[]byte{0x00, 0x00, 0x00, 0x00, 0x01, 50, 0, 6},
+			"invalid image size (0x48)",
+		},
+		{
+			"invalid tables",
+			append([]byte{0x00, 0x00, 0x00, 0x00, 0x01, 255, 8, 6, 0x00, 0x00, 0x00, 64},
+				make([]byte, 64)...),
+			"invalid quantization tables",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			d := NewDecoder()
+			_, _, err := d.Decode(mustMarshal(rtp.Packet{
+				Header: rtp.Header{
+					Version:     2,
+					Marker:      true,
+					PayloadType: 26,
+				},
+				Payload: ca.payload,
+			}))
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+// Package rtpmpegts contains a RTP/MPEG-TS decoder and encoder.
+package rtpmpegts
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/rtptime"
+)
+
+const (
+	rtpClockRate = 90000
+
+	// PacketSize is the size of a MPEG-TS packet.
+	PacketSize = 188
+
+	syncByte = 0x47
+)
+
+// Decoder is a RTP/MPEG-TS decoder.
+// It outputs MPEG-TS packets aligned to the sync byte, even when
+// they are not aligned to RTP packets.
+type Decoder struct {
+	timeDecoder *rtptime.Decoder
+	buf         []byte
+	nextSeq     uint16
+}
+
+// NewDecoder allocates a Decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{
+		timeDecoder: rtptime.NewDecoder(rtpClockRate),
+	}
+}
+
+// Decode decodes MPEG-TS packets from a RTP/MPEG-TS packet.
+// It returns the MPEG-TS packets and their timestamp, relative to the
+// first decoded packet. Bytes that precede a sync byte are discarded,
+// while incomplete MPEG-TS packets are kept until they are completed by
+// following RTP packets, or discarded when a RTP packet is lost.
+func (d *Decoder) Decode(byts []byte) ([][]byte, time.Duration, error) {
+	pkt := rtp.Packet{}
+	err := pkt.Unmarshal(byts)
+	if err != nil {
+		d.buf = nil
+		return nil, 0, err
+	}
+
+	ts := d.timeDecoder.Decode(pkt.Timestamp)
+
+	// an incomplete MPEG-TS packet can't be completed when a RTP packet is lost
+	if d.buf != nil && pkt.SequenceNumber != d.nextSeq {
+		d.buf = nil
+	}
+	d.nextSeq = pkt.SequenceNumber + 1
+
+	d.buf = append(d.buf, pkt.Payload...)
+
+	var ret [][]byte
+
+	for {
+		// resync
+		if len(d.buf) > 0 && d.buf[0] != syncByte {
+			i := bytes.IndexByte(d.buf, syncByte)
+			if i < 0 {
+				d.buf = nil
+				break
+			}
+			d.buf = d.buf[i:]
+		}
+
+		if len(d.buf) < PacketSize {
+			break
+		}
+
+		ret = append(ret, append([]byte(nil), d.buf[:PacketSize]...))
+		d.buf = d.buf[PacketSize:]
+	}
+
+	if len(d.buf) == 0 {
+		d.buf = nil
+	} else {
+		d.buf = append([]byte(nil), d.buf...)
+	}
+
+	return ret, ts, nil
+}
@@ -0,0 +1,108 @@
+package rtpmpegts
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func mustMarshal(pkt rtp.Packet) []byte {
+	byts, err := pkt.Marshal()
+	if err != nil {
+		panic(err)
+	}
+	return byts
+}
+
+func testPacket(v byte) []byte {
+	return append([]byte{syncByte}, bytes.Repeat([]byte{v}, PacketSize-1)...)
+}
+
+func TestEncodeDecode(t *testing.T) {
+	e, err := NewEncoder()
+	require.NoError(t, err)
+
+	d := NewDecoder()
+
+	var packets [][]byte
+	for i := 0; i < 10; i++ {
+		packets = append(packets, testPacket(byte(i)))
+	}
+
+	frames, err := e.Write(1*time.Second, packets)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(frames))
+
+	var dec [][]byte
+	for _, frame := range frames {
+		ret, ts, err := d.Decode(frame)
+		require.NoError(t, err)
+		require.Equal(t, time.Duration(0), ts)
+		dec = append(dec, ret...)
+	}
+	require.Equal(t, packets, dec)
+
+	_, err = e.Write(0, [][]byte{{0x47, 0x01}})
+	require.EqualError(t, err, "invalid MPEG-TS packet")
+}
+
+func TestDecodeUnaligned(t *testing.T) {
+	d := NewDecoder()
+
+	// garbage, a packet and the first half of another packet
+	payload := append([]byte{0x01, 0x02}, testPacket(1)...)
+	payload = append(payload, testPacket(2)[:100]...)
+
+	ret, _, err := d.Decode(mustMarshal(rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    33,
+			SequenceNumber: 1,
+			Timestamp:      90000,
+		},
+		Payload: payload,
+	}))
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{testPacket(1)}, ret)
+
+	ret, ts, err := d.Decode(mustMarshal(rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    33,
+			SequenceNumber: 2,
+			Timestamp:      90000 + 9000,
+		},
+		Payload: testPacket(2)[100:],
+	}))
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{testPacket(2)}, ret)
+	require.Equal(t, 100*time.Millisecond, ts)
+}
+
+func TestDecodePacketLost(t *testing.T) {
+	d := NewDecoder()
+
+	_, _, err := d.Decode(mustMarshal(rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    33,
+			SequenceNumber: 1,
+		},
+		Payload: testPacket(1)[:100],
+	}))
+	require.NoError(t, err)
+
+	ret, _, err := d.Decode(mustMarshal(rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    33,
+			SequenceNumber: 3,
+		},
+		Payload: append(testPacket(2)[100:], testPacket(3)...),
+	}))
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{testPacket(3)}, ret)
+}
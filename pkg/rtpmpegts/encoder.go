@@ -0,0 +1,93 @@
+package rtpmpegts
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/rtptime"
+)
+
+const (
+	rtpVersion = 0x02
+
+	// packets per RTP packet, in order to fit a 1500 bytes MTU
+	packetsPerRTPPacket = 7
+)
+
+// Encoder is a RTP/MPEG-TS encoder.
+type Encoder struct {
+	sequenceNumber uint16
+	ssrc           uint32
+	timeEncoder    *rtptime.Encoder
+	started        time.Duration
+}
+
+// NewEncoder allocates an Encoder.
+// The payload type is always the static payload type 33.
+func NewEncoder() (*Encoder, error) {
+	return &Encoder{
+		sequenceNumber: uint16(rand.Uint32()),
+		ssrc:           rand.Uint32(),
+		timeEncoder:    rtptime.NewEncoder(rtpClockRate, rand.Uint32()),
+	}, nil
+}
+
+// Write encodes MPEG-TS packets into RTP/MPEG-TS packets.
+// Every RTP packet contains up to 7 MPEG-TS packets.
+func (e *Encoder) Write(ts time.Duration, packets [][]byte) ([][]byte, error) {
+	if len(packets) == 0 {
+		return nil, fmt.Errorf("no MPEG-TS packets provided")
+	}
+
+	for _, p := range packets {
+		if len(p) != PacketSize || p[0] != syncByte {
+			return nil, fmt.Errorf("invalid MPEG-TS packet")
+		}
+	}
+
+	if e.started == 0 {
+		e.started = ts
+	}
+
+	rtpTs := e.timeEncoder.Encode(ts - e.started)
+
+	var frames [][]byte
+
+	for len(packets) > 0 {
+		n := packetsPerRTPPacket
+		if n > len(packets) {
+			n = len(packets)
+		}
+
+		payload := make([]byte, 0, n*PacketSize)
+		for _, p := range packets[:n] {
+			payload = append(payload, p...)
+		}
+		packets = packets[n:]
+
+		rpkt := rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    33,
+				SequenceNumber: e.sequenceNumber,
+				Timestamp:      rtpTs,
+				SSRC:           e.ssrc,
+				Marker:         (len(packets) == 0),
+			},
+			Payload: payload,
+		}
+		e.sequenceNumber++
+
+		byts, err := rpkt.Marshal()
+		if err != nil {
+			return nil, err
+		}
+
+		frames = append(frames, byts)
+	}
+
+	return frames, nil
+}
@@ -0,0 +1,163 @@
+// Package rtpnack implements utilities to request and perform the retransmission
+// of lost RTP packets, through generic NACKs (RFC 4585) and RTX packets (RFC 4588).
+package rtpnack
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+// maximum number of consecutive lost packets that are requested.
+// Bigger gaps are caused by discontinuities in the stream.
+const requesterMaxLost = 256
+
+var errPayloadTooShort = fmt.Errorf("payload is too short")
+
+// Requester is a utility to request the retransmission of lost RTP packets.
+// It detects gaps in sequence numbers of received packets and generates
+// generic NACKs that must be sent to the sender of the stream.
+type Requester struct {
+	receiverSSRC   uint32
+	rtxEnabled     bool
+	rtxPayloadType uint8
+	payloadType    uint8
+
+	initialized bool
+	expected    uint16
+	mediaSSRC   uint32
+}
+
+// NewRequester allocates a Requester.
+func NewRequester(receiverSSRC *uint32) *Requester {
+	return &Requester{
+		receiverSSRC: func() uint32 {
+			if receiverSSRC == nil {
+				return rand.Uint32()
+			}
+			return *receiverSSRC
+		}(),
+	}
+}
+
+// NewRequesterWithRTX allocates a Requester that decodes RTX packets, that are
+// packets retransmitted with a dedicated payload type.
+// payloadType is the payload type of the packets that are retransmitted.
+func NewRequesterWithRTX(receiverSSRC *uint32, rtxPayloadType uint8, payloadType uint8) *Requester {
+	r := NewRequester(receiverSSRC)
+	r.rtxEnabled = true
+	r.rtxPayloadType = rtxPayloadType
+	r.payloadType = payloadType
+	return r
+}
+
+// Process processes a received RTP packet.
+// It returns the packet, that is decoded when it is a RTX packet, or nil
+// if the packet must be discarded, and a RTCP NACK that requests the
+// retransmission of the packets that have been lost before it, or nil
+// when no packets have been lost.
+// Retransmitted packets are returned after the packets that follow them,
+// therefore they must be put in order by a rtpreorderer.Reorderer.
+func (r *Requester) Process(buf []byte) ([]byte, []byte) {
+	// do not parse the entire packet, extract only the fields we need
+	if len(buf) < 12 {
+		return buf, nil
+	}
+
+	if r.rtxEnabled && (buf[1]&0x7F) == r.rtxPayloadType {
+		if !r.initialized {
+			return nil, nil
+		}
+
+		pkt, err := decodeRTX(buf, r.payloadType, r.mediaSSRC)
+		if err != nil {
+			return nil, nil
+		}
+		return pkt, nil
+	}
+
+	sequenceNumber := binary.BigEndian.Uint16(buf[2:])
+	r.mediaSSRC = binary.BigEndian.Uint32(buf[8:])
+
+	if !r.initialized {
+		r.initialized = true
+		r.expected = sequenceNumber + 1
+		return buf, nil
+	}
+
+	diff := int16(sequenceNumber - r.expected)
+
+	// retransmitted or reordered packet
+	if diff < 0 {
+		return buf, nil
+	}
+
+	first := r.expected
+	r.expected = sequenceNumber + 1
+
+	if diff == 0 || diff > requesterMaxLost {
+		return buf, nil
+	}
+
+	nack := rtcp.TransportLayerNack{
+		SenderSSRC: r.receiverSSRC,
+		MediaSSRC:  r.mediaSSRC,
+		Nacks:      nackPairs(first, uint16(diff)),
+	}
+
+	byts, err := nack.Marshal()
+	if err != nil {
+		return buf, nil
+	}
+
+	return buf, byts
+}
+
+// nackPairs encodes a range of lost packets into NACK pairs.
+func nackPairs(first uint16, count uint16) []rtcp.NackPair {
+	var ret []rtcp.NackPair
+
+	for count > 0 {
+		pair := rtcp.NackPair{PacketID: first}
+		count--
+
+		// every pair contains the packet ID and a bitmask of the following 16 packets
+		n := count
+		if n > 16 {
+			n = 16
+		}
+		for i := uint16(0); i < n; i++ {
+			pair.LostPackets |= 1 << i
+		}
+
+		ret = append(ret, pair)
+		first += n + 1
+		count -= n
+	}
+
+	return ret
+}
+
+// decodeRTX decodes a RTX packet into the packet it retransmits.
+func decodeRTX(buf []byte, payloadType uint8, ssrc uint32) ([]byte, error) {
+	var pkt rtp.Packet
+	err := pkt.Unmarshal(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	// the payload starts with the original sequence number
+	if len(pkt.Payload) < 2 {
+		return nil, errPayloadTooShort
+	}
+
+	pkt.PayloadType = payloadType
+	pkt.SequenceNumber = binary.BigEndian.Uint16(pkt.Payload)
+	pkt.SSRC = ssrc
+	pkt.Payload = pkt.Payload[2:]
+
+	return pkt.Marshal()
+}
@@ -0,0 +1,83 @@
+package rtpnack
+
+import (
+	"testing"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func mustMarshalRTP(pkt rtp.Packet) []byte {
+	byts, _ := pkt.Marshal()
+	return byts
+}
+
+func rtpPacket(payloadType uint8, sequenceNumber uint16, ssrc uint32, payload []byte) []byte {
+	return mustMarshalRTP(rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    payloadType,
+			SequenceNumber: sequenceNumber,
+			Timestamp:      45343,
+			SSRC:           ssrc,
+		},
+		Payload: payload,
+	})
+}
+
+func TestRequester(t *testing.T) {
+	receiverSSRC := uint32(0x65f83afb)
+	r := NewRequester(&receiverSSRC)
+
+	for _, seq := range []uint16{65534, 65535, 0} {
+		pkt, nack := r.Process(rtpPacket(96, seq, 0x9dbb7812, []byte{0x01}))
+		require.Equal(t, rtpPacket(96, seq, 0x9dbb7812, []byte{0x01}), pkt)
+		require.Nil(t, nack)
+	}
+
+	// packets from 1 to 20 are lost
+	pkt, nack := r.Process(rtpPacket(96, 21, 0x9dbb7812, []byte{0x01}))
+	require.Equal(t, rtpPacket(96, 21, 0x9dbb7812, []byte{0x01}), pkt)
+
+	frames, err := rtcp.Unmarshal(nack)
+	require.NoError(t, err)
+	require.Equal(t, []rtcp.Packet{&rtcp.TransportLayerNack{
+		SenderSSRC: 0x65f83afb,
+		MediaSSRC:  0x9dbb7812,
+		Nacks: []rtcp.NackPair{
+			{PacketID: 1, LostPackets: 0xFFFF},
+			{PacketID: 18, LostPackets: 0x0003},
+		},
+	}}, frames)
+
+	// retransmitted packet
+	pkt, nack = r.Process(rtpPacket(96, 5, 0x9dbb7812, []byte{0x01}))
+	require.Equal(t, rtpPacket(96, 5, 0x9dbb7812, []byte{0x01}), pkt)
+	require.Nil(t, nack)
+
+	// discontinuity
+	_, nack = r.Process(rtpPacket(96, 1000, 0x9dbb7812, []byte{0x01}))
+	require.Nil(t, nack)
+}
+
+func TestRequesterRTX(t *testing.T) {
+	r := NewRequesterWithRTX(nil, 97, 96)
+
+	// RTX packets received before any other packet are discarded
+	pkt, _ := r.Process(rtpPacket(97, 300, 0x12345678, []byte{0x00, 0x05, 0x01}))
+	require.Nil(t, pkt)
+
+	r.Process(rtpPacket(96, 4, 0x9dbb7812, []byte{0x01}))
+
+	_, nack := r.Process(rtpPacket(96, 6, 0x9dbb7812, []byte{0x01}))
+	require.NotNil(t, nack)
+
+	pkt, nack = r.Process(rtpPacket(97, 301, 0x12345678, []byte{0x00, 0x05, 0x02}))
+	require.Equal(t, rtpPacket(96, 5, 0x9dbb7812, []byte{0x02}), pkt)
+	require.Nil(t, nack)
+
+	// invalid RTX packet
+	pkt, _ = r.Process(rtpPacket(97, 302, 0x12345678, []byte{0x00}))
+	require.Nil(t, pkt)
+}
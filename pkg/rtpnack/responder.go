@@ -0,0 +1,128 @@
+package rtpnack
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"sync"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+// Responder is a utility to retransmit lost RTP packets.
+// It stores the last sent packets and returns the ones that are
+// requested by generic NACKs sent by the receiver of the stream.
+type Responder struct {
+	size              int
+	rtxEnabled        bool
+	rtxPayloadType    uint8
+	rtxSSRC           uint32
+	rtxSequenceNumber uint16
+	mutex             sync.Mutex
+
+	buffer [][]byte
+}
+
+// NewResponder allocates a Responder.
+// size is the number of sent packets that are stored and can be retransmitted.
+// Packets are retransmitted as they are.
+func NewResponder(size int) *Responder {
+	return &Responder{
+		size:   size,
+		buffer: make([][]byte, size),
+	}
+}
+
+// NewResponderWithRTX allocates a Responder that retransmits packets inside
+// RTX packets, with the given payload type.
+func NewResponderWithRTX(size int, rtxPayloadType uint8) *Responder {
+	r := NewResponder(size)
+	r.rtxEnabled = true
+	r.rtxPayloadType = rtxPayloadType
+	r.rtxSSRC = rand.Uint32()
+	r.rtxSequenceNumber = uint16(rand.Uint32())
+	return r
+}
+
+// ProcessFrame stores a sent RTP packet.
+// The packet is copied, therefore buf can be reused after the call.
+func (r *Responder) ProcessFrame(buf []byte) {
+	if len(buf) < 12 {
+		return
+	}
+	sequenceNumber := binary.BigEndian.Uint16(buf[2:])
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	pos := int(sequenceNumber) % r.size
+	r.buffer[pos] = append(r.buffer[pos][:0], buf...)
+}
+
+// ProcessNACK processes RTCP frames sent by the receiver of the stream.
+// It returns the RTP packets that are requested by generic NACKs and are
+// still stored.
+func (r *Responder) ProcessNACK(buf []byte) [][]byte {
+	frames, err := rtcp.Unmarshal(buf)
+	if err != nil {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var ret [][]byte
+
+	for _, frame := range frames {
+		nack, ok := frame.(*rtcp.TransportLayerNack)
+		if !ok {
+			continue
+		}
+
+		for _, pair := range nack.Nacks {
+			for _, sequenceNumber := range pair.PacketList() {
+				pkt := r.buffer[int(sequenceNumber)%r.size]
+
+				// the packet has been overwritten by a newer one
+				if pkt == nil || binary.BigEndian.Uint16(pkt[2:]) != sequenceNumber {
+					continue
+				}
+
+				if !r.rtxEnabled {
+					ret = append(ret, append([]byte(nil), pkt...))
+					continue
+				}
+
+				rtx, err := r.encodeRTX(pkt)
+				if err != nil {
+					continue
+				}
+				ret = append(ret, rtx)
+			}
+		}
+	}
+
+	return ret
+}
+
+// encodeRTX encodes a packet into a RTX packet.
+func (r *Responder) encodeRTX(buf []byte) ([]byte, error) {
+	var pkt rtp.Packet
+	err := pkt.Unmarshal(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	// the payload starts with the original sequence number
+	payload := make([]byte, 2+len(pkt.Payload))
+	binary.BigEndian.PutUint16(payload, pkt.SequenceNumber)
+	copy(payload[2:], pkt.Payload)
+
+	pkt.PayloadType = r.rtxPayloadType
+	pkt.SequenceNumber = r.rtxSequenceNumber
+	pkt.SSRC = r.rtxSSRC
+	pkt.Payload = payload
+	r.rtxSequenceNumber++
+
+	return pkt.Marshal()
+}
@@ -0,0 +1,57 @@
+package rtpnack
+
+import (
+	"testing"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+)
+
+func nackPacket(pairs ...rtcp.NackPair) []byte {
+	byts, _ := rtcp.TransportLayerNack{
+		SenderSSRC: 0x65f83afb,
+		MediaSSRC:  0x9dbb7812,
+		Nacks:      pairs,
+	}.Marshal()
+	return byts
+}
+
+func TestResponder(t *testing.T) {
+	r := NewResponder(4)
+
+	for seq := uint16(65533); seq != 3; seq++ {
+		r.ProcessFrame(rtpPacket(96, seq, 0x9dbb7812, []byte{byte(seq)}))
+	}
+
+	// packets 65533 and 65534 have been overwritten
+	pkts := r.ProcessNACK(nackPacket(rtcp.NackPair{PacketID: 65533, LostPackets: 0x0007}))
+	require.Equal(t, [][]byte{
+		rtpPacket(96, 65535, 0x9dbb7812, []byte{0xFF}),
+		rtpPacket(96, 0, 0x9dbb7812, []byte{0x00}),
+	}, pkts)
+
+	// RTCP frames that are not NACKs are ignored
+	byts, _ := (&rtcp.ReceiverReport{SSRC: 0x65f83afb}).Marshal()
+	require.Nil(t, r.ProcessNACK(byts))
+}
+
+func TestResponderRTX(t *testing.T) {
+	r := NewResponderWithRTX(16, 97)
+	r.rtxSSRC = 0x12345678
+	r.rtxSequenceNumber = 300
+
+	r.ProcessFrame(rtpPacket(96, 5, 0x9dbb7812, []byte{0x01, 0x02}))
+	r.ProcessFrame(rtpPacket(96, 6, 0x9dbb7812, []byte{0x03, 0x04}))
+
+	pkts := r.ProcessNACK(nackPacket(rtcp.NackPair{PacketID: 5, LostPackets: 0x0001}))
+	require.Equal(t, [][]byte{
+		rtpPacket(97, 300, 0x12345678, []byte{0x00, 0x05, 0x01, 0x02}),
+		rtpPacket(97, 301, 0x12345678, []byte{0x00, 0x06, 0x03, 0x04}),
+	}, pkts)
+
+	// RTX packets are decoded by a Requester
+	req := NewRequesterWithRTX(nil, 97, 96)
+	req.Process(rtpPacket(96, 4, 0x9dbb7812, []byte{0x01}))
+	pkt, _ := req.Process(pkts[0])
+	require.Equal(t, rtpPacket(96, 5, 0x9dbb7812, []byte{0x01, 0x02}), pkt)
+}
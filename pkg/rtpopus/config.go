@@ -0,0 +1,85 @@
+package rtpopus
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Config contains the parameters of an Opus stream, that are
+// exchanged with the fmtp attribute (RFC 7587, section 6.1).
+type Config struct {
+	// channel count of the stream, that is 1 or 2.
+	ChannelCount int
+
+	// whether the sender uses the discontinuous transmission.
+	DTX bool
+
+	// whether the sender uses the in-band forward error correction.
+	InbandFEC bool
+
+	// maximum sample rate of the stream. It is zero when not specified.
+	MaxPlaybackRate int
+}
+
+func parseBool(params map[string]string, key string) (bool, bool, error) {
+	v, ok := params[key]
+	if !ok {
+		return false, false, nil
+	}
+
+	switch v {
+	case "0":
+		return false, true, nil
+
+	case "1":
+		return true, true, nil
+	}
+
+	return false, false, fmt.Errorf("invalid %s (%v)", key, v)
+}
+
+// UnmarshalFmtp fills the configuration with the parameters of a fmtp
+// attribute. Keys must be lower case. When a parameter is missing,
+// the default value of the RFC is used.
+func (c *Config) UnmarshalFmtp(params map[string]string) error {
+	*c = Config{
+		ChannelCount: 1,
+	}
+
+	// sprop-stereo describes the stream that is sent, while stereo
+	// describes the stream that is preferred by the receiver.
+	// The latter is used by servers that don't set the former.
+	stereo, ok, err := parseBool(params, "sprop-stereo")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		stereo, _, err = parseBool(params, "stereo")
+		if err != nil {
+			return err
+		}
+	}
+	if stereo {
+		c.ChannelCount = 2
+	}
+
+	c.DTX, _, err = parseBool(params, "usedtx")
+	if err != nil {
+		return err
+	}
+
+	c.InbandFEC, _, err = parseBool(params, "useinbandfec")
+	if err != nil {
+		return err
+	}
+
+	if v, ok := params["maxplaybackrate"]; ok {
+		tmp, err := strconv.ParseUint(v, 10, 31)
+		if err != nil {
+			return fmt.Errorf("invalid maxplaybackrate (%v)", v)
+		}
+		c.MaxPlaybackRate = int(tmp)
+	}
+
+	return nil
+}
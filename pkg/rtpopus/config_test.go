@@ -0,0 +1,74 @@
+package rtpopus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigUnmarshalFmtp(t *testing.T) {
+	for _, ca := range []struct {
+		name   string
+		params map[string]string
+		conf   Config
+	}{
+		{
+			"defaults",
+			nil,
+			Config{
+				ChannelCount: 1,
+			},
+		},
+		{
+			"sprop-stereo",
+			map[string]string{"sprop-stereo": "1", "stereo": "0"},
+			Config{
+				ChannelCount: 2,
+			},
+		},
+		{
+			"stereo",
+			map[string]string{"stereo": "1"},
+			Config{
+				ChannelCount: 2,
+			},
+		},
+		{
+			"all",
+			map[string]string{
+				"sprop-stereo":    "0",
+				"usedtx":          "1",
+				"useinbandfec":    "1",
+				"maxplaybackrate": "16000",
+			},
+			Config{
+				ChannelCount:    1,
+				DTX:             true,
+				InbandFEC:       true,
+				MaxPlaybackRate: 16000,
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var conf Config
+			err := conf.UnmarshalFmtp(ca.params)
+			require.NoError(t, err)
+			require.Equal(t, ca.conf, conf)
+		})
+	}
+}
+
+func TestConfigUnmarshalFmtpErrors(t *testing.T) {
+	for _, ca := range []struct {
+		params map[string]string
+		err    string
+	}{
+		{map[string]string{"sprop-stereo": "2"}, "invalid sprop-stereo (2)"},
+		{map[string]string{"usedtx": "yes"}, "invalid usedtx (yes)"},
+		{map[string]string{"maxplaybackrate": "-1"}, "invalid maxplaybackrate (-1)"},
+	} {
+		var conf Config
+		err := conf.UnmarshalFmtp(ca.params)
+		require.EqualError(t, err, ca.err)
+	}
+}
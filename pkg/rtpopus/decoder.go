@@ -0,0 +1,53 @@
+// Package rtpopus contains a RTP/Opus decoder and encoder.
+package rtpopus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/rtptime"
+)
+
+const (
+	// Opus always uses a 48khz clock, regardless of the sample rate
+	// of the stream.
+	rtpClockRate = 48000
+)
+
+// IsDTX returns whether an Opus packet is a discontinuous transmission
+// packet, that doesn't contain audio and signals silence.
+func IsDTX(packet []byte) bool {
+	return len(packet) <= 2
+}
+
+// Decoder is a RTP/Opus decoder.
+type Decoder struct {
+	timeDecoder *rtptime.Decoder
+}
+
+// NewDecoder allocates a Decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{
+		timeDecoder: rtptime.NewDecoder(rtpClockRate),
+	}
+}
+
+// Decode decodes an Opus packet from a RTP/Opus packet.
+// It returns the packet and its timestamp, relative to the first decoded packet.
+func (d *Decoder) Decode(byts []byte) ([]byte, time.Duration, error) {
+	pkt := rtp.Packet{}
+	err := pkt.Unmarshal(byts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ts := d.timeDecoder.Decode(pkt.Timestamp)
+
+	if len(pkt.Payload) == 0 {
+		return nil, 0, fmt.Errorf("payload is empty")
+	}
+
+	return pkt.Payload, ts, nil
+}
@@ -0,0 +1,40 @@
+package rtpopus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	e, err := NewEncoder(96)
+	require.NoError(t, err)
+
+	d := NewDecoder()
+
+	for i, ca := range []struct {
+		packet []byte
+		marker bool
+	}{
+		{[]byte{0xfc, 0x01, 0x02, 0x03}, true},
+		{[]byte{0xfc, 0x04, 0x05, 0x06}, false},
+		{[]byte{0xf8}, false},
+		{[]byte{0xfc, 0x07, 0x08, 0x09}, true},
+	} {
+		byts, err := e.Write(1*time.Second+time.Duration(i)*20*time.Millisecond, ca.packet)
+		require.NoError(t, err)
+
+		var pkt rtp.Packet
+		err = pkt.Unmarshal(byts)
+		require.NoError(t, err)
+		require.Equal(t, ca.marker, pkt.Marker)
+
+		packet, ts, err := d.Decode(byts)
+		require.NoError(t, err)
+		require.Equal(t, ca.packet, packet)
+		require.Equal(t, time.Duration(i)*20*time.Millisecond, ts)
+		require.Equal(t, len(ca.packet) == 1, IsDTX(packet))
+	}
+}
@@ -0,0 +1,74 @@
+package rtpopus
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/rtptime"
+)
+
+const (
+	rtpVersion        = 0x02
+	rtpPayloadMaxSize = 1460 // 1500 (mtu) - 20 (ip header) - 8 (udp header) - 12 (rtp header)
+)
+
+// Encoder is a RTP/Opus encoder.
+type Encoder struct {
+	payloadType    uint8
+	sequenceNumber uint16
+	ssrc           uint32
+	timeEncoder    *rtptime.Encoder
+	started        time.Duration
+	silence        bool
+}
+
+// NewEncoder allocates an Encoder.
+func NewEncoder(payloadType uint8) (*Encoder, error) {
+	return &Encoder{
+		payloadType:    payloadType,
+		sequenceNumber: uint16(rand.Uint32()),
+		ssrc:           rand.Uint32(),
+		timeEncoder:    rtptime.NewEncoder(rtpClockRate, rand.Uint32()),
+		silence:        true,
+	}, nil
+}
+
+// Write encodes an Opus packet into a RTP/Opus packet.
+// The marker is set on the first packet after a silence,
+// as suggested by RFC 7587.
+func (e *Encoder) Write(ts time.Duration, packet []byte) ([]byte, error) {
+	if len(packet) == 0 {
+		return nil, fmt.Errorf("packet is empty")
+	}
+
+	if len(packet) > rtpPayloadMaxSize {
+		return nil, fmt.Errorf("packet is too big")
+	}
+
+	if e.started == 0 {
+		e.started = ts
+	}
+
+	rtpTs := e.timeEncoder.Encode(ts - e.started)
+
+	dtx := IsDTX(packet)
+
+	rpkt := rtp.Packet{
+		Header: rtp.Header{
+			Version:        rtpVersion,
+			PayloadType:    e.payloadType,
+			SequenceNumber: e.sequenceNumber,
+			Timestamp:      rtpTs,
+			SSRC:           e.ssrc,
+			Marker:         e.silence && !dtx,
+		},
+		Payload: packet,
+	}
+	e.sequenceNumber++
+	e.silence = dtx
+
+	return rpkt.Marshal()
+}
@@ -0,0 +1,112 @@
+// Package rtppacer implements a utility to schedule the transmission of RTP packets.
+package rtppacer
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// when the time computed from timestamps differs from the current time
+	// more than this, the time reference is reset; this happens with
+	// discontinuities or when the source is slower than real time.
+	maxDrift = 1 * time.Second
+
+	// packets that would be sent later than this are discarded; this happens
+	// when the bitrate of the stream is greater than the maximum bitrate.
+	maxBacklog = 1 * time.Second
+)
+
+type pacerTrack struct {
+	clockRate float64
+
+	started   bool
+	startTime time.Time
+	startRTP  uint32
+}
+
+// Pacer schedules the transmission of RTP packets of one or more tracks,
+// in order to send them according to their timestamps and to spread bursts,
+// like the ones caused by keyframes, over time.
+type Pacer struct {
+	bitrate float64
+	mutex   sync.Mutex
+	tracks  map[int]*pacerTrack
+	next    time.Time
+}
+
+// New allocates a Pacer.
+// bitrate is the maximum bitrate, in bits per second, at which packets are sent.
+func New(bitrate int) *Pacer {
+	return &Pacer{
+		bitrate: float64(bitrate),
+		tracks:  make(map[int]*pacerTrack),
+	}
+}
+
+// AddTrack adds a track.
+// Packets of tracks that have not been added are sent as soon as the bitrate allows it.
+func (p *Pacer) AddTrack(trackID int, clockRate int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.tracks[trackID] = &pacerTrack{
+		clockRate: float64(clockRate),
+	}
+}
+
+// Schedule returns the time at which a RTP packet must be sent, or false
+// if the packet must be discarded, since the backlog is too long.
+// now is the current time, buf is the packet.
+func (p *Pacer) Schedule(now time.Time, trackID int, buf []byte) (time.Time, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	ret := now
+
+	if t, ok := p.tracks[trackID]; ok && len(buf) >= 12 {
+		rtpTime := uint32(buf[4])<<24 | uint32(buf[5])<<16 | uint32(buf[6])<<8 | uint32(buf[7])
+
+		if !t.started {
+			t.started = true
+			t.startTime = now
+			t.startRTP = rtpTime
+		} else {
+			ticks := int32(rtpTime - t.startRTP)
+			pts := t.startTime.Add(time.Duration(float64(ticks) * float64(time.Second) / t.clockRate))
+
+			if pts.Sub(now) > maxDrift || now.Sub(pts) > maxDrift {
+				t.startTime = now
+				t.startRTP = rtpTime
+			} else if pts.After(ret) {
+				ret = pts
+			}
+		}
+	}
+
+	if p.next.After(ret) {
+		if p.next.Sub(now) > maxBacklog {
+			return time.Time{}, false
+		}
+		ret = p.next
+	}
+
+	p.next = ret.Add(time.Duration(float64(len(buf)*8) * float64(time.Second) / p.bitrate))
+
+	return ret, true
+}
+
+// Wait waits until a RTP packet can be sent.
+// It returns false if the packet must be discarded.
+func (p *Pacer) Wait(trackID int, buf []byte) bool {
+	now := time.Now()
+	t, ok := p.Schedule(now, trackID, buf)
+	if !ok {
+		return false
+	}
+
+	if t.After(now) {
+		time.Sleep(t.Sub(now))
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package rtppacer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func rtpPacket(rtpTime uint32, size int) []byte {
+	buf := make([]byte, size)
+	buf[0] = 0x80
+	buf[4] = byte(rtpTime >> 24)
+	buf[5] = byte(rtpTime >> 16)
+	buf[6] = byte(rtpTime >> 8)
+	buf[7] = byte(rtpTime)
+	return buf
+}
+
+func TestPacer(t *testing.T) {
+	// 1 Mbit/s, 1250 bytes take 10ms
+	p := New(1000000)
+	p.AddTrack(0, 90000)
+
+	start := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+
+	for _, ca := range []struct {
+		name     string
+		now      time.Duration
+		trackID  int
+		rtpTime  uint32
+		size     int
+		expected time.Duration
+	}{
+		{
+			"first packet",
+			0,
+			0,
+			90000,
+			1250,
+			0,
+		},
+		{
+			"burst, limited by bitrate",
+			0,
+			0,
+			90000,
+			1250,
+			10 * time.Millisecond,
+		},
+		{
+			"following frame, scheduled by timestamp",
+			0,
+			0,
+			90000 + 9000,
+			1250,
+			100 * time.Millisecond,
+		},
+		{
+			"late frame, sent immediately",
+			300 * time.Millisecond,
+			0,
+			90000 + 18000,
+			1250,
+			300 * time.Millisecond,
+		},
+		{
+			"discontinuity, time reference reset",
+			400 * time.Millisecond,
+			0,
+			900000,
+			1250,
+			400 * time.Millisecond,
+		},
+		{
+			"after discontinuity",
+			400 * time.Millisecond,
+			0,
+			900000 + 4500,
+			1250,
+			450 * time.Millisecond,
+		},
+		{
+			"unknown track, limited by bitrate",
+			400 * time.Millisecond,
+			1,
+			0,
+			1250,
+			460 * time.Millisecond,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			ts, ok := p.Schedule(start.Add(ca.now), ca.trackID, rtpPacket(ca.rtpTime, ca.size))
+			require.True(t, ok)
+			require.Equal(t, start.Add(ca.expected), ts)
+		})
+	}
+}
+
+func TestPacerBacklog(t *testing.T) {
+	// 1 Mbit/s, 12500 bytes take 100ms
+	p := New(1000000)
+
+	start := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+
+	for i := 0; i <= 10; i++ {
+		ts, ok := p.Schedule(start, 0, rtpPacket(0, 12500))
+		require.True(t, ok)
+		require.Equal(t, start.Add(time.Duration(i)*100*time.Millisecond), ts)
+	}
+
+	// the backlog is longer than 1 second
+	_, ok := p.Schedule(start, 0, rtpPacket(0, 12500))
+	require.False(t, ok)
+
+	ts, ok := p.Schedule(start.Add(500*time.Millisecond), 0, rtpPacket(0, 12500))
+	require.True(t, ok)
+	require.Equal(t, start.Add(1100*time.Millisecond), ts)
+}
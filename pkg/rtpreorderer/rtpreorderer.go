@@ -0,0 +1,117 @@
+// Package rtpreorderer implements a utility to reorder RTP packets
+// received through unreliable transports (UDP).
+package rtpreorderer
+
+// Reorderer is a utility to reorder RTP packets.
+// Packets are identified by their sequence number and are returned
+// in order; packets that are not received before the buffer
+// is full are considered lost. Packets that precede the expected one
+// by more than the buffer size are considered a restart of the stream.
+type Reorderer struct {
+	size        int
+	initialized bool
+	expected    uint16
+	buffer      [][]byte
+	pos         int
+	count       int
+}
+
+// New allocates a Reorderer.
+// size is the maximum number of packets that can be buffered while waiting
+// for a missing packet.
+func New(size int) *Reorderer {
+	return &Reorderer{
+		size:   size,
+		buffer: make([][]byte, size),
+	}
+}
+
+// Process processes a RTP packet.
+// It returns a list of packets that are ready to be consumed, in order,
+// and the number of packets that have been lost.
+// Packets that are buffered are copied, therefore buf can be reused
+// after the call.
+func (r *Reorderer) Process(buf []byte) ([][]byte, int) {
+	// do not parse the entire packet, extract only the sequence number
+	if len(buf) < 4 {
+		return [][]byte{buf}, 0
+	}
+	sequenceNumber := uint16(buf[2])<<8 | uint16(buf[3])
+
+	if !r.initialized {
+		r.initialized = true
+		r.expected = sequenceNumber + 1
+		return [][]byte{buf}, 0
+	}
+
+	relPos := int16(sequenceNumber - r.expected)
+
+	if relPos < 0 {
+		// old packet or duplicate
+		if -int(relPos) <= r.size {
+			return nil, 0
+		}
+
+		// the gap is too big for the packet to be a late one:
+		// the stream has been restarted, therefore restart from the packet
+		ret := r.flush()
+		ret = append(ret, buf)
+		r.expected = sequenceNumber + 1
+		return ret, 0
+	}
+
+	// buffer is full: return all the buffered packets
+	// and consider the missing ones as lost
+	if int(relPos) >= r.size {
+		ret := r.flush()
+		lost := int(relPos) - len(ret)
+		ret = append(ret, buf)
+		r.expected = sequenceNumber + 1
+		return ret, lost
+	}
+
+	// expected packet: return it with the following buffered ones
+	if relPos == 0 {
+		ret := [][]byte{buf}
+		r.expected++
+		r.pos = (r.pos + 1) % r.size
+
+		for r.count > 0 && r.buffer[r.pos] != nil {
+			ret = append(ret, r.buffer[r.pos])
+			r.buffer[r.pos] = nil
+			r.count--
+			r.expected++
+			r.pos = (r.pos + 1) % r.size
+		}
+
+		return ret, 0
+	}
+
+	// packet in the future: buffer it
+	p := (r.pos + int(relPos)) % r.size
+	if r.buffer[p] == nil {
+		r.buffer[p] = append([]byte(nil), buf...)
+		r.count++
+	}
+
+	return nil, 0
+}
+
+// flush returns all the buffered packets and empties the buffer.
+func (r *Reorderer) flush() [][]byte {
+	ret := make([][]byte, 0, r.count+1)
+
+	for i := 0; i < r.size && r.count > 0; i++ {
+		p := (r.pos + i) % r.size
+		if r.buffer[p] != nil {
+			ret = append(ret, r.buffer[p])
+			r.buffer[p] = nil
+			r.count--
+		}
+	}
+
+	r.pos = 0
+	r.count = 0
+
+	return ret
+}
@@ -0,0 +1,77 @@
+package rtpreorderer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func pkt(sequenceNumber uint16) []byte {
+	return []byte{
+		0x80, 0x60, byte(sequenceNumber >> 8), byte(sequenceNumber),
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+	}
+}
+
+func TestReorderer(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		in   []uint16
+		out  [][]uint16
+		lost []int
+	}{
+		{
+			"ordered",
+			[]uint16{65534, 65535, 0, 1},
+			[][]uint16{{65534}, {65535}, {0}, {1}},
+			[]int{0, 0, 0, 0},
+		},
+		{
+			"reordered",
+			[]uint16{10, 12, 13, 11, 14},
+			[][]uint16{{10}, nil, nil, {11, 12, 13}, {14}},
+			[]int{0, 0, 0, 0, 0},
+		},
+		{
+			"duplicate and old",
+			[]uint16{10, 11, 11, 9, 12},
+			[][]uint16{{10}, {11}, nil, nil, {12}},
+			[]int{0, 0, 0, 0, 0},
+		},
+		{
+			"lost",
+			[]uint16{10, 12, 14, 15},
+			[][]uint16{{10}, nil, nil, {12, 14, 15}},
+			[]int{0, 0, 0, 2},
+		},
+		{
+			"big gap",
+			[]uint16{10, 100},
+			[][]uint16{{10}, {100}},
+			[]int{0, 89},
+		},
+		{
+			"big backward gap",
+			[]uint16{100, 102, 10, 11},
+			[][]uint16{{100}, nil, {102, 10}, {11}},
+			[]int{0, 0, 0, 0},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			r := New(4)
+
+			for i, seq := range ca.in {
+				out, lost := r.Process(pkt(seq))
+
+				var outSeqs []uint16
+				for _, p := range out {
+					outSeqs = append(outSeqs, uint16(p[2])<<8|uint16(p[3]))
+				}
+
+				require.Equal(t, ca.out[i], outSeqs)
+				require.Equal(t, ca.lost[i], lost)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+// Package rtpsimpleaudio contains a RTP decoder and encoder for audio codecs
+// that don't need a specific payload format, like G711, G722 and G726.
+package rtpsimpleaudio
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/rtptime"
+)
+
+// Decoder is a RTP decoder for simple audio codecs.
+type Decoder struct {
+	timeDecoder *rtptime.Decoder
+}
+
+// NewDecoder allocates a Decoder.
+// The clock rate of G722 is 8000, although its sample rate is 16000.
+func NewDecoder(clockRate int) *Decoder {
+	return &Decoder{
+		timeDecoder: rtptime.NewDecoder(clockRate),
+	}
+}
+
+// Decode decodes an audio frame from a RTP packet.
+// It returns the frame and its timestamp, relative to the first decoded packet.
+func (d *Decoder) Decode(byts []byte) ([]byte, time.Duration, error) {
+	pkt := rtp.Packet{}
+	err := pkt.Unmarshal(byts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ts := d.timeDecoder.Decode(pkt.Timestamp)
+
+	if len(pkt.Payload) == 0 {
+		return nil, 0, fmt.Errorf("payload is empty")
+	}
+
+	return pkt.Payload, ts, nil
+}
@@ -0,0 +1,37 @@
+package rtpsimpleaudio
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	e, err := NewEncoder(0, 8000)
+	require.NoError(t, err)
+
+	d := NewDecoder(8000)
+
+	for i := 0; i < 3; i++ {
+		frame := bytes.Repeat([]byte{byte(i)}, 160)
+
+		byts, err := e.Write(1*time.Second+time.Duration(i)*20*time.Millisecond, frame)
+		require.NoError(t, err)
+
+		var pkt rtp.Packet
+		err = pkt.Unmarshal(byts)
+		require.NoError(t, err)
+		require.Equal(t, uint8(0), pkt.PayloadType)
+
+		dec, ts, err := d.Decode(byts)
+		require.NoError(t, err)
+		require.Equal(t, frame, dec)
+		require.Equal(t, time.Duration(i)*20*time.Millisecond, ts)
+	}
+
+	_, err = e.Write(0, make([]byte, 1500))
+	require.EqualError(t, err, "frame is too big")
+}
@@ -0,0 +1,67 @@
+package rtpsimpleaudio
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/rtptime"
+)
+
+const (
+	rtpVersion        = 0x02
+	rtpPayloadMaxSize = 1460 // 1500 (mtu) - 20 (ip header) - 8 (udp header) - 12 (rtp header)
+)
+
+// Encoder is a RTP encoder for simple audio codecs.
+type Encoder struct {
+	payloadType    uint8
+	sequenceNumber uint16
+	ssrc           uint32
+	timeEncoder    *rtptime.Encoder
+	started        time.Duration
+}
+
+// NewEncoder allocates an Encoder.
+// The clock rate of G722 is 8000, although its sample rate is 16000.
+func NewEncoder(payloadType uint8, clockRate int) (*Encoder, error) {
+	return &Encoder{
+		payloadType:    payloadType,
+		sequenceNumber: uint16(rand.Uint32()),
+		ssrc:           rand.Uint32(),
+		timeEncoder:    rtptime.NewEncoder(clockRate, rand.Uint32()),
+	}, nil
+}
+
+// Write encodes an audio frame into a RTP packet.
+func (e *Encoder) Write(ts time.Duration, frame []byte) ([]byte, error) {
+	if len(frame) == 0 {
+		return nil, fmt.Errorf("frame is empty")
+	}
+
+	if len(frame) > rtpPayloadMaxSize {
+		return nil, fmt.Errorf("frame is too big")
+	}
+
+	if e.started == 0 {
+		e.started = ts
+	}
+
+	rtpTs := e.timeEncoder.Encode(ts - e.started)
+
+	rpkt := rtp.Packet{
+		Header: rtp.Header{
+			Version:        rtpVersion,
+			PayloadType:    e.payloadType,
+			SequenceNumber: e.sequenceNumber,
+			Timestamp:      rtpTs,
+			SSRC:           e.ssrc,
+		},
+		Payload: frame,
+	}
+	e.sequenceNumber++
+
+	return rpkt.Marshal()
+}
@@ -0,0 +1,162 @@
+// Package rtpsync implements a utility to synchronize the timestamps
+// of multiple tracks of a stream.
+package rtpsync
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+
+	"github.com/aler9/gortsplib/internal/ntp"
+	"github.com/aler9/gortsplib/pkg/base"
+)
+
+// minimum interval between sender reports that is used to estimate
+// the actual clock rate of a track.
+const driftMinInterval = 5 * time.Second
+
+// maximum difference between the estimated clock rate of a track
+// and the nominal one. Bigger differences are caused by discontinuities
+// in the stream, and are not considered drift.
+const driftMaxRatio = 0.01
+
+type track struct {
+	clockRate float64
+
+	// estimated clock rate
+	rate float64
+
+	// first sender report
+	firstSRNTP time.Time
+
+	// RTP ticks between the first and the last sender report
+	srTicks int64
+
+	// last sender report
+	srReceived bool
+	srNTP      time.Time
+	srRTP      uint32
+}
+
+func (t *track) processSenderReport(sr *rtcp.SenderReport) {
+	ntp := ntp.Decode(sr.NTPTime)
+
+	if !t.srReceived {
+		t.srReceived = true
+		t.firstSRNTP = ntp
+		t.srNTP = ntp
+		t.srRTP = sr.RTPTime
+		return
+	}
+
+	t.srTicks += int64(int32(sr.RTPTime - t.srRTP))
+	t.srNTP = ntp
+	t.srRTP = sr.RTPTime
+
+	// estimate the actual clock rate, that is the nominal one plus drift,
+	// by comparing the RTP time with the NTP time elapsed since the first
+	// sender report.
+	elapsed := ntp.Sub(t.firstSRNTP)
+	if elapsed < driftMinInterval {
+		return
+	}
+
+	rate := float64(t.srTicks) / elapsed.Seconds()
+	if rate > t.clockRate*(1+driftMaxRatio) || rate < t.clockRate*(1-driftMaxRatio) {
+		// discontinuity: restart estimation from this sender report
+		t.firstSRNTP = ntp
+		t.srTicks = 0
+		t.rate = t.clockRate
+		return
+	}
+
+	t.rate = rate
+}
+
+func (t *track) packetNTP(rtpTime uint32) time.Time {
+	ticks := int32(rtpTime - t.srRTP)
+	return t.srNTP.Add(time.Duration(float64(ticks) * float64(time.Second) / t.rate))
+}
+
+// Synchronizer is a utility to synchronize the timestamps of multiple
+// tracks of a stream, like the audio and video tracks of a camera.
+// Every track has its own random RTP timestamp offset and its own clock,
+// that may drift; the Synchronizer uses the RTCP sender reports of
+// every track to convert RTP timestamps into presentation timestamps
+// that share the same time domain, and can be used to mux tracks together.
+type Synchronizer struct {
+	mutex  sync.Mutex
+	tracks []*track
+
+	refReceived bool
+	ref         time.Time
+}
+
+// New allocates a Synchronizer.
+// clockRates contains the clock rate of every track, indexed by track ID.
+func New(clockRates []int) *Synchronizer {
+	s := &Synchronizer{
+		tracks: make([]*track, len(clockRates)),
+	}
+
+	for i, clockRate := range clockRates {
+		s.tracks[i] = &track{
+			clockRate: float64(clockRate),
+			rate:      float64(clockRate),
+		}
+	}
+
+	return s
+}
+
+// ProcessFrame extracts the needed data from RTCP frames.
+// RTP frames and frames of unknown tracks are ignored, therefore all the frames
+// received by the callback passed to ClientConn.ReadFrames() can be passed here.
+func (s *Synchronizer) ProcessFrame(trackID int, streamType base.StreamType, buf []byte) {
+	if streamType != base.StreamTypeRTCP || trackID < 0 || trackID >= len(s.tracks) {
+		return
+	}
+
+	frames, err := rtcp.Unmarshal(buf)
+	if err != nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, frame := range frames {
+		if sr, ok := (frame).(*rtcp.SenderReport); ok {
+			s.tracks[trackID].processSenderReport(sr)
+		}
+	}
+}
+
+// PTS returns the presentation timestamp of a RTP packet of a track.
+// Presentation timestamps of all tracks are relative to the first packet
+// whose presentation timestamp has been computed, therefore packets of
+// other tracks that were captured before it have a negative timestamp.
+// It returns false if no sender report has been received yet for the track.
+func (s *Synchronizer) PTS(trackID int, rtpTime uint32) (time.Duration, bool) {
+	if trackID < 0 || trackID >= len(s.tracks) {
+		return 0, false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	t := s.tracks[trackID]
+	if !t.srReceived {
+		return 0, false
+	}
+
+	ntp := t.packetNTP(rtpTime)
+
+	if !s.refReceived {
+		s.refReceived = true
+		s.ref = ntp
+	}
+
+	return ntp.Sub(s.ref), true
+}
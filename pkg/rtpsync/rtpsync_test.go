@@ -0,0 +1,95 @@
+package rtpsync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aler9/gortsplib/internal/ntp"
+	"github.com/aler9/gortsplib/pkg/base"
+)
+
+func senderReport(ts time.Time, rtpTime uint32) []byte {
+	byts, _ := (&rtcp.SenderReport{
+		SSRC:    0x38F27A2F,
+		NTPTime: ntp.Encode(ts),
+		RTPTime: rtpTime,
+	}).Marshal()
+	return byts
+}
+
+func TestSynchronizer(t *testing.T) {
+	s := New([]int{90000, 48000})
+
+	now := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+
+	// no sender reports yet
+	_, ok := s.PTS(0, 1000)
+	require.Equal(t, false, ok)
+
+	// RTP packets are ignored
+	s.ProcessFrame(0, base.StreamTypeRTP, []byte{0x80, 0x60, 0x00, 0x01})
+
+	// tracks with different random offsets
+	s.ProcessFrame(0, base.StreamTypeRTCP, senderReport(now, 0xFFFFFFFF-45000+1))
+	s.ProcessFrame(1, base.StreamTypeRTCP, senderReport(now.Add(200*time.Millisecond), 20000))
+
+	pts, ok := s.PTS(0, 0xFFFFFFFF-45000+1)
+	require.Equal(t, true, ok)
+	require.Equal(t, time.Duration(0), pts)
+
+	// wraparound
+	pts, ok = s.PTS(0, 45000)
+	require.Equal(t, true, ok)
+	require.Equal(t, time.Second, pts)
+
+	pts, ok = s.PTS(1, 20000-9600)
+	require.Equal(t, true, ok)
+	require.Equal(t, time.Duration(0), pts)
+
+	pts, ok = s.PTS(1, 20000+48000)
+	require.Equal(t, true, ok)
+	require.Equal(t, 1200*time.Millisecond, pts)
+
+	// unknown track
+	_, ok = s.PTS(2, 0)
+	require.Equal(t, false, ok)
+}
+
+func TestSynchronizerDrift(t *testing.T) {
+	s := New([]int{90000})
+
+	now := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+
+	// the clock of the sender is faster than the nominal one
+	s.ProcessFrame(0, base.StreamTypeRTCP, senderReport(now, 0))
+	s.ProcessFrame(0, base.StreamTypeRTCP, senderReport(now.Add(10*time.Second), 900090))
+
+	pts, ok := s.PTS(0, 900090)
+	require.Equal(t, true, ok)
+	require.Equal(t, time.Duration(0), pts)
+
+	pts, ok = s.PTS(0, 900090+90009*10)
+	require.Equal(t, true, ok)
+	require.InDelta(t, float64(10*time.Second), float64(pts), float64(time.Microsecond))
+}
+
+func TestSynchronizerDiscontinuity(t *testing.T) {
+	s := New([]int{90000})
+
+	now := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+
+	// the RTP time is reset by the sender
+	s.ProcessFrame(0, base.StreamTypeRTCP, senderReport(now, 500000))
+	s.ProcessFrame(0, base.StreamTypeRTCP, senderReport(now.Add(10*time.Second), 0))
+
+	pts, ok := s.PTS(0, 0)
+	require.Equal(t, true, ok)
+	require.Equal(t, time.Duration(0), pts)
+
+	pts, ok = s.PTS(0, 90000)
+	require.Equal(t, true, ok)
+	require.Equal(t, time.Second, pts)
+}
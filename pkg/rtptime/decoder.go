@@ -0,0 +1,38 @@
+package rtptime
+
+import (
+	"time"
+)
+
+// Decoder is a RTP timestamp decoder.
+type Decoder struct {
+	clockRate time.Duration
+	started   bool
+	prev      uint32
+	overall   int64
+}
+
+// NewDecoder allocates a Decoder.
+func NewDecoder(clockRate int) *Decoder {
+	return &Decoder{
+		clockRate: time.Duration(clockRate),
+	}
+}
+
+// Decode decodes a RTP timestamp into a timestamp, relative to the first
+// decoded RTP timestamp. Wraparounds of the 32-bit RTP timestamp are handled,
+// as well as timestamps that go backwards, like the ones of B-frames.
+func (d *Decoder) Decode(ts uint32) time.Duration {
+	if !d.started {
+		d.started = true
+		d.prev = ts
+		return 0
+	}
+
+	// the difference between two consecutive timestamps is assumed to be
+	// smaller than half of the 32-bit range, in both directions.
+	d.overall += int64(int32(ts - d.prev))
+	d.prev = ts
+
+	return divide(d.overall, d.clockRate)
+}
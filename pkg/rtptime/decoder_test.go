@@ -0,0 +1,43 @@
+package rtptime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoder(t *testing.T) {
+	e := NewEncoder(90000, 12345)
+	require.Equal(t, uint32(12345), e.Encode(0))
+	require.Equal(t, uint32(12345+3600), e.Encode(40*time.Millisecond))
+
+	// wraparound
+	e = NewEncoder(90000, 0xFFFFFFFF-8999)
+	require.Equal(t, uint32(0xFFFFFFFF), e.Encode(99999*time.Microsecond+999*time.Nanosecond))
+	require.Equal(t, uint32(0), e.Encode(100*time.Millisecond))
+
+	// long durations
+	e = NewEncoder(90000, 0)
+	require.Equal(t, uint32((uint64(100*24*3600)*90000)%(1<<32)), e.Encode(100*24*time.Hour))
+}
+
+func TestDecoder(t *testing.T) {
+	d := NewDecoder(90000)
+	require.Equal(t, time.Duration(0), d.Decode(0xFFFFFFFF-8999))
+	require.Equal(t, 100*time.Millisecond, d.Decode(0))
+	require.Equal(t, 140*time.Millisecond, d.Decode(3600))
+
+	// timestamps can go backwards
+	require.Equal(t, 120*time.Millisecond, d.Decode(1800))
+}
+
+func TestDecoderLongDuration(t *testing.T) {
+	e := NewEncoder(48000, 1234)
+	d := NewDecoder(48000)
+
+	// many wraparounds
+	for i := time.Duration(0); i <= 100*24*time.Hour; i += time.Hour {
+		require.Equal(t, i, d.Decode(e.Encode(i)))
+	}
+}
@@ -0,0 +1,42 @@
+// Package rtptime contains a RTP timestamp encoder and decoder.
+package rtptime
+
+import (
+	"time"
+)
+
+// multiply multiplies a duration by a clock rate, without overflowing
+// with durations of years.
+func multiply(v time.Duration, clockRate time.Duration) time.Duration {
+	secs := v / time.Second
+	dec := v % time.Second
+	return secs*clockRate + dec*clockRate/time.Second
+}
+
+// divide divides a count of clock ticks by a clock rate, without overflowing.
+func divide(v int64, clockRate time.Duration) time.Duration {
+	secs := time.Duration(v) / clockRate
+	dec := time.Duration(v) % clockRate
+	return secs*time.Second + dec*time.Second/clockRate
+}
+
+// Encoder is a RTP timestamp encoder.
+type Encoder struct {
+	clockRate time.Duration
+	initialTs uint32
+}
+
+// NewEncoder allocates an Encoder.
+func NewEncoder(clockRate int, initialTs uint32) *Encoder {
+	return &Encoder{
+		clockRate: time.Duration(clockRate),
+		initialTs: initialTs,
+	}
+}
+
+// Encode encodes a timestamp, relative to the beginning of the stream,
+// into a RTP timestamp. The RTP timestamp wraps around when it exceeds
+// 32 bits, as required by RTP.
+func (e *Encoder) Encode(ts time.Duration) uint32 {
+	return e.initialTs + uint32(multiply(ts, e.clockRate))
+}
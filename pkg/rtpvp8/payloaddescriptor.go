@@ -0,0 +1,161 @@
+package rtpvp8
+
+import (
+	"fmt"
+)
+
+// PayloadDescriptor is a VP8 payload descriptor (RFC 7741, section 4.2).
+type PayloadDescriptor struct {
+	// the frame can be discarded without affecting other frames
+	NonReference bool
+
+	// the packet starts a VP8 partition
+	StartOfPartition bool
+
+	// index of the partition
+	PartitionIndex uint8
+
+	// (optional) picture ID, that is 7 or 15 bits long
+	PictureID *uint16
+
+	// (optional) temporal level zero index
+	TL0PicIdx *uint8
+
+	// (optional) temporal layer index
+	TID *uint8
+
+	// the frame depends only on the base layer
+	LayerSync bool
+
+	// (optional) temporal key frame index
+	KeyIdx *uint8
+}
+
+// Unmarshal decodes a payload descriptor.
+// It returns the size of the descriptor.
+func (d *PayloadDescriptor) Unmarshal(buf []byte) (int, error) {
+	if len(buf) < 1 {
+		return 0, fmt.Errorf("payload is too short")
+	}
+
+	*d = PayloadDescriptor{
+		NonReference:     (buf[0] & 0x20) != 0,
+		StartOfPartition: (buf[0] & 0x10) != 0,
+		PartitionIndex:   buf[0] & 0x07,
+	}
+	n := 1
+
+	// extended control bits are not present
+	if (buf[0] & 0x80) == 0 {
+		return n, nil
+	}
+
+	if len(buf) < 2 {
+		return 0, fmt.Errorf("payload is too short")
+	}
+	ext := buf[1]
+	n++
+
+	if (ext & 0x80) != 0 {
+		if len(buf) < (n + 1) {
+			return 0, fmt.Errorf("payload is too short")
+		}
+
+		// 15 bits picture ID
+		if (buf[n] & 0x80) != 0 {
+			if len(buf) < (n + 2) {
+				return 0, fmt.Errorf("payload is too short")
+			}
+
+			v := uint16(buf[n]&0x7F)<<8 | uint16(buf[n+1])
+			d.PictureID = &v
+			n += 2
+		} else {
+			v := uint16(buf[n])
+			d.PictureID = &v
+			n++
+		}
+	}
+
+	if (ext & 0x40) != 0 {
+		if len(buf) < (n + 1) {
+			return 0, fmt.Errorf("payload is too short")
+		}
+
+		v := buf[n]
+		d.TL0PicIdx = &v
+		n++
+	}
+
+	if (ext & 0x30) != 0 {
+		if len(buf) < (n + 1) {
+			return 0, fmt.Errorf("payload is too short")
+		}
+
+		if (ext & 0x20) != 0 {
+			v := buf[n] >> 6
+			d.TID = &v
+			d.LayerSync = (buf[n] & 0x20) != 0
+		}
+
+		if (ext & 0x10) != 0 {
+			v := buf[n] & 0x1F
+			d.KeyIdx = &v
+		}
+
+		n++
+	}
+
+	return n, nil
+}
+
+// Marshal encodes a payload descriptor.
+// Picture IDs greater than 127 are encoded with 15 bits.
+func (d PayloadDescriptor) Marshal() []byte {
+	b0 := d.PartitionIndex & 0x07
+	if d.NonReference {
+		b0 |= 0x20
+	}
+	if d.StartOfPartition {
+		b0 |= 0x10
+	}
+
+	var ext byte
+	var extBuf []byte
+
+	if d.PictureID != nil {
+		ext |= 0x80
+		if *d.PictureID > 0x7F {
+			extBuf = append(extBuf, 0x80|byte(*d.PictureID>>8)&0x7F, byte(*d.PictureID))
+		} else {
+			extBuf = append(extBuf, byte(*d.PictureID))
+		}
+	}
+
+	if d.TL0PicIdx != nil {
+		ext |= 0x40
+		extBuf = append(extBuf, *d.TL0PicIdx)
+	}
+
+	if d.TID != nil || d.KeyIdx != nil {
+		var v byte
+		if d.TID != nil {
+			ext |= 0x20
+			v |= *d.TID << 6
+			if d.LayerSync {
+				v |= 0x20
+			}
+		}
+		if d.KeyIdx != nil {
+			ext |= 0x10
+			v |= *d.KeyIdx & 0x1F
+		}
+		extBuf = append(extBuf, v)
+	}
+
+	if ext == 0 {
+		return []byte{b0}
+	}
+
+	return append([]byte{b0 | 0x80, ext}, extBuf...)
+}
@@ -0,0 +1,92 @@
+package rtpvp8
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func uint8Ptr(v uint8) *uint8 {
+	return &v
+}
+
+func uint16Ptr(v uint16) *uint16 {
+	return &v
+}
+
+var casesPayloadDescriptor = []struct {
+	name string
+	byts []byte
+	desc PayloadDescriptor
+}{
+	{
+		"minimal",
+		[]byte{0x10},
+		PayloadDescriptor{
+			StartOfPartition: true,
+		},
+	},
+	{
+		"non reference, partition",
+		[]byte{0x23},
+		PayloadDescriptor{
+			NonReference:   true,
+			PartitionIndex: 3,
+		},
+	},
+	{
+		"7 bits picture id",
+		[]byte{0x90, 0x80, 0x11},
+		PayloadDescriptor{
+			StartOfPartition: true,
+			PictureID:        uint16Ptr(0x11),
+		},
+	},
+	{
+		"all fields",
+		[]byte{0x90, 0xf0, 0x92, 0x34, 0x05, 0x65},
+		PayloadDescriptor{
+			StartOfPartition: true,
+			PictureID:        uint16Ptr(0x1234),
+			TL0PicIdx:        uint8Ptr(5),
+			TID:              uint8Ptr(1),
+			LayerSync:        true,
+			KeyIdx:           uint8Ptr(5),
+		},
+	},
+}
+
+func TestPayloadDescriptorUnmarshal(t *testing.T) {
+	for _, ca := range casesPayloadDescriptor {
+		t.Run(ca.name, func(t *testing.T) {
+			var desc PayloadDescriptor
+			n, err := desc.Unmarshal(append(ca.byts, 0x01, 0x02))
+			require.NoError(t, err)
+			require.Equal(t, len(ca.byts), n)
+			require.Equal(t, ca.desc, desc)
+		})
+	}
+}
+
+func TestPayloadDescriptorMarshal(t *testing.T) {
+	for _, ca := range casesPayloadDescriptor {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, ca.byts, ca.desc.Marshal())
+		})
+	}
+}
+
+func TestPayloadDescriptorUnmarshalErrors(t *testing.T) {
+	for _, byts := range [][]byte{
+		{},
+		{0x90},
+		{0x90, 0x80},
+		{0x90, 0x80, 0x80},
+		{0x90, 0x40},
+		{0x90, 0x20},
+	} {
+		var desc PayloadDescriptor
+		_, err := desc.Unmarshal(byts)
+		require.EqualError(t, err, "payload is too short")
+	}
+}
@@ -0,0 +1,135 @@
+// Package rtpvp9 contains a RTP/VP9 decoder and encoder.
+package rtpvp9
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/rtptime"
+)
+
+const (
+	rtpClockRate = 90000
+)
+
+// IsKeyFrame returns whether a VP9 frame is a key frame.
+// It reads the uncompressed header, that is at the beginning of every frame.
+func IsKeyFrame(frame []byte) bool {
+	if len(frame) < 1 {
+		return false
+	}
+
+	// frame marker
+	if (frame[0] >> 6) != 0x02 {
+		return false
+	}
+
+	profile := (frame[0]>>5)&0x01 | ((frame[0]>>4)&0x01)<<1
+	pos := uint(4)
+
+	// reserved bit
+	if profile == 3 {
+		pos++
+	}
+
+	readBit := func() (bool, bool) {
+		byteIndex := pos / 8
+		if int(byteIndex) >= len(frame) {
+			return false, false
+		}
+		v := (frame[byteIndex] >> (7 - pos%8)) & 0x01
+		pos++
+		return v != 0, true
+	}
+
+	// show existing frame
+	showExistingFrame, ok := readBit()
+	if !ok || showExistingFrame {
+		return false
+	}
+
+	// frame type
+	frameType, ok := readBit()
+	if !ok {
+		return false
+	}
+
+	return !frameType
+}
+
+// Decoder is a RTP/VP9 decoder.
+// It reassembles frames that are split into multiple packets.
+type Decoder struct {
+	timeDecoder *rtptime.Decoder
+
+	// for fragmented frames
+	fragmentedBuf     []byte
+	fragmentedTs      uint32
+	fragmentedNextSeq uint16
+}
+
+// NewDecoder allocates a Decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{
+		timeDecoder: rtptime.NewDecoder(rtpClockRate),
+	}
+}
+
+// Decode decodes a VP9 frame from a RTP/VP9 packet.
+// It returns the frame and its timestamp, relative to the first decoded packet.
+// If a frame is split into multiple packets, it returns no frame
+// until the last packet is received.
+func (d *Decoder) Decode(byts []byte) ([]byte, time.Duration, error) {
+	pkt := rtp.Packet{}
+	err := pkt.Unmarshal(byts)
+	if err != nil {
+		d.fragmentedBuf = nil
+		return nil, 0, err
+	}
+
+	ts := d.timeDecoder.Decode(pkt.Timestamp)
+
+	var desc PayloadDescriptor
+	n, err := desc.Unmarshal(pkt.Payload)
+	if err != nil {
+		d.fragmentedBuf = nil
+		return nil, 0, err
+	}
+	payload := pkt.Payload[n:]
+
+	// first packet of a frame.
+	// a frame that was not completed is discarded.
+	if desc.StartOfFrame {
+		d.fragmentedBuf = append([]byte(nil), payload...)
+		d.fragmentedTs = pkt.Timestamp
+
+	} else {
+		if d.fragmentedBuf == nil {
+			return nil, 0, fmt.Errorf("received a non-starting fragment")
+		}
+
+		if pkt.Timestamp != d.fragmentedTs || pkt.SequenceNumber != d.fragmentedNextSeq {
+			d.fragmentedBuf = nil
+			return nil, 0, fmt.Errorf("a packet has been lost")
+		}
+
+		d.fragmentedBuf = append(d.fragmentedBuf, payload...)
+	}
+
+	d.fragmentedNextSeq = pkt.SequenceNumber + 1
+
+	if !desc.EndOfFrame {
+		return nil, 0, nil
+	}
+
+	frame := d.fragmentedBuf
+	d.fragmentedBuf = nil
+
+	if len(frame) == 0 {
+		return nil, 0, fmt.Errorf("frame is empty")
+	}
+
+	return frame, ts, nil
+}
@@ -0,0 +1,86 @@
+package rtpvp9
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsKeyFrame(t *testing.T) {
+	for _, ca := range []struct {
+		name  string
+		frame []byte
+		ok    bool
+	}{
+		{"key frame", []byte{0x82, 0x49, 0x83}, true},
+		{"non-key frame", []byte{0x86, 0x00}, false},
+		{"profile 3 key frame", []byte{0xb0, 0x00}, true},
+		{"profile 3 non-key frame", []byte{0xb2, 0x00}, false},
+		{"show existing frame", []byte{0x88}, false},
+		{"invalid frame marker", []byte{0x02}, false},
+		{"empty", []byte{}, false},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, ca.ok, IsKeyFrame(ca.frame))
+		})
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	e, err := NewEncoderWithPayloadMaxSize(96, 100)
+	require.NoError(t, err)
+
+	d := NewDecoder()
+
+	for i, frame := range [][]byte{
+		append([]byte{0x82}, bytes.Repeat([]byte{0x01}, 50)...),
+		append([]byte{0x86}, bytes.Repeat([]byte{0x02}, 250)...),
+	} {
+		packets, err := e.Write(1*time.Second+time.Duration(i)*40*time.Millisecond, frame)
+		require.NoError(t, err)
+		require.Equal(t, []int{1, 3}[i], len(packets))
+
+		for j, pkt := range packets {
+			dec, ts, err := d.Decode(pkt)
+			require.NoError(t, err)
+
+			if j != len(packets)-1 {
+				require.Nil(t, dec)
+				continue
+			}
+
+			require.Equal(t, frame, dec)
+			require.Equal(t, time.Duration(i)*40*time.Millisecond, ts)
+			require.Equal(t, i == 0, IsKeyFrame(dec))
+		}
+	}
+}
+
+func TestDecodePacketLost(t *testing.T) {
+	e, err := NewEncoderWithPayloadMaxSize(96, 100)
+	require.NoError(t, err)
+
+	d := NewDecoder()
+
+	packets, err := e.Write(0, bytes.Repeat([]byte{0x01}, 250))
+	require.NoError(t, err)
+
+	_, _, err = d.Decode(packets[0])
+	require.NoError(t, err)
+
+	_, _, err = d.Decode(packets[2])
+	require.EqualError(t, err, "a packet has been lost")
+
+	// the frame is discarded until the next one starts
+	_, _, err = d.Decode(packets[2])
+	require.EqualError(t, err, "received a non-starting fragment")
+
+	packets, err = e.Write(40*time.Millisecond, bytes.Repeat([]byte{0x01}, 50))
+	require.NoError(t, err)
+
+	frame, _, err := d.Decode(packets[0])
+	require.NoError(t, err)
+	require.Equal(t, bytes.Repeat([]byte{0x01}, 50), frame)
+}
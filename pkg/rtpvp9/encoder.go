@@ -0,0 +1,116 @@
+package rtpvp9
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/aler9/gortsplib/pkg/rtptime"
+)
+
+const (
+	rtpVersion = 0x02
+
+	// DefaultPayloadMaxSize is the default maximum size of RTP payloads.
+	DefaultPayloadMaxSize = 1460 // 1500 (mtu) - 20 (ip header) - 8 (udp header) - 12 (rtp header)
+)
+
+// Encoder is a RTP/VP9 encoder.
+type Encoder struct {
+	payloadType    uint8
+	payloadMaxSize int
+	sequenceNumber uint16
+	ssrc           uint32
+	timeEncoder    *rtptime.Encoder
+	started        time.Duration
+	pictureID      uint16
+}
+
+// NewEncoder allocates an Encoder.
+func NewEncoder(payloadType uint8) (*Encoder, error) {
+	return NewEncoderWithPayloadMaxSize(payloadType, DefaultPayloadMaxSize)
+}
+
+// NewEncoderWithPayloadMaxSize allocates an Encoder that produces RTP payloads
+// with the given maximum size.
+func NewEncoderWithPayloadMaxSize(payloadType uint8, payloadMaxSize int) (*Encoder, error) {
+	// a payload must contain the descriptor and at least one byte of frame
+	if payloadMaxSize < 4 {
+		return nil, fmt.Errorf("payload max size is too small (%d)", payloadMaxSize)
+	}
+
+	return &Encoder{
+		payloadType:    payloadType,
+		payloadMaxSize: payloadMaxSize,
+		sequenceNumber: uint16(rand.Uint32()),
+		ssrc:           rand.Uint32(),
+		timeEncoder:    rtptime.NewEncoder(rtpClockRate, rand.Uint32()),
+		pictureID:      uint16(rand.Uint32()) & 0x7FFF,
+	}, nil
+}
+
+// Write encodes a VP9 frame into RTP/VP9 packets.
+// Frames that are bigger than the maximum payload size are split into
+// multiple packets. Every frame is marked with an increasing picture ID,
+// that allows receivers to detect losses.
+func (e *Encoder) Write(ts time.Duration, frame []byte) ([][]byte, error) {
+	if len(frame) == 0 {
+		return nil, fmt.Errorf("frame is empty")
+	}
+
+	if e.started == 0 {
+		e.started = ts
+	}
+
+	// rtp/vp9 uses a 90khz clock
+	rtpTime := e.timeEncoder.Encode(ts - e.started)
+
+	pictureID := e.pictureID
+	e.pictureID = (e.pictureID + 1) & 0x7FFF
+
+	interPicturePredicted := !IsKeyFrame(frame)
+
+	var frames [][]byte
+
+	for i := 0; len(frame) > 0; i++ {
+		// the descriptor has always the same size, since the picture ID
+		// is not changed inside a frame
+		le := e.payloadMaxSize - len(PayloadDescriptor{PictureID: &pictureID}.Marshal())
+		if le > len(frame) {
+			le = len(frame)
+		}
+
+		desc := PayloadDescriptor{
+			InterPicturePredicted: interPicturePredicted,
+			StartOfFrame:          (i == 0),
+			EndOfFrame:            (le == len(frame)),
+			PictureID:             &pictureID,
+		}.Marshal()
+
+		rpkt := rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    e.payloadType,
+				SequenceNumber: e.sequenceNumber,
+				Timestamp:      rtpTime,
+				SSRC:           e.ssrc,
+				// the marker is set on the last packet of the frame
+				Marker: (le == len(frame)),
+			},
+			Payload: append(desc, frame[:le]...),
+		}
+		e.sequenceNumber++
+		frame = frame[le:]
+
+		byts, err := rpkt.Marshal()
+		if err != nil {
+			return nil, err
+		}
+
+		frames = append(frames, byts)
+	}
+
+	return frames, nil
+}
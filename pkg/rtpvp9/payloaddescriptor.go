@@ -0,0 +1,326 @@
+package rtpvp9
+
+import (
+	"fmt"
+)
+
+// PictureGroupDescription describes a picture of a picture group,
+// inside a scalability structure.
+type PictureGroupDescription struct {
+	// temporal layer index
+	TID uint8
+
+	// switching up point
+	SwitchingUpPoint bool
+
+	// differences between the picture ID of the picture and the ones of
+	// its references
+	PDiffs []uint8
+}
+
+// ScalabilityStructure is a VP9 scalability structure, that describes
+// the resolution of spatial layers and the structure of picture groups.
+type ScalabilityStructure struct {
+	// number of spatial layers
+	SpatialLayerCount int
+
+	// (optional) width and height of every spatial layer
+	Widths  []uint16
+	Heights []uint16
+
+	// (optional) picture group
+	PictureGroup []PictureGroupDescription
+}
+
+// PayloadDescriptor is a VP9 payload descriptor (RFC 9628, section 4.2).
+type PayloadDescriptor struct {
+	// the picture is predicted from previous pictures
+	InterPicturePredicted bool
+
+	// flexible mode, in which references are signaled with PDiffs
+	FlexibleMode bool
+
+	// the packet starts a frame
+	StartOfFrame bool
+
+	// the packet ends a frame
+	EndOfFrame bool
+
+	// the frame is not used to predict higher spatial layers
+	NotReferenceForUpperLayers bool
+
+	// (optional) picture ID, that is 7 or 15 bits long
+	PictureID *uint16
+
+	// (optional) temporal layer index. It is present together with SID.
+	TID *uint8
+
+	// switching up point
+	SwitchingUpPoint bool
+
+	// (optional) spatial layer index. It is present together with TID.
+	SID *uint8
+
+	// the frame depends on the lower spatial layer
+	InterLayerDependency bool
+
+	// (optional) temporal level zero index
+	TL0PicIdx *uint8
+
+	// differences between the picture ID of the picture and the ones of
+	// its references, used in flexible mode
+	PDiffs []uint8
+
+	// (optional) scalability structure
+	ScalabilityStructure *ScalabilityStructure
+}
+
+// Unmarshal decodes a payload descriptor.
+// It returns the size of the descriptor.
+func (d *PayloadDescriptor) Unmarshal(buf []byte) (int, error) {
+	if len(buf) < 1 {
+		return 0, fmt.Errorf("payload is too short")
+	}
+
+	b0 := buf[0]
+	*d = PayloadDescriptor{
+		InterPicturePredicted:      (b0 & 0x40) != 0,
+		FlexibleMode:               (b0 & 0x10) != 0,
+		StartOfFrame:               (b0 & 0x08) != 0,
+		EndOfFrame:                 (b0 & 0x04) != 0,
+		NotReferenceForUpperLayers: (b0 & 0x01) != 0,
+	}
+	n := 1
+
+	next := func() (byte, error) {
+		if len(buf) < (n + 1) {
+			return 0, fmt.Errorf("payload is too short")
+		}
+		v := buf[n]
+		n++
+		return v, nil
+	}
+
+	if (b0 & 0x80) != 0 {
+		b, err := next()
+		if err != nil {
+			return 0, err
+		}
+
+		// 15 bits picture ID
+		if (b & 0x80) != 0 {
+			b2, err := next()
+			if err != nil {
+				return 0, err
+			}
+
+			v := uint16(b&0x7F)<<8 | uint16(b2)
+			d.PictureID = &v
+		} else {
+			v := uint16(b)
+			d.PictureID = &v
+		}
+	}
+
+	if (b0 & 0x20) != 0 {
+		b, err := next()
+		if err != nil {
+			return 0, err
+		}
+
+		tid := b >> 5
+		sid := (b >> 1) & 0x07
+		d.TID = &tid
+		d.SwitchingUpPoint = (b & 0x10) != 0
+		d.SID = &sid
+		d.InterLayerDependency = (b & 0x01) != 0
+
+		if !d.FlexibleMode {
+			v, err := next()
+			if err != nil {
+				return 0, err
+			}
+			d.TL0PicIdx = &v
+		}
+	}
+
+	if d.FlexibleMode && d.InterPicturePredicted {
+		// up to 3 reference indexes
+		for i := 0; i < 3; i++ {
+			b, err := next()
+			if err != nil {
+				return 0, err
+			}
+
+			d.PDiffs = append(d.PDiffs, b>>1)
+
+			if (b & 0x01) == 0 {
+				break
+			}
+		}
+	}
+
+	if (b0 & 0x02) != 0 {
+		ss, err := unmarshalScalabilityStructure(next)
+		if err != nil {
+			return 0, err
+		}
+		d.ScalabilityStructure = ss
+	}
+
+	return n, nil
+}
+
+func unmarshalScalabilityStructure(next func() (byte, error)) (*ScalabilityStructure, error) {
+	b, err := next()
+	if err != nil {
+		return nil, err
+	}
+
+	ss := &ScalabilityStructure{
+		SpatialLayerCount: int(b>>5) + 1,
+	}
+
+	if (b & 0x10) != 0 {
+		for i := 0; i < ss.SpatialLayerCount; i++ {
+			var v [4]byte
+			for j := range v {
+				v[j], err = next()
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			ss.Widths = append(ss.Widths, uint16(v[0])<<8|uint16(v[1]))
+			ss.Heights = append(ss.Heights, uint16(v[2])<<8|uint16(v[3]))
+		}
+	}
+
+	if (b & 0x08) != 0 {
+		count, err := next()
+		if err != nil {
+			return nil, err
+		}
+
+		for i := 0; i < int(count); i++ {
+			b, err := next()
+			if err != nil {
+				return nil, err
+			}
+
+			pg := PictureGroupDescription{
+				TID:              b >> 5,
+				SwitchingUpPoint: (b & 0x10) != 0,
+			}
+
+			refCount := int((b >> 2) & 0x03)
+			for j := 0; j < refCount; j++ {
+				v, err := next()
+				if err != nil {
+					return nil, err
+				}
+				pg.PDiffs = append(pg.PDiffs, v)
+			}
+
+			ss.PictureGroup = append(ss.PictureGroup, pg)
+		}
+	}
+
+	return ss, nil
+}
+
+// Marshal encodes a payload descriptor.
+// Picture IDs greater than 127 are encoded with 15 bits.
+func (d PayloadDescriptor) Marshal() []byte {
+	buf := []byte{0}
+
+	if d.InterPicturePredicted {
+		buf[0] |= 0x40
+	}
+	if d.FlexibleMode {
+		buf[0] |= 0x10
+	}
+	if d.StartOfFrame {
+		buf[0] |= 0x08
+	}
+	if d.EndOfFrame {
+		buf[0] |= 0x04
+	}
+	if d.NotReferenceForUpperLayers {
+		buf[0] |= 0x01
+	}
+
+	if d.PictureID != nil {
+		buf[0] |= 0x80
+		if *d.PictureID > 0x7F {
+			buf = append(buf, 0x80|byte(*d.PictureID>>8)&0x7F, byte(*d.PictureID))
+		} else {
+			buf = append(buf, byte(*d.PictureID))
+		}
+	}
+
+	if d.TID != nil && d.SID != nil {
+		buf[0] |= 0x20
+
+		v := (*d.TID << 5) | ((*d.SID & 0x07) << 1)
+		if d.SwitchingUpPoint {
+			v |= 0x10
+		}
+		if d.InterLayerDependency {
+			v |= 0x01
+		}
+		buf = append(buf, v)
+
+		if !d.FlexibleMode {
+			var tl0PicIdx uint8
+			if d.TL0PicIdx != nil {
+				tl0PicIdx = *d.TL0PicIdx
+			}
+			buf = append(buf, tl0PicIdx)
+		}
+	}
+
+	if d.FlexibleMode && d.InterPicturePredicted {
+		for i, pdiff := range d.PDiffs {
+			v := pdiff << 1
+			if i != (len(d.PDiffs) - 1) {
+				v |= 0x01
+			}
+			buf = append(buf, v)
+		}
+	}
+
+	if d.ScalabilityStructure != nil {
+		buf[0] |= 0x02
+		buf = append(buf, d.ScalabilityStructure.marshal()...)
+	}
+
+	return buf
+}
+
+func (ss ScalabilityStructure) marshal() []byte {
+	buf := []byte{byte(ss.SpatialLayerCount-1) << 5}
+
+	if ss.Widths != nil {
+		buf[0] |= 0x10
+		for i := range ss.Widths {
+			buf = append(buf, byte(ss.Widths[i]>>8), byte(ss.Widths[i]),
+				byte(ss.Heights[i]>>8), byte(ss.Heights[i]))
+		}
+	}
+
+	if ss.PictureGroup != nil {
+		buf[0] |= 0x08
+		buf = append(buf, byte(len(ss.PictureGroup)))
+		for _, pg := range ss.PictureGroup {
+			v := (pg.TID << 5) | (byte(len(pg.PDiffs)) << 2)
+			if pg.SwitchingUpPoint {
+				v |= 0x10
+			}
+			buf = append(buf, v)
+			buf = append(buf, pg.PDiffs...)
+		}
+	}
+
+	return buf
+}
@@ -0,0 +1,127 @@
+package rtpvp9
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func uint8Ptr(v uint8) *uint8 {
+	return &v
+}
+
+func uint16Ptr(v uint16) *uint16 {
+	return &v
+}
+
+var casesPayloadDescriptor = []struct {
+	name string
+	byts []byte
+	desc PayloadDescriptor
+}{
+	{
+		"minimal",
+		[]byte{0x08},
+		PayloadDescriptor{
+			StartOfFrame: true,
+		},
+	},
+	{
+		"7 bits picture id",
+		[]byte{0x8c, 0x11},
+		PayloadDescriptor{
+			StartOfFrame: true,
+			EndOfFrame:   true,
+			PictureID:    uint16Ptr(0x11),
+		},
+	},
+	{
+		"layer indices",
+		[]byte{0xa8, 0x92, 0x34, 0x53, 0x05},
+		PayloadDescriptor{
+			StartOfFrame:         true,
+			PictureID:            uint16Ptr(0x1234),
+			TID:                  uint8Ptr(2),
+			SwitchingUpPoint:     true,
+			SID:                  uint8Ptr(1),
+			InterLayerDependency: true,
+			TL0PicIdx:            uint8Ptr(5),
+		},
+	},
+	{
+		"flexible mode",
+		[]byte{0x58, 0x03, 0x06},
+		PayloadDescriptor{
+			InterPicturePredicted: true,
+			FlexibleMode:          true,
+			StartOfFrame:          true,
+			PDiffs:                []uint8{1, 3},
+		},
+	},
+	{
+		"scalability structure",
+		[]byte{
+			0x0a, 0x38, 0x01, 0x40, 0x00, 0xb4, 0x02, 0x80,
+			0x01, 0x68, 0x02, 0x00, 0x34, 0x01,
+		},
+		PayloadDescriptor{
+			StartOfFrame: true,
+			ScalabilityStructure: &ScalabilityStructure{
+				SpatialLayerCount: 2,
+				Widths:            []uint16{320, 640},
+				Heights:           []uint16{180, 360},
+				PictureGroup: []PictureGroupDescription{
+					{
+						TID: 0,
+					},
+					{
+						TID:              1,
+						SwitchingUpPoint: true,
+						PDiffs:           []uint8{1},
+					},
+				},
+			},
+		},
+	},
+}
+
+func TestPayloadDescriptorUnmarshal(t *testing.T) {
+	for _, ca := range casesPayloadDescriptor {
+		t.Run(ca.name, func(t *testing.T) {
+			var desc PayloadDescriptor
+			n, err := desc.Unmarshal(append(ca.byts, 0x01, 0x02))
+			require.NoError(t, err)
+			require.Equal(t, len(ca.byts), n)
+			require.Equal(t, ca.desc, desc)
+		})
+	}
+}
+
+func TestPayloadDescriptorMarshal(t *testing.T) {
+	for _, ca := range casesPayloadDescriptor {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, ca.byts, ca.desc.Marshal())
+		})
+	}
+}
+
+func TestPayloadDescriptorUnmarshalErrors(t *testing.T) {
+	for _, byts := range [][]byte{
+		{},
+		{0x80},
+		{0x80, 0x80},
+		{0x20},
+		{0x20, 0x00},
+		{0x50},
+		{0x50, 0x01},
+		{0x02},
+		{0x02, 0x10},
+		{0x02, 0x08},
+		{0x02, 0x08, 0x01},
+		{0x02, 0x08, 0x01, 0x04},
+	} {
+		var desc PayloadDescriptor
+		_, err := desc.Unmarshal(byts)
+		require.EqualError(t, err, "payload is too short")
+	}
+}
@@ -0,0 +1,451 @@
+// Package rtsptest contains a lightweight RTSP server that can be used to
+// test clients without external servers.
+package rtsptest
+
+import (
+	"bufio"
+	"crypto/tls"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aler9/gortsplib/pkg/base"
+	"github.com/aler9/gortsplib/pkg/headers"
+)
+
+const (
+	serverSession = "12345678"
+	serverPath    = "/stream"
+)
+
+// Packet is a packet sent by a Server to its clients after a PLAY request.
+type Packet struct {
+	TrackID    int
+	StreamType base.StreamType
+	Payload    []byte
+}
+
+// Conf allows to configure a Server.
+type Conf struct {
+	// SDP sent in response to DESCRIBE requests.
+	SDP []byte
+
+	// packets sent to every client, in order, after a PLAY request.
+	// Packets of tracks that were not set up by the client are skipped.
+	// Tracks are identified by the "trackID=N" control attribute or,
+	// if it is missing, by the order of the SETUP requests.
+	Packets []Packet
+
+	// interval between packets.
+	// It defaults to 0.
+	PacketInterval time.Duration
+
+	// callback called for every request. If it returns a response, the
+	// response is sent instead of the default one.
+	// It defaults to nil.
+	OnRequest func(req *base.Request) *base.Response
+
+	// callback called for every frame sent by a client, with TCP or UDP.
+	// It defaults to nil.
+	OnFrame func(frame *base.InterleavedFrame)
+
+	// if set, the server accepts RTSPS connections only.
+	// It defaults to nil.
+	TLSConfig *tls.Config
+}
+
+// Server is a lightweight RTSP server that serves a single stream, with a
+// given SDP, and sends a given list of packets with UDP or TCP.
+// Streams can also be published to the Server with ANNOUNCE and RECORD;
+// published frames are passed to OnFrame.
+type Server struct {
+	conf     Conf
+	listener net.Listener
+	rtpConn  net.PacketConn
+	rtcpConn net.PacketConn
+
+	mutex     sync.Mutex
+	conns     map[net.Conn]struct{}
+	udpTracks map[string]int
+	wg        sync.WaitGroup
+}
+
+// New allocates a Server that listens on a random port of the loopback interface.
+func New(conf Conf) (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.TLSConfig != nil {
+		listener = tls.NewListener(listener, conf.TLSConfig)
+	}
+
+	rtpConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	rtcpConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		listener.Close()
+		rtpConn.Close()
+		return nil, err
+	}
+
+	s := &Server{
+		conf:      conf,
+		listener:  listener,
+		rtpConn:   rtpConn,
+		rtcpConn:  rtcpConn,
+		conns:     make(map[net.Conn]struct{}),
+		udpTracks: make(map[string]int),
+	}
+
+	s.wg.Add(3)
+	go s.run()
+	go s.runUDP(rtpConn, base.StreamTypeRTP)
+	go s.runUDP(rtcpConn, base.StreamTypeRTCP)
+
+	return s, nil
+}
+
+// Close closes the server and all its connections.
+func (s *Server) Close() {
+	s.listener.Close()
+
+	s.mutex.Lock()
+	for nconn := range s.conns {
+		nconn.Close()
+	}
+	s.mutex.Unlock()
+
+	s.rtpConn.Close()
+	s.rtcpConn.Close()
+
+	s.wg.Wait()
+}
+
+// URL returns the URL of the stream.
+func (s *Server) URL() *base.URL {
+	scheme := "rtsp"
+	if s.conf.TLSConfig != nil {
+		scheme = "rtsps"
+	}
+	return base.MustParseURL(scheme + "://" + s.listener.Addr().String() + serverPath)
+}
+
+func (s *Server) run() {
+	defer s.wg.Done()
+
+	for {
+		nconn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mutex.Lock()
+		s.conns[nconn] = struct{}{}
+		s.mutex.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+
+			c := &serverConn{
+				s:      s,
+				nconn:  nconn,
+				bw:     bufio.NewWriter(nconn),
+				tracks: make(map[int]*headers.Transport),
+			}
+			c.run()
+
+			s.mutex.Lock()
+			delete(s.conns, nconn)
+			s.mutex.Unlock()
+
+			nconn.Close()
+		}()
+	}
+}
+
+// runUDP reads the frames that are published with UDP. They are associated
+// with a track through the client ports sent in the SETUP request.
+func (s *Server) runUDP(pc net.PacketConn, streamType base.StreamType) {
+	defer s.wg.Done()
+
+	buf := make([]byte, 2048)
+
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		s.mutex.Lock()
+		trackID, ok := s.udpTracks[addr.String()]
+		s.mutex.Unlock()
+
+		if ok && s.conf.OnFrame != nil {
+			s.conf.OnFrame(&base.InterleavedFrame{
+				TrackID:    trackID,
+				StreamType: streamType,
+				Payload:    append([]byte(nil), buf[:n]...),
+			})
+		}
+	}
+}
+
+type serverConn struct {
+	s      *Server
+	nconn  net.Conn
+	tracks map[int]*headers.Transport
+	setups int
+
+	writeMutex sync.Mutex
+	bw         *bufio.Writer
+}
+
+func (c *serverConn) run() {
+	br := bufio.NewReader(c.nconn)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	for {
+		var req base.Request
+		var frame base.InterleavedFrame
+		frame.Payload = make([]byte, 2048)
+
+		what, err := base.ReadInterleavedFrameOrRequest(&frame, &req, br)
+		if err != nil {
+			return
+		}
+
+		if f, ok := what.(*base.InterleavedFrame); ok {
+			if c.s.conf.OnFrame != nil {
+				c.s.conf.OnFrame(f)
+			}
+			continue
+		}
+
+		res := c.handleRequest(&req)
+
+		c.writeMutex.Lock()
+		err = res.Write(c.bw)
+		c.writeMutex.Unlock()
+		if err != nil {
+			return
+		}
+
+		switch {
+		case req.Method == base.Teardown:
+			return
+
+		case req.Method == base.Play && res.StatusCode == base.StatusOK:
+			tracks := make(map[int]*headers.Transport, len(c.tracks))
+			for trackID, th := range c.tracks {
+				tracks[trackID] = th
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c.writePackets(tracks, done)
+			}()
+		}
+	}
+}
+
+func (c *serverConn) handleRequest(req *base.Request) *base.Response {
+	res := func() *base.Response {
+		if c.s.conf.OnRequest != nil {
+			if res := c.s.conf.OnRequest(req); res != nil {
+				return res
+			}
+		}
+
+		switch req.Method {
+		case base.Options:
+			return &base.Response{
+				StatusCode: base.StatusOK,
+				Header: base.Header{
+					"Public": base.HeaderValue{strings.Join([]string{
+						string(base.Describe),
+						string(base.Announce),
+						string(base.Setup),
+						string(base.Play),
+						string(base.Record),
+						string(base.Pause),
+						string(base.GetParameter),
+						string(base.Teardown),
+					}, ", ")},
+				},
+			}
+
+		case base.Describe:
+			return &base.Response{
+				StatusCode: base.StatusOK,
+				Header: base.Header{
+					"Content-Type": base.HeaderValue{"application/sdp"},
+					"Content-Base": base.HeaderValue{c.s.URL().String() + "/"},
+				},
+				Body: c.s.conf.SDP,
+			}
+
+		case base.Setup:
+			return c.handleSetup(req)
+
+		case base.Announce:
+			return &base.Response{
+				StatusCode: base.StatusOK,
+			}
+
+		case base.Play, base.Record, base.Pause, base.GetParameter, base.SetParameter, base.Teardown:
+			return &base.Response{
+				StatusCode: base.StatusOK,
+				Header: base.Header{
+					"Session": base.HeaderValue{serverSession},
+				},
+			}
+		}
+
+		return &base.Response{
+			StatusCode: base.StatusNotImplemented,
+		}
+	}()
+
+	if res.Header == nil {
+		res.Header = make(base.Header)
+	}
+	res.Header["CSeq"] = req.Header["CSeq"]
+
+	return res
+}
+
+func (c *serverConn) handleSetup(req *base.Request) *base.Response {
+	th, err := headers.ReadTransport(req.Header["Transport"])
+	if err != nil {
+		return &base.Response{
+			StatusCode: base.StatusBadRequest,
+		}
+	}
+
+	trackID := c.setups
+	if i := strings.LastIndex(req.URL.Path, "trackID="); i >= 0 {
+		tmp, err := strconv.ParseInt(req.URL.Path[i+len("trackID="):], 10, 64)
+		if err != nil {
+			return &base.Response{
+				StatusCode: base.StatusBadRequest,
+			}
+		}
+		trackID = int(tmp)
+	}
+	c.setups++
+
+	delivery := base.StreamDeliveryUnicast
+	resTh := &headers.Transport{
+		Protocol: th.Protocol,
+		Delivery: &delivery,
+	}
+
+	switch th.Protocol {
+	case base.StreamProtocolUDP:
+		if th.ClientPorts == nil {
+			return &base.Response{
+				StatusCode: base.StatusBadRequest,
+			}
+		}
+
+		resTh.ClientPorts = th.ClientPorts
+		resTh.ServerPorts = &[2]int{
+			c.s.rtpConn.LocalAddr().(*net.UDPAddr).Port,
+			c.s.rtcpConn.LocalAddr().(*net.UDPAddr).Port,
+		}
+
+		ip := c.nconn.RemoteAddr().(*net.TCPAddr).IP
+		c.s.mutex.Lock()
+		for _, port := range th.ClientPorts {
+			c.s.udpTracks[(&net.UDPAddr{IP: ip, Port: port}).String()] = trackID
+		}
+		c.s.mutex.Unlock()
+
+	case base.StreamProtocolTCP:
+		if th.InterleavedIds == nil {
+			th.InterleavedIds = &[2]int{trackID * 2, trackID*2 + 1}
+		}
+		resTh.InterleavedIds = th.InterleavedIds
+
+	default:
+		return &base.Response{
+			StatusCode: base.StatusUnsupportedTransport,
+		}
+	}
+
+	c.tracks[trackID] = resTh
+
+	return &base.Response{
+		StatusCode: base.StatusOK,
+		Header: base.Header{
+			"Session":   base.HeaderValue{serverSession},
+			"Transport": resTh.Write(),
+		},
+	}
+}
+
+func (c *serverConn) writePackets(tracks map[int]*headers.Transport, done chan struct{}) {
+	for _, pkt := range c.s.conf.Packets {
+		if c.s.conf.PacketInterval != 0 {
+			select {
+			case <-time.After(c.s.conf.PacketInterval):
+			case <-done:
+				return
+			}
+		}
+
+		th, ok := tracks[pkt.TrackID]
+		if !ok {
+			continue
+		}
+
+		err := c.writePacket(th, pkt)
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (c *serverConn) writePacket(th *headers.Transport, pkt Packet) error {
+	if th.Protocol == base.StreamProtocolUDP {
+		port := th.ClientPorts[0]
+		conn := c.s.rtpConn
+		if pkt.StreamType == base.StreamTypeRTCP {
+			port = th.ClientPorts[1]
+			conn = c.s.rtcpConn
+		}
+
+		_, err := conn.WriteTo(pkt.Payload, &net.UDPAddr{
+			IP:   c.nconn.RemoteAddr().(*net.TCPAddr).IP,
+			Port: port,
+		})
+		return err
+	}
+
+	channel := th.InterleavedIds[0]
+	if pkt.StreamType == base.StreamTypeRTCP {
+		channel = th.InterleavedIds[1]
+	}
+
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	return base.InterleavedFrame{
+		Channel: channel,
+		Payload: pkt.Payload,
+	}.Write(c.bw)
+}
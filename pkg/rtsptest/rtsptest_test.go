@@ -0,0 +1,118 @@
+package rtsptest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aler9/gortsplib"
+	"github.com/aler9/gortsplib/pkg/base"
+)
+
+func TestServer(t *testing.T) {
+	for _, proto := range []gortsplib.StreamProtocol{
+		gortsplib.StreamProtocolUDP,
+		gortsplib.StreamProtocolTCP,
+	} {
+		t.Run(proto.String(), func(t *testing.T) {
+			track, err := gortsplib.NewTrackH264(96, []byte("123456"), []byte("123456"))
+			require.NoError(t, err)
+
+			s, err := New(Conf{
+				SDP: gortsplib.Tracks{track}.Write(),
+				Packets: []Packet{
+					{
+						TrackID:    0,
+						StreamType: base.StreamTypeRTP,
+						Payload:    []byte{0x80, 0x60, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01},
+					},
+					{
+						TrackID:    1,
+						StreamType: base.StreamTypeRTP,
+						Payload:    []byte{0x80, 0x60, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x02},
+					},
+					{
+						TrackID:    0,
+						StreamType: base.StreamTypeRTP,
+						Payload:    []byte{0x80, 0x60, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x03},
+					},
+				},
+			})
+			require.NoError(t, err)
+			defer s.Close()
+
+			conn, err := gortsplib.ClientConf{
+				StreamProtocol: &proto,
+			}.DialRead(s.URL().String())
+			require.NoError(t, err)
+			defer conn.Close()
+
+			frameRecv := make(chan []byte, 10)
+			conn.ReadFrames(func(trackID int, streamType gortsplib.StreamType, payload []byte) {
+				if streamType == gortsplib.StreamTypeRTP {
+					frameRecv <- append([]byte(nil), payload...)
+				}
+			})
+
+			// the packet of the track that was not set up is skipped
+			require.Equal(t, byte(0x01), (<-frameRecv)[12])
+			require.Equal(t, byte(0x03), (<-frameRecv)[12])
+		})
+	}
+}
+
+func TestServerOnRequest(t *testing.T) {
+	s, err := New(Conf{
+		OnRequest: func(req *base.Request) *base.Response {
+			if req.Method == base.Describe {
+				return &base.Response{
+					StatusCode: base.StatusNotFound,
+				}
+			}
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	defer s.Close()
+
+	_, err = gortsplib.DialRead(s.URL().String())
+	require.Error(t, err)
+}
+
+func TestServerPublish(t *testing.T) {
+	for _, proto := range []gortsplib.StreamProtocol{
+		gortsplib.StreamProtocolUDP,
+		gortsplib.StreamProtocolTCP,
+	} {
+		t.Run(proto.String(), func(t *testing.T) {
+			frameRecv := make(chan *base.InterleavedFrame, 10)
+
+			s, err := New(Conf{
+				OnFrame: func(frame *base.InterleavedFrame) {
+					if frame.StreamType == base.StreamTypeRTP {
+						frameRecv <- frame
+					}
+				},
+			})
+			require.NoError(t, err)
+			defer s.Close()
+
+			track, err := gortsplib.NewTrackH264(96, []byte("123456"), []byte("123456"))
+			require.NoError(t, err)
+
+			conn, err := gortsplib.ClientConf{
+				StreamProtocol: &proto,
+			}.DialPublish(s.URL().String(), gortsplib.Tracks{track})
+			require.NoError(t, err)
+			defer conn.Close()
+
+			pkt := []byte{0x80, 0x60, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01}
+			err = conn.WriteFrame(0, gortsplib.StreamTypeRTP, pkt)
+			require.NoError(t, err)
+
+			frame := <-frameRecv
+			require.Equal(t, 0, frame.TrackID)
+			require.Equal(t, pkt, frame.Payload)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package gortsplib
 import (
 	"fmt"
 	"net"
+	"sync"
 	"time"
 )
 
@@ -22,14 +23,31 @@ func newServer(conf ServerConf, address string) (*Server, error) {
 	if conf.ReadBufferCount == 0 {
 		conf.ReadBufferCount = 512
 	}
+	if conf.WriteBufferCount == 0 {
+		conf.WriteBufferCount = conf.ReadBufferCount
+	}
+	if conf.MaxInterleavedPayloadSize == 0 {
+		conf.MaxInterleavedPayloadSize = 2048
+	}
 	if conf.Listen == nil {
 		conf.Listen = net.Listen
 	}
 
-	if conf.TLSConfig != nil && conf.UDPRTPListener != nil {
+	if conf.TLSConfig != nil && (conf.UDPRTPListener != nil || conf.UDPPortRange[0] != 0) {
 		return nil, fmt.Errorf("TLS can't be used together with UDP")
 	}
 
+	if conf.UDPPortRange != [2]int{0, 0} {
+		if conf.UDPRTPListener != nil {
+			return nil, fmt.Errorf("UDPPortRange and UDPRTPListener can't be used together")
+		}
+
+		if conf.UDPPortRange[0] <= 0 || conf.UDPPortRange[1] > 65535 ||
+			(conf.UDPPortRange[1]-(conf.UDPPortRange[0]+conf.UDPPortRange[0]%2)) < 1 {
+			return nil, fmt.Errorf("invalid UDP port range (%v)", conf.UDPPortRange)
+		}
+	}
+
 	if (conf.UDPRTPListener != nil && conf.UDPRTCPListener == nil) ||
 		(conf.UDPRTPListener == nil && conf.UDPRTCPListener != nil) {
 		return nil, fmt.Errorf("UDPRTPListener and UDPRTPListener must be used together")
@@ -67,3 +85,79 @@ func (s *Server) Accept() (*ServerConn, error) {
 
 	return newServerConn(s.conf, nconn), nil
 }
+
+// Serve accepts connections and routes their requests to handler, until the
+// server is closed. When Serve returns, all the connections it accepted are closed.
+func (s *Server) Serve(handler ServerHandler) error {
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	conns := make(map[*ServerConn]struct{})
+	rejected := make(map[*ServerConn]struct{})
+
+	defer func() {
+		mutex.Lock()
+		for sc := range conns {
+			sc.Close()
+			delete(conns, sc)
+		}
+		for sc := range rejected {
+			sc.Close()
+		}
+		mutex.Unlock()
+
+		wg.Wait()
+	}()
+
+	for {
+		sc, err := s.Accept()
+		if err != nil {
+			return err
+		}
+
+		mutex.Lock()
+		if s.conf.MaxConnections != 0 && len(conns) >= s.conf.MaxConnections {
+			rejected[sc] = struct{}{}
+			mutex.Unlock()
+			log(s.conf.Logger, LogLevelWarn, "connection rejected",
+				LogField{"remote_addr", sc.nconn.RemoteAddr().String()},
+				LogField{"reason", "maximum number of connections reached"})
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sc.reject()
+
+				mutex.Lock()
+				delete(rejected, sc)
+				mutex.Unlock()
+			}()
+			continue
+		}
+		conns[sc] = struct{}{}
+		mutex.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if h, ok := handler.(ServerHandlerOnConnOpen); ok {
+				h.OnConnOpen(sc)
+			}
+
+			err := <-sc.Read(serverHandlerReadHandlers(handler, sc))
+
+			mutex.Lock()
+			_, open := conns[sc]
+			delete(conns, sc)
+			mutex.Unlock()
+
+			if open {
+				sc.Close()
+			}
+
+			if h, ok := handler.(ServerHandlerOnConnClose); ok {
+				h.OnConnClose(sc, err)
+			}
+		}()
+	}
+}
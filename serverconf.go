@@ -14,10 +14,18 @@ func Serve(address string) (*Server, error) {
 	return DefaultServerConf.Serve(address)
 }
 
+// ListenAndServe starts a server on the given address and routes the
+// requests of incoming connections to handler.
+func ListenAndServe(address string, handler ServerHandler) error {
+	return DefaultServerConf.ListenAndServe(address, handler)
+}
+
 // ServerConf allows to configure a Server.
 // All fields are optional.
 type ServerConf struct {
 	// A TLS configuration to accept TLS (RTSPS) connections.
+	// Client certificates can be required by setting ClientAuth and ClientCAs,
+	// and can be read with ServerConn.TLSConnectionState().
 	TLSConfig *tls.Config
 
 	// A ServerUDPListener to send and receive UDP/RTP packets.
@@ -28,6 +36,13 @@ type ServerConf struct {
 	// If UDPRTPListener and UDPRTCPListener are not null, the server can accept and send UDP streams.
 	UDPRTCPListener *ServerUDPListener
 
+	// Range of ports from which a RTP/RTCP port pair is allocated for every
+	// track of every connection that uses UDP. RTP ports are even and
+	// RTCP ports are the following odd ones.
+	// It can't be used together with UDPRTPListener and UDPRTCPListener.
+	// It defaults to [0 0] (port pairs are not allocated).
+	UDPPortRange [2]int
+
 	// Timeout of read operations.
 	// It defaults to 10 seconds
 	ReadTimeout time.Duration
@@ -36,6 +51,45 @@ type ServerConf struct {
 	// It defaults to 10 seconds
 	WriteTimeout time.Duration
 
+	// Maximum amount of time a connection can stay without sending anything,
+	// when it's not publishing with TCP; in that case ReadTimeout is used.
+	// Clients that are reading or publishing with UDP must send keepalives.
+	// It defaults to 0 (connections are never closed for inactivity).
+	IdleTimeout time.Duration
+
+	// Maximum number of concurrent connections accepted by Serve().
+	// Connections that exceed the limit receive a 503 Service Unavailable
+	// response to their first request, and are then closed.
+	// It defaults to 0 (no limit).
+	MaxConnections int
+
+	// Maximum number of tracks that a connection can announce or setup.
+	// Since every connection holds a single session, it also limits
+	// the resources allocated by a session.
+	// It defaults to 0 (no limit).
+	MaxTracks int
+
+	// Maximum size of the header of requests.
+	// It defaults to 0 (only the size of single entries is limited).
+	MaxRequestHeaderSize int
+
+	// Maximum size of the body of requests, including SDPs sent with ANNOUNCE.
+	// It defaults to 128KB.
+	MaxRequestBodySize int
+
+	// Maximum length of the method of requests.
+	// It defaults to 128.
+	MaxRequestMethodLength int
+
+	// Maximum number of header entries of requests.
+	// It defaults to 255.
+	MaxRequestHeaderCount int
+
+	// Maximum size of the payload of interleaved frames received with TCP.
+	// Connections that send bigger frames are closed.
+	// It defaults to 2048.
+	MaxInterleavedPayloadSize int
+
 	// Read buffer count.
 	// If greater than 1, allows to pass buffers to routines different than the one
 	// that is reading frames.
@@ -44,6 +98,22 @@ type ServerConf struct {
 	// It defaults to 512
 	ReadBufferCount uint64
 
+	// Size of the queue of frames to be sent to each reader with TCP.
+	// Readers of a ServerStream that are not fast enough to empty their queue
+	// are disconnected.
+	// It defaults to ReadBufferCount
+	WriteBufferCount uint64
+
+	// Logger that receives connection lifecycle events, requests, responses
+	// and errors.
+	// It defaults to nil (nothing is logged).
+	Logger Logger
+
+	// Metrics provider that receives counters and gauges about connections,
+	// sessions, frames, bytes and lost packets.
+	// It defaults to nil (metrics are not collected).
+	Metrics Metrics
+
 	// Function used to initialize the TCP listener.
 	// It defaults to net.Listen
 	Listen func(network string, address string) (net.Listener, error)
@@ -53,3 +123,15 @@ type ServerConf struct {
 func (c ServerConf) Serve(address string) (*Server, error) {
 	return newServer(c, address)
 }
+
+// ListenAndServe starts a server on the given address and routes the
+// requests of incoming connections to handler.
+func (c ServerConf) ListenAndServe(address string, handler ServerHandler) error {
+	s, err := c.Serve(address)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	return s.Serve(handler)
+}
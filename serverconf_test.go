@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -151,7 +152,7 @@ func (ts *testServ) handleConn(conn *ServerConn) {
 		}, nil
 	}
 
-	onPlay := func(req *base.Request) (*base.Response, error) {
+	onPlay := func(req *base.Request, rng *headers.Range) (*base.Response, error) {
 		ts.mutex.Lock()
 		defer ts.mutex.Unlock()
 
@@ -279,6 +280,40 @@ y++U32uuSFiXDcSLarfIsE992MEJLSAynbF1Rsgsr3gXbGiuToJRyxbIeVy7gwzD
 -----END RSA PRIVATE KEY-----
 `)
 
+// testServPublish publishes a stream to a testServ with a ClientConn, by writing
+// a RTP packet periodically, and returns a function that stops publishing.
+func testServPublish(t *testing.T) func() {
+	track, err := NewTrackH264(96, []byte{0x67, 0x64, 0x00, 0x0c}, []byte{0x68, 0xee})
+	require.NoError(t, err)
+
+	proto := StreamProtocolTCP
+	conn, err := ClientConf{
+		StreamProtocol: &proto,
+	}.DialPublish("rtsp://localhost:8554/teststream", Tracks{track})
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(50 * time.Millisecond)
+		defer ticker.Stop()
+
+		for seq := uint16(0); ; seq++ {
+			<-ticker.C
+			err := conn.WriteFrame(track.ID, StreamTypeRTP, testRTPPacket(seq))
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return func() {
+		conn.Close()
+		<-done
+	}
+}
+
 func TestServerPublishRead(t *testing.T) {
 	for _, ca := range []struct {
 		encrypted      bool
@@ -314,6 +349,8 @@ func TestServerPublishRead(t *testing.T) {
 
 		t.Run(encryptedStr+"_"+ca.publisherSoft+"_"+ca.publisherProto+"_"+
 			ca.readerSoft+"_"+ca.readerProto, func(t *testing.T) {
+			requireDocker(t, ca.publisherSoft, ca.readerSoft)
+
 			var proto string
 			var tlsConf *tls.Config
 			if !ca.encrypted {
@@ -418,19 +455,7 @@ func TestServerResponseBeforeFrames(t *testing.T) {
 	require.NoError(t, err)
 	defer ts.close()
 
-	cnt1, err := newContainer("ffmpeg", "publish", []string{
-		"-re",
-		"-stream_loop", "-1",
-		"-i", "emptyvideo.ts",
-		"-c", "copy",
-		"-f", "rtsp",
-		"-rtsp_transport", "tcp",
-		"rtsp://localhost:8554/teststream",
-	})
-	require.NoError(t, err)
-	defer cnt1.close()
-
-	time.Sleep(1 * time.Second)
+	defer testServPublish(t)()
 
 	conn, err := net.Dial("tcp", "localhost:8554")
 	require.NoError(t, err)
@@ -487,19 +512,7 @@ func TestServerPlayMultiple(t *testing.T) {
 	require.NoError(t, err)
 	defer ts.close()
 
-	cnt1, err := newContainer("ffmpeg", "publish", []string{
-		"-re",
-		"-stream_loop", "-1",
-		"-i", "emptyvideo.ts",
-		"-c", "copy",
-		"-f", "rtsp",
-		"-rtsp_transport", "tcp",
-		"rtsp://localhost:8554/teststream",
-	})
-	require.NoError(t, err)
-	defer cnt1.close()
-
-	time.Sleep(1 * time.Second)
+	defer testServPublish(t)()
 
 	conn, err := net.Dial("tcp", "localhost:8554")
 	require.NoError(t, err)
@@ -565,19 +578,7 @@ func TestServerPauseMultiple(t *testing.T) {
 	require.NoError(t, err)
 	defer ts.close()
 
-	cnt1, err := newContainer("ffmpeg", "publish", []string{
-		"-re",
-		"-stream_loop", "-1",
-		"-i", "emptyvideo.ts",
-		"-c", "copy",
-		"-f", "rtsp",
-		"-rtsp_transport", "tcp",
-		"rtsp://localhost:8554/teststream",
-	})
-	require.NoError(t, err)
-	defer cnt1.close()
-
-	time.Sleep(1 * time.Second)
+	defer testServPublish(t)()
 
 	conn, err := net.Dial("tcp", "localhost:8554")
 	require.NoError(t, err)
@@ -651,3 +652,1354 @@ func TestServerPauseMultiple(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, base.StatusOK, res.StatusCode)
 }
+
+type testServerHandler struct {
+	track *Track
+	open  chan struct{}
+	close chan error
+}
+
+func (h *testServerHandler) OnConnOpen(sc *ServerConn) {
+	close(h.open)
+}
+
+func (h *testServerHandler) OnConnClose(sc *ServerConn, err error) {
+	h.close <- err
+}
+
+func (h *testServerHandler) OnDescribe(sc *ServerConn, req *base.Request) (*base.Response, error) {
+	return &base.Response{
+		StatusCode: base.StatusOK,
+		Header: base.Header{
+			"Content-Base": base.HeaderValue{req.URL.String() + "/"},
+			"Content-Type": base.HeaderValue{"application/sdp"},
+		},
+		Body: Tracks{h.track}.Write(),
+	}, nil
+}
+
+func TestServerHandler(t *testing.T) {
+	track, err := NewTrackH264(96, []byte("123456"), []byte("123456"))
+	require.NoError(t, err)
+
+	h := &testServerHandler{
+		track: track,
+		open:  make(chan struct{}),
+		close: make(chan error, 1),
+	}
+
+	s, err := Serve(":8554")
+	require.NoError(t, err)
+
+	serveDone := make(chan error)
+	go func() {
+		serveDone <- s.Serve(h)
+	}()
+
+	u := base.MustParseURL("rtsp://localhost:8554/teststream")
+
+	conn, err := Dial(u.Scheme, u.Host)
+	require.NoError(t, err)
+
+	<-h.open
+
+	res, err := conn.Options(u)
+	require.NoError(t, err)
+	require.Equal(t, base.HeaderValue{"DESCRIBE, GET_PARAMETER, TEARDOWN"}, res.Header["Public"])
+
+	tracks, _, err := conn.Describe(u)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(tracks))
+
+	conn.Close()
+	<-h.close
+
+	s.Close()
+	<-serveDone
+}
+
+type testServerKickHandler struct {
+	close chan error
+}
+
+func (h *testServerKickHandler) OnConnClose(sc *ServerConn, err error) {
+	h.close <- err
+}
+
+func (h *testServerKickHandler) OnDescribe(sc *ServerConn, req *base.Request) (*base.Response, error) {
+	// kick the client; the connection is closed again by Serve()
+	sc.Close()
+	return &base.Response{
+		StatusCode: base.StatusNotFound,
+	}, nil
+}
+
+func TestServerHandlerCloseConn(t *testing.T) {
+	h := &testServerKickHandler{
+		close: make(chan error, 1),
+	}
+
+	s, err := Serve(":8554")
+	require.NoError(t, err)
+
+	serveDone := make(chan error)
+	go func() {
+		serveDone <- s.Serve(h)
+	}()
+
+	_, _, err = Describe("rtsp://localhost:8554/teststream")
+	require.Error(t, err)
+	<-h.close
+
+	s.Close()
+	<-serveDone
+}
+
+type testServerTLSHandler struct {
+	peerCertificates chan int
+}
+
+func (h *testServerTLSHandler) OnDescribe(sc *ServerConn, req *base.Request) (*base.Response, error) {
+	h.peerCertificates <- len(sc.TLSConnectionState().PeerCertificates)
+	return &base.Response{
+		StatusCode: base.StatusNotFound,
+	}, nil
+}
+
+func TestServerTLSClientCertificate(t *testing.T) {
+	cert, err := tls.X509KeyPair(serverCert, serverKey)
+	require.NoError(t, err)
+
+	h := &testServerTLSHandler{
+		peerCertificates: make(chan int, 1),
+	}
+
+	s, err := ServerConf{
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientAuth:   tls.RequireAnyClientCert,
+		},
+	}.Serve(":8555")
+	require.NoError(t, err)
+
+	serveDone := make(chan error)
+	go func() {
+		serveDone <- s.Serve(h)
+	}()
+
+	u := base.MustParseURL("rtsps://localhost:8555/teststream")
+
+	// without a client certificate
+	conn, err := Dial(u.Scheme, u.Host)
+	require.NoError(t, err)
+	_, _, err = conn.Describe(u)
+	require.Error(t, err)
+	conn.Close()
+
+	// with a client certificate
+	conn, err = ClientConf{
+		TLSConfig: &tls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       []tls.Certificate{cert},
+		},
+	}.Dial(u.Scheme, u.Host)
+	require.NoError(t, err)
+	_, _, err = conn.Describe(u)
+	require.Error(t, err)
+	require.Equal(t, 1, <-h.peerCertificates)
+	conn.Close()
+
+	s.Close()
+	<-serveDone
+}
+
+type testServerStreamHandler struct {
+	stream *ServerStream
+	play   chan struct{}
+}
+
+func (h *testServerStreamHandler) OnConnClose(sc *ServerConn, err error) {
+	h.stream.RemoveReader(sc)
+}
+
+func (h *testServerStreamHandler) OnDescribe(sc *ServerConn, req *base.Request) (*base.Response, error) {
+	return &base.Response{
+		StatusCode: base.StatusOK,
+		Header: base.Header{
+			"Content-Base": base.HeaderValue{req.URL.String() + "/"},
+			"Content-Type": base.HeaderValue{"application/sdp"},
+		},
+		Body: h.stream.Tracks().Write(),
+	}, nil
+}
+
+func (h *testServerStreamHandler) OnSetup(sc *ServerConn, req *base.Request, th *headers.Transport,
+	basePath string, trackID int) (*base.Response, error) {
+	res := &base.Response{
+		StatusCode: base.StatusOK,
+		Header: base.Header{
+			"Session": base.HeaderValue{"12345678"},
+		},
+	}
+
+	if th.Delivery != nil && *th.Delivery == base.StreamDeliveryMulticast {
+		if mt := h.stream.MulticastTransport(trackID); mt != nil {
+			res.Header["Transport"] = mt.Write()
+		}
+	}
+
+	return res, nil
+}
+
+func (h *testServerStreamHandler) OnPlay(sc *ServerConn, req *base.Request, rng *headers.Range) (*base.Response, error) {
+	h.stream.AddReader(sc)
+	h.play <- struct{}{}
+
+	res := &base.Response{
+		StatusCode: base.StatusOK,
+		Header: base.Header{
+			"Session": base.HeaderValue{"12345678"},
+		},
+	}
+
+	if rtpInfo := h.stream.RTPInfo(sc, req.URL); rtpInfo != nil {
+		res.Header["RTP-Info"] = rtpInfo.Write()
+	}
+
+	return res, nil
+}
+
+func TestServerStream(t *testing.T) {
+	track, err := NewTrackH264(96, []byte("123456"), []byte("123456"))
+	require.NoError(t, err)
+
+	h := &testServerStreamHandler{
+		stream: NewServerStream(Tracks{track}),
+		play:   make(chan struct{}, 2),
+	}
+	defer h.stream.Close()
+
+	s, err := Serve(":8554")
+	require.NoError(t, err)
+
+	serveDone := make(chan error)
+	go func() {
+		serveDone <- s.Serve(h)
+	}()
+
+	proto := StreamProtocolTCP
+	conf := ClientConf{
+		StreamProtocol: &proto,
+	}
+
+	var frames []chan []byte
+	for i := 0; i < 2; i++ {
+		conn, err := conf.DialRead("rtsp://localhost:8554/teststream")
+		require.NoError(t, err)
+		defer conn.Close()
+		<-h.play
+
+		require.Equal(t, Tracks{track}.Write(), conn.SDP())
+
+		frameRecv := make(chan []byte, 1)
+		frames = append(frames, frameRecv)
+		conn.ReadFrames(func(trackID int, streamType StreamType, payload []byte) {
+			if streamType == StreamTypeRTP {
+				select {
+				case frameRecv <- append([]byte(nil), payload...):
+				default:
+				}
+			}
+		})
+	}
+
+	require.Equal(t, 2, h.stream.ReadersLen())
+
+	h.stream.WriteFrame(0, StreamTypeRTP, []byte{0x01, 0x02, 0x03, 0x04})
+
+	for _, frameRecv := range frames {
+		require.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, <-frameRecv)
+	}
+
+	s.Close()
+	<-serveDone
+}
+
+func TestServerStreamMulticast(t *testing.T) {
+	track, err := NewTrackH264(96, []byte("123456"), []byte("123456"))
+	require.NoError(t, err)
+
+	h := &testServerStreamHandler{
+		stream: NewServerStream(Tracks{track}),
+		play:   make(chan struct{}, 2),
+	}
+	defer h.stream.Close()
+
+	err = h.stream.EnableMulticast(net.ParseIP("224.1.0.1"), 35100, 1)
+	require.NoError(t, err)
+
+	s, err := Serve(":8554")
+	require.NoError(t, err)
+
+	serveDone := make(chan error)
+	go func() {
+		serveDone <- s.Serve(h)
+	}()
+
+	proto := StreamProtocolUDPMulticast
+	conf := ClientConf{
+		StreamProtocol: &proto,
+	}
+
+	var frames []chan []byte
+	for i := 0; i < 2; i++ {
+		conn, err := conf.DialRead("rtsp://localhost:8554/teststream")
+		require.NoError(t, err)
+		defer conn.Close()
+		<-h.play
+
+		frameRecv := make(chan []byte, 1)
+		frames = append(frames, frameRecv)
+		conn.ReadFrames(func(trackID int, streamType StreamType, payload []byte) {
+			if streamType == StreamTypeRTP {
+				select {
+				case frameRecv <- append([]byte(nil), payload...):
+				default:
+				}
+			}
+		})
+	}
+
+	require.Equal(t, 2, h.stream.ReadersLen())
+
+	h.stream.WriteFrame(0, StreamTypeRTP, []byte{0x01, 0x02, 0x03, 0x04})
+
+	for _, frameRecv := range frames {
+		require.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, <-frameRecv)
+	}
+
+	s.Close()
+	<-serveDone
+}
+
+func TestServerStreamMulticastDisabled(t *testing.T) {
+	track, err := NewTrackH264(96, []byte("123456"), []byte("123456"))
+	require.NoError(t, err)
+
+	h := &testServerStreamHandler{
+		stream: NewServerStream(Tracks{track}),
+		play:   make(chan struct{}, 2),
+	}
+	defer h.stream.Close()
+
+	s, err := Serve(":8554")
+	require.NoError(t, err)
+
+	serveDone := make(chan error)
+	go func() {
+		serveDone <- s.Serve(h)
+	}()
+
+	proto := StreamProtocolUDPMulticast
+	conf := ClientConf{
+		StreamProtocol: &proto,
+	}
+
+	_, err = conf.DialRead("rtsp://localhost:8554/teststream")
+	require.Error(t, err)
+
+	s.Close()
+	<-serveDone
+}
+
+func TestServerStreamEnableMulticastErrors(t *testing.T) {
+	track, err := NewTrackH264(96, []byte("123456"), []byte("123456"))
+	require.NoError(t, err)
+
+	for _, ca := range []struct {
+		name string
+		ip   net.IP
+		port int
+	}{
+		{
+			"unicast ip",
+			net.ParseIP("127.0.0.1"),
+			35100,
+		},
+		{
+			"odd port",
+			net.ParseIP("224.1.0.1"),
+			35101,
+		},
+		{
+			"port out of range",
+			net.ParseIP("224.1.0.1"),
+			65534,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			stream := NewServerStream(Tracks{track})
+			defer stream.Close()
+
+			err := stream.EnableMulticast(ca.ip, ca.port, 0)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestServerStreamPacing(t *testing.T) {
+	track, err := NewTrackH264(96, []byte("123456"), []byte("123456"))
+	require.NoError(t, err)
+
+	stream := NewServerStream(Tracks{track})
+	defer stream.Close()
+
+	err = stream.EnablePacing(0)
+	require.Error(t, err)
+
+	// 100 bytes every 10ms
+	err = stream.EnablePacing(80000)
+	require.NoError(t, err)
+
+	start := time.Now()
+
+	for i := 0; i < 5; i++ {
+		pkt := make([]byte, 100)
+		pkt[0] = 0x80
+		stream.WriteFrame(0, StreamTypeRTP, pkt)
+	}
+
+	require.True(t, time.Since(start) >= 40*time.Millisecond)
+}
+
+func TestServerUDPPortRange(t *testing.T) {
+	track, err := NewTrackH264(96, []byte("123456"), []byte("123456"))
+	require.NoError(t, err)
+
+	t.Run("read", func(t *testing.T) {
+		h := &testServerStreamHandler{
+			stream: NewServerStream(Tracks{track}),
+			play:   make(chan struct{}, 2),
+		}
+		defer h.stream.Close()
+
+		s, err := ServerConf{
+			UDPPortRange: [2]int{35001, 35010},
+		}.Serve(":8554")
+		require.NoError(t, err)
+
+		serveDone := make(chan error)
+		go func() {
+			serveDone <- s.Serve(h)
+		}()
+
+		proto := StreamProtocolUDP
+		conf := ClientConf{
+			StreamProtocol: &proto,
+		}
+
+		var frames []chan []byte
+		for i := 0; i < 2; i++ {
+			conn, err := conf.DialRead("rtsp://localhost:8554/teststream")
+			require.NoError(t, err)
+			defer conn.Close()
+			<-h.play
+
+			frameRecv := make(chan []byte, 1)
+			frames = append(frames, frameRecv)
+			conn.ReadFrames(func(trackID int, streamType StreamType, payload []byte) {
+				if streamType == StreamTypeRTP {
+					select {
+					case frameRecv <- append([]byte(nil), payload...):
+					default:
+					}
+				}
+			})
+		}
+
+		h.stream.WriteFrame(0, StreamTypeRTP, []byte{0x01, 0x02, 0x03, 0x04})
+
+		for _, frameRecv := range frames {
+			require.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, <-frameRecv)
+		}
+
+		s.Close()
+		<-serveDone
+	})
+
+	t.Run("publish", func(t *testing.T) {
+		h := &testServerRecordHandler{
+			frames:     make(chan []byte, 10),
+			transports: make(chan *headers.Transport, 1),
+		}
+
+		s, err := ServerConf{
+			UDPPortRange: [2]int{35001, 35010},
+		}.Serve(":8554")
+		require.NoError(t, err)
+
+		serveDone := make(chan error)
+		go func() {
+			serveDone <- s.Serve(h)
+		}()
+
+		proto := StreamProtocolUDP
+		conn, err := ClientConf{
+			StreamProtocol: &proto,
+		}.DialPublish("rtsp://localhost:8554/teststream", Tracks{track})
+		require.NoError(t, err)
+		<-h.transports
+
+		err = conn.WriteFrame(0, StreamTypeRTP, []byte{0x01, 0x02, 0x03, 0x04})
+		require.NoError(t, err)
+		require.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, <-h.frames)
+
+		conn.Close()
+
+		s.Close()
+		<-serveDone
+	})
+}
+
+func TestServerUDPPortRangeErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		conf ServerConf
+	}{
+		{
+			"range too small",
+			ServerConf{UDPPortRange: [2]int{35001, 35002}},
+		},
+		{
+			"invalid port",
+			ServerConf{UDPPortRange: [2]int{35000, 70000}},
+		},
+		{
+			"tls",
+			ServerConf{
+				UDPPortRange: [2]int{35000, 35010},
+				TLSConfig:    &tls.Config{},
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			_, err := ca.conf.Serve(":8554")
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestServerUDPInvalidClientPorts(t *testing.T) {
+	track, err := NewTrackH264(96, []byte("123456"), []byte("123456"))
+	require.NoError(t, err)
+
+	h := &testServerStreamHandler{
+		stream: NewServerStream(Tracks{track}),
+		play:   make(chan struct{}, 1),
+	}
+	defer h.stream.Close()
+
+	s, err := ServerConf{
+		UDPPortRange: [2]int{35000, 35010},
+	}.Serve(":8554")
+	require.NoError(t, err)
+
+	serveDone := make(chan error)
+	go func() {
+		serveDone <- s.Serve(h)
+	}()
+
+	conn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+	bw := bufio.NewWriter(conn)
+
+	err = base.Request{
+		Method: base.Setup,
+		URL:    base.MustParseURL("rtsp://localhost:8554/teststream/trackID=0"),
+		Header: base.Header{
+			"CSeq":      base.HeaderValue{"1"},
+			"Transport": base.HeaderValue{"RTP/AVP;unicast;client_port=0-70000"},
+		},
+	}.Write(bw)
+	require.NoError(t, err)
+
+	var res base.Response
+	err = res.Read(br)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusBadRequest, res.StatusCode)
+
+	s.Close()
+	<-serveDone
+}
+
+type testServerRecordTracksHandler struct {
+	*testServerRecordHandler
+	announcedTracks chan Tracks
+	frameTrackIDs   chan int
+}
+
+func (h *testServerRecordTracksHandler) OnRecord(sc *ServerConn, req *base.Request) (*base.Response, error) {
+	h.announcedTracks <- sc.AnnouncedTracks()
+	return h.testServerRecordHandler.OnRecord(sc, req)
+}
+
+func (h *testServerRecordTracksHandler) OnFrame(sc *ServerConn, trackID int, streamType StreamType, payload []byte) {
+	if streamType == StreamTypeRTP {
+		h.frameTrackIDs <- trackID
+	}
+}
+
+func TestServerRecordControlAttributes(t *testing.T) {
+	sdp := []byte("v=0\r\n" +
+		"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+		"s=Stream\r\n" +
+		"c=IN IP4 127.0.0.1\r\n" +
+		"t=0 0\r\n" +
+		"m=video 0 RTP/AVP 96\r\n" +
+		"a=rtpmap:96 H264/90000\r\n" +
+		"a=control:streamid=0\r\n" +
+		"m=audio 0 RTP/AVP 97\r\n" +
+		"a=rtpmap:97 MPEG4-GENERIC/44100/2\r\n" +
+		"a=control:rtsp://localhost:8554/teststream/streamid=1\r\n")
+
+	h := &testServerRecordTracksHandler{
+		testServerRecordHandler: &testServerRecordHandler{},
+		announcedTracks:         make(chan Tracks, 1),
+		frameTrackIDs:           make(chan int, 1),
+	}
+
+	s, err := Serve(":8554")
+	require.NoError(t, err)
+
+	serveDone := make(chan error)
+	go func() {
+		serveDone <- s.Serve(h)
+	}()
+
+	conn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+	bw := bufio.NewWriter(conn)
+
+	err = base.Request{
+		Method: base.Announce,
+		URL:    base.MustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq":         base.HeaderValue{"1"},
+			"Content-Type": base.HeaderValue{"application/sdp"},
+		},
+		Body: sdp,
+	}.Write(bw)
+	require.NoError(t, err)
+
+	var res base.Response
+	err = res.Read(br)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	// setup tracks in reverse order
+	for i, ca := range []struct {
+		control        string
+		interleavedIds [2]int
+		statusCode     base.StatusCode
+	}{
+		{"streamid=1", [2]int{2, 3}, base.StatusOK},
+		{"streamid=0", [2]int{0, 1}, base.StatusOK},
+	} {
+		err = base.Request{
+			Method: base.Setup,
+			URL:    base.MustParseURL("rtsp://localhost:8554/teststream/" + ca.control),
+			Header: base.Header{
+				"CSeq":      base.HeaderValue{strconv.FormatInt(int64(i+2), 10)},
+				"Transport": base.HeaderValue{fmt.Sprintf("RTP/AVP/TCP;unicast;interleaved=%d-%d;mode=record", ca.interleavedIds[0], ca.interleavedIds[1])},
+			},
+		}.Write(bw)
+		require.NoError(t, err)
+
+		err = res.Read(br)
+		require.NoError(t, err)
+		require.Equal(t, ca.statusCode, res.StatusCode)
+	}
+
+	err = base.Request{
+		Method: base.Record,
+		URL:    base.MustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq": base.HeaderValue{"5"},
+		},
+	}.Write(bw)
+	require.NoError(t, err)
+
+	err = res.Read(br)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	announcedTracks := <-h.announcedTracks
+	require.Equal(t, 2, len(announcedTracks))
+	control, _ := announcedTracks[1].Attribute("control")
+	require.Equal(t, "rtsp://localhost:8554/teststream/streamid=1", control)
+
+	err = base.InterleavedFrame{
+		Channel: 2,
+		Payload: []byte{0x01, 0x02, 0x03, 0x04},
+	}.Write(bw)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, <-h.frameTrackIDs)
+
+	s.Close()
+	<-serveDone
+}
+
+func TestServerRecordSetupTooManyTracks(t *testing.T) {
+	track, err := NewTrackH264(96, []byte("123456"), []byte("123456"))
+	require.NoError(t, err)
+
+	s, err := Serve(":8554")
+	require.NoError(t, err)
+
+	serveDone := make(chan error)
+	go func() {
+		serveDone <- s.Serve(&testServerRecordHandler{})
+	}()
+
+	conn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+	bw := bufio.NewWriter(conn)
+
+	err = base.Request{
+		Method: base.Announce,
+		URL:    base.MustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq":         base.HeaderValue{"1"},
+			"Content-Type": base.HeaderValue{"application/sdp"},
+		},
+		Body: Tracks{track}.Write(),
+	}.Write(bw)
+	require.NoError(t, err)
+
+	var res base.Response
+	err = res.Read(br)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	for i, statusCode := range []base.StatusCode{base.StatusOK, base.StatusBadRequest} {
+		err = base.Request{
+			Method: base.Setup,
+			URL:    base.MustParseURL("rtsp://localhost:8554/teststream/unknown"),
+			Header: base.Header{
+				"CSeq":      base.HeaderValue{strconv.FormatInt(int64(i+2), 10)},
+				"Transport": base.HeaderValue{fmt.Sprintf("RTP/AVP/TCP;unicast;interleaved=%d-%d;mode=record", i*2, i*2+1)},
+			},
+		}.Write(bw)
+		require.NoError(t, err)
+
+		err = res.Read(br)
+		require.NoError(t, err)
+		require.Equal(t, statusCode, res.StatusCode)
+	}
+
+	s.Close()
+	<-serveDone
+}
+
+func TestServerMaxConnections(t *testing.T) {
+	s, err := ServerConf{
+		MaxConnections: 1,
+	}.Serve(":8554")
+	require.NoError(t, err)
+
+	serveDone := make(chan error)
+	go func() {
+		serveDone <- s.Serve(&testServerRecordHandler{})
+	}()
+
+	var res base.Response
+
+	conn1, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer conn1.Close()
+	br1 := bufio.NewReader(conn1)
+	bw1 := bufio.NewWriter(conn1)
+
+	err = base.Request{
+		Method: base.Options,
+		URL:    base.MustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq": base.HeaderValue{"1"},
+		},
+	}.Write(bw1)
+	require.NoError(t, err)
+
+	err = res.Read(br1)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	conn2, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer conn2.Close()
+	br2 := bufio.NewReader(conn2)
+
+	err = base.Request{
+		Method: base.Options,
+		URL:    base.MustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq": base.HeaderValue{"1"},
+		},
+	}.Write(bufio.NewWriter(conn2))
+	require.NoError(t, err)
+
+	err = res.Read(br2)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusServiceUnavailable, res.StatusCode)
+	require.Equal(t, base.HeaderValue{"1"}, res.Header["CSeq"])
+
+	_, err = br2.ReadByte()
+	require.Equal(t, io.EOF, err)
+
+	s.Close()
+	<-serveDone
+}
+
+type testLogEntry struct {
+	level  LogLevel
+	event  string
+	fields map[string]interface{}
+}
+
+type testLogger struct {
+	mutex   sync.Mutex
+	entries []testLogEntry
+}
+
+func (l *testLogger) Log(level LogLevel, event string, fields ...LogField) {
+	e := testLogEntry{
+		level:  level,
+		event:  event,
+		fields: make(map[string]interface{}),
+	}
+	for _, f := range fields {
+		e.fields[f.Key] = f.Value
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.entries = append(l.entries, e)
+}
+
+func (l *testLogger) find(event string) []testLogEntry {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	var ret []testLogEntry
+	for _, e := range l.entries {
+		if e.event == event {
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
+
+func TestServerLogger(t *testing.T) {
+	l := &testLogger{}
+
+	s, err := ServerConf{
+		Logger: l,
+	}.Serve(":8554")
+	require.NoError(t, err)
+
+	serveDone := make(chan error)
+	go func() {
+		serveDone <- s.Serve(&testServerRecordHandler{})
+	}()
+
+	conn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	br := bufio.NewReader(conn)
+	bw := bufio.NewWriter(conn)
+
+	err = base.Request{
+		Method: base.Options,
+		URL:    base.MustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq": base.HeaderValue{"1"},
+		},
+	}.Write(bw)
+	require.NoError(t, err)
+
+	var res base.Response
+	err = res.Read(br)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	conn.Close()
+
+	s.Close()
+	<-serveDone
+
+	localAddr := conn.LocalAddr().String()
+
+	entries := l.find("connection opened")
+	require.Equal(t, 1, len(entries))
+	require.Equal(t, LogLevelInfo, entries[0].level)
+	require.Equal(t, localAddr, entries[0].fields["remote_addr"])
+
+	entries = l.find("request")
+	require.Equal(t, 1, len(entries))
+	require.Equal(t, LogLevelDebug, entries[0].level)
+	require.Equal(t, base.Options, entries[0].fields["method"])
+
+	entries = l.find("response")
+	require.Equal(t, 1, len(entries))
+	require.Equal(t, base.StatusOK, entries[0].fields["status"])
+
+	entries = l.find("connection closed")
+	require.Equal(t, 1, len(entries))
+	require.Equal(t, localAddr, entries[0].fields["remote_addr"])
+}
+
+type testMetrics struct {
+	mutex  sync.Mutex
+	values map[string]float64
+}
+
+func newTestMetrics() *testMetrics {
+	return &testMetrics{
+		values: make(map[string]float64),
+	}
+}
+
+func (m *testMetrics) AddCounter(name string, value float64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.values[name] += value
+}
+
+func (m *testMetrics) AddGauge(name string, value float64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.values[name] += value
+}
+
+func (m *testMetrics) get(name string) float64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.values[name]
+}
+
+func TestServerMetrics(t *testing.T) {
+	m := newTestMetrics()
+
+	h := &testServerRecordHandler{
+		frames: make(chan []byte, 10),
+	}
+
+	s, err := ServerConf{
+		Metrics: m,
+	}.Serve(":8554")
+	require.NoError(t, err)
+
+	serveDone := make(chan error)
+	go func() {
+		serveDone <- s.Serve(h)
+	}()
+
+	track, err := NewTrackH264(96, []byte("123456"), []byte("123456"))
+	require.NoError(t, err)
+
+	proto := StreamProtocolTCP
+	conn, err := ClientConf{
+		StreamProtocol: &proto,
+	}.DialPublish("rtsp://localhost:8554/teststream", Tracks{track})
+	require.NoError(t, err)
+
+	err = conn.WriteFrame(0, StreamTypeRTP, []byte{0x01, 0x02, 0x03, 0x04})
+	require.NoError(t, err)
+	<-h.frames
+
+	require.Equal(t, float64(1), m.get(MetricServerConnections))
+	require.Equal(t, float64(1), m.get(MetricServerSessions))
+	require.Equal(t, float64(1), m.get(MetricServerFramesReceived))
+	require.Equal(t, float64(4), m.get(MetricServerBytesReceived))
+
+	conn.Close()
+
+	s.Close()
+	<-serveDone
+
+	require.Equal(t, float64(0), m.get(MetricServerConnections))
+	require.Equal(t, float64(0), m.get(MetricServerSessions))
+}
+
+func TestServerIdleTimeout(t *testing.T) {
+	s, err := ServerConf{
+		IdleTimeout: 500 * time.Millisecond,
+	}.Serve(":8554")
+	require.NoError(t, err)
+
+	serveDone := make(chan error)
+	go func() {
+		serveDone <- s.Serve(&testServerRecordHandler{})
+	}()
+
+	conn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = bufio.NewReader(conn).ReadByte()
+	require.Equal(t, io.EOF, err)
+
+	s.Close()
+	<-serveDone
+}
+
+func TestServerRequestLimits(t *testing.T) {
+	track1, err := NewTrackH264(96, []byte("123456"), []byte("123456"))
+	require.NoError(t, err)
+
+	track2, err := NewTrackH264(97, []byte("123456"), []byte("123456"))
+	require.NoError(t, err)
+
+	for _, ca := range []string{
+		"max tracks",
+		"max header size",
+		"max header count",
+		"max method length",
+		"max body size",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			conf := ServerConf{}
+			switch ca {
+			case "max tracks":
+				conf.MaxTracks = 1
+			case "max header size":
+				conf.MaxRequestHeaderSize = 40
+			case "max header count":
+				conf.MaxRequestHeaderCount = 2
+			case "max method length":
+				conf.MaxRequestMethodLength = 4
+			case "max body size":
+				conf.MaxRequestBodySize = 100
+			}
+
+			s, err := conf.Serve(":8554")
+			require.NoError(t, err)
+
+			serveDone := make(chan error)
+			go func() {
+				serveDone <- s.Serve(&testServerRecordHandler{})
+			}()
+
+			conn, err := net.Dial("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer conn.Close()
+			br := bufio.NewReader(conn)
+			bw := bufio.NewWriter(conn)
+
+			err = base.Request{
+				Method: base.Announce,
+				URL:    base.MustParseURL("rtsp://localhost:8554/teststream"),
+				Header: base.Header{
+					"CSeq":         base.HeaderValue{"1"},
+					"Content-Type": base.HeaderValue{"application/sdp"},
+				},
+				Body: Tracks{track1, track2}.Write(),
+			}.Write(bw)
+			require.NoError(t, err)
+
+			var res base.Response
+			err = res.Read(br)
+			if ca == "max tracks" {
+				require.NoError(t, err)
+				require.Equal(t, base.StatusBadRequest, res.StatusCode)
+			} else {
+				// the connection is closed without a response
+				require.Equal(t, io.EOF, err)
+			}
+
+			s.Close()
+			<-serveDone
+		})
+	}
+}
+
+func TestServerMux(t *testing.T) {
+	track1, err := NewTrackH264(96, []byte("123456"), []byte("123456"))
+	require.NoError(t, err)
+
+	track2, err := NewTrackH264(97, []byte("123456"), []byte("123456"))
+	require.NoError(t, err)
+
+	h1 := &testServerStreamHandler{
+		stream: NewServerStream(Tracks{track1}),
+		play:   make(chan struct{}, 1),
+	}
+	defer h1.stream.Close()
+
+	h2 := &testServerStreamHandler{
+		stream: NewServerStream(Tracks{track1, track2}),
+		play:   make(chan struct{}, 1),
+	}
+	defer h2.stream.Close()
+
+	h3 := &testServerStreamHandler{
+		stream: NewServerStream(Tracks{track2}),
+		play:   make(chan struct{}, 1),
+	}
+	defer h3.stream.Close()
+
+	mux := NewServerMux()
+	mux.Handle("/cam1", h1)
+	mux.Handle("/archive/*", h2)
+	mux.Handle("/", h3)
+
+	s, err := Serve(":8554")
+	require.NoError(t, err)
+
+	serveDone := make(chan error)
+	go func() {
+		serveDone <- s.Serve(mux)
+	}()
+
+	proto := StreamProtocolTCP
+	conf := ClientConf{
+		StreamProtocol: &proto,
+	}
+
+	for _, ca := range []struct {
+		path string
+		h    *testServerStreamHandler
+	}{
+		{"cam1", h1},
+		{"archive/2021/rec1", h2},
+	} {
+		t.Run(ca.path, func(t *testing.T) {
+			conn, err := conf.DialRead("rtsp://localhost:8554/" + ca.path)
+			require.NoError(t, err)
+			defer conn.Close()
+			<-ca.h.play
+
+			require.Equal(t, ca.h.stream.Tracks().Write(), conn.SDP())
+			require.Equal(t, 1, ca.h.stream.ReadersLen())
+		})
+	}
+
+	tracks, _, err := conf.Describe("rtsp://localhost:8554/")
+	require.NoError(t, err)
+	require.Equal(t, h3.stream.Tracks().Write(), tracks.Write())
+
+	_, err = conf.DialRead("rtsp://localhost:8554/cam2")
+	require.Error(t, err)
+
+	s.Close()
+	<-serveDone
+}
+
+func TestServerMuxInvalidPatterns(t *testing.T) {
+	mux := NewServerMux()
+	mux.Handle("/cam1", &testServerRecordHandler{})
+	mux.Handle("/", &testServerRecordHandler{})
+
+	for _, pattern := range []string{
+		"cam2",
+		"/cam1",
+		"/cam1/",
+		"/",
+	} {
+		t.Run(pattern, func(t *testing.T) {
+			require.Panics(t, func() {
+				mux.Handle(pattern, &testServerRecordHandler{})
+			})
+		})
+	}
+}
+
+type testServerSeekHandler struct {
+	*testServerStreamHandler
+	ranges chan *headers.Range
+}
+
+func (h *testServerSeekHandler) OnPlay(sc *ServerConn, req *base.Request, rng *headers.Range) (*base.Response, error) {
+	h.ranges <- rng
+	return h.testServerStreamHandler.OnPlay(sc, req, rng)
+}
+
+func (h *testServerSeekHandler) OnPause(sc *ServerConn, req *base.Request) (*base.Response, error) {
+	h.stream.RemoveReader(sc)
+	return &base.Response{
+		StatusCode: base.StatusOK,
+		Header: base.Header{
+			"Session": base.HeaderValue{"12345678"},
+		},
+	}, nil
+}
+
+func TestServerPlayRangeRTPInfo(t *testing.T) {
+	track, err := NewTrackH264(96, []byte("123456"), []byte("123456"))
+	require.NoError(t, err)
+
+	h := &testServerSeekHandler{
+		testServerStreamHandler: &testServerStreamHandler{
+			stream: NewServerStream(Tracks{track}),
+			play:   make(chan struct{}, 2),
+		},
+		ranges: make(chan *headers.Range, 2),
+	}
+	defer h.stream.Close()
+
+	s, err := Serve(":8554")
+	require.NoError(t, err)
+
+	serveDone := make(chan error)
+	go func() {
+		serveDone <- s.Serve(h)
+	}()
+
+	conn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+	bw := bufio.NewWriter(conn)
+
+	err = base.Request{
+		Method: base.Setup,
+		URL:    base.MustParseURL("rtsp://localhost:8554/teststream/trackID=0"),
+		Header: base.Header{
+			"CSeq":      base.HeaderValue{"1"},
+			"Transport": base.HeaderValue{"RTP/AVP/TCP;unicast;interleaved=0-1"},
+		},
+	}.Write(bw)
+	require.NoError(t, err)
+
+	var res base.Response
+	err = res.Read(br)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	err = base.Request{
+		Method: base.Play,
+		URL:    base.MustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq": base.HeaderValue{"2"},
+		},
+	}.Write(bw)
+	require.NoError(t, err)
+
+	err = res.Read(br)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+	require.Nil(t, <-h.ranges)
+	_, ok := res.Header["RTP-INFO"]
+	require.Equal(t, false, ok)
+
+	h.stream.WriteFrame(0, StreamTypeRTP, []byte{
+		0x80, 0x60, 0x12, 0x34, 0x00, 0x00, 0x03, 0xe8,
+		0x00, 0x00, 0x00, 0x01, 0x05,
+	})
+
+	var frame base.InterleavedFrame
+	frame.Payload = make([]byte, 2048)
+	err = frame.Read(br)
+	require.NoError(t, err)
+
+	err = base.Request{
+		Method: base.Pause,
+		URL:    base.MustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq": base.HeaderValue{"3"},
+		},
+	}.Write(bw)
+	require.NoError(t, err)
+
+	err = res.Read(br)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	err = base.Request{
+		Method: base.Play,
+		URL:    base.MustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq":  base.HeaderValue{"4"},
+			"Range": base.HeaderValue{"npt=5-"},
+		},
+	}.Write(bw)
+	require.NoError(t, err)
+
+	err = res.Read(br)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+	require.Equal(t, &headers.Range{
+		Value: &headers.RangeNPT{
+			Start: headers.RangeNPTTime(5 * time.Second),
+		},
+	}, <-h.ranges)
+	require.Equal(t, base.HeaderValue{"url=rtsp://localhost:8554/teststream/trackID=0;seq=4661;rtptime=1000"},
+		res.Header["RTP-INFO"])
+
+	s.Close()
+	<-serveDone
+}
+
+type testServerPauseHandler struct {
+	*testServerRedirectHandler
+}
+
+func (h *testServerPauseHandler) OnPause(sc *ServerConn, req *base.Request) (*base.Response, error) {
+	h.stream.RemoveReader(sc)
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, nil
+}
+
+func TestServerRedirect(t *testing.T) {
+	track, err := NewTrackH264(96, []byte("123456"), []byte("123456"))
+	require.NoError(t, err)
+
+	h := &testServerPauseHandler{
+		testServerRedirectHandler: &testServerRedirectHandler{
+			testServerStreamHandler: &testServerStreamHandler{
+				stream: NewServerStream(Tracks{track}),
+				play:   make(chan struct{}, 1),
+			},
+			conns: make(chan *ServerConn, 1),
+		},
+	}
+	defer h.stream.Close()
+
+	s, err := Serve(":8554")
+	require.NoError(t, err)
+
+	serveDone := make(chan error)
+	go func() {
+		serveDone <- s.Serve(h)
+	}()
+
+	proto := StreamProtocolTCP
+	conn, err := ClientConf{
+		StreamProtocol: &proto,
+	}.DialRead("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.ReadFrames(func(trackID int, streamType StreamType, payload []byte) {
+	})
+
+	sc := <-h.conns
+
+	// the connection state is read from another routine
+	_, err = conn.Pause()
+	require.NoError(t, err)
+
+	err = sc.Redirect(base.MustParseURL("rtsp://localhost:8554/teststream"),
+		base.MustParseURL("rtsp://otherhost:8554/teststream"))
+	require.EqualError(t, err, "the client is not reading or publishing")
+
+	s.Close()
+	<-serveDone
+}
@@ -8,6 +8,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -23,7 +24,6 @@ const (
 	serverConnWriteBufferSize        = 4096
 	serverConnCheckStreamInterval    = 5 * time.Second
 	serverConnReceiverReportInterval = 10 * time.Second
-	serverConnTCPFrameReadBufferSize = 2048
 )
 
 // server errors.
@@ -65,9 +65,22 @@ func (s ServerConnState) String() string {
 type ServerConnTrack struct {
 	rtpPort  int
 	rtcpPort int
+
+	// listeners allocated from ServerConf.UDPPortRange
+	udpRTPListener  *ServerUDPListener
+	udpRTCPListener *ServerUDPListener
+}
+
+// udpListeners returns the listeners used to send and receive packets of the track.
+func (t ServerConnTrack) udpListeners(conf ServerConf) (*ServerUDPListener, *ServerUDPListener) {
+	if t.udpRTPListener != nil {
+		return t.udpRTPListener, t.udpRTCPListener
+	}
+	return conf.UDPRTPListener, conf.UDPRTCPListener
 }
 
-func extractTrackID(controlPath string, mode *headers.TransportMode, trackLen int) (int, error) {
+func extractTrackID(controlPath string, mode *headers.TransportMode,
+	announcedTracks Tracks, setuppedLen int) (int, error) {
 	if mode == nil || *mode == headers.TransportModePlay {
 		if !strings.HasPrefix(controlPath, "trackID=") {
 			return 0, fmt.Errorf("invalid control attribute (%s)", controlPath)
@@ -82,7 +95,21 @@ func extractTrackID(controlPath string, mode *headers.TransportMode, trackLen in
 		return trackID, nil
 	}
 
-	return trackLen, nil
+	// in record mode, search the announced track with the given control attribute.
+	// control attributes can be relative or absolute.
+	for trackID, track := range announcedTracks {
+		control, _ := track.Attribute("control")
+		if control != "" && (control == controlPath || strings.HasSuffix(control, "/"+controlPath)) {
+			return trackID, nil
+		}
+	}
+
+	// tracks without a matching control attribute are setupped in order
+	if setuppedLen >= len(announcedTracks) {
+		return 0, fmt.Errorf("all the announced tracks have already been setup")
+	}
+
+	return setuppedLen, nil
 }
 
 // ServerConnReadHandlers allows to set the handlers required by ServerConn.Read.
@@ -108,7 +135,8 @@ type ServerConnReadHandlers struct {
 	OnSetup func(req *base.Request, th *headers.Transport, basePath string, trackID int) (*base.Response, error)
 
 	// called after receiving a PLAY request.
-	OnPlay func(req *base.Request) (*base.Response, error)
+	// rng is the parsed Range header, or nil if the header is not present.
+	OnPlay func(req *base.Request, rng *headers.Range) (*base.Response, error)
 
 	// called after receiving a RECORD request.
 	OnRecord func(req *base.Request) (*base.Response, error)
@@ -135,11 +163,13 @@ type ServerConnReadHandlers struct {
 type ServerConn struct {
 	conf               ServerConf
 	nconn              net.Conn
+	tlsConn            *tls.Conn
 	br                 *bufio.Reader
 	bw                 *bufio.Writer
 	state              ServerConnState
 	tracks             map[int]ServerConnTrack
 	tracksProtocol     *StreamProtocol
+	announcedTracks    Tracks
 	readHandlers       ServerConnReadHandlers
 	rtcpReceivers      []*rtcpreceiver.RTCPReceiver
 	doEnableFrames     bool
@@ -147,6 +177,9 @@ type ServerConn struct {
 	readTimeoutEnabled bool
 
 	// writer
+	writeMutex          sync.Mutex
+	cseq                int
+	streamStarted       bool // the response to PLAY or RECORD has been sent
 	frameRingBuffer     *ringbuffer.RingBuffer
 	backgroundWriteDone chan struct{}
 
@@ -158,33 +191,79 @@ type ServerConn struct {
 
 	// in
 	terminate chan struct{}
+	closeOnce sync.Once
+	closeErr  error
 }
 
 func newServerConn(conf ServerConf, nconn net.Conn) *ServerConn {
+	var tlsConn *tls.Conn
 	conn := func() net.Conn {
 		if conf.TLSConfig != nil {
-			return tls.Server(nconn, conf.TLSConfig)
+			tlsConn = tls.Server(nconn, conf.TLSConfig)
+			return tlsConn
 		}
 		return nconn
 	}()
 
+	log(conf.Logger, LogLevelInfo, "connection opened",
+		LogField{"remote_addr", nconn.RemoteAddr().String()})
+	addGauge(conf.Metrics, MetricServerConnections, 1)
+
 	return &ServerConn{
 		conf:                conf,
 		nconn:               nconn,
+		tlsConn:             tlsConn,
 		br:                  bufio.NewReaderSize(conn, serverConnReadBufferSize),
 		bw:                  bufio.NewWriterSize(conn, serverConnWriteBufferSize),
 		tracks:              make(map[int]ServerConnTrack),
-		frameRingBuffer:     ringbuffer.New(conf.ReadBufferCount),
+		frameRingBuffer:     ringbuffer.New(conf.WriteBufferCount),
 		backgroundWriteDone: make(chan struct{}),
 		terminate:           make(chan struct{}),
 	}
 }
 
 // Close closes all the connection resources.
+// It can be called multiple times, for instance by a handler that wants to
+// disconnect a client and by Server.Serve.
 func (sc *ServerConn) Close() error {
-	err := sc.nconn.Close()
-	close(sc.terminate)
-	return err
+	sc.closeOnce.Do(func() {
+		sc.closeErr = sc.nconn.Close()
+		close(sc.terminate)
+		addGauge(sc.conf.Metrics, MetricServerConnections, -1)
+	})
+	return sc.closeErr
+}
+
+// readLimits returns the limits that are applied to requests sent by the client.
+// Responses are rarely sent by clients, and are subject to the same limits.
+func (sc *ServerConn) readLimits() base.ReadLimits {
+	return base.ReadLimits{
+		MaxMethodLength: sc.conf.MaxRequestMethodLength,
+		MaxHeaderSize:   sc.conf.MaxRequestHeaderSize,
+		MaxHeaderCount:  sc.conf.MaxRequestHeaderCount,
+		MaxBodySize:     sc.conf.MaxRequestBodySize,
+	}
+}
+
+// reject replies to the first request of the connection with
+// 503 Service Unavailable, then closes the connection.
+func (sc *ServerConn) reject() {
+	defer sc.Close()
+
+	sc.nconn.SetReadDeadline(time.Now().Add(sc.conf.ReadTimeout))
+	var req base.Request
+	err := req.ReadWithLimits(sc.br, sc.readLimits())
+	if err != nil {
+		return
+	}
+
+	sc.nconn.SetWriteDeadline(time.Now().Add(sc.conf.WriteTimeout))
+	base.Response{
+		StatusCode: base.StatusServiceUnavailable,
+		Header: base.Header{
+			"CSeq": req.Header["CSeq"],
+		},
+	}.Write(sc.bw)
 }
 
 // State returns the state.
@@ -208,11 +287,50 @@ func (sc *ServerConn) HasTrack(trackID int) bool {
 	return ok
 }
 
+// AnnouncedTracks returns the tracks announced by the client
+// when publishing, or nil if the client is not publishing.
+func (sc *ServerConn) AnnouncedTracks() Tracks {
+	return sc.announcedTracks
+}
+
 // Tracks returns the setupped tracks.
 func (sc *ServerConn) Tracks() map[int]ServerConnTrack {
 	return sc.tracks
 }
 
+// Redirect sends a REDIRECT request to the client, asking it to connect to
+// another URL; it can be used to move clients to other servers.
+// u is the URL of the stream that is being read or published.
+// This can be called only when the client is reading or publishing.
+func (sc *ServerConn) Redirect(u *base.URL, location *base.URL) error {
+	// the state is not read directly, since this can be called by any routine;
+	// streamStarted is changed by the connection routine with writeMutex.
+	sc.writeMutex.Lock()
+	defer sc.writeMutex.Unlock()
+
+	if !sc.streamStarted {
+		return fmt.Errorf("the client is not reading or publishing")
+	}
+
+	sc.cseq++
+	req := &base.Request{
+		Method: base.Redirect,
+		URL:    u,
+		Header: base.Header{
+			"CSeq":     base.HeaderValue{strconv.FormatInt(int64(sc.cseq), 10)},
+			"Location": base.HeaderValue{location.String()},
+		},
+	}
+
+	if sc.framesEnabled {
+		sc.frameRingBuffer.Push(req)
+		return nil
+	}
+
+	sc.nconn.SetWriteDeadline(time.Now().Add(sc.conf.WriteTimeout))
+	return req.Write(sc.bw)
+}
+
 func (sc *ServerConn) backgroundWrite() {
 	defer close(sc.backgroundWriteDone)
 
@@ -231,6 +349,10 @@ func (sc *ServerConn) backgroundWrite() {
 			sc.nconn.SetWriteDeadline(time.Now().Add(sc.conf.WriteTimeout))
 			w.Write(sc.bw)
 
+		case *base.Request:
+			sc.nconn.SetWriteDeadline(time.Now().Add(sc.conf.WriteTimeout))
+			w.Write(sc.bw)
+
 		default:
 			panic(fmt.Errorf("unsupported type: %T", what))
 		}
@@ -255,6 +377,18 @@ func (sc *ServerConn) NetConn() net.Conn {
 	return sc.nconn
 }
 
+// TLSConnectionState returns the state of the TLS connection,
+// that contains the certificates provided by the client.
+// It returns nil if the connection is not encrypted.
+// The state is complete only after the first request has been received.
+func (sc *ServerConn) TLSConnectionState() *tls.ConnectionState {
+	if sc.tlsConn == nil {
+		return nil
+	}
+	cs := sc.tlsConn.ConnectionState()
+	return &cs
+}
+
 func (sc *ServerConn) ip() net.IP {
 	return sc.nconn.RemoteAddr().(*net.TCPAddr).IP
 }
@@ -266,19 +400,24 @@ func (sc *ServerConn) zone() string {
 func (sc *ServerConn) frameModeEnable() {
 	switch sc.state {
 	case ServerConnStatePlay:
+		addGauge(sc.conf.Metrics, MetricServerSessions, 1)
+
 		if *sc.tracksProtocol == StreamProtocolTCP {
 			sc.doEnableFrames = true
 		}
 
 	case ServerConnStateRecord:
+		addGauge(sc.conf.Metrics, MetricServerSessions, 1)
+
 		if *sc.tracksProtocol == StreamProtocolTCP {
 			sc.doEnableFrames = true
 			sc.readTimeoutEnabled = true
 
 		} else {
 			for trackID, track := range sc.tracks {
-				sc.conf.UDPRTPListener.addPublisher(sc.ip(), track.rtpPort, trackID, sc)
-				sc.conf.UDPRTCPListener.addPublisher(sc.ip(), track.rtcpPort, trackID, sc)
+				rtpListener, rtcpListener := track.udpListeners(sc.conf)
+				rtpListener.addPublisher(sc.ip(), track.rtpPort, trackID, sc)
+				rtcpListener.addPublisher(sc.ip(), track.rtcpPort, trackID, sc)
 
 				// open the firewall by sending packets to the counterpart
 				sc.WriteFrame(trackID, StreamTypeRTP,
@@ -295,8 +434,14 @@ func (sc *ServerConn) frameModeEnable() {
 }
 
 func (sc *ServerConn) frameModeDisable() {
+	sc.writeMutex.Lock()
+	sc.streamStarted = false
+	sc.writeMutex.Unlock()
+
 	switch sc.state {
 	case ServerConnStatePlay:
+		addGauge(sc.conf.Metrics, MetricServerSessions, -1)
+
 		if *sc.tracksProtocol == StreamProtocolTCP {
 			sc.framesEnabled = false
 			sc.frameRingBuffer.Close()
@@ -304,6 +449,8 @@ func (sc *ServerConn) frameModeDisable() {
 		}
 
 	case ServerConnStateRecord:
+		addGauge(sc.conf.Metrics, MetricServerSessions, -1)
+
 		close(sc.backgroundRecordTerminate)
 		<-sc.backgroundRecordDone
 
@@ -317,8 +464,9 @@ func (sc *ServerConn) frameModeDisable() {
 
 		} else {
 			for _, track := range sc.tracks {
-				sc.conf.UDPRTPListener.removePublisher(sc.ip(), track.rtpPort)
-				sc.conf.UDPRTCPListener.removePublisher(sc.ip(), track.rtcpPort)
+				rtpListener, rtcpListener := track.udpListeners(sc.conf)
+				rtpListener.removePublisher(sc.ip(), track.rtpPort)
+				rtcpListener.removePublisher(sc.ip(), track.rtcpPort)
 			}
 		}
 	}
@@ -425,10 +573,17 @@ func (sc *ServerConn) handleRequest(req *base.Request) (*base.Response, error) {
 				}, errors.New("no tracks defined")
 			}
 
+			if sc.conf.MaxTracks != 0 && len(tracks) > sc.conf.MaxTracks {
+				return &base.Response{
+					StatusCode: base.StatusBadRequest,
+				}, fmt.Errorf("too many tracks (%d), the maximum is %d", len(tracks), sc.conf.MaxTracks)
+			}
+
 			res, err := sc.readHandlers.OnAnnounce(req, tracks)
 
 			if res.StatusCode == 200 {
 				sc.state = ServerConnStatePreRecord
+				sc.announcedTracks = tracks
 
 				sc.rtcpReceivers = make([]*rtcpreceiver.RTCPReceiver, len(tracks))
 				sc.udpLastFrameTimes = make([]*int64, len(tracks))
@@ -471,45 +626,69 @@ func (sc *ServerConn) handleRequest(req *base.Request) (*base.Response, error) {
 				}, fmt.Errorf("transport header: %s", err)
 			}
 
+			proto := th.Protocol
 			if th.Delivery != nil && *th.Delivery == base.StreamDeliveryMulticast {
-				return &base.Response{
-					StatusCode: base.StatusBadRequest,
-				}, fmt.Errorf("multicast is not supported")
+				if th.Protocol != StreamProtocolUDP {
+					return &base.Response{
+						StatusCode: base.StatusBadRequest,
+					}, fmt.Errorf("multicast can be used only with UDP")
+				}
+
+				if th.Mode != nil && *th.Mode == headers.TransportModeRecord {
+					return &base.Response{
+						StatusCode: base.StatusBadRequest,
+					}, fmt.Errorf("multicast can't be used to publish")
+				}
+
+				proto = StreamProtocolUDPMulticast
 			}
 
-			trackID, err := extractTrackID(controlPath, th.Mode, len(sc.tracks))
+			trackID, err := extractTrackID(controlPath, th.Mode, sc.announcedTracks, len(sc.tracks))
 			if err != nil {
 				return &base.Response{
 					StatusCode: base.StatusBadRequest,
 				}, err
 			}
 
+			if sc.conf.MaxTracks != 0 && trackID >= sc.conf.MaxTracks {
+				return &base.Response{
+					StatusCode: base.StatusBadRequest,
+				}, fmt.Errorf("invalid track id (%d), the maximum number of tracks is %d",
+					trackID, sc.conf.MaxTracks)
+			}
+
 			if _, ok := sc.tracks[trackID]; ok {
 				return &base.Response{
 					StatusCode: base.StatusBadRequest,
 				}, fmt.Errorf("track %d has already been setup", trackID)
 			}
 
-			if sc.tracksProtocol != nil && *sc.tracksProtocol != th.Protocol {
+			if sc.tracksProtocol != nil && *sc.tracksProtocol != proto {
 				return &base.Response{
 					StatusCode: base.StatusBadRequest,
 				}, fmt.Errorf("can't setup tracks with different protocols")
 			}
 
-			if th.Protocol == StreamProtocolUDP {
-				if sc.conf.UDPRTPListener == nil {
+			switch proto {
+			case StreamProtocolUDPMulticast:
+				// client ports are not needed, since packets are sent to the group
+
+			case StreamProtocolUDP:
+				if sc.conf.UDPRTPListener == nil && sc.conf.UDPPortRange[0] == 0 {
 					return &base.Response{
 						StatusCode: base.StatusUnsupportedTransport,
 					}, nil
 				}
 
-				if th.ClientPorts == nil {
+				if th.ClientPorts == nil ||
+					th.ClientPorts[0] <= 0 || th.ClientPorts[0] > 65535 ||
+					th.ClientPorts[1] <= 0 || th.ClientPorts[1] > 65535 {
 					return &base.Response{
 						StatusCode: base.StatusBadRequest,
 					}, fmt.Errorf("transport header does not have valid client ports (%v)", req.Header["Transport"])
 				}
 
-			} else {
+			default:
 				if th.InterleavedIds == nil {
 					return &base.Response{
 						StatusCode: base.StatusBadRequest,
@@ -541,16 +720,47 @@ func (sc *ServerConn) handleRequest(req *base.Request) (*base.Response, error) {
 				}
 			}
 
+			var track ServerConnTrack
+
+			if proto == StreamProtocolUDP && sc.conf.UDPPortRange[0] != 0 {
+				track.udpRTPListener, track.udpRTCPListener, err = newServerUDPListenerPair(sc.conf)
+				if err != nil {
+					return &base.Response{
+						StatusCode: base.StatusInternalServerError,
+					}, err
+				}
+			}
+
 			res, err := sc.readHandlers.OnSetup(req, th, basePath, trackID)
 
+			if res.StatusCode != 200 && track.udpRTPListener != nil {
+				track.udpRTPListener.Close()
+				track.udpRTCPListener.Close()
+			}
+
+			// the multicast Transport header must be provided by the handler,
+			// since the group is a property of the stream.
+			if res.StatusCode == 200 && proto == StreamProtocolUDPMulticast {
+				if _, ok := res.Header["Transport"]; !ok {
+					return &base.Response{
+						StatusCode: base.StatusUnsupportedTransport,
+					}, nil
+				}
+			}
+
 			if res.StatusCode == 200 {
-				sc.tracksProtocol = &th.Protocol
+				sc.tracksProtocol = &proto
 
-				if th.Protocol == StreamProtocolUDP {
-					sc.tracks[trackID] = ServerConnTrack{
-						rtpPort:  th.ClientPorts[0],
-						rtcpPort: th.ClientPorts[1],
-					}
+				switch proto {
+				case StreamProtocolUDPMulticast:
+					sc.tracks[trackID] = ServerConnTrack{}
+
+				case StreamProtocolUDP:
+					track.rtpPort = th.ClientPorts[0]
+					track.rtcpPort = th.ClientPorts[1]
+					sc.tracks[trackID] = track
+
+					rtpListener, rtcpListener := track.udpListeners(sc.conf)
 
 					res.Header["Transport"] = headers.Transport{
 						Protocol: StreamProtocolUDP,
@@ -559,10 +769,10 @@ func (sc *ServerConn) handleRequest(req *base.Request) (*base.Response, error) {
 							return &v
 						}(),
 						ClientPorts: th.ClientPorts,
-						ServerPorts: &[2]int{sc.conf.UDPRTPListener.port(), sc.conf.UDPRTCPListener.port()},
+						ServerPorts: &[2]int{rtpListener.port(), rtcpListener.port()},
 					}.Write()
 
-				} else {
+				default:
 					sc.tracks[trackID] = ServerConnTrack{}
 
 					res.Header["Transport"] = headers.Transport{
@@ -613,7 +823,17 @@ func (sc *ServerConn) handleRequest(req *base.Request) (*base.Response, error) {
 				}, fmt.Errorf("no tracks have been setup")
 			}
 
-			res, err := sc.readHandlers.OnPlay(req)
+			var rng *headers.Range
+			if v, ok := req.Header["Range"]; ok {
+				rng, err = headers.ReadRange(v)
+				if err != nil {
+					return &base.Response{
+						StatusCode: base.StatusBadRequest,
+					}, fmt.Errorf("range header: %s", err)
+				}
+			}
+
+			res, err := sc.readHandlers.OnPlay(req, rng)
 
 			if res.StatusCode == 200 && sc.state != ServerConnStatePlay {
 				sc.state = ServerConnStatePlay
@@ -725,8 +945,18 @@ func (sc *ServerConn) backgroundRead() error {
 	var tcpFrameBuffer *multibuffer.MultiBuffer
 
 	handleRequestOuter := func(req *base.Request) error {
+		log(sc.conf.Logger, LogLevelDebug, "request",
+			LogField{"remote_addr", sc.nconn.RemoteAddr().String()},
+			LogField{"method", req.Method},
+			LogField{"url", req.URL.Redacted()})
+
 		res, err := sc.handleRequest(req)
 
+		log(sc.conf.Logger, LogLevelDebug, "response",
+			LogField{"remote_addr", sc.nconn.RemoteAddr().String()},
+			LogField{"method", req.Method},
+			LogField{"status", res.StatusCode})
+
 		if res.Header == nil {
 			res.Header = base.Header{}
 		}
@@ -743,19 +973,21 @@ func (sc *ServerConn) backgroundRead() error {
 			sc.readHandlers.OnResponse(res)
 		}
 
+		sc.writeMutex.Lock()
+		defer sc.writeMutex.Unlock()
+
 		// start background write
 		if sc.doEnableFrames {
 			sc.doEnableFrames = false
-			sc.framesEnabled = true
 
 			if sc.state == ServerConnStateRecord {
-				tcpFrameBuffer = multibuffer.New(sc.conf.ReadBufferCount, serverConnTCPFrameReadBufferSize)
+				tcpFrameBuffer = multibuffer.New(sc.conf.ReadBufferCount, uint64(sc.conf.MaxInterleavedPayloadSize))
 			} else {
 				// when playing, tcpFrameBuffer is only used to receive RTCP receiver reports,
 				// that are much smaller than RTP frames and are sent at a fixed interval
 				// (about 2 frames every 10 secs).
 				// decrease RAM consumption by allocating less buffers.
-				tcpFrameBuffer = multibuffer.New(8, serverConnTCPFrameReadBufferSize)
+				tcpFrameBuffer = multibuffer.New(8, uint64(sc.conf.MaxInterleavedPayloadSize))
 			}
 
 			// write response before frames
@@ -764,6 +996,7 @@ func (sc *ServerConn) backgroundRead() error {
 
 			// start background write
 			sc.frameRingBuffer.Reset()
+			sc.framesEnabled = true
 			sc.backgroundWriteDone = make(chan struct{})
 			go sc.backgroundWrite()
 
@@ -777,10 +1010,15 @@ func (sc *ServerConn) backgroundRead() error {
 			res.Write(sc.bw)
 		}
 
+		// REDIRECT requests can be sent after the response
+		sc.streamStarted = sc.state == ServerConnStatePlay || sc.state == ServerConnStateRecord
+
 		return err
 	}
 
+	limits := sc.readLimits()
 	var req base.Request
+	var res base.Response
 	var frame base.InterleavedFrame
 	var errRet error
 
@@ -788,11 +1026,13 @@ outer:
 	for {
 		if sc.readTimeoutEnabled {
 			sc.nconn.SetReadDeadline(time.Now().Add(sc.conf.ReadTimeout))
+		} else if sc.conf.IdleTimeout != 0 {
+			sc.nconn.SetReadDeadline(time.Now().Add(sc.conf.IdleTimeout))
 		}
 
 		if sc.framesEnabled {
 			frame.Payload = tcpFrameBuffer.Next()
-			what, err := base.ReadInterleavedFrameOrRequest(&frame, &req, sc.br)
+			what, err := base.ReadInterleavedFrameOrRequestOrResponseWithLimits(&frame, &req, &res, sc.br, limits)
 			if err != nil {
 				errRet = err
 				break outer
@@ -803,7 +1043,7 @@ outer:
 				// forward frame only if it has been set up
 				if _, ok := sc.tracks[frame.TrackID]; ok {
 					if sc.state == ServerConnStateRecord {
-						sc.rtcpReceivers[frame.TrackID].ProcessFrame(time.Now(),
+						sc.processReceivedFrame(frame.TrackID, time.Now(),
 							frame.StreamType, frame.Payload)
 					}
 					sc.readHandlers.OnFrame(frame.TrackID, frame.StreamType, frame.Payload)
@@ -815,10 +1055,12 @@ outer:
 					errRet = err
 					break outer
 				}
+
+				// responses to requests sent by the server are ignored
 			}
 
 		} else {
-			err := req.Read(sc.br)
+			what, err := base.ReadRequestOrResponseWithLimits(&req, &res, sc.br, limits)
 			if err != nil {
 				if atomic.LoadInt32(&sc.udpTimeout) == 1 {
 					errRet = fmt.Errorf("no UDP packets received recently (maybe there's a firewall/NAT in between)")
@@ -828,16 +1070,30 @@ outer:
 				break outer
 			}
 
-			err = handleRequestOuter(&req)
-			if err != nil {
-				errRet = err
-				break outer
+			// responses to requests sent by the server are ignored
+			if _, ok := what.(*base.Request); ok {
+				err = handleRequestOuter(&req)
+				if err != nil {
+					errRet = err
+					break outer
+				}
 			}
 		}
 	}
 
 	sc.frameModeDisable()
 
+	for _, track := range sc.tracks {
+		if track.udpRTPListener != nil {
+			track.udpRTPListener.Close()
+			track.udpRTCPListener.Close()
+		}
+	}
+
+	log(sc.conf.Logger, LogLevelInfo, "connection closed",
+		LogField{"remote_addr", sc.nconn.RemoteAddr().String()},
+		LogField{"error", errRet})
+
 	return errRet
 }
 
@@ -858,35 +1114,68 @@ func (sc *ServerConn) Read(readHandlers ServerConnReadHandlers) chan error {
 
 // WriteFrame writes a frame.
 func (sc *ServerConn) WriteFrame(trackID int, streamType StreamType, payload []byte) {
-	if *sc.tracksProtocol == StreamProtocolUDP {
+	sc.writeFrame(trackID, streamType, payload)
+}
+
+// writeFrame writes a frame and returns false if the frame caused an older one
+// to be discarded, since the client is not reading fast enough.
+func (sc *ServerConn) writeFrame(trackID int, streamType StreamType, payload []byte) bool {
+	addCounter(sc.conf.Metrics, MetricServerFramesSent, 1)
+	addCounter(sc.conf.Metrics, MetricServerBytesSent, float64(len(payload)))
+
+	switch *sc.tracksProtocol {
+	case StreamProtocolUDPMulticast:
+		// frames are sent to the multicast group by ServerStream
+		return true
+
+	case StreamProtocolUDP:
 		track := sc.tracks[trackID]
+		rtpListener, rtcpListener := track.udpListeners(sc.conf)
 
 		if streamType == StreamTypeRTP {
-			sc.conf.UDPRTPListener.write(payload, &net.UDPAddr{
+			rtpListener.write(payload, &net.UDPAddr{
 				IP:   sc.ip(),
 				Zone: sc.zone(),
 				Port: track.rtpPort,
 			})
-			return
+			return true
 		}
 
-		sc.conf.UDPRTCPListener.write(payload, &net.UDPAddr{
+		rtcpListener.write(payload, &net.UDPAddr{
 			IP:   sc.ip(),
 			Zone: sc.zone(),
 			Port: track.rtcpPort,
 		})
-		return
+		return true
 	}
 
 	// StreamProtocolTCP
 
-	sc.frameRingBuffer.Push(&base.InterleavedFrame{
-		TrackID:    trackID,
-		StreamType: streamType,
-		Payload:    payload,
+	// interleaved ids of tracks are 2*trackID and 2*trackID+1
+	channel := trackID * 2
+	if streamType == StreamTypeRTCP {
+		channel++
+	}
+
+	return sc.frameRingBuffer.Push(&base.InterleavedFrame{
+		Channel: channel,
+		Payload: payload,
 	})
 }
 
+// processReceivedFrame passes a frame received from a publisher to the RTCP
+// receiver of the track.
+func (sc *ServerConn) processReceivedFrame(trackID int, now time.Time,
+	streamType StreamType, payload []byte) {
+	lost := sc.rtcpReceivers[trackID].ProcessFrame(now, streamType, payload)
+
+	addCounter(sc.conf.Metrics, MetricServerFramesReceived, 1)
+	addCounter(sc.conf.Metrics, MetricServerBytesReceived, float64(len(payload)))
+	if lost != 0 {
+		addCounter(sc.conf.Metrics, MetricServerPacketsLost, float64(lost))
+	}
+}
+
 func (sc *ServerConn) backgroundRecord() {
 	defer close(sc.backgroundRecordDone)
 
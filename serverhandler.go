@@ -0,0 +1,131 @@
+package gortsplib
+
+import (
+	"github.com/aler9/gortsplib/pkg/base"
+	"github.com/aler9/gortsplib/pkg/headers"
+)
+
+// ServerHandler is the interface implemented by all the server handlers.
+// A handler can implement one or more of the ServerHandlerOn* interfaces;
+// methods that are not implemented are replaced by the default behavior of ServerConn.
+type ServerHandler interface{}
+
+// ServerHandlerOnConnOpen can be implemented by a ServerHandler.
+type ServerHandlerOnConnOpen interface {
+	// called when a connection is opened.
+	OnConnOpen(sc *ServerConn)
+}
+
+// ServerHandlerOnConnClose can be implemented by a ServerHandler.
+type ServerHandlerOnConnClose interface {
+	// called when a connection is closed.
+	OnConnClose(sc *ServerConn, err error)
+}
+
+// ServerHandlerOnDescribe can be implemented by a ServerHandler.
+type ServerHandlerOnDescribe interface {
+	// called after receiving a DESCRIBE request.
+	OnDescribe(sc *ServerConn, req *base.Request) (*base.Response, error)
+}
+
+// ServerHandlerOnAnnounce can be implemented by a ServerHandler.
+type ServerHandlerOnAnnounce interface {
+	// called after receiving an ANNOUNCE request.
+	OnAnnounce(sc *ServerConn, req *base.Request, tracks Tracks) (*base.Response, error)
+}
+
+// ServerHandlerOnSetup can be implemented by a ServerHandler.
+type ServerHandlerOnSetup interface {
+	// called after receiving a SETUP request.
+	OnSetup(sc *ServerConn, req *base.Request, th *headers.Transport,
+		basePath string, trackID int) (*base.Response, error)
+}
+
+// ServerHandlerOnPlay can be implemented by a ServerHandler.
+type ServerHandlerOnPlay interface {
+	// called after receiving a PLAY request.
+	// rng is the parsed Range header, or nil if the header is not present.
+	OnPlay(sc *ServerConn, req *base.Request, rng *headers.Range) (*base.Response, error)
+}
+
+// ServerHandlerOnRecord can be implemented by a ServerHandler.
+type ServerHandlerOnRecord interface {
+	// called after receiving a RECORD request.
+	OnRecord(sc *ServerConn, req *base.Request) (*base.Response, error)
+}
+
+// ServerHandlerOnPause can be implemented by a ServerHandler.
+type ServerHandlerOnPause interface {
+	// called after receiving a PAUSE request.
+	OnPause(sc *ServerConn, req *base.Request) (*base.Response, error)
+}
+
+// ServerHandlerOnTeardown can be implemented by a ServerHandler.
+type ServerHandlerOnTeardown interface {
+	// called after receiving a TEARDOWN request.
+	OnTeardown(sc *ServerConn, req *base.Request) (*base.Response, error)
+}
+
+// ServerHandlerOnFrame can be implemented by a ServerHandler.
+type ServerHandlerOnFrame interface {
+	// called after receiving a frame.
+	OnFrame(sc *ServerConn, trackID int, streamType StreamType, payload []byte)
+}
+
+// serverHandlerReadHandlers converts a ServerHandler into the ServerConnReadHandlers of a connection.
+func serverHandlerReadHandlers(handler ServerHandler, sc *ServerConn) ServerConnReadHandlers {
+	var rh ServerConnReadHandlers
+
+	if h, ok := handler.(ServerHandlerOnDescribe); ok {
+		rh.OnDescribe = func(req *base.Request) (*base.Response, error) {
+			return h.OnDescribe(sc, req)
+		}
+	}
+
+	if h, ok := handler.(ServerHandlerOnAnnounce); ok {
+		rh.OnAnnounce = func(req *base.Request, tracks Tracks) (*base.Response, error) {
+			return h.OnAnnounce(sc, req, tracks)
+		}
+	}
+
+	if h, ok := handler.(ServerHandlerOnSetup); ok {
+		rh.OnSetup = func(req *base.Request, th *headers.Transport,
+			basePath string, trackID int) (*base.Response, error) {
+			return h.OnSetup(sc, req, th, basePath, trackID)
+		}
+	}
+
+	if h, ok := handler.(ServerHandlerOnPlay); ok {
+		rh.OnPlay = func(req *base.Request, rng *headers.Range) (*base.Response, error) {
+			return h.OnPlay(sc, req, rng)
+		}
+	}
+
+	if h, ok := handler.(ServerHandlerOnRecord); ok {
+		rh.OnRecord = func(req *base.Request) (*base.Response, error) {
+			return h.OnRecord(sc, req)
+		}
+	}
+
+	if h, ok := handler.(ServerHandlerOnPause); ok {
+		rh.OnPause = func(req *base.Request) (*base.Response, error) {
+			return h.OnPause(sc, req)
+		}
+	}
+
+	if h, ok := handler.(ServerHandlerOnTeardown); ok {
+		rh.OnTeardown = func(req *base.Request) (*base.Response, error) {
+			return h.OnTeardown(sc, req)
+		}
+	}
+
+	if h, ok := handler.(ServerHandlerOnFrame); ok {
+		rh.OnFrame = func(trackID int, streamType StreamType, payload []byte) {
+			h.OnFrame(sc, trackID, streamType, payload)
+		}
+	} else {
+		rh.OnFrame = func(trackID int, streamType StreamType, payload []byte) {}
+	}
+
+	return rh
+}
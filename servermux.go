@@ -0,0 +1,265 @@
+package gortsplib
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aler9/gortsplib/pkg/base"
+	"github.com/aler9/gortsplib/pkg/headers"
+)
+
+// ServerMux is a ServerHandler that routes requests to other ServerHandlers,
+// depending on the path of the stream.
+//
+// Patterns are paths like "/cam1", that match only the given path, or paths
+// ending with "/*" like "/archive/*", that match every path with the given prefix.
+// When multiple patterns match a path, the longest one is used.
+//
+// A connection is bound to a handler with the first ANNOUNCE or SETUP request;
+// subsequent requests and frames of the connection are routed to that handler.
+// OnConnOpen is not routed, since the path of a connection is not known
+// when it is opened.
+type ServerMux struct {
+	mutex    sync.RWMutex
+	exact    map[string]ServerHandler
+	prefixes map[string]ServerHandler
+	conns    map[*ServerConn]ServerHandler
+}
+
+// NewServerMux allocates a ServerMux.
+func NewServerMux() *ServerMux {
+	return &ServerMux{
+		exact:    make(map[string]ServerHandler),
+		prefixes: make(map[string]ServerHandler),
+		conns:    make(map[*ServerConn]ServerHandler),
+	}
+}
+
+// Handle registers the handler for the given pattern.
+// It panics if the pattern is invalid or if a handler already exists for it.
+func (m *ServerMux) Handle(pattern string, handler ServerHandler) {
+	if !strings.HasPrefix(pattern, "/") {
+		panic(fmt.Errorf("invalid pattern (%s)", pattern))
+	}
+
+	if handler == nil {
+		panic(fmt.Errorf("handler of pattern %s is nil", pattern))
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if strings.HasSuffix(pattern, "/*") {
+		prefix := pattern[:len(pattern)-1]
+		if _, ok := m.prefixes[prefix]; ok {
+			panic(fmt.Errorf("a handler already exists for pattern %s", pattern))
+		}
+		m.prefixes[prefix] = handler
+		return
+	}
+
+	// the root pattern is kept as is, since match() prefixes paths with a slash
+	if pattern != "/" {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	if _, ok := m.exact[pattern]; ok {
+		panic(fmt.Errorf("a handler already exists for pattern %s", pattern))
+	}
+	m.exact[pattern] = handler
+}
+
+// match returns the handler of a path.
+func (m *ServerMux) match(path string) (ServerHandler, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	path = "/" + strings.TrimSuffix(path, "/")
+
+	if h, ok := m.exact[path]; ok {
+		return h, true
+	}
+
+	var ret ServerHandler
+	retLen := 0
+	for prefix, h := range m.prefixes {
+		if strings.HasPrefix(path+"/", prefix) && len(prefix) > retLen {
+			ret = h
+			retLen = len(prefix)
+		}
+	}
+
+	return ret, ret != nil
+}
+
+// handler returns the handler bound to a connection or, if the connection
+// is not bound yet, the handler of the path of a request.
+func (m *ServerMux) handler(sc *ServerConn, u *base.URL, bind bool) (ServerHandler, bool) {
+	m.mutex.RLock()
+	h, ok := m.conns[sc]
+	m.mutex.RUnlock()
+
+	if ok {
+		return h, true
+	}
+
+	path, ok := u.BasePath()
+	if !ok {
+		return nil, false
+	}
+
+	h, ok = m.match(path)
+	if !ok {
+		return nil, false
+	}
+
+	if bind {
+		m.mutex.Lock()
+		m.conns[sc] = h
+		m.mutex.Unlock()
+	}
+
+	return h, true
+}
+
+func serverMuxNotFound(req *base.Request) (*base.Response, error) {
+	return &base.Response{
+		StatusCode: base.StatusNotFound,
+	}, fmt.Errorf("no handler found for path (%s)", req.URL)
+}
+
+func serverMuxUnhandled(req *base.Request) (*base.Response, error) {
+	return &base.Response{
+		StatusCode: base.StatusBadRequest,
+	}, fmt.Errorf("unhandled method: %v", req.Method)
+}
+
+// OnConnClose implements ServerHandlerOnConnClose.
+func (m *ServerMux) OnConnClose(sc *ServerConn, err error) {
+	m.mutex.Lock()
+	h, ok := m.conns[sc]
+	delete(m.conns, sc)
+	m.mutex.Unlock()
+
+	if !ok {
+		return
+	}
+
+	if h, ok := h.(ServerHandlerOnConnClose); ok {
+		h.OnConnClose(sc, err)
+	}
+}
+
+// OnDescribe implements ServerHandlerOnDescribe.
+func (m *ServerMux) OnDescribe(sc *ServerConn, req *base.Request) (*base.Response, error) {
+	h, ok := m.handler(sc, req.URL, false)
+	if !ok {
+		return serverMuxNotFound(req)
+	}
+
+	if h, ok := h.(ServerHandlerOnDescribe); ok {
+		return h.OnDescribe(sc, req)
+	}
+	return serverMuxUnhandled(req)
+}
+
+// OnAnnounce implements ServerHandlerOnAnnounce.
+func (m *ServerMux) OnAnnounce(sc *ServerConn, req *base.Request, tracks Tracks) (*base.Response, error) {
+	h, ok := m.handler(sc, req.URL, true)
+	if !ok {
+		return serverMuxNotFound(req)
+	}
+
+	if h, ok := h.(ServerHandlerOnAnnounce); ok {
+		return h.OnAnnounce(sc, req, tracks)
+	}
+	return serverMuxUnhandled(req)
+}
+
+// OnSetup implements ServerHandlerOnSetup.
+func (m *ServerMux) OnSetup(sc *ServerConn, req *base.Request, th *headers.Transport,
+	basePath string, trackID int) (*base.Response, error) {
+	// the SETUP URL contains the control attribute, use the base path
+	u := req.URL.Clone()
+	u.Path = "/" + basePath
+	u.RawPath = ""
+
+	h, ok := m.handler(sc, u, true)
+	if !ok {
+		return serverMuxNotFound(req)
+	}
+
+	if h, ok := h.(ServerHandlerOnSetup); ok {
+		return h.OnSetup(sc, req, th, basePath, trackID)
+	}
+	return serverMuxUnhandled(req)
+}
+
+// OnPlay implements ServerHandlerOnPlay.
+func (m *ServerMux) OnPlay(sc *ServerConn, req *base.Request, rng *headers.Range) (*base.Response, error) {
+	h, ok := m.handler(sc, req.URL, false)
+	if !ok {
+		return serverMuxNotFound(req)
+	}
+
+	if h, ok := h.(ServerHandlerOnPlay); ok {
+		return h.OnPlay(sc, req, rng)
+	}
+	return serverMuxUnhandled(req)
+}
+
+// OnRecord implements ServerHandlerOnRecord.
+func (m *ServerMux) OnRecord(sc *ServerConn, req *base.Request) (*base.Response, error) {
+	h, ok := m.handler(sc, req.URL, false)
+	if !ok {
+		return serverMuxNotFound(req)
+	}
+
+	if h, ok := h.(ServerHandlerOnRecord); ok {
+		return h.OnRecord(sc, req)
+	}
+	return serverMuxUnhandled(req)
+}
+
+// OnPause implements ServerHandlerOnPause.
+func (m *ServerMux) OnPause(sc *ServerConn, req *base.Request) (*base.Response, error) {
+	h, ok := m.handler(sc, req.URL, false)
+	if !ok {
+		return serverMuxNotFound(req)
+	}
+
+	if h, ok := h.(ServerHandlerOnPause); ok {
+		return h.OnPause(sc, req)
+	}
+	return serverMuxUnhandled(req)
+}
+
+// OnTeardown implements ServerHandlerOnTeardown.
+func (m *ServerMux) OnTeardown(sc *ServerConn, req *base.Request) (*base.Response, error) {
+	h, ok := m.handler(sc, req.URL, false)
+	if ok {
+		if h, ok := h.(ServerHandlerOnTeardown); ok {
+			return h.OnTeardown(sc, req)
+		}
+	}
+
+	// same behavior of ServerConn when OnTeardown is not set
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, ErrServerTeardown
+}
+
+// OnFrame implements ServerHandlerOnFrame.
+func (m *ServerMux) OnFrame(sc *ServerConn, trackID int, streamType StreamType, payload []byte) {
+	m.mutex.RLock()
+	h, ok := m.conns[sc]
+	m.mutex.RUnlock()
+
+	if !ok {
+		return
+	}
+
+	if h, ok := h.(ServerHandlerOnFrame); ok {
+		h.OnFrame(sc, trackID, streamType, payload)
+	}
+}
@@ -0,0 +1,317 @@
+package gortsplib
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aler9/gortsplib/pkg/base"
+	"github.com/aler9/gortsplib/pkg/headers"
+	"github.com/aler9/gortsplib/pkg/rtppacer"
+)
+
+const (
+	serverStreamMulticastDefaultTTL = 16
+)
+
+// ServerStream is a stream that can be read by multiple ServerConns.
+// Frames written to the stream are replicated to every reader, with TCP or UDP.
+// Each reader has its own write queue, whose size is ServerConf.WriteBufferCount;
+// readers that are not fast enough to empty their queue are evicted
+// and their connection is closed.
+// When multicast is enabled, frames are sent once to the multicast group,
+// regardless of the number of readers that are using multicast.
+type ServerStream struct {
+	tracks Tracks
+
+	mutex            sync.RWMutex
+	readers          map[*ServerConn]struct{}
+	multicastReaders map[*ServerConn]struct{}
+	closed           bool
+
+	pacer *rtppacer.Pacer
+
+	// last RTP packets
+	rtpInfoMutex sync.Mutex
+	rtpInfos     []serverStreamRTPInfo
+
+	// multicast
+	multicastIP   net.IP
+	multicastPort int
+	multicastTTL  uint
+	multicastConn *net.UDPConn
+}
+
+type serverStreamRTPInfo struct {
+	received       bool
+	sequenceNumber uint16
+	timestamp      uint32
+}
+
+// NewServerStream allocates a ServerStream.
+func NewServerStream(tracks Tracks) *ServerStream {
+	return &ServerStream{
+		tracks:           tracks,
+		readers:          make(map[*ServerConn]struct{}),
+		multicastReaders: make(map[*ServerConn]struct{}),
+		rtpInfos:         make([]serverStreamRTPInfo, len(tracks)),
+	}
+}
+
+// EnablePacing enables the pacing of RTP packets: packets are sent to readers
+// according to their timestamps, and bursts, like the ones caused by keyframes,
+// are spread over time in order not to exceed the given bitrate, in bits per second.
+// WriteFrame() blocks until the packet can be sent. Packets that can't be sent
+// within 1 second, since the stream bitrate is greater, are discarded.
+// It must be called before frames are written.
+func (st *ServerStream) EnablePacing(bitrate int) error {
+	if bitrate <= 0 {
+		return fmt.Errorf("invalid bitrate (%d)", bitrate)
+	}
+
+	st.pacer = rtppacer.New(bitrate)
+
+	for trackID, track := range st.tracks {
+		clockRate, err := track.ClockRate()
+		if err == nil {
+			st.pacer.AddTrack(trackID, clockRate)
+		}
+	}
+
+	return nil
+}
+
+// EnableMulticast allows readers to receive the stream with UDP multicast.
+// Frames of track i are sent to port+i*2 (RTP) and port+i*2+1 (RTCP)
+// of the given multicast group. If ttl is zero, it defaults to 16.
+// On systems other than Linux, the TTL can't be set and ttl must be 1.
+// It must be called before readers are added.
+func (st *ServerStream) EnableMulticast(ip net.IP, port int, ttl uint) error {
+	if ip == nil || !ip.IsMulticast() {
+		return fmt.Errorf("invalid multicast IP (%v)", ip)
+	}
+
+	if (port%2) != 0 || port <= 0 || (port+len(st.tracks)*2) > 65535 {
+		return fmt.Errorf("invalid multicast port (%d)", port)
+	}
+
+	if ttl == 0 {
+		ttl = serverStreamMulticastDefaultTTL
+	}
+
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	if st.multicastConn != nil {
+		return fmt.Errorf("multicast is already enabled")
+	}
+
+	isIPv6 := ip.To4() == nil
+
+	network := "udp4"
+	if isIPv6 {
+		network = "udp6"
+	}
+
+	pc, err := net.ListenUDP(network, nil)
+	if err != nil {
+		return err
+	}
+
+	err = setMulticastTTL(pc, isIPv6, int(ttl))
+	if err != nil {
+		pc.Close()
+		return err
+	}
+
+	st.multicastIP = ip
+	st.multicastPort = port
+	st.multicastTTL = ttl
+	st.multicastConn = pc
+	return nil
+}
+
+// MulticastTransport returns the Transport header that must be sent to readers
+// that request multicast delivery of a track, usually inside the OnSetup callback.
+// It returns nil if multicast is not enabled.
+func (st *ServerStream) MulticastTransport(trackID int) *headers.Transport {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+
+	if st.multicastConn == nil {
+		return nil
+	}
+
+	delivery := base.StreamDeliveryMulticast
+	destination := st.multicastIP.String()
+	ttl := st.multicastTTL
+
+	return &headers.Transport{
+		Protocol:    StreamProtocolUDP,
+		Delivery:    &delivery,
+		Destination: &destination,
+		Ports:       &[2]int{st.multicastPort + trackID*2, st.multicastPort + trackID*2 + 1},
+		TTL:         &ttl,
+	}
+}
+
+// Close closes the stream and removes all the readers.
+// Connections of readers are not closed.
+func (st *ServerStream) Close() {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	st.closed = true
+	st.readers = make(map[*ServerConn]struct{})
+	st.multicastReaders = make(map[*ServerConn]struct{})
+
+	if st.multicastConn != nil {
+		st.multicastConn.Close()
+		st.multicastConn = nil
+	}
+}
+
+// Tracks returns the tracks of the stream.
+func (st *ServerStream) Tracks() Tracks {
+	return st.tracks
+}
+
+// RTPInfo returns a RTP-Info header that contains, for every track that has
+// been setupped by the reader and that has already been written, the sequence
+// number of the next RTP packet and the timestamp of the last one.
+// u is the URL of the stream.
+// It can be used inside the OnPlay callback to allow readers to resume
+// after a pause or a seek. It returns nil if no RTP packets have been written yet.
+func (st *ServerStream) RTPInfo(sc *ServerConn, u *base.URL) *headers.RTPInfo {
+	st.rtpInfoMutex.Lock()
+	defer st.rtpInfoMutex.Unlock()
+
+	var ret headers.RTPInfo
+	baseURL := strings.TrimSuffix(u.CloneWithoutCredentials().String(), "/")
+
+	for trackID, info := range st.rtpInfos {
+		if !info.received || !sc.HasTrack(trackID) {
+			continue
+		}
+
+		sequenceNumber := info.sequenceNumber + 1
+		timestamp := info.timestamp
+
+		ret = append(ret, &headers.RTPInfoEntry{
+			URL:            baseURL + "/trackID=" + strconv.FormatInt(int64(trackID), 10),
+			SequenceNumber: &sequenceNumber,
+			Timestamp:      &timestamp,
+		})
+	}
+
+	if ret == nil {
+		return nil
+	}
+	return &ret
+}
+
+// ReadersLen returns the number of readers.
+func (st *ServerStream) ReadersLen() int {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+	return len(st.readers) + len(st.multicastReaders)
+}
+
+// AddReader adds a reader to the stream.
+// It must be called after the reader has setupped its tracks,
+// usually inside the OnPlay callback.
+func (st *ServerStream) AddReader(sc *ServerConn) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	if st.closed {
+		return
+	}
+
+	if p := sc.TracksProtocol(); p != nil && *p == StreamProtocolUDPMulticast {
+		st.multicastReaders[sc] = struct{}{}
+		return
+	}
+
+	st.readers[sc] = struct{}{}
+}
+
+// RemoveReader removes a reader from the stream.
+// It must be called before the reader pauses or closes its connection.
+func (st *ServerStream) RemoveReader(sc *ServerConn) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	delete(st.readers, sc)
+	delete(st.multicastReaders, sc)
+}
+
+// WriteFrame writes a frame to all the readers that have setupped the track.
+func (st *ServerStream) WriteFrame(trackID int, streamType StreamType, payload []byte) {
+	if st.pacer != nil && streamType == StreamTypeRTP && !st.pacer.Wait(trackID, payload) {
+		return
+	}
+
+	if streamType == StreamTypeRTP && len(payload) >= 12 && trackID < len(st.rtpInfos) {
+		st.rtpInfoMutex.Lock()
+		st.rtpInfos[trackID] = serverStreamRTPInfo{
+			received:       true,
+			sequenceNumber: uint16(payload[2])<<8 | uint16(payload[3]),
+			timestamp: uint32(payload[4])<<24 | uint32(payload[5])<<16 |
+				uint32(payload[6])<<8 | uint32(payload[7]),
+		}
+		st.rtpInfoMutex.Unlock()
+	}
+
+	var slowReaders []*ServerConn
+
+	func() {
+		st.mutex.RLock()
+		defer st.mutex.RUnlock()
+
+		if len(st.multicastReaders) != 0 {
+			port := st.multicastPort + trackID*2
+			if streamType == StreamTypeRTCP {
+				port++
+			}
+
+			st.multicastConn.WriteTo(payload, &net.UDPAddr{
+				IP:   st.multicastIP,
+				Port: port,
+			})
+		}
+
+		for sc := range st.readers {
+			if !sc.HasTrack(trackID) {
+				continue
+			}
+
+			if !sc.writeFrame(trackID, streamType, payload) {
+				slowReaders = append(slowReaders, sc)
+			}
+		}
+	}()
+
+	if slowReaders == nil {
+		return
+	}
+
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	for _, sc := range slowReaders {
+		if _, ok := st.readers[sc]; !ok {
+			continue
+		}
+
+		delete(st.readers, sc)
+
+		log(sc.conf.Logger, LogLevelWarn, "slow reader disconnected",
+			LogField{"remote_addr", sc.nconn.RemoteAddr().String()})
+
+		// make Read() return; the connection is then closed by its owner.
+		sc.nconn.Close()
+	}
+}
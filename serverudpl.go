@@ -1,7 +1,10 @@
 package gortsplib
 
 import (
+	"fmt"
+	"math/rand"
 	"net"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -76,6 +79,37 @@ func NewServerUDPListener(address string) (*ServerUDPListener, error) {
 	}, nil
 }
 
+// newServerUDPListenerPair allocates a RTP/RTCP listener pair, with ports
+// chosen randomly from ServerConf.UDPPortRange.
+func newServerUDPListenerPair(conf ServerConf) (*ServerUDPListener, *ServerUDPListener, error) {
+	// rtp must be even and rtcp odd
+	min := conf.UDPPortRange[0] + conf.UDPPortRange[0]%2
+	count := (conf.UDPPortRange[1] - min + 1) / 2
+	start := rand.Intn(count)
+
+	for i := 0; i < count; i++ {
+		rtpPort := min + ((start+i)%count)*2
+
+		rtpListener, err := NewServerUDPListener(":" + strconv.FormatInt(int64(rtpPort), 10))
+		if err != nil {
+			continue
+		}
+
+		rtcpListener, err := NewServerUDPListener(":" + strconv.FormatInt(int64(rtpPort+1), 10))
+		if err != nil {
+			rtpListener.Close()
+			continue
+		}
+
+		rtpListener.initialize(conf, StreamTypeRTP)
+		rtcpListener.initialize(conf, StreamTypeRTCP)
+		return rtpListener, rtcpListener, nil
+	}
+
+	return nil, nil, fmt.Errorf("no UDP port pairs available in range %d-%d",
+		conf.UDPPortRange[0], conf.UDPPortRange[1])
+}
+
 // Close closes the listener.
 func (s *ServerUDPListener) Close() {
 	s.pc.Close()
@@ -129,7 +163,7 @@ func (s *ServerUDPListener) run() {
 
 				now := time.Now()
 				atomic.StoreInt64(pubData.publisher.udpLastFrameTimes[pubData.trackID], now.Unix())
-				pubData.publisher.rtcpReceivers[pubData.trackID].ProcessFrame(now, s.streamType, buf[:n])
+				pubData.publisher.processReceivedFrame(pubData.trackID, now, s.streamType, buf[:n])
 				pubData.publisher.readHandlers.OnFrame(pubData.trackID, s.streamType, buf[:n])
 			}()
 		}
@@ -0,0 +1,30 @@
+//go:build linux
+// +build linux
+
+package gortsplib
+
+import (
+	"syscall"
+)
+
+// setMulticastTTL sets the TTL (or the hop limit) of multicast packets sent through a socket.
+func setMulticastTTL(conn syscall.Conn, ipv6 bool, ttl int) error {
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var serr error
+	err = rc.Control(func(fd uintptr) {
+		if ipv6 {
+			serr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_MULTICAST_HOPS, ttl)
+		} else {
+			serr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_MULTICAST_TTL, ttl)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return serr
+}
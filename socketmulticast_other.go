@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+package gortsplib
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// setMulticastTTL is not implemented on this system; only the default TTL (1) can be used.
+func setMulticastTTL(conn syscall.Conn, ipv6 bool, ttl int) error {
+	if ttl != 1 {
+		return fmt.Errorf("setting the multicast TTL is not supported on this system")
+	}
+	return nil
+}
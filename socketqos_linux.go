@@ -0,0 +1,49 @@
+//go:build linux
+// +build linux
+
+package gortsplib
+
+import (
+	"syscall"
+)
+
+// setSocketQoS sets the DSCP and the priority of the packets sent through a socket.
+// Connections that don't expose the underlying socket are left untouched.
+func setSocketQoS(conn interface{}, dscp int, priority int) error {
+	if dscp == 0 && priority == 0 {
+		return nil
+	}
+
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return nil
+	}
+
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var serr error
+	err = rc.Control(func(fd uintptr) {
+		if dscp != 0 {
+			// the socket can be either IPv4 or IPv6; IP_TOS is applied to
+			// IPv4 traffic and IPV6_TCLASS to IPv6 traffic.
+			err4 := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TOS, dscp<<2)
+			err6 := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_TCLASS, dscp<<2)
+			if err4 != nil && err6 != nil {
+				serr = err4
+				return
+			}
+		}
+
+		if priority != 0 {
+			serr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_PRIORITY, priority)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return serr
+}
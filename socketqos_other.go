@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package gortsplib
+
+// setSocketQoS is not implemented on this system; sockets are left untouched.
+func setSocketQoS(conn interface{}, dscp int, priority int) error {
+	return nil
+}
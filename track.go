@@ -4,21 +4,56 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
 
 	"github.com/notedit/rtmp/codec/aac"
+	"github.com/pion/rtp"
 	psdp "github.com/pion/sdp/v3"
 
 	"github.com/aler9/gortsplib/pkg/base"
+	"github.com/aler9/gortsplib/pkg/rtpopus"
 	"github.com/aler9/gortsplib/pkg/sdp"
 )
 
+// TrackURLMode is the strategy used to compute the URL of a track
+// from its control attribute and from the base URL.
+// In every mode, tracks without a control attribute or with the "*" control
+// attribute use the base URL.
+type TrackURLMode int
+
+// standard modes.
+const (
+	// TrackURLModeAppend appends relative control attributes to the base URL,
+	// after the query string, if any. Absolute control attributes are used
+	// with the host and the credentials of the base URL, in order to
+	// support servers behind NAT, and with the query string of the base URL,
+	// if they don't have one.
+	TrackURLModeAppend TrackURLMode = iota
+
+	// TrackURLModeAppendPath is like TrackURLModeAppend, but relative control
+	// attributes are appended to the path of the base URL, and the query string
+	// is preserved at the end of the URL.
+	TrackURLModeAppendPath
+
+	// TrackURLModeStrict resolves control attributes as described in
+	// RFC 2326 and RFC 3986: the base URL is provided by the Content-Base or
+	// Content-Location headers, relative control attributes are resolved
+	// against it and absolute control attributes are used as they are.
+	// The query string of the base URL is preserved if the track URL
+	// points to the same host and doesn't have a query string.
+	TrackURLModeStrict
+)
+
 // Track is a track available in a certain URL.
 type Track struct {
 	// base url
 	BaseURL *base.URL
 
+	// strategy used to compute the track URL from the base URL
+	URLMode TrackURLMode
+
 	// id
 	ID int
 
@@ -57,6 +92,33 @@ func NewTrackH264(payloadType uint8, sps []byte, pps []byte) (*Track, error) {
 	}, nil
 }
 
+// NewTrackH265 initializes an H265 track.
+func NewTrackH265(payloadType uint8, vps []byte, sps []byte, pps []byte) (*Track, error) {
+	typ := strconv.FormatInt(int64(payloadType), 10)
+
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "video",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{typ},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: typ + " H265/90000",
+				},
+				{
+					Key: "fmtp",
+					Value: typ + " sprop-vps=" + base64.StdEncoding.EncodeToString(vps) + "; " +
+						"sprop-sps=" + base64.StdEncoding.EncodeToString(sps) + "; " +
+						"sprop-pps=" + base64.StdEncoding.EncodeToString(pps),
+				},
+			},
+		},
+	}, nil
+}
+
 // NewTrackAAC initializes an AAC track.
 func NewTrackAAC(payloadType uint8, config []byte) (*Track, error) {
 	codec, err := aac.FromMPEG4AudioConfigBytes(config)
@@ -109,14 +171,323 @@ func NewTrackAAC(payloadType uint8, config []byte) (*Track, error) {
 	}, nil
 }
 
+// NewTrackJPEG initializes a JPEG (MJPEG) track, that uses the static
+// payload type 26 (RFC 2435).
+func NewTrackJPEG() (*Track, error) {
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "video",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"26"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "26 JPEG/90000",
+				},
+			},
+		},
+	}, nil
+}
+
+// NewTrackMPEGTS initializes a MPEG-TS track, that uses the static
+// payload type 33 (RFC 2250).
+func NewTrackMPEGTS() (*Track, error) {
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "video",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"33"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "33 MP2T/90000",
+				},
+			},
+		},
+	}, nil
+}
+
+// NewTrackVP8 initializes a VP8 track.
+func NewTrackVP8(payloadType uint8) (*Track, error) {
+	typ := strconv.FormatInt(int64(payloadType), 10)
+
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "video",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{typ},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: typ + " VP8/90000",
+				},
+			},
+		},
+	}, nil
+}
+
+// NewTrackVP9 initializes a VP9 track.
+func NewTrackVP9(payloadType uint8) (*Track, error) {
+	typ := strconv.FormatInt(int64(payloadType), 10)
+
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "video",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{typ},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: typ + " VP9/90000",
+				},
+			},
+		},
+	}, nil
+}
+
+// NewTrackAV1 initializes an AV1 track.
+func NewTrackAV1(payloadType uint8) (*Track, error) {
+	typ := strconv.FormatInt(int64(payloadType), 10)
+
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "video",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{typ},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: typ + " AV1/90000",
+				},
+			},
+		},
+	}, nil
+}
+
+// NewTrackOpus initializes an Opus track.
+func NewTrackOpus(payloadType uint8, channelCount int) (*Track, error) {
+	if channelCount != 1 && channelCount != 2 {
+		return nil, fmt.Errorf("unsupported channel count: %d", channelCount)
+	}
+
+	typ := strconv.FormatInt(int64(payloadType), 10)
+
+	// RFC 7587: the rtpmap must always contain 48000 Hz and 2 channels;
+	// the actual channel count is specified with sprop-stereo.
+	stereo := "0"
+	if channelCount == 2 {
+		stereo = "1"
+	}
+
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "audio",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{typ},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: typ + " opus/48000/2",
+				},
+				{
+					Key:   "fmtp",
+					Value: typ + " sprop-stereo=" + stereo,
+				},
+			},
+		},
+	}, nil
+}
+
+// NewTrackG711 initializes a G711 track, that uses the mu-law (PCMU)
+// or the A-law (PCMA) encoding, with 8000 Hz and a single channel.
+func NewTrackG711(mulaw bool) (*Track, error) {
+	// static payload types
+	typ := "8"
+	name := "PCMA"
+	if mulaw {
+		typ = "0"
+		name = "PCMU"
+	}
+
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "audio",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{typ},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: typ + " " + name + "/8000",
+				},
+			},
+		},
+	}, nil
+}
+
+// NewTrackG722 initializes a G722 track, that uses the static payload type 9.
+// The RTP clock rate is 8000 Hz, although the sample rate is 16000 Hz
+// (RFC 3551, section 4.5.2).
+func NewTrackG722() (*Track, error) {
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "audio",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{"9"},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: "9 G722/8000",
+				},
+			},
+		},
+	}, nil
+}
+
+// NewTrackG726 initializes a G726 track, with the given bitrate in kbit/s
+// (16, 24, 32 or 40), 8000 Hz and a single channel.
+func NewTrackG726(payloadType uint8, bitrate int) (*Track, error) {
+	if bitrate != 16 && bitrate != 24 && bitrate != 32 && bitrate != 40 {
+		return nil, fmt.Errorf("unsupported bitrate: %d", bitrate)
+	}
+
+	typ := strconv.FormatInt(int64(payloadType), 10)
+
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "audio",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{typ},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key:   "rtpmap",
+					Value: typ + " G726-" + strconv.FormatInt(int64(bitrate), 10) + "/8000",
+				},
+			},
+		},
+	}, nil
+}
+
+// NewTrackLPCM initializes a LPCM track (L8, L16 or L24).
+func NewTrackLPCM(payloadType uint8, bitDepth int, sampleRate int, channelCount int) (*Track, error) {
+	if bitDepth != 8 && bitDepth != 16 && bitDepth != 24 {
+		return nil, fmt.Errorf("unsupported bit depth: %d", bitDepth)
+	}
+
+	if channelCount <= 0 {
+		return nil, fmt.Errorf("invalid channel count: %d", channelCount)
+	}
+
+	typ := strconv.FormatInt(int64(payloadType), 10)
+
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   "audio",
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{typ},
+			},
+			Attributes: []psdp.Attribute{
+				{
+					Key: "rtpmap",
+					Value: typ + " L" + strconv.FormatInt(int64(bitDepth), 10) +
+						"/" + strconv.FormatInt(int64(sampleRate), 10) +
+						"/" + strconv.FormatInt(int64(channelCount), 10),
+				},
+			},
+		},
+	}, nil
+}
+
+// NewTrackMetadata initializes an ONVIF metadata track, that carries
+// XML documents with analytics events.
+func NewTrackMetadata(payloadType uint8) (*Track, error) {
+	return NewTrackGeneric("application", payloadType, "vnd.onvif.metadata/90000", "")
+}
+
+// NewTrackGeneric initializes a track of any codec, including the ones that
+// are not natively supported, like ONVIF metadata, KLV or proprietary codecs.
+// rtpmap is the encoding name followed by the clock rate and optional
+// parameters (i.e. "vnd.onvif.metadata/90000"). fmtp contains the format
+// parameters. Both are specified without the payload type; rtpmap can be
+// empty when the payload type is static, while fmtp can always be empty.
+func NewTrackGeneric(media string, payloadType uint8, rtpmap string, fmtp string) (*Track, error) {
+	if media == "" {
+		return nil, fmt.Errorf("media is empty")
+	}
+
+	if payloadType > 127 {
+		return nil, fmt.Errorf("invalid payload type: %d", payloadType)
+	}
+
+	typ := strconv.FormatInt(int64(payloadType), 10)
+
+	var attributes []psdp.Attribute
+
+	if rtpmap != "" {
+		tmp := strings.Split(rtpmap, "/")
+		if len(tmp) != 2 && len(tmp) != 3 {
+			return nil, fmt.Errorf("invalid rtpmap (%v)", rtpmap)
+		}
+
+		_, err := strconv.ParseUint(tmp[1], 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rtpmap (%v)", rtpmap)
+		}
+
+		attributes = append(attributes, psdp.Attribute{
+			Key:   "rtpmap",
+			Value: typ + " " + rtpmap,
+		})
+	} else if payloadType >= 96 {
+		return nil, fmt.Errorf("rtpmap is required with dynamic payload types")
+	}
+
+	if fmtp != "" {
+		attributes = append(attributes, psdp.Attribute{
+			Key:   "fmtp",
+			Value: typ + " " + fmtp,
+		})
+	}
+
+	return &Track{
+		Media: &psdp.MediaDescription{
+			MediaName: psdp.MediaName{
+				Media:   media,
+				Protos:  []string{"RTP", "AVP"},
+				Formats: []string{typ},
+			},
+			Attributes: attributes,
+		},
+	}, nil
+}
+
 // ClockRate returns the clock rate of the track.
 func (t *Track) ClockRate() (int, error) {
-	if len(t.Media.MediaName.Formats) != 1 {
+	formats := t.formats()
+	if len(formats) != 1 {
 		return 0, fmt.Errorf("invalid format (%v)", t.Media.MediaName.Formats)
 	}
 
 	// get clock rate from payload type
-	switch t.Media.MediaName.Formats[0] {
+	switch formats[0] {
 	case "0", "1", "2", "3", "4", "5", "7", "8", "9", "12", "13", "15", "18":
 		return 8000, nil
 
@@ -161,28 +532,588 @@ func (t *Track) ClockRate() (int, error) {
 	return 0, fmt.Errorf("attribute 'rtpmap' not found")
 }
 
+// MediaType returns the media type of the track ("video", "audio", "application", ...).
+func (t *Track) MediaType() string {
+	return t.Media.MediaName.Media
+}
+
+// PayloadType returns the RTP payload type of the track.
+func (t *Track) PayloadType() (uint8, error) {
+	formats := t.formats()
+	if len(formats) != 1 {
+		return 0, fmt.Errorf("invalid format (%v)", t.Media.MediaName.Formats)
+	}
+
+	v, err := strconv.ParseUint(formats[0], 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid payload type (%v)", formats[0])
+	}
+
+	return uint8(v), nil
+}
+
+// EncodingName returns the encoding name of the track, in uppercase ("H264", "MPEG4-GENERIC", ...).
+// It is read from the rtpmap attribute or, when missing, from the static payload type.
+// It returns an empty string if the encoding name can't be determined.
+func (t *Track) EncodingName() string {
+	if v, ok := t.Attribute("rtpmap"); ok {
+		tmp := strings.Split(v, " ")
+		if len(tmp) >= 2 {
+			return strings.ToUpper(strings.Split(tmp[1], "/")[0])
+		}
+		return ""
+	}
+
+	if len(t.Media.MediaName.Formats) != 1 {
+		return ""
+	}
+
+	// https://tools.ietf.org/html/rfc3551#section-6
+	switch t.Media.MediaName.Formats[0] {
+	case "0":
+		return "PCMU"
+
+	case "8":
+		return "PCMA"
+
+	case "9":
+		return "G722"
+
+	case "14":
+		return "MPA"
+
+	case "26":
+		return "JPEG"
+
+	case "32":
+		return "MPV"
+
+	case "33":
+		return "MP2T"
+	}
+
+	return ""
+}
+
+// IsH264 returns whether the track is an H264 track.
+func (t *Track) IsH264() bool {
+	return t.MediaType() == "video" && t.EncodingName() == "H264"
+}
+
+// IsH265 returns whether the track is an H265 track.
+func (t *Track) IsH265() bool {
+	return t.MediaType() == "video" && t.EncodingName() == "H265"
+}
+
+// IsJPEG returns whether the track is a JPEG (MJPEG) track.
+func (t *Track) IsJPEG() bool {
+	return t.MediaType() == "video" && t.EncodingName() == "JPEG"
+}
+
+// IsMPEGTS returns whether the track is a MPEG-TS track.
+func (t *Track) IsMPEGTS() bool {
+	return t.EncodingName() == "MP2T"
+}
+
+// IsVP8 returns whether the track is a VP8 track.
+func (t *Track) IsVP8() bool {
+	return t.MediaType() == "video" && t.EncodingName() == "VP8"
+}
+
+// IsVP9 returns whether the track is a VP9 track.
+func (t *Track) IsVP9() bool {
+	return t.MediaType() == "video" && t.EncodingName() == "VP9"
+}
+
+// IsAV1 returns whether the track is an AV1 track.
+func (t *Track) IsAV1() bool {
+	return t.MediaType() == "video" && t.EncodingName() == "AV1"
+}
+
+// IsMetadata returns whether the track is an ONVIF metadata track.
+// Its payloads are XML documents, that can be split into multiple packets;
+// the last packet of a document has the marker bit set.
+func (t *Track) IsMetadata() bool {
+	return t.MediaType() == "application" && t.EncodingName() == "VND.ONVIF.METADATA"
+}
+
+// IsAAC returns whether the track is an AAC track.
+func (t *Track) IsAAC() bool {
+	return t.MediaType() == "audio" && t.EncodingName() == "MPEG4-GENERIC"
+}
+
+// IsG711 returns whether the track is a G711 track (PCMU or PCMA).
+func (t *Track) IsG711() bool {
+	if t.MediaType() != "audio" {
+		return false
+	}
+
+	name := t.EncodingName()
+	return name == "PCMU" || name == "PCMA"
+}
+
+// IsG722 returns whether the track is a G722 track.
+func (t *Track) IsG722() bool {
+	return t.MediaType() == "audio" && t.EncodingName() == "G722"
+}
+
+// IsG726 returns whether the track is a G726 track.
+func (t *Track) IsG726() bool {
+	return t.MediaType() == "audio" && strings.HasPrefix(t.EncodingName(), "G726-")
+}
+
+// IsOpus returns whether the track is an Opus track.
+func (t *Track) IsOpus() bool {
+	return t.MediaType() == "audio" && t.EncodingName() == "OPUS"
+}
+
+// fmtpParams returns the parameters of the fmtp attribute.
+func (t *Track) fmtpParams() (map[string]string, error) {
+	v, ok := t.Attribute("fmtp")
+	if !ok {
+		return nil, fmt.Errorf("attribute 'fmtp' not found")
+	}
+
+	tmp := strings.SplitN(v, " ", 2)
+	if len(tmp) != 2 {
+		return nil, fmt.Errorf("invalid fmtp (%v)", v)
+	}
+
+	ret := make(map[string]string)
+
+	for _, kv := range strings.Split(tmp[1], ";") {
+		kv = strings.Trim(kv, " ")
+		if len(kv) == 0 {
+			continue
+		}
+
+		tmp := strings.SplitN(kv, "=", 2)
+		if len(tmp) != 2 {
+			return nil, fmt.Errorf("invalid fmtp (%v)", v)
+		}
+
+		ret[strings.ToLower(tmp[0])] = tmp[1]
+	}
+
+	return ret, nil
+}
+
+// formatEncodingName returns the encoding name of a format of the track,
+// in uppercase, read from the rtpmap attribute of the format.
+func (t *Track) formatEncodingName(format string) string {
+	for _, attr := range t.Media.Attributes {
+		if attr.Key != "rtpmap" {
+			continue
+		}
+
+		tmp := strings.SplitN(attr.Value, " ", 2)
+		if len(tmp) == 2 && tmp[0] == format {
+			return strings.ToUpper(strings.Split(tmp[1], "/")[0])
+		}
+	}
+	return ""
+}
+
+// formats returns the formats of the track, excluding RTX formats (RFC 4588)
+// and FEC formats (RFC 5109), that are used to retransmit or to protect
+// the packets of another format.
+func (t *Track) formats() []string {
+	var ret []string
+	for _, format := range t.Media.MediaName.Formats {
+		switch t.formatEncodingName(format) {
+		case "RTX", "ULPFEC":
+		default:
+			ret = append(ret, format)
+		}
+	}
+	return ret
+}
+
+// rtxPayloadType returns the payload type of the RTX format (RFC 4588)
+// that is used to retransmit the packets of the track, if present.
+func (t *Track) rtxPayloadType() (uint8, bool) {
+	payloadType, err := t.PayloadType()
+	if err != nil {
+		return 0, false
+	}
+	apt := "apt=" + strconv.FormatUint(uint64(payloadType), 10)
+
+	for _, attr := range t.Media.Attributes {
+		if attr.Key != "fmtp" {
+			continue
+		}
+
+		tmp := strings.SplitN(attr.Value, " ", 2)
+		if len(tmp) != 2 || t.formatEncodingName(tmp[0]) != "RTX" {
+			continue
+		}
+
+		for _, kv := range strings.Split(tmp[1], ";") {
+			if strings.Trim(kv, " ") != apt {
+				continue
+			}
+
+			v, err := strconv.ParseUint(tmp[0], 10, 8)
+			if err != nil {
+				return 0, false
+			}
+			return uint8(v), true
+		}
+	}
+
+	return 0, false
+}
+
+// fecPayloadType returns the payload type of the FEC format (RFC 5109)
+// that is used to protect the packets of the track, if present.
+func (t *Track) fecPayloadType() (uint8, bool) {
+	for _, format := range t.Media.MediaName.Formats {
+		if t.formatEncodingName(format) != "ULPFEC" {
+			continue
+		}
+
+		v, err := strconv.ParseUint(format, 10, 8)
+		if err != nil {
+			return 0, false
+		}
+		return uint8(v), true
+	}
+
+	return 0, false
+}
+
+// ExtractDataH264 extracts the SPS and PPS from an H264 track,
+// by reading the sprop-parameter-sets of the fmtp attribute.
+// The SPS can be decoded with h264.SPS.
+func (t *Track) ExtractDataH264() ([]byte, []byte, error) {
+	params, err := t.fmtpParams()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v, ok := params["sprop-parameter-sets"]
+	if !ok {
+		return nil, nil, fmt.Errorf("sprop-parameter-sets not found")
+	}
+
+	tmp := strings.Split(v, ",")
+	if len(tmp) < 2 {
+		return nil, nil, fmt.Errorf("invalid sprop-parameter-sets (%v)", v)
+	}
+
+	sps, err := base64.StdEncoding.DecodeString(tmp[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid sprop-parameter-sets (%v)", v)
+	}
+
+	pps, err := base64.StdEncoding.DecodeString(tmp[1])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid sprop-parameter-sets (%v)", v)
+	}
+
+	return sps, pps, nil
+}
+
+// SetDataH264 sets the SPS and PPS of an H264 track, by writing the
+// sprop-parameter-sets and the profile-level-id of the fmtp attribute.
+// Other parameters are preserved. It allows to refresh tracks whose SDP
+// doesn't contain the parameters, after they have been received in-band.
+func (t *Track) SetDataH264(sps []byte, pps []byte) error {
+	if len(sps) < 4 {
+		return fmt.Errorf("invalid SPS")
+	}
+
+	if len(pps) == 0 {
+		return fmt.Errorf("invalid PPS")
+	}
+
+	if len(t.Media.MediaName.Formats) != 1 {
+		return fmt.Errorf("invalid format (%v)", t.Media.MediaName.Formats)
+	}
+	typ := t.Media.MediaName.Formats[0]
+
+	newParams := map[string]string{
+		"sprop-parameter-sets": base64.StdEncoding.EncodeToString(sps) +
+			"," + base64.StdEncoding.EncodeToString(pps),
+		"profile-level-id": strings.ToUpper(hex.EncodeToString(sps[1:4])),
+	}
+
+	var params []string
+
+	for i, attr := range t.Media.Attributes {
+		if attr.Key != "fmtp" {
+			continue
+		}
+
+		tmp := strings.SplitN(attr.Value, " ", 2)
+		if len(tmp) == 2 {
+			for _, kv := range strings.Split(tmp[1], ";") {
+				kv = strings.Trim(kv, " ")
+				if len(kv) == 0 {
+					continue
+				}
+
+				key := strings.ToLower(strings.SplitN(kv, "=", 2)[0])
+				if v, ok := newParams[key]; ok {
+					kv = key + "=" + v
+					delete(newParams, key)
+				}
+
+				params = append(params, kv)
+			}
+		}
+
+		// remove the attribute, it is added again below
+		t.Media.Attributes = append(t.Media.Attributes[:i], t.Media.Attributes[i+1:]...)
+		break
+	}
+
+	if params == nil {
+		params = append(params, "packetization-mode=1")
+	}
+
+	for _, key := range []string{"sprop-parameter-sets", "profile-level-id"} {
+		if v, ok := newParams[key]; ok {
+			params = append(params, key+"="+v)
+		}
+	}
+
+	t.Media.Attributes = append(t.Media.Attributes, psdp.Attribute{
+		Key:   "fmtp",
+		Value: typ + " " + strings.Join(params, "; "),
+	})
+
+	return nil
+}
+
+// ExtractDataAAC extracts the MPEG-4 audio configuration from an AAC track,
+// by reading the config parameter of the fmtp attribute.
+func (t *Track) ExtractDataAAC() ([]byte, error) {
+	params, err := t.fmtpParams()
+	if err != nil {
+		return nil, err
+	}
+
+	v, ok := params["config"]
+	if !ok {
+		return nil, fmt.Errorf("config not found")
+	}
+
+	config, err := hex.DecodeString(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config (%v)", v)
+	}
+
+	return config, nil
+}
+
+// ExtractDataG726 extracts the bitrate of a G726 track, in kbit/s.
+func (t *Track) ExtractDataG726() (int, error) {
+	name := t.EncodingName()
+	if !strings.HasPrefix(name, "G726-") {
+		return 0, fmt.Errorf("invalid encoding name (%v)", name)
+	}
+
+	v, err := strconv.ParseInt(name[len("G726-"):], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid encoding name (%v)", name)
+	}
+
+	return int(v), nil
+}
+
+// ExtractDataOpus extracts the configuration of an Opus track.
+// The fmtp attribute is optional; when it is missing, the default
+// configuration is returned.
+func (t *Track) ExtractDataOpus() (*rtpopus.Config, error) {
+	var params map[string]string
+
+	if _, ok := t.Attribute("fmtp"); ok {
+		var err error
+		params, err = t.fmtpParams()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var conf rtpopus.Config
+	err := conf.UnmarshalFmtp(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conf, nil
+}
+
+// Attribute returns the value of the first SDP attribute of the track
+// with the given key, and whether the attribute is present.
+func (t *Track) Attribute(key string) (string, bool) {
+	for _, attr := range t.Media.Attributes {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// Direction returns the direction of the track, that is one of
+// "sendrecv", "sendonly", "recvonly" or "inactive".
+// If the direction is not specified, "sendrecv" is returned.
+func (t *Track) Direction() string {
+	for _, attr := range t.Media.Attributes {
+		switch attr.Key {
+		case "sendrecv", "sendonly", "recvonly", "inactive":
+			return attr.Key
+		}
+	}
+	return "sendrecv"
+}
+
+// IsBackchannel returns whether the track is an ONVIF backchannel track,
+// that is used to send frames to the server while reading.
+// Backchannel tracks are marked with the sendonly attribute.
+func (t *Track) IsBackchannel() bool {
+	return t.Direction() == "sendonly"
+}
+
+// ExtensionID returns the ID that is associated to the RTP header extension
+// with the given URI by the extmap attributes of the track (RFC 8285),
+// and whether the extension is present.
+func (t *Track) ExtensionID(uri string) (uint8, bool) {
+	for id, u := range t.extensionURIs() {
+		if u == uri {
+			return id, true
+		}
+	}
+
+	return 0, false
+}
+
+// extensionURIs returns the URIs of the RTP header extensions that are
+// declared by the extmap attributes of the track, indexed by ID.
+func (t *Track) extensionURIs() map[uint8]string {
+	ret := make(map[uint8]string)
+
+	for _, attr := range t.Media.Attributes {
+		if attr.Key != "extmap" {
+			continue
+		}
+
+		tmp := strings.Fields(attr.Value)
+		if len(tmp) < 2 {
+			continue
+		}
+
+		// remove direction
+		id := strings.SplitN(tmp[0], "/", 2)[0]
+
+		v, err := strconv.ParseUint(id, 10, 8)
+		if err != nil || v == 0 {
+			continue
+		}
+
+		ret[uint8(v)] = tmp[1]
+	}
+
+	return ret
+}
+
+// Extensions returns the RTP header extensions of a packet whose IDs are
+// associated to URIs by the extmap attributes of the track, indexed by URI.
+// It returns nil if the packet doesn't contain any of them.
+func (t *Track) Extensions(pkt *rtp.Packet) map[string][]byte {
+	return packetExtensions(t.extensionURIs(), pkt)
+}
+
+func packetExtensions(uris map[uint8]string, pkt *rtp.Packet) map[string][]byte {
+	if !pkt.Extension {
+		return nil
+	}
+
+	var ret map[string][]byte
+	for id, uri := range uris {
+		v := pkt.GetExtension(id)
+		if v == nil {
+			continue
+		}
+
+		if ret == nil {
+			ret = make(map[string][]byte)
+		}
+		ret[uri] = v
+	}
+
+	return ret
+}
+
+// AddExtension associates the RTP header extension with the given URI to an ID,
+// by adding an extmap attribute to the track (RFC 8285).
+// IDs from 1 to 14 can be used with one-byte headers, while IDs up to 255
+// require two-byte headers.
+func (t *Track) AddExtension(id uint8, uri string) error {
+	if id == 0 {
+		return fmt.Errorf("invalid extension ID (%d)", id)
+	}
+
+	for _, attr := range t.Media.Attributes {
+		if attr.Key != "extmap" {
+			continue
+		}
+
+		tmp := strings.Fields(attr.Value)
+		if len(tmp) < 2 {
+			continue
+		}
+
+		if strings.SplitN(tmp[0], "/", 2)[0] == strconv.FormatUint(uint64(id), 10) {
+			return fmt.Errorf("extension ID %d is already in use", id)
+		}
+	}
+
+	t.Media.Attributes = append(t.Media.Attributes, psdp.Attribute{
+		Key:   "extmap",
+		Value: strconv.FormatUint(uint64(id), 10) + " " + uri,
+	})
+
+	return nil
+}
+
 // URL returns the track url.
 func (t *Track) URL() (*base.URL, error) {
 	if t.BaseURL == nil {
 		return nil, fmt.Errorf("empty base url")
 	}
 
-	control := func() string {
-		for _, attr := range t.Media.Attributes {
-			if attr.Key == "control" {
-				return attr.Value
-			}
-		}
-		return ""
-	}()
+	control, _ := t.Attribute("control")
 
-	// no control attribute, use base URL
-	if control == "" {
+	// no control attribute or aggregate control, use base URL
+	if control == "" || control == "*" {
 		return t.BaseURL, nil
 	}
 
+	if t.URLMode == TrackURLModeStrict {
+		ref, err := url.Parse(control)
+		if err != nil {
+			return nil, err
+		}
+
+		ur := (*base.URL)((*url.URL)(t.BaseURL).ResolveReference(ref))
+		if ur.Scheme != "rtsp" && ur.Scheme != "rtsps" && ur.Scheme != "rtspt" {
+			return nil, fmt.Errorf("unsupported scheme '%s'", ur.Scheme)
+		}
+
+		// keep credentials and query if the host is the same
+		if ur.Host == t.BaseURL.Host {
+			if ur.User == nil {
+				ur.User = t.BaseURL.User
+			}
+			preserveQuery(ur, t.BaseURL)
+		}
+		return ur, nil
+	}
+
 	// control attribute contains an absolute path
-	if strings.HasPrefix(control, "rtsp://") {
+	if strings.HasPrefix(control, "rtsp://") || strings.HasPrefix(control, "rtsps://") {
 		ur, err := base.ParseURL(control)
 		if err != nil {
 			return nil, err
@@ -191,15 +1122,38 @@ func (t *Track) URL() (*base.URL, error) {
 		// copy host and credentials
 		ur.Host = t.BaseURL.Host
 		ur.User = t.BaseURL.User
+		preserveQuery(ur, t.BaseURL)
 		return ur, nil
 	}
 
 	// control attribute contains a relative control attribute
 	ur := t.BaseURL.Clone()
+
+	if t.URLMode == TrackURLModeAppendPath && ur.RawQuery != "" {
+		query := ur.RawQuery
+		ur.RawQuery = ""
+		ur.AddControlAttribute(control)
+
+		// the query of the control attribute, if any, has precedence
+		if ur.RawQuery == "" {
+			ur.RawQuery = query
+		}
+		return ur, nil
+	}
+
 	ur.AddControlAttribute(control)
 	return ur, nil
 }
 
+// preserveQuery copies the query of the base URL into a track URL that doesn't
+// have one, since query parameters are often used by servers to select the
+// stream or to authenticate clients, and requests without them are rejected.
+func preserveQuery(ur *base.URL, baseURL *base.URL) {
+	if ur.RawQuery == "" && !ur.ForceQuery {
+		ur.RawQuery = baseURL.RawQuery
+	}
+}
+
 // Tracks is a list of tracks.
 type Tracks []*Track
 
@@ -265,7 +1219,7 @@ func (ts Tracks) Write() []byte {
 				var ret []psdp.Attribute
 
 				for _, attr := range track.Media.Attributes {
-					if attr.Key == "rtpmap" || attr.Key == "fmtp" {
+					if attr.Key == "rtpmap" || attr.Key == "fmtp" || attr.Key == "extmap" {
 						ret = append(ret, attr)
 					}
 				}
@@ -0,0 +1,65 @@
+package gortsplib
+
+import (
+	"net"
+
+	"github.com/aler9/gortsplib/pkg/multibuffer"
+)
+
+// number of packets that are read with a single system call, when supported.
+const udpBatchReaderSize = 16
+
+type udpBatchReaderImpl interface {
+	// read reads a batch of packets into buffers of bufs and calls cb for each of them.
+	// It returns false if batching is not supported.
+	read(bufs *multibuffer.MultiBuffer, cb func(buf []byte, addr *net.UDPAddr)) (bool, error)
+}
+
+// udpBatchReader reads UDP packets in batches, in order to reduce the number of
+// system calls. Batching is supported only on Linux; on other systems
+// and when the kernel doesn't support it, packets are read one by one.
+type udpBatchReader struct {
+	pc   net.PacketConn
+	bufs *multibuffer.MultiBuffer
+	impl udpBatchReaderImpl
+}
+
+// newUDPBatchReader allocates a udpBatchReader.
+// Buffers passed to the read callback remain valid until at least
+// bufferCount other packets are read, like the ones of a single multibuffer.
+func newUDPBatchReader(pc net.PacketConn, bufferCount uint64, bufferSize uint64) *udpBatchReader {
+	r := &udpBatchReader{
+		pc: pc,
+	}
+
+	r.impl = newUDPBatchReaderImpl(pc)
+	if r.impl != nil {
+		r.bufs = multibuffer.New(bufferCount*udpBatchReaderSize, bufferSize)
+	} else {
+		r.bufs = multibuffer.New(bufferCount, bufferSize)
+	}
+
+	return r
+}
+
+// read reads one or more packets and calls cb for each of them.
+func (r *udpBatchReader) read(cb func(buf []byte, addr *net.UDPAddr)) error {
+	if r.impl != nil {
+		ok, err := r.impl.read(r.bufs, cb)
+		if ok {
+			return err
+		}
+
+		// batching is not supported by the kernel
+		r.impl = nil
+	}
+
+	buf := r.bufs.Next()
+	n, addr, err := r.pc.ReadFrom(buf)
+	if err != nil {
+		return err
+	}
+
+	cb(buf[:n], addr.(*net.UDPAddr))
+	return nil
+}
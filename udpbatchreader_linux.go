@@ -0,0 +1,119 @@
+//go:build linux
+// +build linux
+
+package gortsplib
+
+import (
+	"net"
+	"syscall"
+	"unsafe"
+
+	"github.com/aler9/gortsplib/pkg/multibuffer"
+)
+
+// mmsghdr is the C struct mmsghdr, used by recvmmsg().
+type mmsghdr struct {
+	hdr syscall.Msghdr
+	len uint32
+}
+
+type udpBatchReaderMmsg struct {
+	rc     syscall.RawConn
+	msgs   [udpBatchReaderSize]mmsghdr
+	iovecs [udpBatchReaderSize]syscall.Iovec
+	names  [udpBatchReaderSize]syscall.RawSockaddrAny
+	bufs   [udpBatchReaderSize][]byte
+}
+
+func newUDPBatchReaderImpl(pc net.PacketConn) udpBatchReaderImpl {
+	sc, ok := pc.(syscall.Conn)
+	if !ok {
+		return nil
+	}
+
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return nil
+	}
+
+	return &udpBatchReaderMmsg{rc: rc}
+}
+
+func (r *udpBatchReaderMmsg) read(bufs *multibuffer.MultiBuffer,
+	cb func(buf []byte, addr *net.UDPAddr)) (bool, error) {
+	for i := range r.msgs {
+		r.bufs[i] = bufs.Next()
+		r.iovecs[i].Base = &r.bufs[i][0]
+		r.iovecs[i].SetLen(len(r.bufs[i]))
+
+		r.msgs[i] = mmsghdr{}
+		r.msgs[i].hdr.Name = (*byte)(unsafe.Pointer(&r.names[i]))
+		r.msgs[i].hdr.Namelen = syscall.SizeofSockaddrAny
+		r.msgs[i].hdr.Iov = &r.iovecs[i]
+		r.msgs[i].hdr.Iovlen = 1
+	}
+
+	var n int
+	var errno syscall.Errno
+
+	err := r.rc.Read(func(fd uintptr) bool {
+		r1, _, e := syscall.Syscall6(syscall.SYS_RECVMMSG, fd,
+			uintptr(unsafe.Pointer(&r.msgs[0])), uintptr(len(r.msgs)),
+			syscall.MSG_DONTWAIT, 0, 0)
+
+		// wait until the socket is readable
+		if e == syscall.EAGAIN || e == syscall.EINTR {
+			return false
+		}
+
+		n = int(r1)
+		errno = e
+		return true
+	})
+	if err != nil {
+		return true, err
+	}
+
+	switch errno {
+	case 0:
+	case syscall.ENOSYS:
+		return false, nil
+	default:
+		return true, errno
+	}
+
+	for i := 0; i < n; i++ {
+		addr := sockaddrToUDPAddr(&r.names[i])
+		if addr == nil {
+			continue
+		}
+
+		cb(r.bufs[i][:r.msgs[i].len], addr)
+	}
+
+	return true, nil
+}
+
+func sockaddrToUDPAddr(rsa *syscall.RawSockaddrAny) *net.UDPAddr {
+	switch rsa.Addr.Family {
+	case syscall.AF_INET:
+		sa := (*syscall.RawSockaddrInet4)(unsafe.Pointer(rsa))
+		port := (*[2]byte)(unsafe.Pointer(&sa.Port))
+		return &net.UDPAddr{
+			IP:   net.IPv4(sa.Addr[0], sa.Addr[1], sa.Addr[2], sa.Addr[3]),
+			Port: int(port[0])<<8 | int(port[1]),
+		}
+
+	case syscall.AF_INET6:
+		sa := (*syscall.RawSockaddrInet6)(unsafe.Pointer(rsa))
+		port := (*[2]byte)(unsafe.Pointer(&sa.Port))
+		ip := make(net.IP, net.IPv6len)
+		copy(ip, sa.Addr[:])
+		return &net.UDPAddr{
+			IP:   ip,
+			Port: int(port[0])<<8 | int(port[1]),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package gortsplib
+
+import (
+	"net"
+)
+
+func newUDPBatchReaderImpl(pc net.PacketConn) udpBatchReaderImpl {
+	return nil
+}